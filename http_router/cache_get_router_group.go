@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"maps"
 	"net/http"
 	"os"
 	"strconv"
@@ -11,11 +13,15 @@ import (
 	"time"
 
 	"AbstractManager/service"
+	"AbstractManager/util/cache_key_builder"
 	"AbstractManager/util/filter_translator"
+	"AbstractManager/util/tracing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ========== Lookup 路由组 ==========
@@ -31,9 +37,12 @@ type LookupRouterGroup[T any] struct {
 	customFilterFunc   func(context.Context, *redis.Client, []string) ([]string, error)
 
 	// Cache Aside 配置
-	cacheAsideTTL   time.Duration     // 从DB加载后的缓存TTL
-	cacheHitRefresh bool              // 是否在缓存命中时刷新TTL
-	buildKeyFromID  func(uint) string // 从ID构建Redis key的函数
+	cacheAsideTTL   time.Duration                   // 从DB加载后的缓存TTL
+	cacheHitRefresh bool                            // 是否在缓存命中时刷新TTL
+	keyParser       *cache_key_builder.KeyParser[T] // 缓存键模板，未命中的键据此转换为数据库查询条件
+
+	// 日志记录器（为空时使用 Service 的日志记录器）
+	Logger *slog.Logger
 }
 
 func NewLookupRouterGroup[T any](
@@ -66,9 +75,11 @@ func (lrg *LookupRouterGroup[T]) SetCacheAsideConfig(ttl time.Duration, refreshO
 	return lrg
 }
 
-// SetKeyBuilder 设置从ID构建key的函数
-func (lrg *LookupRouterGroup[T]) SetKeyBuilder(builder func(uint) string) *LookupRouterGroup[T] {
-	lrg.buildKeyFromID = builder
+// SetKeyTemplate 设置缓存键模板（如 "cache:product:{id}:{category}"），模板非法时 panic
+// 未命中的键按模板解析为数据库查询条件，从数据库加载的数据按模板生成键。
+// 未设置时：key 模式形如 "user:*" 则使用 "user:" + cache 标签声明的键字段（未声明时为各主键列，如 "{tenant}:{sku}"），否则使用 Service.CacheKeyTemplate(ctx)
+func (lrg *LookupRouterGroup[T]) SetKeyTemplate(template string) *LookupRouterGroup[T] {
+	lrg.keyParser = cache_key_builder.MustKeyParser[T](template)
 	return lrg
 }
 
@@ -80,13 +91,25 @@ func (lrg *LookupRouterGroup[T]) SetCustomFilter(
 	return lrg
 }
 
+// SetLogger 设置路由组的日志记录器
+func (lrg *LookupRouterGroup[T]) SetLogger(l *slog.Logger) *LookupRouterGroup[T] {
+	lrg.Logger = l
+	return lrg
+}
+
+func (lrg *LookupRouterGroup[T]) logger() *slog.Logger {
+	return groupLogger(lrg.Logger, lrg.Service)
+}
+
 // ========== 路由注册 ==========
 
 func (lrg *LookupRouterGroup[T]) RegisterRoutes(basePath string) {
-	lrg.RouterGroup.POST(basePath+"/lookup", lrg.HandleLookup)
-	lrg.RouterGroup.GET(basePath+"/:key", lrg.HandleGetByKey)
-	lrg.RouterGroup.POST(basePath+"/count", lrg.HandleCount)
-	lrg.RouterGroup.POST(basePath+"/invalidate", lrg.HandleInvalidate)
+	resource := lrg.Service.ResourceName
+	lrg.RouterGroup.GET(basePath, routeHandlers(resource, "LookupRouterGroup.HandleList", lrg.logger, lrg.HandleList)...)
+	lrg.RouterGroup.POST(basePath+"/lookup", routeHandlers(resource, "LookupRouterGroup.HandleLookup", lrg.logger, lrg.HandleLookup)...)
+	lrg.RouterGroup.GET(basePath+"/:key", routeHandlers(resource, "LookupRouterGroup.HandleGetByKey", lrg.logger, lrg.HandleGetByKey)...)
+	lrg.RouterGroup.POST(basePath+"/count", routeHandlers(resource, "LookupRouterGroup.HandleCount", lrg.logger, lrg.HandleCount)...)
+	lrg.RouterGroup.POST(basePath+"/invalidate", routeHandlers(resource, "LookupRouterGroup.HandleInvalidate", lrg.logger, lrg.HandleInvalidate)...)
 }
 
 // ========== 请求/响应结构 ==========
@@ -94,21 +117,26 @@ func (lrg *LookupRouterGroup[T]) RegisterRoutes(basePath string) {
 type LookupRequest struct {
 	KeyPattern      string                          `json:"key_pattern"`       // 可选，覆盖默认 key 模式
 	Filters         []filter_translator.FilterParam `json:"filters"`           // 过滤条件
+	Where           *filter_translator.FilterNode   `json:"where,omitempty"`   // and / or / not 条件树，与 filters 以 AND 合并
+	Expr            string                          `json:"expr,omitempty"`    // 文本过滤表达式，与 filters 以 AND 合并
 	UseCustomFilter bool                            `json:"use_custom_filter"` // 是否使用自定义过滤器
 	FallbackToDB    bool                            `json:"fallback_db"`       // 是否回源数据库
+	Fields          []string                        `json:"fields,omitempty"`  // 只返回这些字段（在缓存解码后裁剪）
 }
 
 type LookupResponse[T any] struct {
-	Code    int           `json:"code"`
-	Message string        `json:"message"`
-	Data    map[string]*T `json:"data"`
-	Keys    []string      `json:"keys"`
-	Count   int           `json:"count"`
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"` // map[string]*T；指定 fields 时值为只含这些字段的对象
+	Keys    []string    `json:"keys"`
+	Count   int         `json:"count"`
 }
 
 type LookupCountRequest struct {
 	KeyPattern      string                          `json:"key_pattern"`
 	Filters         []filter_translator.FilterParam `json:"filters"`
+	Where           *filter_translator.FilterNode   `json:"where,omitempty"`
+	Expr            string                          `json:"expr,omitempty"`
 	UseCustomFilter bool                            `json:"use_custom_filter"`
 }
 
@@ -134,11 +162,17 @@ type InvalidateResponse struct {
 func (lrg *LookupRouterGroup[T]) executeLookup(
 	ctx context.Context,
 	keyPattern string,
-	filters []filter_translator.FilterParam,
+	filters *filter_translator.FilterNode,
 	useCustomFilter bool,
 	fallbackToDB bool,
 ) (map[string]*T, []string, error) {
 
+	// 0. 按列策略校验过滤字段（Redis 过滤使用 json 字段名，数据库回源使用列名）
+	dbFilters, err := resolveFilterTree(lrg.Service, filters)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid filters: %w", err)
+	}
+
 	// 1. 获取所有匹配的键
 	redisClient := service.GetRedis()
 	allKeys, err := redisClient.Keys(ctx, keyPattern).Result()
@@ -155,13 +189,18 @@ func (lrg *LookupRouterGroup[T]) executeLookup(
 	}
 
 	// 3. 翻译并应用通用过滤器
-	if len(filters) > 0 {
-		redisFilters, err := lrg.TranslatorRegistry.TranslateBatch(filters)
+	if filters != nil {
+		// 绑定 T 的 schema，过滤值按字段类型转换后再与缓存中的 JSON 比较
+		s, err := lrg.Service.ModelSchema()
+		if err != nil {
+			return nil, nil, err
+		}
+		redisFilter, err := lrg.TranslatorRegistry.ForSchema(s).TranslateTree(filters)
 		if err != nil {
-			return nil, nil, fmt.Errorf("invalid filters: %w", err)
+			return nil, nil, service.NewValidationError("filters", "invalid filters", err)
 		}
 
-		allKeys, err = filter_translator.ApplyRedisFilters(ctx, redisClient, allKeys, redisFilters)
+		allKeys, err = redisFilter.ApplyRedis(ctx, redisClient, allKeys)
 		if err != nil {
 			return nil, nil, fmt.Errorf("filter application failed: %w", err)
 		}
@@ -180,17 +219,16 @@ func (lrg *LookupRouterGroup[T]) executeLookup(
 	// 1. 有 filters 时，总是从 DB 查询（因为可能缓存中没有符合条件的数据）
 	// 2. 无 filters 且 fallback_db=true 时，从 DB 加载所有数据
 	if len(allKeys) == 0 {
-		if len(filters) > 0 || fallbackToDB {
-			return lrg.loadFromDBAndCache(ctx, keyPattern, filters)
+		if filters != nil || fallbackToDB {
+			return lrg.loadFromDBAndCache(ctx, keyPattern, dbFilters)
 		}
 		return make(map[string]*T), []string{}, nil
 	}
 
 	// 4. 从缓存查询数据
 	opts := &service.LookupQueryOptions{
-		KeyPattern:   keyPattern,
-		CacheExpire:  lrg.defaultCacheExpire,
-		FallbackToDB: fallbackToDB,
+		KeyPattern:  keyPattern,
+		CacheExpire: lrg.defaultCacheExpire,
 	}
 
 	result, err := lrg.Service.LookupQuery(ctx, allKeys, opts)
@@ -198,6 +236,23 @@ func (lrg *LookupRouterGroup[T]) executeLookup(
 		return nil, nil, fmt.Errorf("lookup query failed: %w", err)
 	}
 
+	// 5. 未命中的键（SCAN 之后过期）按键模板回源
+	if fallbackToDB {
+		var missed []string
+		for _, key := range allKeys {
+			if result[key] == nil {
+				missed = append(missed, key)
+			}
+		}
+		if len(missed) > 0 {
+			loaded, err := lrg.loadKeysFromDB(ctx, keyPattern, missed)
+			if err != nil {
+				return nil, nil, err
+			}
+			maps.Copy(result, loaded)
+		}
+	}
+
 	return result, allKeys, nil
 }
 
@@ -205,15 +260,15 @@ func (lrg *LookupRouterGroup[T]) executeLookup(
 func (lrg *LookupRouterGroup[T]) loadFromDBAndCache(
 	ctx context.Context,
 	keyPattern string,
-	filters []filter_translator.FilterParam,
+	filters *filter_translator.FilterNode,
 ) (map[string]*T, []string, error) {
-	// 将 Redis filters 转换为 GORM 查询条件
+	// 将条件树转换为 GORM 查询条件
 	var queryFunc func(*gorm.DB) *gorm.DB
 
-	if len(filters) > 0 {
-		gormFilters, err := filter_translator.DefaultGormRegistry.TranslateBatch(filters)
+	if filters != nil {
+		gormFilters, err := translateGormTree(lrg.Service, filter_translator.DefaultGormRegistry, filters)
 		if err != nil {
-			return nil, nil, fmt.Errorf("invalid gorm filters: %w", err)
+			return nil, nil, service.NewValidationError("filters", "invalid filters", err)
 		}
 
 		queryFunc = func(db *gorm.DB) *gorm.DB {
@@ -226,72 +281,146 @@ func (lrg *LookupRouterGroup[T]) loadFromDBAndCache(
 		return nil, nil, fmt.Errorf("failed to query from database: %w", err)
 	}
 
-	if len(queryResult.Data) == 0 {
-		return make(map[string]*T), []string{}, nil
+	parser, err := lrg.parser(ctx, keyPattern)
+	if err != nil {
+		return nil, nil, err
 	}
+	resultMap, keys := lrg.cacheRows(ctx, parser, queryResult.Data)
+	return resultMap, keys, nil
+}
 
-	// 批量写入缓存
-	rdb := service.GetRedis()
-	pipe := rdb.Pipeline()
-
-	resultMap := make(map[string]*T)
-	keys := make([]string, 0, len(queryResult.Data))
+// loadKeysFromDB 按键模板把缓存键转换为查询条件，从数据库加载并回写缓存
+// 数据库中不存在的键不出现在结果中
+func (lrg *LookupRouterGroup[T]) loadKeysFromDB(ctx context.Context, keyPattern string, keys []string) (map[string]*T, error) {
+	parser, err := lrg.parser(ctx, keyPattern)
+	if err != nil {
+		return nil, err
+	}
+	queryFunc, err := lrg.keysQuery(parser, keys)
+	if err != nil {
+		return nil, err
+	}
+	queryResult, err := lrg.Service.GetQueryWithoutTransaction(ctx, queryFunc, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query from database: %w", err)
+	}
+	resultMap, _ := lrg.cacheRows(ctx, parser, queryResult.Data)
+	return resultMap, nil
+}
 
-	for i := range queryResult.Data {
-		item := &queryResult.Data[i]
+// cacheRows 按键模板生成键并通过一个 pipeline 写入缓存，写入失败只记录警告
+func (lrg *LookupRouterGroup[T]) cacheRows(ctx context.Context, parser *cache_key_builder.KeyParser[T], rows []T) (map[string]*T, []string) {
+	resultMap := make(map[string]*T, len(rows))
+	keys := make([]string, 0, len(rows))
+	if len(rows) == 0 {
+		return resultMap, keys
+	}
 
-		// 序列化为 JSON
+	pipe := service.GetRedis().Pipeline()
+	for i := range rows {
+		item := &rows[i]
 		jsonData, err := json.Marshal(item)
 		if err != nil {
+			lrg.logger().WarnContext(ctx, "failed to marshal item for cache",
+				slog.String("operation", "cacheRows"), slog.Any("error", err))
 			continue
 		}
 
-		// 从 JSON 中提取 ID（通用方法）
-		var tempMap map[string]interface{}
-		if err := json.Unmarshal(jsonData, &tempMap); err != nil {
-			continue
-		}
-
-		id, ok := tempMap["id"].(float64) // JSON 数字默认是 float64
-		if !ok {
-			continue
-		}
-
-		key := fmt.Sprintf("user:%d", uint(id))
-
-		// 写入 Pipeline
+		key := parser.BuildKey(item)
 		pipe.Set(ctx, key, jsonData, lrg.cacheAsideTTL)
-
 		resultMap[key] = item
 		keys = append(keys, key)
 	}
 
-	// 执行批量写入
 	if len(keys) > 0 {
 		if _, err := pipe.Exec(ctx); err != nil {
 			// 即使缓存失败，也返回数据库数据
-			return resultMap, keys, nil
+			lrg.logger().WarnContext(ctx, "failed to write cache",
+				slog.String("operation", "cacheRows"), slog.Int("key_count", len(keys)), slog.Any("error", err))
 		}
 	}
-
-	return resultMap, keys, nil
+	return resultMap, keys
 }
 
-// ========== Cache Aside 模式核心逻辑 ==========
+// ========== 键模板 ==========
 
-// extractIDFromKey 从 Redis key 中提取 ID (例如 "user:123" -> 123)
-func (lrg *LookupRouterGroup[T]) extractIDFromKey(key string) (uint, error) {
-	parts := strings.Split(key, ":")
-	if len(parts) < 2 {
-		return 0, fmt.Errorf("invalid key format: %s", key)
+// parser 返回键模板：优先使用 SetKeyTemplate 的模板，其次由 "prefix*" 形式的 key 模式推导，最后使用 Service 的单条缓存键格式
+// 启用键命名空间时 Service 的模板带当前代数段，因此每次调用重新获取
+// 推导出的多占位符模板按 Service 复合主键的格式转义占位符值（见 KeyParser.EscapeValues）
+func (lrg *LookupRouterGroup[T]) parser(ctx context.Context, keyPattern string) (*cache_key_builder.KeyParser[T], error) {
+	if lrg.keyParser != nil {
+		return lrg.keyParser, nil
+	}
+	template, err := lrg.derivedTemplate(ctx, keyPattern)
+	if err != nil {
+		return nil, err
+	}
+	parser, err := cache_key_builder.NewKeyParser[T](template)
+	if err != nil {
+		return nil, err
+	}
+	if len(parser.Placeholders()) > 1 {
+		parser = parser.EscapeValues()
 	}
-	id, err := strconv.ParseUint(parts[len(parts)-1], 10, 32)
+	return parser, nil
+}
+
+// derivedTemplate 未设置 SetKeyTemplate 时的键模板
+func (lrg *LookupRouterGroup[T]) derivedTemplate(ctx context.Context, keyPattern string) (string, error) {
+	if prefix, ok := strings.CutSuffix(keyPattern, "*"); ok && prefix != "" && !strings.ContainsAny(prefix, "*?[\\") {
+		if builder, err := cache_key_builder.TagKeyBuilderOf[T](); err == nil && builder != nil {
+			return prefix + builder.SuffixTemplate(), nil
+		}
+		pks := []string{"id"}
+		if names, err := lrg.Service.PrimaryKeys(); err == nil {
+			pks = names
+		}
+		return prefix + "{" + strings.Join(pks, "}:{") + "}", nil
+	}
+	return lrg.Service.CacheKeyTemplate(ctx)
+}
+
+// keysQuery 把缓存键解析为数据库查询条件：单占位符模板为 IN，多占位符为各键条件的 OR
+func (lrg *LookupRouterGroup[T]) keysQuery(parser *cache_key_builder.KeyParser[T], keys []string) (func(*gorm.DB) *gorm.DB, error) {
+	s, err := lrg.Service.ModelSchema()
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse ID from key %s: %w", key, err)
+		return nil, err
+	}
+
+	placeholders := parser.Placeholders()
+	columns := make([]clause.Column, len(placeholders))
+	for i, name := range placeholders {
+		field := s.LookUpField(parser.FieldName(name))
+		if field == nil || field.DBName == "" {
+			return nil, fmt.Errorf("key template %q: placeholder {%s} is not a column of %s", parser.Template(), name, lrg.Service.ResourceName)
+		}
+		columns[i] = clause.Column{Name: field.DBName}
 	}
-	return uint(id), nil
+
+	conds := make([]clause.Expression, 0, len(keys))
+	ids := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		values, err := parser.Parse(key)
+		if err != nil {
+			return nil, service.NewValidationError("key", "key does not match key template", err)
+		}
+		eqs := make([]clause.Expression, len(columns))
+		for i, name := range placeholders {
+			eqs[i] = clause.Eq{Column: columns[i], Value: values[name]}
+		}
+		conds = append(conds, clause.And(eqs...))
+		ids = append(ids, values[placeholders[0]])
+	}
+
+	var where clause.Expression = clause.Or(conds...)
+	if len(columns) == 1 {
+		where = clause.IN{Column: columns[0], Values: ids}
+	}
+	return func(db *gorm.DB) *gorm.DB { return db.Where(where) }, nil
 }
 
+// ========== Cache Aside 模式核心逻辑 ==========
+
 // getByKeyCacheAside 实现 Cache Aside 模式的单个键查询
 // 1. 先查 Redis
 // 2. 如果命中：根据配置决定是否刷新 TTL
@@ -319,23 +448,21 @@ func (lrg *LookupRouterGroup[T]) getByKeyCacheAside(ctx context.Context, key str
 
 	if err != redis.Nil {
 		// Redis 错误（非 key 不存在）
-		return nil, false, fmt.Errorf("redis get error: %w", err)
+		return nil, false, fmt.Errorf("redis get error: %w", service.ClassifyCacheError(err))
 	}
 
-	// Step 2: Cache Miss - 从数据库查询
-	id, err := lrg.extractIDFromKey(key)
+	// Step 2: Cache Miss - 按键模板解析出查询条件，从数据库查询
+	parser, err := lrg.parser(ctx, lrg.defaultKeyPattern)
+	if err != nil {
+		return nil, false, err
+	}
+	queryFunc, err := lrg.keysQuery(parser, []string{key})
 	if err != nil {
 		return nil, false, err
 	}
 
 	// 使用 ServiceManager 的 GetQueryWithoutTransaction 查询单条数据
-	queryResult, err := lrg.Service.GetQueryWithoutTransaction(
-		ctx,
-		func(db *gorm.DB) *gorm.DB {
-			return db.Where("id = ?", id)
-		},
-		nil,
-	)
+	queryResult, err := lrg.Service.GetQueryWithoutTransaction(ctx, queryFunc, nil)
 
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to query from database: %w", err)
@@ -343,7 +470,7 @@ func (lrg *LookupRouterGroup[T]) getByKeyCacheAside(ctx context.Context, key str
 
 	if len(queryResult.Data) == 0 {
 		// 数据库中也不存在
-		return nil, false, fmt.Errorf("record not found for key: %s", key)
+		return nil, false, &service.NotFoundError{Resource: lrg.Service.ResourceName, Key: key}
 	}
 
 	result = queryResult.Data[0]
@@ -369,13 +496,43 @@ func (lrg *LookupRouterGroup[T]) getByKeyCacheAside(ctx context.Context, key str
 func (lrg *LookupRouterGroup[T]) HandleLookup(c *gin.Context) {
 	var req LookupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "invalid request",
-			"error":   err.Error(),
-		})
+		abortInvalid(c, "invalid request", err)
+		return
+	}
+	lrg.serveLookup(c, &req)
+}
+
+// HandleList GET 列表查询：过滤条件与 fields 来自查询字符串（语法见 filter_translator.ParseQueryString），
+// 其余参数：expr、key_pattern、use_custom_filter、fallback_db。缓存结果按键返回，不支持 sort / page
+func (lrg *LookupRouterGroup[T]) HandleList(c *gin.Context) {
+	params, err := filter_translator.ParseQueryString(c.Request.URL.Query())
+	if err != nil {
+		abortInvalid(c, "invalid query string", err)
+		return
+	}
+	if len(params.Sorts) > 0 || params.Page > 0 || params.PageSize > 0 {
+		abortInvalid(c, "sort and pagination are not supported by lookup", nil)
+		return
+	}
+
+	lrg.serveLookup(c, &LookupRequest{
+		KeyPattern:      c.Query("key_pattern"),
+		Filters:         params.Filters,
+		Expr:            c.Query("expr"),
+		UseCustomFilter: c.Query("use_custom_filter") == "true",
+		FallbackToDB:    c.Query("fallback_db") == "true",
+		Fields:          params.Fields,
+	})
+}
+
+// serveLookup 执行 Lookup 请求并输出响应（POST /lookup 与 GET 列表共用）
+func (lrg *LookupRouterGroup[T]) serveLookup(c *gin.Context, req *LookupRequest) {
+	filters, err := combineFilters(req.Filters, req.Where, req.Expr)
+	if err != nil {
+		abortWithError(c, "invalid filters", err)
 		return
 	}
+	trace.SpanFromContext(c.Request.Context()).SetAttributes(tracing.FiltersAttr(filters))
 
 	// 使用请求中的 key pattern，如果没有则使用默认值
 	keyPattern := req.KeyPattern
@@ -384,10 +541,13 @@ func (lrg *LookupRouterGroup[T]) HandleLookup(c *gin.Context) {
 	}
 
 	if keyPattern == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "key_pattern is required (or set default via SetDefaults)",
-		})
+		abortInvalid(c, "key_pattern is required (or set default via SetDefaults)", nil)
+		return
+	}
+
+	proj, err := lrg.Service.NewProjection(req.Fields)
+	if err != nil {
+		abortWithError(c, "invalid fields", err)
 		return
 	}
 
@@ -395,56 +555,63 @@ func (lrg *LookupRouterGroup[T]) HandleLookup(c *gin.Context) {
 	result, keys, err := lrg.executeLookup(
 		c.Request.Context(),
 		keyPattern,
-		req.Filters,
+		filters,
 		req.UseCustomFilter,
 		req.FallbackToDB,
 	)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "lookup failed",
-			"error":   err.Error(),
-		})
+		abortWithError(c, "lookup failed", err)
 		return
 	}
 
+	var data interface{} = result
+	if proj != nil {
+		if data, err = service.ProjectMap(proj, result); err != nil {
+			abortWithError(c, "lookup failed", err)
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, LookupResponse[T]{
 		Code:    0,
 		Message: "success",
-		Data:    result,
+		Data:    data,
 		Keys:    keys,
 		Count:   len(result),
 	})
 }
 
-// HandleGetByKey 使用 Cache Aside 模式处理单个键查询
+// HandleGetByKey 使用 Cache Aside 模式处理单个键查询，支持 ?fields=id,name 只返回部分字段
 func (lrg *LookupRouterGroup[T]) HandleGetByKey(c *gin.Context) {
 	key := c.Param("key")
 	ctx := c.Request.Context()
 
+	proj, err := lrg.Service.NewProjection(parseFields(c))
+	if err != nil {
+		abortWithError(c, "invalid fields", err)
+		return
+	}
+
+	// 缓存与回源都读取完整对象，投影在解码后进行，同一缓存条目可服务不同投影
 	result, cacheHit, err := lrg.getByKeyCacheAside(ctx, key)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, gin.H{
-				"code":    404,
-				"message": "key not found",
-				"error":   err.Error(),
-			})
+		abortWithError(c, "lookup failed", err)
+		return
+	}
+
+	var data interface{} = result
+	if proj != nil {
+		if data, err = proj.Apply(result); err != nil {
+			abortWithError(c, "lookup failed", err)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "lookup failed",
-			"error":   err.Error(),
-		})
-		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":      0,
 		"message":   "success",
-		"data":      result,
+		"data":      data,
 		"cache_hit": cacheHit,
 		"source":    getSource(cacheHit),
 	})
@@ -453,13 +620,16 @@ func (lrg *LookupRouterGroup[T]) HandleGetByKey(c *gin.Context) {
 func (lrg *LookupRouterGroup[T]) HandleCount(c *gin.Context) {
 	var req LookupCountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "invalid request",
-			"error":   err.Error(),
-		})
+		abortInvalid(c, "invalid request", err)
+		return
+	}
+
+	filters, err := combineFilters(req.Filters, req.Where, req.Expr)
+	if err != nil {
+		abortWithError(c, "invalid filters", err)
 		return
 	}
+	trace.SpanFromContext(c.Request.Context()).SetAttributes(tracing.FiltersAttr(filters))
 
 	keyPattern := req.KeyPattern
 	if keyPattern == "" {
@@ -467,10 +637,7 @@ func (lrg *LookupRouterGroup[T]) HandleCount(c *gin.Context) {
 	}
 
 	if keyPattern == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "key_pattern is required",
-		})
+		abortInvalid(c, "key_pattern is required", nil)
 		return
 	}
 
@@ -478,17 +645,13 @@ func (lrg *LookupRouterGroup[T]) HandleCount(c *gin.Context) {
 	_, keys, err := lrg.executeLookup(
 		c.Request.Context(),
 		keyPattern,
-		req.Filters,
+		filters,
 		req.UseCustomFilter,
 		false, // 计数不需要回源
 	)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "count failed",
-			"error":   err.Error(),
-		})
+		abortWithError(c, "count failed", err)
 		return
 	}
 
@@ -502,11 +665,7 @@ func (lrg *LookupRouterGroup[T]) HandleCount(c *gin.Context) {
 func (lrg *LookupRouterGroup[T]) HandleInvalidate(c *gin.Context) {
 	var req InvalidateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "invalid request",
-			"error":   err.Error(),
-		})
+		abortInvalid(c, "invalid request", err)
 		return
 	}
 
@@ -516,30 +675,19 @@ func (lrg *LookupRouterGroup[T]) HandleInvalidate(c *gin.Context) {
 	if req.Pattern != "" {
 		// 按模式删除
 		if err := lrg.Service.InvalidateCacheByPattern(ctx, req.Pattern); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
-				"message": "invalidate failed",
-				"error":   err.Error(),
-			})
+			abortWithError(c, "invalidate failed", err)
 			return
 		}
 		deletedCount = -1 // -1 表示按模式删除，无法精确统计
 	} else if len(req.Keys) > 0 {
 		// 按键列表删除
 		if err := lrg.Service.InvalidateCache(ctx, req.Keys...); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
-				"message": "invalidate failed",
-				"error":   err.Error(),
-			})
+			abortWithError(c, "invalidate failed", err)
 			return
 		}
 		deletedCount = len(req.Keys)
 	} else {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "either keys or pattern must be provided",
-		})
+		abortInvalid(c, "either keys or pattern must be provided", nil)
 		return
 	}
 
@@ -1,7 +1,7 @@
 package http_router
 
 import (
-	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"strconv"
@@ -12,6 +12,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ==================== 请求/响应结构 ====================
@@ -79,10 +80,12 @@ type WritedownRouterGroup[T any] struct {
 	RouterGroup *gin.RouterGroup
 	Service     *service.ServiceManager[T]
 	KeyBuilder  cache_key_builder.KeyBuilder[T]
+	Logger      *slog.Logger // 日志记录器（为空时使用 Service 的日志记录器）
 }
 
 type WritedownRouterConfig[T any] struct {
 	KeyBuilder cache_key_builder.KeyBuilder[T]
+	Logger     *slog.Logger
 }
 
 func NewWritedownRouterGroup[T any](rg *gin.RouterGroup, svc *service.ServiceManager[T], cfg ...*WritedownRouterConfig[T]) *WritedownRouterGroup[T] {
@@ -90,6 +93,9 @@ func NewWritedownRouterGroup[T any](rg *gin.RouterGroup, svc *service.ServiceMan
 	if len(cfg) > 0 && cfg[0] != nil && cfg[0].KeyBuilder != nil {
 		wdg.KeyBuilder = cfg[0].KeyBuilder
 	}
+	if len(cfg) > 0 && cfg[0] != nil && cfg[0].Logger != nil {
+		wdg.Logger = cfg[0].Logger
+	}
 	return wdg
 }
 
@@ -97,14 +103,24 @@ func (wdg *WritedownRouterGroup[T]) SetKeyBuilder(builder cache_key_builder.KeyB
 	wdg.KeyBuilder = builder
 }
 
+func (wdg *WritedownRouterGroup[T]) SetLogger(l *slog.Logger) *WritedownRouterGroup[T] {
+	wdg.Logger = l
+	return wdg
+}
+
+func (wdg *WritedownRouterGroup[T]) logger() *slog.Logger {
+	return groupLogger(wdg.Logger, wdg.Service)
+}
+
 func (wdg *WritedownRouterGroup[T]) RegisterRoutes(basePath string) {
 	r := wdg.RouterGroup
-	r.POST(basePath+"/write", wdg.HandleWritedownSingle)
-	r.POST(basePath+"/write-lock", wdg.HandleWritedownWithLock)
-	r.POST(basePath+"/write-version", wdg.HandleWritedownWithVersion)
-	r.POST(basePath+"/refresh", wdg.HandleRefreshCache)
-	r.POST(basePath+"/batch-write", wdg.HandleWritedownQuery)
-	r.POST(basePath+"/warmup", wdg.HandleWarmupCache)
+	resource := wdg.Service.ResourceName
+	r.POST(basePath+"/write", routeHandlers(resource, "WritedownRouterGroup.HandleWritedownSingle", wdg.logger, wdg.HandleWritedownSingle)...)
+	r.POST(basePath+"/write-lock", routeHandlers(resource, "WritedownRouterGroup.HandleWritedownWithLock", wdg.logger, wdg.HandleWritedownWithLock)...)
+	r.POST(basePath+"/write-version", routeHandlers(resource, "WritedownRouterGroup.HandleWritedownWithVersion", wdg.logger, wdg.HandleWritedownWithVersion)...)
+	r.POST(basePath+"/refresh", routeHandlers(resource, "WritedownRouterGroup.HandleRefreshCache", wdg.logger, wdg.HandleRefreshCache)...)
+	r.POST(basePath+"/batch-write", routeHandlers(resource, "WritedownRouterGroup.HandleWritedownQuery", wdg.logger, wdg.HandleWritedownQuery)...)
+	r.POST(basePath+"/warmup", routeHandlers(resource, "WritedownRouterGroup.HandleWarmupCache", wdg.logger, wdg.HandleWarmupCache)...)
 }
 
 // ==================== 公共辅助 (逻辑更新点) ====================
@@ -134,32 +150,53 @@ func parseExpiration(fallbackDefault int64, customSec int64) time.Duration {
 	return time.Duration(fallbackDefault) * time.Second
 }
 
-func respondError[T any](c *gin.Context, code int, msg string) {
-	c.JSON(code, WritedownResponse[T]{Code: code, Message: msg})
-}
-
 func respondSuccess[T any](c *gin.Context, items int, data *T) {
 	c.JSON(http.StatusOK, WritedownResponse[T]{Code: 0, Message: "success", ItemsWritten: items, Data: data})
 }
 
+// getKeyFunc 键函数：优先使用 KeyBuilder，其次使用请求中的模板；都没有时返回 nil，由 Service 使用默认键（cache 标签或主键）
 func (wdg *WritedownRouterGroup[T]) getKeyFunc(template string) func(*T) string {
 	if wdg.KeyBuilder != nil {
 		return cache_key_builder.BuildKeyFunc(wdg.KeyBuilder)
 	}
+	if template == "" {
+		return nil
+	}
 	builder := cache_key_builder.NewTemplateKeyBuilder[T](template)
 	return cache_key_builder.BuildKeyFunc[T](builder)
 }
 
+// resolveKey 请求未给出 key 时按 KeyBuilder 或 Service 的默认键生成
+func (wdg *WritedownRouterGroup[T]) resolveKey(c *gin.Context, key string, data *T) (string, error) {
+	if key != "" {
+		return key, nil
+	}
+	if wdg.KeyBuilder != nil {
+		return wdg.KeyBuilder.BuildKey(data), nil
+	}
+	return wdg.Service.CacheKey(c.Request.Context(), data)
+}
+
+// resolveIDKey 按主键（单值、复合主键的对象或数组）生成查询条件；请求未给出 key 时使用 Service.CacheKeyByID
+func (wdg *WritedownRouterGroup[T]) resolveIDKey(c *gin.Context, key string, id interface{}) (string, func(*gorm.DB) *gorm.DB, error) {
+	queryFunc, err := wdg.Service.PrimaryKeyQuery(id)
+	if err != nil {
+		return "", nil, err
+	}
+	if key == "" {
+		if key, err = wdg.Service.CacheKeyByID(c.Request.Context(), id); err != nil {
+			return "", nil, err
+		}
+	}
+	return key, queryFunc, nil
+}
+
 // ==================== 单个写入 ====================
 
 func (wdg *WritedownRouterGroup[T]) HandleWritedownSingle(c *gin.Context) {
 	var req WritedownSingleRequest[T]
 	if err := c.ShouldBindJSON(&req); err != nil {
-		respondError[T](c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
-		return
-	}
-	if req.Key == "" {
-		respondError[T](c, http.StatusBadRequest, "key cannot be empty")
+		abortInvalid(c, "invalid request", err)
 		return
 	}
 
@@ -171,15 +208,19 @@ func (wdg *WritedownRouterGroup[T]) HandleWritedownSingle(c *gin.Context) {
 	if req.Data != nil {
 		data = req.Data
 	} else if req.ID != nil {
-		data, err = wdg.Service.GetSingle(c.Request.Context(), func(db *gorm.DB) *gorm.DB {
-			return db.Where("id = ?", req.ID)
-		}, nil)
+		data, err = wdg.Service.GetSingleByID(c.Request.Context(), req.ID, nil)
 		if err != nil {
-			respondError[T](c, http.StatusInternalServerError, fmt.Sprintf("failed to load data: %v", err))
+			abortWithError(c, "failed to load data", err)
 			return
 		}
 	} else {
-		respondError[T](c, http.StatusBadRequest, "either data or id must be provided")
+		abortInvalid(c, "either data or id must be provided", nil)
+		return
+	}
+
+	key, err := wdg.resolveKey(c, req.Key, data)
+	if err != nil {
+		abortWithError(c, "failed to build key", err)
 		return
 	}
 
@@ -191,13 +232,13 @@ func (wdg *WritedownRouterGroup[T]) HandleWritedownSingle(c *gin.Context) {
 	}
 
 	if req.Async {
-		wdg.Service.WritedownSingleAsync(c.Request.Context(), req.Key, data, expiration)
+		wdg.Service.WritedownSingleAsync(c.Request.Context(), key, data, expiration)
 		c.JSON(http.StatusOK, WritedownResponse[T]{Code: 0, Message: "async write initiated"})
 		return
 	}
 
-	if err := wdg.Service.WritedownSingle(c.Request.Context(), req.Key, data, opts); err != nil {
-		respondError[T](c, http.StatusInternalServerError, fmt.Sprintf("writedown failed: %v", err))
+	if err := wdg.Service.WritedownSingle(c.Request.Context(), key, data, opts); err != nil {
+		abortWithError(c, "writedown failed", err)
 		return
 	}
 
@@ -207,7 +248,7 @@ func (wdg *WritedownRouterGroup[T]) HandleWritedownSingle(c *gin.Context) {
 func (wdg *WritedownRouterGroup[T]) HandleWritedownWithLock(c *gin.Context) {
 	var req WritedownWithLockRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		respondError[T](c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		abortInvalid(c, "invalid request", err)
 		return
 	}
 
@@ -215,11 +256,15 @@ func (wdg *WritedownRouterGroup[T]) HandleWritedownWithLock(c *gin.Context) {
 	expiration := parseExpiration(3600, req.Expiration)
 	lockTimeout := parseExpiration(5, req.LockTimeout)
 
-	queryFunc := func(db *gorm.DB) *gorm.DB { return db.Where("id = ?", req.ID) }
+	key, queryFunc, err := wdg.resolveIDKey(c, req.Key, req.ID)
+	if err != nil {
+		abortWithError(c, "invalid id", err)
+		return
+	}
 
-	data, err := wdg.Service.WritedownSingleWithLock(c.Request.Context(), req.Key, queryFunc, expiration, lockTimeout)
+	data, err := wdg.Service.WritedownSingleWithLock(c.Request.Context(), key, queryFunc, expiration, lockTimeout)
 	if err != nil {
-		respondError[T](c, http.StatusInternalServerError, fmt.Sprintf("writedown with lock failed: %v", err))
+		abortWithError(c, "writedown with lock failed", err)
 		return
 	}
 
@@ -229,17 +274,22 @@ func (wdg *WritedownRouterGroup[T]) HandleWritedownWithLock(c *gin.Context) {
 func (wdg *WritedownRouterGroup[T]) HandleWritedownWithVersion(c *gin.Context) {
 	var req WritedownWithVersionRequest[T]
 	if err := c.ShouldBindJSON(&req); err != nil {
-		respondError[T](c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		abortInvalid(c, "invalid request", err)
 		return
 	}
-	if req.Key == "" || req.Data == nil {
-		respondError[T](c, http.StatusBadRequest, "key and data cannot be empty")
+	if req.Data == nil {
+		abortInvalid(c, "data cannot be empty", nil)
+		return
+	}
+	key, err := wdg.resolveKey(c, req.Key, req.Data)
+	if err != nil {
+		abortWithError(c, "failed to build key", err)
 		return
 	}
 
 	expiration := parseExpiration(3600, req.Expiration)
-	if err := wdg.Service.WritedownSingleWithVersion(c.Request.Context(), req.Key, req.Data, req.Version, expiration); err != nil {
-		respondError[T](c, http.StatusInternalServerError, fmt.Sprintf("writedown with version failed: %v", err))
+	if err := wdg.Service.WritedownSingleWithVersion(c.Request.Context(), key, req.Data, req.Version, expiration); err != nil {
+		abortWithError(c, "writedown with version failed", err)
 		return
 	}
 
@@ -249,19 +299,23 @@ func (wdg *WritedownRouterGroup[T]) HandleWritedownWithVersion(c *gin.Context) {
 func (wdg *WritedownRouterGroup[T]) HandleRefreshCache(c *gin.Context) {
 	var req RefreshCacheRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		respondError[T](c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		abortInvalid(c, "invalid request", err)
 		return
 	}
-	if req.Key == "" || req.ID == nil {
-		respondError[T](c, http.StatusBadRequest, "key and id cannot be empty")
+	if req.ID == nil {
+		abortInvalid(c, "id cannot be empty", nil)
 		return
 	}
 
 	expiration := parseExpiration(3600, req.Expiration)
-	queryFunc := func(db *gorm.DB) *gorm.DB { return db.Where("id = ?", req.ID) }
+	key, queryFunc, err := wdg.resolveIDKey(c, req.Key, req.ID)
+	if err != nil {
+		abortWithError(c, "invalid id", err)
+		return
+	}
 
-	if err := wdg.Service.RefreshSingleCacheFromDB(c.Request.Context(), req.Key, queryFunc, expiration); err != nil {
-		respondError[T](c, http.StatusInternalServerError, fmt.Sprintf("refresh cache failed: %v", err))
+	if err := wdg.Service.RefreshSingleCacheFromDB(c.Request.Context(), key, queryFunc, expiration); err != nil {
+		abortWithError(c, "refresh cache failed", err)
 		return
 	}
 
@@ -272,11 +326,7 @@ func (wdg *WritedownRouterGroup[T]) HandleRefreshCache(c *gin.Context) {
 func (wdg *WritedownRouterGroup[T]) HandleWritedownQuery(c *gin.Context) {
 	var req WritedownQueryRequest[T]
 	if err := c.ShouldBindJSON(&req); err != nil {
-		respondError[T](c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
-		return
-	}
-	if req.KeyTemplate == "" {
-		respondError[T](c, http.StatusBadRequest, "key_template cannot be empty")
+		abortInvalid(c, "invalid request", err)
 		return
 	}
 
@@ -295,7 +345,7 @@ func (wdg *WritedownRouterGroup[T]) HandleWritedownQuery(c *gin.Context) {
 			BatchSize:  req.BatchSize,
 			Overwrite:  req.Overwrite,
 		}); err != nil {
-			respondError[T](c, http.StatusInternalServerError, fmt.Sprintf("writedown by ids failed: %v", err))
+			abortWithError(c, "writedown by ids failed", err)
 			return
 		}
 		respondSuccess[T](c, len(req.IDs), nil)
@@ -306,7 +356,7 @@ func (wdg *WritedownRouterGroup[T]) HandleWritedownQuery(c *gin.Context) {
 			BatchSize:  req.BatchSize,
 			Overwrite:  req.Overwrite,
 		}); err != nil {
-			respondError[T](c, http.StatusInternalServerError, fmt.Sprintf("writedown all failed: %v", err))
+			abortWithError(c, "writedown all failed", err)
 			return
 		}
 		respondSuccess[T](c, 0, nil)
@@ -329,7 +379,7 @@ func writeBatch[T any](c *gin.Context, wdg *WritedownRouterGroup[T], data []T, b
 		err = wdg.Service.WritedownQuery(c.Request.Context(), data, buildKey, opts)
 	}
 	if err != nil {
-		respondError[T](c, http.StatusInternalServerError, fmt.Sprintf("writedown query failed: %v", err))
+		abortWithError(c, "writedown query failed", err)
 		return
 	}
 	respondSuccess[T](c, len(data), nil)
@@ -338,11 +388,7 @@ func writeBatch[T any](c *gin.Context, wdg *WritedownRouterGroup[T], data []T, b
 func (wdg *WritedownRouterGroup[T]) HandleWarmupCache(c *gin.Context) {
 	var req WarmupCacheRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		respondError[T](c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
-		return
-	}
-	if req.KeyTemplate == "" {
-		respondError[T](c, http.StatusBadRequest, "key_template cannot be empty")
+		abortInvalid(c, "invalid request", err)
 		return
 	}
 	if req.Expiration == 0 {
@@ -355,11 +401,19 @@ func (wdg *WritedownRouterGroup[T]) HandleWarmupCache(c *gin.Context) {
 		req.OrderBy = "id"
 	}
 
+	orderBy, err := wdg.Service.ResolveColumn(req.OrderBy, service.UsageSort)
+	if err != nil {
+		abortWithError(c, "invalid order_by", err)
+		return
+	}
+
 	buildKey := wdg.getKeyFunc(req.KeyTemplate)
-	queryFunc := func(db *gorm.DB) *gorm.DB { return db.Order(fmt.Sprintf("%s DESC", req.OrderBy)).Limit(req.Limit) }
+	queryFunc := func(db *gorm.DB) *gorm.DB {
+		return db.Order(clause.OrderByColumn{Column: clause.Column{Name: orderBy}, Desc: true}).Limit(req.Limit)
+	}
 
 	if err := wdg.Service.WarmupCache(c.Request.Context(), queryFunc, buildKey, parseExpiration(3600, req.Expiration)); err != nil {
-		respondError[T](c, http.StatusInternalServerError, fmt.Sprintf("warmup cache failed: %v", err))
+		abortWithError(c, "warmup cache failed", err)
 		return
 	}
 	respondSuccess[T](c, 0, nil)
@@ -0,0 +1,104 @@
+package http_router
+
+import (
+	"errors"
+	"net/http"
+
+	"AbstractManager/service"
+	"AbstractManager/util/field_validator"
+	"AbstractManager/util/filter_translator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ========== 错误码 ==========
+
+// 机器可读的错误码，出现在错误响应的 error_code 字段中
+const (
+	ErrCodeInvalidRequest   = "INVALID_REQUEST"
+	ErrCodeNotFound         = "NOT_FOUND"
+	ErrCodeConflict         = "CONFLICT"
+	ErrCodeVersionOutdated  = "VERSION_OUTDATED"
+	ErrCodeLockNotAcquired  = "LOCK_NOT_ACQUIRED"
+	ErrCodeCacheUnavailable = "CACHE_UNAVAILABLE"
+	ErrCodeRejected         = "REJECTED"
+	ErrCodeInternal         = "INTERNAL_ERROR"
+)
+
+// ErrorResponse 统一错误响应
+type ErrorResponse struct {
+	Code      int         `json:"code"`       // HTTP 状态码
+	ErrorCode string      `json:"error_code"` // 机器可读错误码
+	Message   string      `json:"message"`
+	Error     string      `json:"error,omitempty"`
+	Details   interface{} `json:"details,omitempty"` // 字段级错误明细（如 field_validator.Errors）或表达式错误位置
+}
+
+// errorMapping service 错误分类到 HTTP 状态码/错误码的映射，按顺序匹配
+var errorMapping = []struct {
+	target error
+	status int
+	code   string
+}{
+	{service.ErrValidation, http.StatusBadRequest, ErrCodeInvalidRequest},
+	{service.ErrNotFound, http.StatusNotFound, ErrCodeNotFound},
+	{service.ErrVersionOutdated, http.StatusConflict, ErrCodeVersionOutdated},
+	{service.ErrConflict, http.StatusConflict, ErrCodeConflict},
+	{service.ErrLockNotAcquired, http.StatusConflict, ErrCodeLockNotAcquired},
+	{service.ErrCacheUnavailable, http.StatusServiceUnavailable, ErrCodeCacheUnavailable},
+	{service.ErrRejected, http.StatusForbidden, ErrCodeRejected},
+}
+
+// mapError 返回错误对应的 HTTP 状态码与错误码，未归类的错误视为 500
+func mapError(err error) (int, string) {
+	for _, m := range errorMapping {
+		if errors.Is(err, m.target) {
+			return m.status, m.code
+		}
+	}
+	return http.StatusInternalServerError, ErrCodeInternal
+}
+
+// errorMiddleware 错误映射中间件：处理器通过 abortWithError 登记错误，由这里统一输出响应
+func errorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		last := c.Errors.Last()
+		status, code := mapError(last.Err)
+		message, _ := last.Meta.(string)
+		if message == "" {
+			message = http.StatusText(status)
+		}
+		resp := ErrorResponse{
+			Code:      status,
+			ErrorCode: code,
+			Message:   message,
+			Error:     last.Err.Error(),
+		}
+		var fieldErrs field_validator.Errors
+		var exprErr *filter_translator.ExprError
+		switch {
+		case errors.As(last.Err, &fieldErrs):
+			resp.Details = fieldErrs
+		case errors.As(last.Err, &exprErr):
+			resp.Details = gin.H{"line": exprErr.Line, "column": exprErr.Column, "snippet": exprErr.Snippet()}
+		}
+		c.JSON(status, resp)
+	}
+}
+
+// abortWithError 登记错误并中止处理链，message 为面向调用方的简短描述
+func abortWithError(c *gin.Context, message string, err error) {
+	_ = c.Error(err).SetMeta(message)
+	c.Abort()
+}
+
+// abortInvalid 请求参数错误（400），err 可为 nil
+func abortInvalid(c *gin.Context, message string, err error) {
+	abortWithError(c, message, service.NewValidationError("", message, err))
+}
@@ -1,13 +1,17 @@
 package http_router
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 
 	"AbstractManager/service"
 	"AbstractManager/util/filter_translator"
+	"AbstractManager/util/tracing"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 )
 
@@ -20,9 +24,78 @@ type PaginatedQueryMethod[T any] struct {
 	FilterFunc func(*gorm.DB) *gorm.DB
 	OrderBy    string
 	Order      string
+
+	Sorts           []service.SortParam // 默认多列排序（非空时优先于 OrderBy/Order）
+	AllowClientSort bool                // 是否允许请求中的 sort 覆盖默认排序
+	SearchFields    []string            // 请求参数 q 搜索的字段，为空时方法不支持搜索
+}
+
+// ExecuteOptions 单次执行时由客户端指定的参数
+type ExecuteOptions struct {
+	Columns  []string            // 只查询这些列（字段投影）
+	Sorts    []service.SortParam // 客户端排序，需方法允许
+	PageSize int                 // 客户端每页数量，0 或超过方法的 PageSize 时使用方法的 PageSize
+
+	Cursor    string // 游标分页：上一次响应中的 next_cursor / prev_cursor
+	UseCursor bool   // 游标分页首页
+	SkipCount bool   // 跳过总数统计
+
+	Search string // 搜索词（q），在方法的 SearchFields 中搜索
+
+	// filters 对应的条件树，service 启用查询缓存时参与缓存键（为空且 filters 非空时不使用缓存）
+	Filters *filter_translator.FilterNode
+}
+
+// SetDefaultSort 设置默认排序
+func (m *PaginatedQueryMethod[T]) SetDefaultSort(sorts ...service.SortParam) *PaginatedQueryMethod[T] {
+	m.Sorts = sorts
+	return m
+}
+
+// SetClientSort 设置是否允许客户端覆盖排序
+func (m *PaginatedQueryMethod[T]) SetClientSort(allowed bool) *PaginatedQueryMethod[T] {
+	m.AllowClientSort = allowed
+	return m
+}
+
+// SearchOptions 按搜索词生成 service 搜索选项；客户端未指定排序且非游标分页时按相关度排序
+func (m *PaginatedQueryMethod[T]) SearchOptions(eo *ExecuteOptions) (*service.SearchOptions, error) {
+	if eo == nil || eo.Search == "" {
+		return nil, nil
+	}
+	if len(m.SearchFields) == 0 {
+		return nil, service.NewValidationError("q", fmt.Sprintf("method %s does not support search", m.Name), nil)
+	}
+	return &service.SearchOptions{
+		Query:            eo.Search,
+		Fields:           m.SearchFields,
+		OrderByRelevance: len(eo.Sorts) == 0 && eo.Cursor == "" && !eo.UseCursor,
+	}, nil
+}
+
+// Execute 执行分页查询（使用方法的默认排序）
+func (m *PaginatedQueryMethod[T]) Execute(ctx context.Context, page int, filters []filter_translator.GormFilter) (*service.QueryResult[T], error) {
+	return m.ExecuteWith(ctx, page, filters, nil)
 }
 
-func (m *PaginatedQueryMethod[T]) Execute(page int, filters []filter_translator.GormFilter) (*service.QueryResult[T], error) {
+// ExecuteWith 按客户端参数执行分页查询
+// 客户端排序字段由 service 按列策略（ColumnPolicy.Sort）校验
+func (m *PaginatedQueryMethod[T]) ExecuteWith(ctx context.Context, page int, filters []filter_translator.GormFilter, eo *ExecuteOptions) (*service.QueryResult[T], error) {
+	if eo == nil {
+		eo = &ExecuteOptions{}
+	}
+	sorts := m.Sorts
+	if len(eo.Sorts) > 0 {
+		if !m.AllowClientSort {
+			return nil, service.NewValidationError("sort", fmt.Sprintf("method %s does not allow client sorting", m.Name), nil)
+		}
+		sorts = eo.Sorts
+	}
+	search, err := m.SearchOptions(eo)
+	if err != nil {
+		return nil, err
+	}
+
 	queryFunc := func(db *gorm.DB) *gorm.DB {
 		if m.FilterFunc != nil {
 			db = m.FilterFunc(db)
@@ -34,14 +107,31 @@ func (m *PaginatedQueryMethod[T]) Execute(page int, filters []filter_translator.
 		return db
 	}
 
+	pageSize := m.PageSize
+	if eo.PageSize > 0 && (pageSize <= 0 || eo.PageSize < pageSize) {
+		pageSize = eo.PageSize
+	}
+
 	opts := &service.QueryOptions{
 		Page:     page,
-		PageSize: m.PageSize,
+		PageSize: pageSize,
 		OrderBy:  m.OrderBy,
 		Order:    m.Order,
+		Sorts:    sorts,
+		Select:   eo.Columns,
+
+		Cursor:    eo.Cursor,
+		UseCursor: eo.UseCursor,
+		SkipCount: eo.SkipCount,
+
+		Search: search,
 	}
 
-	return m.Service.GetQuery(nil, queryFunc, opts)
+	// 启用查询缓存时按方法名 + 条件树缓存结果（缓存路径返回完整的行，由调用方投影）
+	if m.Service.QueryCacheEnabled() && (eo.Filters != nil || len(filters) == 0) {
+		return m.Service.GetQueryCached(ctx, m.Name, eo.Filters, queryFunc, opts)
+	}
+	return m.Service.GetQuery(ctx, queryFunc, opts)
 }
 
 // ========== 查询方法注册表 (保持不变) ==========
@@ -73,6 +163,8 @@ type QueryRouterGroup[T any] struct {
 	MethodRegistry *QueryMethodRegistry[T]
 	// 【变更2】类型改为 GormTranslatorRegistry (根据示例推断)
 	TranslatorRegistry *filter_translator.GormTranslatorRegistry
+	// 日志记录器（为空时使用 Service 的日志记录器）
+	Logger *slog.Logger
 }
 
 func NewQueryRouterGroup[T any](
@@ -88,9 +180,20 @@ func NewQueryRouterGroup[T any](
 	}
 }
 
+// SetLogger 设置路由组的日志记录器
+func (qrg *QueryRouterGroup[T]) SetLogger(l *slog.Logger) *QueryRouterGroup[T] {
+	qrg.Logger = l
+	return qrg
+}
+
+func (qrg *QueryRouterGroup[T]) logger() *slog.Logger {
+	return groupLogger(qrg.Logger, qrg.Service)
+}
+
 // ========== 注册预定义查询方法 (保持不变) ==========
 
-func (qrg *QueryRouterGroup[T]) RegisterMethod(name string, pageSize int, filterFunc func(*gorm.DB) *gorm.DB, orderBy string, order string) {
+// RegisterMethod 注册分页查询方法，返回的方法可继续设置默认排序与是否允许客户端排序
+func (qrg *QueryRouterGroup[T]) RegisterMethod(name string, pageSize int, filterFunc func(*gorm.DB) *gorm.DB, orderBy string, order string) *PaginatedQueryMethod[T] {
 	method := &PaginatedQueryMethod[T]{
 		Name:       name,
 		PageSize:   pageSize,
@@ -100,6 +203,7 @@ func (qrg *QueryRouterGroup[T]) RegisterMethod(name string, pageSize int, filter
 		Order:      order,
 	}
 	qrg.MethodRegistry.Register(method)
+	return method
 }
 
 func (qrg *QueryRouterGroup[T]) RegisterListMethod(pageSize int) {
@@ -112,38 +216,63 @@ func (qrg *QueryRouterGroup[T]) RegisterActiveListMethod(pageSize int) {
 	}, "created_at", "DESC")
 }
 
-func (qrg *QueryRouterGroup[T]) RegisterSearchMethod(pageSize int, searchFields []string) {
-	qrg.RegisterMethod("search", pageSize, nil, "created_at", "DESC")
+// RegisterSearchMethod 注册 search 方法：请求参数 q 在 searchFields 中搜索（默认 LIKE，
+// 有覆盖这些字段的 FULLTEXT 索引时使用全文检索），结果按相关度排序，created_at 降序作为次级排序
+func (qrg *QueryRouterGroup[T]) RegisterSearchMethod(pageSize int, searchFields []string) *PaginatedQueryMethod[T] {
+	method := qrg.RegisterMethod("search", pageSize, nil, "created_at", "DESC")
+	method.SearchFields = searchFields
+	return method
 }
 
 // ========== 路由注册 ==========
 
 func (qrg *QueryRouterGroup[T]) RegisterRoutes(basePath string) {
-	qrg.RouterGroup.POST(basePath+"/query", qrg.HandleQuery)
-	qrg.RouterGroup.GET(basePath+"/:id", qrg.HandleGetByID)
-	qrg.RouterGroup.POST(basePath+"/count", qrg.HandleCount)
+	resource := qrg.Service.ResourceName
+	qrg.RouterGroup.GET(basePath, routeHandlers(resource, "QueryRouterGroup.HandleList", qrg.logger, qrg.HandleList)...)
+	qrg.RouterGroup.POST(basePath+"/query", routeHandlers(resource, "QueryRouterGroup.HandleQuery", qrg.logger, qrg.HandleQuery)...)
+	qrg.RouterGroup.GET(basePath+idRoutePath(qrg.Service), routeHandlers(resource, "QueryRouterGroup.HandleGetByID", qrg.logger, qrg.HandleGetByID)...)
+	qrg.RouterGroup.POST(basePath+"/count", routeHandlers(resource, "QueryRouterGroup.HandleCount", qrg.logger, qrg.HandleCount)...)
+	qrg.RouterGroup.POST(basePath+"/aggregate", routeHandlers(resource, "QueryRouterGroup.HandleAggregate", qrg.logger, qrg.HandleAggregate)...)
 }
 
 // ========== 请求/响应结构 (保持不变) ==========
 
 type QueryRequest struct {
-	Method  string                          `json:"method"`
-	Page    int                             `json:"page"`
-	Filters []filter_translator.FilterParam `json:"filters"`
+	Method   string                          `json:"method"`
+	Page     int                             `json:"page"`
+	PageSize int                             `json:"page_size,omitempty"` // 每页数量，不超过方法的 PageSize
+	Filters  []filter_translator.FilterParam `json:"filters"`
+	Where    *filter_translator.FilterNode   `json:"where,omitempty"`  // and / or / not 条件树，与 filters 以 AND 合并
+	Expr     string                          `json:"expr,omitempty"`   // 文本过滤表达式（如 age > 18 and name ~ "jo"），与 filters 以 AND 合并
+	Fields   []string                        `json:"fields,omitempty"` // 只返回这些字段（json 名 / 列名 / Go 字段名）
+	Sort     []service.SortParam             `json:"sort,omitempty"`   // 多列排序，方法需允许客户端排序
+
+	Cursor    string `json:"cursor,omitempty"`     // 游标分页：上一次响应的 next_cursor / prev_cursor（此时忽略 page）
+	UseCursor bool   `json:"use_cursor,omitempty"` // 游标分页首页（尚无游标）时置 true
+	SkipCount bool   `json:"skip_count,omitempty"` // 跳过总数统计，total/total_pages 为 0
+
+	Q         string `json:"q,omitempty"`         // 搜索词，方法需配置搜索字段（见 RegisterSearchMethod）
+	Highlight bool   `json:"highlight,omitempty"` // 返回 highlights：与 data 一一对应的命中字段高亮文本
 }
 
 type QueryResponse[T any] struct {
-	Code       int    `json:"code"`
-	Message    string `json:"message"`
-	Data       []T    `json:"data"`
-	Total      int64  `json:"total"`
-	Page       int    `json:"page"`
-	PageSize   int    `json:"page_size"`
-	TotalPages int    `json:"total_pages"`
+	Code       int         `json:"code"`
+	Message    string      `json:"message"`
+	Data       interface{} `json:"data"` // []T；指定 fields 时为只含这些字段的对象列表
+	Total      int64       `json:"total"`
+	Page       int         `json:"page"`
+	PageSize   int         `json:"page_size"`
+	TotalPages int         `json:"total_pages"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	PrevCursor string      `json:"prev_cursor,omitempty"`
+
+	Highlights []map[string]string `json:"highlights,omitempty"`
 }
 
 type CountRequest struct {
 	Filters []filter_translator.FilterParam `json:"filters"`
+	Where   *filter_translator.FilterNode   `json:"where,omitempty"`
+	Expr    string                          `json:"expr,omitempty"`
 }
 
 type CountResponse struct {
@@ -152,69 +281,211 @@ type CountResponse struct {
 	Count   int64  `json:"count"`
 }
 
+// MaxAggregateRows 聚合接口单次最多返回的分组数（请求未指定 limit 或超出时使用）
+const MaxAggregateRows = 1000
+
+type AggregateRequest struct {
+	Filters []filter_translator.FilterParam `json:"filters"`
+	Where   *filter_translator.FilterNode   `json:"where,omitempty"`
+	Expr    string                          `json:"expr,omitempty"`
+	GroupBy []string                        `json:"group_by,omitempty"` // 分组字段，为空时整体聚合为一行
+	Metrics []service.Metric                `json:"metrics"`            // 指标：count / count_distinct / sum / avg / min / max
+	Having  []service.HavingCondition       `json:"having,omitempty"`   // 按指标结果名过滤分组
+	Sort    []service.SortParam             `json:"sort,omitempty"`     // 按分组字段或指标结果名排序
+	Limit   int                             `json:"limit,omitempty"`    // 最多返回的分组数，不超过 MaxAggregateRows
+}
+
+type AggregateResponse struct {
+	Code    int                    `json:"code"`
+	Message string                 `json:"message"`
+	Data    []service.AggregateRow `json:"data"`
+}
+
 // ========== 处理器 ==========
 
 func (qrg *QueryRouterGroup[T]) HandleQuery(c *gin.Context) {
 	var req QueryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "invalid request", "error": err.Error()})
+		abortInvalid(c, "invalid request", err)
 		return
 	}
+	qrg.serveQuery(c, &req)
+}
+
+// HandleList GET 列表查询：过滤、排序与分页来自查询字符串（语法见 filter_translator.ParseQueryString），
+// 其余参数：expr（文本过滤表达式）、method（默认 list）、q、cursor、use_cursor、skip_count、highlight
+func (qrg *QueryRouterGroup[T]) HandleList(c *gin.Context) {
+	params, err := filter_translator.ParseQueryString(c.Request.URL.Query())
+	if err != nil {
+		abortInvalid(c, "invalid query string", err)
+		return
+	}
+
+	req := QueryRequest{
+		Method:    c.DefaultQuery("method", "list"),
+		Page:      params.Page,
+		PageSize:  params.PageSize,
+		Filters:   params.Filters,
+		Expr:      c.Query("expr"),
+		Fields:    params.Fields,
+		Cursor:    c.Query("cursor"),
+		UseCursor: c.Query("use_cursor") == "true",
+		SkipCount: c.Query("skip_count") == "true",
+		Q:         c.Query("q"),
+		Highlight: c.Query("highlight") == "true",
+	}
+	for _, s := range params.Sorts {
+		sort := service.SortParam{Field: s.Field, Direction: service.SortAsc}
+		if s.Desc {
+			sort.Direction = service.SortDesc
+		}
+		req.Sort = append(req.Sort, sort)
+	}
+	qrg.serveQuery(c, &req)
+}
+
+// serveQuery 执行查询请求并输出响应（POST /query 与 GET 列表共用）
+func (qrg *QueryRouterGroup[T]) serveQuery(c *gin.Context, req *QueryRequest) {
+	tree, err := combineFilters(req.Filters, req.Where, req.Expr)
+	if err != nil {
+		abortWithError(c, "invalid filters", err)
+		return
+	}
+	trace.SpanFromContext(c.Request.Context()).SetAttributes(
+		tracing.AttrQueryName.String(req.Method),
+		tracing.FiltersAttr(tree),
+	)
 
 	method, ok := qrg.MethodRegistry.Get(req.Method)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": fmt.Sprintf("unknown method: %s", req.Method)})
+		abortInvalid(c, fmt.Sprintf("unknown method: %s", req.Method), nil)
 		return
 	}
 
-	// 【变更4】TranslateBatch 返回的是 []filter_translator.BaseFilter (与示例同步)
-	filters, err := qrg.TranslatorRegistry.TranslateBatch(req.Filters)
+	// filters、where 与 expr 合并后整体翻译为一个分组过滤器
+	tree, err = resolveFilterTree(qrg.Service, tree)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "invalid filters", "error": err.Error()})
+		abortWithError(c, "invalid filters", err)
 		return
 	}
 
-	result, err := method.Execute(req.Page, filters)
+	filters, err := translateGormTree(qrg.Service, qrg.TranslatorRegistry, tree)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "query failed", "error": err.Error()})
+		abortInvalid(c, "invalid filters", err)
 		return
 	}
 
+	proj, err := qrg.Service.NewProjection(req.Fields)
+	if err != nil {
+		abortWithError(c, "invalid fields", err)
+		return
+	}
+
+	eo := &ExecuteOptions{
+		PageSize:  req.PageSize,
+		Sorts:     req.Sort,
+		Cursor:    req.Cursor,
+		UseCursor: req.UseCursor,
+		SkipCount: req.SkipCount,
+		Search:    req.Q,
+		Filters:   tree,
+	}
+	if proj != nil {
+		eo.Columns = proj.Columns
+	}
+	result, err := method.ExecuteWith(c.Request.Context(), req.Page, filters, eo)
+	if err != nil {
+		abortWithError(c, "query failed", err)
+		return
+	}
+
+	var highlights []map[string]string
+	if req.Highlight {
+		search, err := method.SearchOptions(eo)
+		if err == nil {
+			highlights, err = qrg.Service.Highlight(result.Data, search)
+		}
+		if err != nil {
+			abortWithError(c, "query failed", err)
+			return
+		}
+	}
+
+	var data interface{} = result.Data
+	if proj != nil {
+		if data, err = service.ProjectSlice(proj, result.Data); err != nil {
+			abortWithError(c, "query failed", err)
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, QueryResponse[T]{
 		Code:       0,
 		Message:    "success",
-		Data:       result.Data,
+		Data:       data,
 		Total:      result.Total,
 		Page:       result.Page,
 		PageSize:   result.PageSize,
 		TotalPages: result.TotalPages,
+		NextCursor: result.NextCursor,
+		PrevCursor: result.PrevCursor,
+		Highlights: highlights,
 	})
 }
 
+// HandleGetByID 按主键查询（复合主键的路由为 /:tenant/:sku 形式），支持 ?fields=id,name 只返回部分字段
 func (qrg *QueryRouterGroup[T]) HandleGetByID(c *gin.Context) {
-	id := c.Param("id")
-	result, err := qrg.Service.GetSingleByID(nil, id, nil)
+	id := idFromPath(c, qrg.Service)
+
+	proj, err := qrg.Service.NewProjection(parseFields(c))
 	if err != nil {
-		if err.Error() == "record not found" {
-			c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "record not found"})
+		abortWithError(c, "invalid fields", err)
+		return
+	}
+
+	var opts *service.SingleQueryOptions
+	if proj != nil {
+		opts = &service.SingleQueryOptions{Select: proj.Columns}
+	}
+	result, err := qrg.Service.GetSingleByID(c.Request.Context(), id, opts)
+	if err != nil {
+		abortWithError(c, "query failed", err)
+		return
+	}
+
+	var data interface{} = result
+	if proj != nil {
+		if data, err = proj.Apply(result); err != nil {
+			abortWithError(c, "query failed", err)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "query failed", "error": err.Error()})
-		return
 	}
-	c.JSON(http.StatusOK, gin.H{"code": 0, "message": "success", "data": result})
+	c.JSON(http.StatusOK, gin.H{"code": 0, "message": "success", "data": data})
 }
 
 func (qrg *QueryRouterGroup[T]) HandleCount(c *gin.Context) {
 	var req CountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "invalid request", "error": err.Error()})
+		abortInvalid(c, "invalid request", err)
+		return
+	}
+
+	tree, err := combineFilters(req.Filters, req.Where, req.Expr)
+	if err != nil {
+		abortWithError(c, "invalid filters", err)
+		return
+	}
+	trace.SpanFromContext(c.Request.Context()).SetAttributes(tracing.FiltersAttr(tree))
+
+	tree, err = resolveFilterTree(qrg.Service, tree)
+	if err != nil {
+		abortWithError(c, "invalid filters", err)
 		return
 	}
 
-	filters, err := qrg.TranslatorRegistry.TranslateBatch(req.Filters)
+	filters, err := translateGormTree(qrg.Service, qrg.TranslatorRegistry, tree)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "invalid filters", "error": err.Error()})
+		abortInvalid(c, "invalid filters", err)
 		return
 	}
 
@@ -223,15 +494,65 @@ func (qrg *QueryRouterGroup[T]) HandleCount(c *gin.Context) {
 		return filter_translator.ApplyGormFilters(db, filters)
 	}
 
-	count, err := qrg.Service.CountQuery(nil, queryFunc)
+	count, err := qrg.Service.CountQuery(c.Request.Context(), queryFunc)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "count failed", "error": err.Error()})
+		abortWithError(c, "count failed", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, CountResponse{Code: 0, Message: "success", Count: count})
 }
 
+// HandleAggregate 分组聚合：过滤条件与 query / count 相同，分组与指标字段须通过列策略 Aggregate 检查
+func (qrg *QueryRouterGroup[T]) HandleAggregate(c *gin.Context) {
+	var req AggregateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortInvalid(c, "invalid request", err)
+		return
+	}
+
+	tree, err := combineFilters(req.Filters, req.Where, req.Expr)
+	if err != nil {
+		abortWithError(c, "invalid filters", err)
+		return
+	}
+	trace.SpanFromContext(c.Request.Context()).SetAttributes(tracing.FiltersAttr(tree))
+
+	tree, err = resolveFilterTree(qrg.Service, tree)
+	if err != nil {
+		abortWithError(c, "invalid filters", err)
+		return
+	}
+
+	filters, err := translateGormTree(qrg.Service, qrg.TranslatorRegistry, tree)
+	if err != nil {
+		abortInvalid(c, "invalid filters", err)
+		return
+	}
+
+	queryFunc := func(db *gorm.DB) *gorm.DB {
+		return filter_translator.ApplyGormFilters(db, filters)
+	}
+
+	limit := req.Limit
+	if limit <= 0 || limit > MaxAggregateRows {
+		limit = MaxAggregateRows
+	}
+	rows, err := qrg.Service.Aggregate(c.Request.Context(), queryFunc, &service.AggregateOptions{
+		GroupBy: req.GroupBy,
+		Metrics: req.Metrics,
+		Having:  req.Having,
+		Sorts:   req.Sort,
+		Limit:   limit,
+	})
+	if err != nil {
+		abortWithError(c, "aggregate failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, AggregateResponse{Code: 0, Message: "success", Data: rows})
+}
+
 func (qrg *QueryRouterGroup[T]) RegisterCommonMethods(defaultPageSize int) {
 	qrg.RegisterListMethod(defaultPageSize)
 	qrg.RegisterActiveListMethod(defaultPageSize)
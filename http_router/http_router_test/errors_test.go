@@ -0,0 +1,103 @@
+package http_router_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"AbstractManager/http_router"
+	"AbstractManager/service"
+	"AbstractManager/util/field_validator"
+	"AbstractManager/util/filter_translator"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type account struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	UserName string `json:"user_name"`
+}
+
+// useDryRunDB 安装 DryRun 数据库作为全局连接
+func useDryRunDB(t *testing.T) {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:1)/db",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.UseDB(db)
+}
+
+func TestErrorResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	useDryRunDB(t)
+
+	// 读取钩子返回各类错误，经由 GET /accounts/:id 输出
+	var hookErr error
+	sm := service.NewServiceManager(account{})
+	sm.Before(func(ctx context.Context, op *service.Operation[account]) error { return hookErr }, service.OpGet)
+	engine := gin.New()
+	http_router.NewQueryRouterGroup(engine.Group("/api"), sm).RegisterRoutes("/accounts")
+
+	_, exprErr := filter_translator.ParseExpr("age >")
+	fieldErrs := field_validator.Errors{{Field: "user_name", Rule: "required", Message: "user_name is required"}}
+
+	cases := []struct {
+		name    string
+		path    string
+		err     error
+		status  int
+		code    string
+		details bool
+	}{
+		{"validation", "/api/accounts/1", service.NewValidationError("id", "bad id", nil), http.StatusBadRequest, http_router.ErrCodeInvalidRequest, false},
+		{"field errors", "/api/accounts/1", service.NewValidationError("", "invalid data", fieldErrs), http.StatusBadRequest, http_router.ErrCodeInvalidRequest, true},
+		{"expression", "/api/accounts/1", service.NewValidationError("expr", "invalid filter expression", exprErr), http.StatusBadRequest, http_router.ErrCodeInvalidRequest, true},
+		{"not found", "/api/accounts/1", &service.NotFoundError{Resource: "account", Err: gorm.ErrRecordNotFound}, http.StatusNotFound, http_router.ErrCodeNotFound, false},
+		{"version outdated", "/api/accounts/1", &service.VersionOutdatedError{Key: "k", Current: 2, Provided: 1}, http.StatusConflict, http_router.ErrCodeVersionOutdated, false},
+		{"conflict", "/api/accounts/1", fmt.Errorf("%w: %w", service.ErrConflict, gorm.ErrDuplicatedKey), http.StatusConflict, http_router.ErrCodeConflict, false},
+		{"lock", "/api/accounts/1", fmt.Errorf("writedown: %w", service.ErrLockNotAcquired), http.StatusConflict, http_router.ErrCodeLockNotAcquired, false},
+		{"cache down", "/api/accounts/1", fmt.Errorf("%w: dial tcp", service.ErrCacheUnavailable), http.StatusServiceUnavailable, http_router.ErrCodeCacheUnavailable, false},
+		{"rejected", "/api/accounts/1", service.Reject("read only"), http.StatusForbidden, http_router.ErrCodeRejected, false},
+		{"internal", "/api/accounts/1", errors.New("boom"), http.StatusInternalServerError, http_router.ErrCodeInternal, false},
+		// 超出主键类型范围的 ID 在查询前即被拒绝
+		{"pk out of range", "/api/accounts/18446744073709551616", nil, http.StatusBadRequest, http_router.ErrCodeInvalidRequest, false},
+		{"pk negative", "/api/accounts/-1", nil, http.StatusBadRequest, http_router.ErrCodeInvalidRequest, false},
+	}
+	for _, tc := range cases {
+		hookErr = tc.err
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if w.Code != tc.status {
+			t.Errorf("%s: status = %d, want %d (%s)", tc.name, w.Code, tc.status, w.Body)
+			continue
+		}
+		var body struct {
+			http_router.ErrorResponse
+			Details json.RawMessage `json:"details"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if body.Code != tc.status || body.ErrorCode != tc.code || body.Message != "query failed" {
+			t.Errorf("%s: body = %s", tc.name, w.Body)
+		}
+		if tc.err != nil && body.Error != tc.err.Error() {
+			t.Errorf("%s: error = %q, want %q", tc.name, body.Error, tc.err.Error())
+		}
+		if (len(body.Details) > 0) != tc.details || (tc.name == "expression" && !strings.Contains(string(body.Details), `"line":1`)) {
+			t.Errorf("%s: details = %s", tc.name, body.Details)
+		}
+	}
+}
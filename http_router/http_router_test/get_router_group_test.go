@@ -0,0 +1,213 @@
+package http_router_test
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"AbstractManager/http_router"
+	"AbstractManager/internal/sqltest"
+	"AbstractManager/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type member struct {
+	ID       uint    `gorm:"primaryKey" json:"id"`
+	Name     string  `json:"name"`
+	Score    int     `json:"score"`
+	Nickname *string `json:"nickname"`
+}
+
+// memberRouter 以驱动桩为数据库注册 /api/members 路由：count 查询返回行数，分组查询返回按名字的聚合，其余查询返回全部行
+func memberRouter(t *testing.T, rows ...member) (*gin.Engine, *sqltest.DB) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, fdb := sqltest.Open(t, func(query string, _ []driver.Value) ([]string, [][]driver.Value) {
+		switch {
+		case strings.Contains(query, "GROUP BY"):
+			return []string{"name", "count", "sum_score"}, [][]driver.Value{{"ann", int64(2), int64(30)}, {"bob", int64(1), int64(5)}}
+		case strings.Contains(query, "count("):
+			return []string{"count(*)"}, [][]driver.Value{{int64(len(rows))}}
+		}
+		var out [][]driver.Value
+		for _, r := range rows {
+			var nickname driver.Value
+			if r.Nickname != nil {
+				nickname = *r.Nickname
+			}
+			out = append(out, []driver.Value{int64(r.ID), r.Name, int64(r.Score), nickname})
+		}
+		return []string{"id", "name", "score", "nickname"}, out
+	})
+	service.UseDB(db)
+
+	sm := service.NewServiceManager(member{})
+	sm.TableName = "members"
+	engine := gin.New()
+	qrg := http_router.NewQueryRouterGroup(engine.Group("/api"), sm)
+	qrg.RegisterMethod("list", 10, nil, "id", "ASC").SetClientSort(true)
+	qrg.RegisterRoutes("/members")
+	return engine, fdb
+}
+
+// serve 发送请求并解析 JSON 响应
+func serve(t *testing.T, engine *gin.Engine, method, target, body string) (int, map[string]interface{}) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	engine.ServeHTTP(w, req)
+	var out map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("%s %s: %v (%s)", method, target, err, w.Body)
+	}
+	return w.Code, out
+}
+
+// lastSelect 最后一条非 count 的 SELECT 语句
+func lastSelect(db *sqltest.DB) string {
+	statements := db.Statements()
+	for i := len(statements) - 1; i >= 0; i-- {
+		if strings.HasPrefix(statements[i], "SELECT") && !strings.Contains(statements[i], "count(") {
+			return statements[i]
+		}
+	}
+	return ""
+}
+
+func TestQueryRouterRejectsBadInput(t *testing.T) {
+	engine, _ := memberRouter(t, member{ID: 1, Name: "ann"})
+
+	cases := []struct {
+		name   string
+		method string
+		target string
+		body   string
+	}{
+		{"unknown filter field", http.MethodGet, "/api/members?filter[nope]=1", ""},
+		{"unknown projected field", http.MethodGet, "/api/members?fields=id,nope", ""},
+		{"unknown sort field", http.MethodGet, "/api/members?sort=-nope", ""},
+		{"unknown field in where", http.MethodPost, "/api/members/query", `{"method":"list","where":{"field":"nope","operator":"=","value":1}}`},
+		{"unknown field in expr", http.MethodPost, "/api/members/count", `{"expr":"nope = 1"}`},
+		{"unknown aggregate field", http.MethodPost, "/api/members/aggregate", `{"group_by":["nope"],"metrics":[{"func":"count"}]}`},
+		{"nullable cursor column", http.MethodGet, "/api/members?sort=nickname&use_cursor=true&page_size=2", ""},
+		{"nullable cursor column in body", http.MethodPost, "/api/members/query", `{"method":"list","sort":[{"field":"nickname"}],"use_cursor":true,"page_size":2}`},
+		{"malformed expr", http.MethodGet, "/api/members?expr=" + url.QueryEscape("score >"), ""},
+		{"malformed expr in body", http.MethodPost, "/api/members/query", `{"method":"list","expr":"(score > 1"}`},
+		{"malformed cursor", http.MethodGet, "/api/members?cursor=not-a-cursor", ""},
+	}
+	for _, tc := range cases {
+		status, body := serve(t, engine, tc.method, tc.target, tc.body)
+		if status != http.StatusBadRequest || body["error_code"] != http_router.ErrCodeInvalidRequest {
+			t.Errorf("%s: status = %d, body = %v", tc.name, status, body)
+		}
+	}
+}
+
+func TestQueryRouterListResponse(t *testing.T) {
+	engine, db := memberRouter(t, member{ID: 1, Name: "ann", Score: 10}, member{ID: 2, Name: "amy", Score: 20})
+
+	// 查询字符串过滤、排序与分页
+	status, body := serve(t, engine, http.MethodGet, "/api/members?filter[score][gte]=10&filter[name][starts_with]=a&sort=-score&page=1&page_size=5", "")
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body = %v", status, body)
+	}
+	want := map[string]interface{}{"code": 0.0, "message": "success", "total": 2.0, "page": 1.0, "page_size": 5.0, "total_pages": 1.0}
+	for k, v := range want {
+		if body[k] != v {
+			t.Errorf("%s = %v, want %v", k, body[k], v)
+		}
+	}
+	if data, _ := body["data"].([]interface{}); len(data) != 2 {
+		t.Errorf("data = %v", body["data"])
+	}
+	if sql := lastSelect(db); !strings.Contains(sql, "`name` LIKE ?") || !strings.Contains(sql, "`score` >= ?") || !strings.Contains(sql, "ORDER BY `score` DESC") {
+		t.Errorf("query = %s", sql)
+	}
+
+	// 字段投影：只返回指定字段
+	_, body = serve(t, engine, http.MethodGet, "/api/members?fields=id,name", "")
+	data, _ := body["data"].([]interface{})
+	if len(data) != 2 || !reflect.DeepEqual(data[0], map[string]interface{}{"id": 1.0, "name": "ann"}) {
+		t.Errorf("projected data = %v", body["data"])
+	}
+	if sql := lastSelect(db); !strings.HasPrefix(sql, "SELECT `id`,`name` FROM `members`") {
+		t.Errorf("projected query = %s", sql)
+	}
+
+	// 文本表达式与 filters 以 AND 合并
+	status, body = serve(t, engine, http.MethodPost, "/api/members/query",
+		`{"method":"list","filters":[{"field":"score","operator":">","value":1}],"expr":"name = \"ann\" or not score < 5"}`)
+	if status != http.StatusOK || body["total"] != 2.0 {
+		t.Fatalf("expr query: status = %d, body = %v", status, body)
+	}
+	if sql := lastSelect(db); !strings.Contains(sql, "`score` > ?") || !strings.Contains(sql, "`name` = ?") || !strings.Contains(sql, "NOT") {
+		t.Errorf("expr query = %s", sql)
+	}
+}
+
+func TestQueryRouterCursorPaging(t *testing.T) {
+	engine, db := memberRouter(t,
+		member{ID: 1, Name: "ann", Score: 30}, member{ID: 2, Name: "bob", Score: 20}, member{ID: 3, Name: "cat", Score: 10})
+
+	// 首页：多取一行判断是否有下一页，不统计总数
+	status, body := serve(t, engine, http.MethodGet, "/api/members?sort=-score&use_cursor=true&skip_count=true&page_size=2", "")
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body = %v", status, body)
+	}
+	next, _ := body["next_cursor"].(string)
+	if data, _ := body["data"].([]interface{}); len(data) != 2 || next == "" || body["total"] != 0.0 {
+		t.Fatalf("first page = %v", body)
+	}
+	if _, ok := body["prev_cursor"]; ok {
+		t.Errorf("first page has a prev_cursor: %v", body["prev_cursor"])
+	}
+
+	// 下一页：条件由游标生成，忽略 page
+	db.Reset()
+	status, body = serve(t, engine, http.MethodPost, "/api/members/query",
+		`{"method":"list","sort":[{"field":"score","direction":"desc"}],"cursor":"`+next+`","page":9,"page_size":2}`)
+	if status != http.StatusOK || body["prev_cursor"] == nil {
+		t.Fatalf("next page: status = %d, body = %v", status, body)
+	}
+	if sql := lastSelect(db); !strings.Contains(sql, "WHERE (`score` < ? OR (`score` = ? AND `id` > ?)) ORDER BY `score` DESC,`id` LIMIT ?") {
+		t.Errorf("next page query = %s", sql)
+	}
+
+	// 游标与排序不一致时拒绝
+	status, _ = serve(t, engine, http.MethodGet, "/api/members?sort=score&cursor="+url.QueryEscape(next)+"&page_size=2", "")
+	if status != http.StatusBadRequest {
+		t.Errorf("cursor with a different sort: status = %d", status)
+	}
+}
+
+func TestQueryRouterAggregateResponse(t *testing.T) {
+	engine, db := memberRouter(t)
+
+	status, body := serve(t, engine, http.MethodPost, "/api/members/aggregate",
+		`{"expr":"score > 0","group_by":["name"],"metrics":[{"func":"count"},{"func":"sum","field":"score"}],"sort":[{"field":"count","direction":"desc"}],"limit":10}`)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body = %v", status, body)
+	}
+	want := []interface{}{
+		map[string]interface{}{"keys": map[string]interface{}{"name": "ann"}, "metrics": map[string]interface{}{"count": 2.0, "sum_score": 30.0}},
+		map[string]interface{}{"keys": map[string]interface{}{"name": "bob"}, "metrics": map[string]interface{}{"count": 1.0, "sum_score": 5.0}},
+	}
+	if body["code"] != 0.0 || !reflect.DeepEqual(body["data"], want) {
+		t.Errorf("body = %v", body)
+	}
+	sql := lastSelect(db)
+	for _, part := range []string{"COUNT(*) AS `count`", "SUM(`score`) AS `sum_score`", "WHERE `score` > ?", "GROUP BY `name`", "ORDER BY `count` DESC", "LIMIT ?"} {
+		if !strings.Contains(sql, part) {
+			t.Errorf("aggregate query %s lacks %s", sql, part)
+		}
+	}
+}
@@ -1,7 +1,15 @@
 package http_router
 
 import (
+	"log/slog"
+	"strings"
+	"time"
+
 	serviceManager "AbstractManager/service"
+	"AbstractManager/util/filter_translator"
+	"AbstractManager/util/tracing"
+
+	"github.com/gin-gonic/gin"
 )
 
 // HTTPRouterManager 封装了 ServiceManager 并提供 HTTP 路由注册功能
@@ -27,3 +35,131 @@ func NewHTTPRouterManagerFromModel[T any](model T) *HTTPRouterManager[T] {
 		ServiceManager: serviceManager.NewServiceManager(model),
 	}
 }
+
+// routeHandlers 为单个路由组装处理链：链路追踪中间件 + 日志中间件 + 错误映射中间件 + 业务处理器
+// resource 为资源名，handler 为处理器名（如 "QueryRouterGroup.HandleQuery"）
+// logger 在请求时才求值，因此注册路由之后再调用 SetLogger 同样生效
+func routeHandlers(resource, handler string, logger func() *slog.Logger, h gin.HandlerFunc) []gin.HandlerFunc {
+	return []gin.HandlerFunc{tracing.GinMiddleware(resource, handler), logMiddleware(handler, logger), errorMiddleware(), h}
+}
+
+// combineFilters 合并扁平 filters、where 条件树与 expr 文本表达式（三者以 AND 连接），都为空时返回 nil
+// 表达式语法错误返回 ValidationError，底层 *filter_translator.ExprError 带出错位置
+func combineFilters(filters []filter_translator.FilterParam, where *filter_translator.FilterNode, expr string) (*filter_translator.FilterNode, error) {
+	tree := filter_translator.Combine(filters, where)
+	if strings.TrimSpace(expr) == "" {
+		return tree, nil
+	}
+	parsed, err := filter_translator.ParseExpr(expr)
+	if err != nil {
+		return nil, serviceManager.NewValidationError("expr", "invalid filter expression", err)
+	}
+	if tree == nil {
+		return parsed, nil
+	}
+	return &filter_translator.FilterNode{And: []filter_translator.FilterNode{*tree, *parsed}}, nil
+}
+
+// resolveFilterTree 检查条件树结构，按列策略校验过滤字段，并将字段名替换为数据库列名（返回副本）
+// tree 为 nil 时返回 nil
+func resolveFilterTree[T any](svc *serviceManager.ServiceManager[T], tree *filter_translator.FilterNode) (*filter_translator.FilterNode, error) {
+	if tree == nil {
+		return nil, nil
+	}
+	if err := tree.Validate(); err != nil {
+		return nil, serviceManager.NewValidationError("where", err.Error(), nil)
+	}
+	return tree.Map(func(p filter_translator.FilterParam) (filter_translator.FilterParam, error) {
+		col, err := svc.ResolveColumn(p.Field, serviceManager.UsageFilter)
+		if err != nil {
+			return p, err
+		}
+		p.Field = col
+		return p, nil
+	})
+}
+
+// translateGormTree 将条件树翻译为 GORM 过滤器列表（空树返回 nil）
+// 注册表绑定 T 的 schema，关联字段路径（如 "Profile.city"）翻译为 EXISTS 子查询
+func translateGormTree[T any](svc *serviceManager.ServiceManager[T], registry *filter_translator.GormTranslatorRegistry, tree *filter_translator.FilterNode) ([]filter_translator.GormFilter, error) {
+	if tree == nil {
+		return nil, nil
+	}
+	s, err := svc.ModelSchema()
+	if err != nil {
+		return nil, err
+	}
+	filter, err := registry.ForSchema(s).TranslateTree(tree)
+	if err != nil {
+		return nil, err
+	}
+	return []filter_translator.GormFilter{filter}, nil
+}
+
+// parseFields 解析查询参数 fields（逗号分隔，如 ?fields=id,name）
+func parseFields(c *gin.Context) []string {
+	var fields []string
+	for _, f := range strings.Split(c.Query("fields"), ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// idRoutePath 按 ID 访问的路由路径：单主键为 "/:id"，复合主键为各主键列组成的路径段（如 "/:tenant/:sku"）
+func idRoutePath[T any](svc *serviceManager.ServiceManager[T]) string {
+	pks, err := svc.PrimaryKeys()
+	if err != nil || len(pks) <= 1 {
+		return "/:id"
+	}
+	return "/:" + strings.Join(pks, "/:")
+}
+
+// idFromPath 取出 idRoutePath 路由中的 ID：单主键为 :id 的值，复合主键为按主键顺序排列的路径段
+func idFromPath[T any](c *gin.Context, svc *serviceManager.ServiceManager[T]) interface{} {
+	pks, err := svc.PrimaryKeys()
+	if err != nil || len(pks) <= 1 {
+		return c.Param("id")
+	}
+	parts := make([]string, len(pks))
+	for i, name := range pks {
+		parts[i] = c.Param(name)
+	}
+	return parts
+}
+
+// groupLogger 返回路由组使用的日志记录器：优先使用路由组自身的 Logger，否则沿用 ServiceManager 的
+func groupLogger[T any](l *slog.Logger, svc *serviceManager.ServiceManager[T]) *slog.Logger {
+	if l == nil {
+		return svc.GetLogger()
+	}
+	return l.With(slog.String("resource", svc.ResourceName))
+}
+
+// logMiddleware 记录请求结果：5xx 记为 Error，携带 c.Errors 的 4xx 记为 Warn，其余记为 Debug
+func logMiddleware(handler string, logger func() *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("handler", handler),
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("elapsed", time.Since(start)),
+		}
+
+		level := slog.LevelDebug
+		if len(c.Errors) > 0 {
+			level = slog.LevelWarn
+			attrs = append(attrs, slog.String("error", c.Errors.Last().Err.Error()))
+		}
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		logger().LogAttrs(c.Request.Context(), level, "http request", attrs...)
+	}
+}
@@ -1,13 +1,15 @@
 package http_router
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 
 	"AbstractManager/service"
+	"AbstractManager/util/field_validator"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // ========== 写操作请求/响应结构 ==========
@@ -96,6 +98,8 @@ type WriteResponse struct {
 type WriteRouterGroup[T any] struct {
 	RouterGroup *gin.RouterGroup
 	Service     *service.ServiceManager[T]
+	Logger      *slog.Logger               // 日志记录器（为空时使用 Service 的日志记录器）
+	Validator   *field_validator.Validator // 字段校验器（为空时不做校验）
 }
 
 func NewWriteRouterGroup[T any](
@@ -105,27 +109,46 @@ func NewWriteRouterGroup[T any](
 	return &WriteRouterGroup[T]{
 		RouterGroup: rg,
 		Service:     service,
+		Validator:   field_validator.Default,
 	}
 }
 
+// SetLogger 设置路由组的日志记录器
+func (wrg *WriteRouterGroup[T]) SetLogger(l *slog.Logger) *WriteRouterGroup[T] {
+	wrg.Logger = l
+	return wrg
+}
+
+func (wrg *WriteRouterGroup[T]) logger() *slog.Logger {
+	return groupLogger(wrg.Logger, wrg.Service)
+}
+
+// SetValidator 设置字段校验器，传入 nil 关闭校验
+func (wrg *WriteRouterGroup[T]) SetValidator(v *field_validator.Validator) *WriteRouterGroup[T] {
+	wrg.Validator = v
+	return wrg
+}
+
 // ========== 路由注册 ==========
 
 func (wrg *WriteRouterGroup[T]) RegisterRoutes(basePath string) {
+	resource := wrg.Service.ResourceName
+
 	// 单个操作
-	wrg.RouterGroup.POST(basePath+"/set", wrg.HandleSetSingle)
-	wrg.RouterGroup.POST(basePath+"/insert", wrg.HandleInsert)
-	wrg.RouterGroup.PUT(basePath+"/update", wrg.HandleUpdate)
-	wrg.RouterGroup.DELETE(basePath+"/delete", wrg.HandleDelete)
-	wrg.RouterGroup.POST(basePath+"/upsert", wrg.HandleUpsert)
-	wrg.RouterGroup.POST(basePath+"/increment", wrg.HandleIncrement)
+	wrg.RouterGroup.POST(basePath+"/set", routeHandlers(resource, "WriteRouterGroup.HandleSetSingle", wrg.logger, wrg.HandleSetSingle)...)
+	wrg.RouterGroup.POST(basePath+"/insert", routeHandlers(resource, "WriteRouterGroup.HandleInsert", wrg.logger, wrg.HandleInsert)...)
+	wrg.RouterGroup.PUT(basePath+"/update", routeHandlers(resource, "WriteRouterGroup.HandleUpdate", wrg.logger, wrg.HandleUpdate)...)
+	wrg.RouterGroup.DELETE(basePath+"/delete", routeHandlers(resource, "WriteRouterGroup.HandleDelete", wrg.logger, wrg.HandleDelete)...)
+	wrg.RouterGroup.POST(basePath+"/upsert", routeHandlers(resource, "WriteRouterGroup.HandleUpsert", wrg.logger, wrg.HandleUpsert)...)
+	wrg.RouterGroup.POST(basePath+"/increment", routeHandlers(resource, "WriteRouterGroup.HandleIncrement", wrg.logger, wrg.HandleIncrement)...)
 
 	// 批量操作
-	wrg.RouterGroup.POST(basePath+"/batch/set", wrg.HandleSetQuery)
-	wrg.RouterGroup.POST(basePath+"/batch/insert", wrg.HandleBatchInsert)
-	wrg.RouterGroup.PUT(basePath+"/batch/update", wrg.HandleBatchUpdate)
-	wrg.RouterGroup.DELETE(basePath+"/batch/delete", wrg.HandleBatchDelete)
-	wrg.RouterGroup.POST(basePath+"/batch/upsert", wrg.HandleBatchUpsert)
-	wrg.RouterGroup.POST(basePath+"/batch/increment", wrg.HandleBatchIncrement)
+	wrg.RouterGroup.POST(basePath+"/batch/set", routeHandlers(resource, "WriteRouterGroup.HandleSetQuery", wrg.logger, wrg.HandleSetQuery)...)
+	wrg.RouterGroup.POST(basePath+"/batch/insert", routeHandlers(resource, "WriteRouterGroup.HandleBatchInsert", wrg.logger, wrg.HandleBatchInsert)...)
+	wrg.RouterGroup.PUT(basePath+"/batch/update", routeHandlers(resource, "WriteRouterGroup.HandleBatchUpdate", wrg.logger, wrg.HandleBatchUpdate)...)
+	wrg.RouterGroup.DELETE(basePath+"/batch/delete", routeHandlers(resource, "WriteRouterGroup.HandleBatchDelete", wrg.logger, wrg.HandleBatchDelete)...)
+	wrg.RouterGroup.POST(basePath+"/batch/upsert", routeHandlers(resource, "WriteRouterGroup.HandleBatchUpsert", wrg.logger, wrg.HandleBatchUpsert)...)
+	wrg.RouterGroup.POST(basePath+"/batch/increment", routeHandlers(resource, "WriteRouterGroup.HandleBatchIncrement", wrg.logger, wrg.HandleBatchIncrement)...)
 }
 
 // ========== 单个操作处理器 ==========
@@ -134,18 +157,12 @@ func (wrg *WriteRouterGroup[T]) RegisterRoutes(basePath string) {
 func (wrg *WriteRouterGroup[T]) HandleSetSingle(c *gin.Context) {
 	var req SetSingleRequest[T]
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: fmt.Sprintf("invalid request: %v", err),
-		})
+		abortInvalid(c, "invalid request", err)
 		return
 	}
 
 	if req.Data == nil {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: "data cannot be nil",
-		})
+		abortInvalid(c, "data cannot be nil", nil)
 		return
 	}
 
@@ -154,11 +171,12 @@ func (wrg *WriteRouterGroup[T]) HandleSetSingle(c *gin.Context) {
 		InvalidateCache:  req.InvalidateCache,
 	}
 
+	if !wrg.validateData(c, req.Data) {
+		return
+	}
+
 	if err := wrg.Service.SetSingle(c.Request.Context(), req.Data, opts); err != nil {
-		c.JSON(http.StatusInternalServerError, WriteResponse{
-			Code:    500,
-			Message: fmt.Sprintf("set single failed: %v", err),
-		})
+		abortWithError(c, "set single failed", err)
 		return
 	}
 
@@ -172,26 +190,21 @@ func (wrg *WriteRouterGroup[T]) HandleSetSingle(c *gin.Context) {
 func (wrg *WriteRouterGroup[T]) HandleInsert(c *gin.Context) {
 	var req SetSingleRequest[T]
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: fmt.Sprintf("invalid request: %v", err),
-		})
+		abortInvalid(c, "invalid request", err)
 		return
 	}
 
 	if req.Data == nil {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: "data cannot be nil",
-		})
+		abortInvalid(c, "data cannot be nil", nil)
+		return
+	}
+
+	if !wrg.validateData(c, req.Data) {
 		return
 	}
 
 	if err := wrg.Service.Insert(c.Request.Context(), req.Data); err != nil {
-		c.JSON(http.StatusInternalServerError, WriteResponse{
-			Code:    500,
-			Message: fmt.Sprintf("insert failed: %v", err),
-		})
+		abortWithError(c, "insert failed", err)
 		return
 	}
 
@@ -205,33 +218,33 @@ func (wrg *WriteRouterGroup[T]) HandleInsert(c *gin.Context) {
 func (wrg *WriteRouterGroup[T]) HandleUpdate(c *gin.Context) {
 	var req UpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: fmt.Sprintf("invalid request: %v", err),
-		})
+		abortInvalid(c, "invalid request", err)
 		return
 	}
 
 	if len(req.Updates) == 0 {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: "updates cannot be empty",
-		})
+		abortInvalid(c, "updates cannot be empty", nil)
+		return
+	}
+
+	if !wrg.validateUpdates(c, req.Updates) {
+		return
+	}
+
+	updates, err := wrg.Service.ResolveUpdates(req.Updates)
+	if err != nil {
+		abortWithError(c, "invalid updates", err)
 		return
 	}
 
-	var err error
 	if req.ID != nil {
-		err = wrg.Service.UpdateByID(c.Request.Context(), req.ID, req.Updates)
+		err = wrg.Service.UpdateByID(c.Request.Context(), req.ID, updates)
 	} else {
-		err = wrg.Service.Update(c.Request.Context(), req.Updates, nil)
+		err = wrg.Service.Update(c.Request.Context(), updates, nil)
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, WriteResponse{
-			Code:    500,
-			Message: fmt.Sprintf("update failed: %v", err),
-		})
+		abortWithError(c, "update failed", err)
 		return
 	}
 
@@ -245,18 +258,12 @@ func (wrg *WriteRouterGroup[T]) HandleUpdate(c *gin.Context) {
 func (wrg *WriteRouterGroup[T]) HandleDelete(c *gin.Context) {
 	var req DeleteRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: fmt.Sprintf("invalid request: %v", err),
-		})
+		abortInvalid(c, "invalid request", err)
 		return
 	}
 
 	if req.ID == nil {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: "id cannot be nil",
-		})
+		abortInvalid(c, "id cannot be nil", nil)
 		return
 	}
 
@@ -268,10 +275,7 @@ func (wrg *WriteRouterGroup[T]) HandleDelete(c *gin.Context) {
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, WriteResponse{
-			Code:    500,
-			Message: fmt.Sprintf("delete failed: %v", err),
-		})
+		abortWithError(c, "delete failed", err)
 		return
 	}
 
@@ -285,41 +289,46 @@ func (wrg *WriteRouterGroup[T]) HandleDelete(c *gin.Context) {
 func (wrg *WriteRouterGroup[T]) HandleUpsert(c *gin.Context) {
 	var req UpsertRequest[T]
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: fmt.Sprintf("invalid request: %v", err),
-		})
+		abortInvalid(c, "invalid request", err)
 		return
 	}
 
 	if req.Data == nil {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: "data cannot be nil",
-		})
+		abortInvalid(c, "data cannot be nil", nil)
 		return
 	}
 
 	if len(req.ConflictColumns) == 0 {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: "conflict_columns cannot be empty",
-		})
+		abortInvalid(c, "conflict_columns cannot be empty", nil)
 		return
 	}
 
-	err := wrg.Service.Upsert(
+	if !wrg.validateData(c, req.Data) {
+		return
+	}
+
+	// 冲突字段用于匹配已有行（通常是主键或唯一索引），按过滤用途校验
+	conflictColumns, err := wrg.Service.ResolveColumns(req.ConflictColumns, service.UsageFilter)
+	if err != nil {
+		abortWithError(c, "invalid conflict_columns", err)
+		return
+	}
+
+	updateColumns, err := wrg.Service.ResolveColumns(req.UpdateColumns, service.UsageUpdate)
+	if err != nil {
+		abortWithError(c, "invalid update_columns", err)
+		return
+	}
+
+	err = wrg.Service.Upsert(
 		c.Request.Context(),
 		req.Data,
-		req.ConflictColumns,
-		req.UpdateColumns,
+		conflictColumns,
+		updateColumns,
 	)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, WriteResponse{
-			Code:    500,
-			Message: fmt.Sprintf("upsert failed: %v", err),
-		})
+		abortWithError(c, "upsert failed", err)
 		return
 	}
 
@@ -333,26 +342,17 @@ func (wrg *WriteRouterGroup[T]) HandleUpsert(c *gin.Context) {
 func (wrg *WriteRouterGroup[T]) HandleIncrement(c *gin.Context) {
 	var req IncrementRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: fmt.Sprintf("invalid request: %v", err),
-		})
+		abortInvalid(c, "invalid request", err)
 		return
 	}
 
 	if req.Column == "" {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: "column cannot be empty",
-		})
+		abortInvalid(c, "column cannot be empty", nil)
 		return
 	}
 
 	if req.ID == nil {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: "id cannot be nil",
-		})
+		abortInvalid(c, "id cannot be nil", nil)
 		return
 	}
 
@@ -364,10 +364,7 @@ func (wrg *WriteRouterGroup[T]) HandleIncrement(c *gin.Context) {
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, WriteResponse{
-			Code:    500,
-			Message: fmt.Sprintf("increment/decrement failed: %v", err),
-		})
+		abortWithError(c, "increment/decrement failed", err)
 		return
 	}
 
@@ -383,18 +380,12 @@ func (wrg *WriteRouterGroup[T]) HandleIncrement(c *gin.Context) {
 func (wrg *WriteRouterGroup[T]) HandleSetQuery(c *gin.Context) {
 	var req SetQueryRequest[T]
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: fmt.Sprintf("invalid request: %v", err),
-		})
+		abortInvalid(c, "invalid request", err)
 		return
 	}
 
 	if len(req.Data) == 0 {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: "data cannot be empty",
-		})
+		abortInvalid(c, "data cannot be empty", nil)
 		return
 	}
 
@@ -404,11 +395,12 @@ func (wrg *WriteRouterGroup[T]) HandleSetQuery(c *gin.Context) {
 		InvalidateCache:  req.InvalidateCache,
 	}
 
+	if !wrg.validateItems(c, req.Data) {
+		return
+	}
+
 	if err := wrg.Service.SetQuery(c.Request.Context(), req.Data, opts); err != nil {
-		c.JSON(http.StatusInternalServerError, WriteResponse{
-			Code:    500,
-			Message: fmt.Sprintf("set query failed: %v", err),
-		})
+		abortWithError(c, "set query failed", err)
 		return
 	}
 
@@ -423,18 +415,12 @@ func (wrg *WriteRouterGroup[T]) HandleSetQuery(c *gin.Context) {
 func (wrg *WriteRouterGroup[T]) HandleBatchInsert(c *gin.Context) {
 	var req SetQueryRequest[T]
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: fmt.Sprintf("invalid request: %v", err),
-		})
+		abortInvalid(c, "invalid request", err)
 		return
 	}
 
 	if len(req.Data) == 0 {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: "data cannot be empty",
-		})
+		abortInvalid(c, "data cannot be empty", nil)
 		return
 	}
 
@@ -443,11 +429,12 @@ func (wrg *WriteRouterGroup[T]) HandleBatchInsert(c *gin.Context) {
 		batchSize = 100
 	}
 
+	if !wrg.validateItems(c, req.Data) {
+		return
+	}
+
 	if err := wrg.Service.BatchInsert(c.Request.Context(), req.Data, batchSize); err != nil {
-		c.JSON(http.StatusInternalServerError, WriteResponse{
-			Code:    500,
-			Message: fmt.Sprintf("batch insert failed: %v", err),
-		})
+		abortWithError(c, "batch insert failed", err)
 		return
 	}
 
@@ -462,27 +449,28 @@ func (wrg *WriteRouterGroup[T]) HandleBatchInsert(c *gin.Context) {
 func (wrg *WriteRouterGroup[T]) HandleBatchUpdate(c *gin.Context) {
 	var req BatchUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: fmt.Sprintf("invalid request: %v", err),
-		})
+		abortInvalid(c, "invalid request", err)
 		return
 	}
 
 	if len(req.Updates) == 0 {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: "updates cannot be empty",
-		})
+		abortInvalid(c, "updates cannot be empty", nil)
 		return
 	}
 
-	rowsAffected, err := wrg.Service.BatchUpdate(c.Request.Context(), req.Updates, nil)
+	if !wrg.validateUpdates(c, req.Updates) {
+		return
+	}
+
+	updates, err := wrg.Service.ResolveUpdates(req.Updates)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, WriteResponse{
-			Code:    500,
-			Message: fmt.Sprintf("batch update failed: %v", err),
-		})
+		abortWithError(c, "invalid updates", err)
+		return
+	}
+
+	rowsAffected, err := wrg.Service.BatchUpdate(c.Request.Context(), updates, nil)
+	if err != nil {
+		abortWithError(c, "batch update failed", err)
 		return
 	}
 
@@ -497,39 +485,30 @@ func (wrg *WriteRouterGroup[T]) HandleBatchUpdate(c *gin.Context) {
 func (wrg *WriteRouterGroup[T]) HandleBatchDelete(c *gin.Context) {
 	var req BatchDeleteRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: fmt.Sprintf("invalid request: %v", err),
-		})
+		abortInvalid(c, "invalid request", err)
 		return
 	}
 
 	if len(req.IDs) == 0 {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: "ids cannot be empty",
-		})
+		abortInvalid(c, "ids cannot be empty", nil)
 		return
 	}
 
-	var rowsAffected int64
-	var err error
+	queryFunc, err := wrg.Service.PrimaryKeysQuery(req.IDs)
+	if err != nil {
+		abortWithError(c, "invalid ids", err)
+		return
+	}
 
+	var rowsAffected int64
 	if req.Soft {
-		rowsAffected, err = wrg.Service.BatchSoftDelete(c.Request.Context(), func(db *gorm.DB) *gorm.DB {
-			return db.Where("id IN ?", req.IDs)
-		})
+		rowsAffected, err = wrg.Service.BatchSoftDelete(c.Request.Context(), queryFunc)
 	} else {
-		rowsAffected, err = wrg.Service.BatchDelete(c.Request.Context(), func(db *gorm.DB) *gorm.DB {
-			return db.Where("id IN ?", req.IDs)
-		})
+		rowsAffected, err = wrg.Service.BatchDelete(c.Request.Context(), queryFunc)
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, WriteResponse{
-			Code:    500,
-			Message: fmt.Sprintf("batch delete failed: %v", err),
-		})
+		abortWithError(c, "batch delete failed", err)
 		return
 	}
 
@@ -544,26 +523,17 @@ func (wrg *WriteRouterGroup[T]) HandleBatchDelete(c *gin.Context) {
 func (wrg *WriteRouterGroup[T]) HandleBatchUpsert(c *gin.Context) {
 	var req BatchUpsertRequest[T]
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: fmt.Sprintf("invalid request: %v", err),
-		})
+		abortInvalid(c, "invalid request", err)
 		return
 	}
 
 	if len(req.Data) == 0 {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: "data cannot be empty",
-		})
+		abortInvalid(c, "data cannot be empty", nil)
 		return
 	}
 
 	if len(req.ConflictColumns) == 0 {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: "conflict_columns cannot be empty",
-		})
+		abortInvalid(c, "conflict_columns cannot be empty", nil)
 		return
 	}
 
@@ -572,19 +542,33 @@ func (wrg *WriteRouterGroup[T]) HandleBatchUpsert(c *gin.Context) {
 		batchSize = 100
 	}
 
-	err := wrg.Service.BatchUpsert(
+	if !wrg.validateItems(c, req.Data) {
+		return
+	}
+
+	// 冲突字段用于匹配已有行（通常是主键或唯一索引），按过滤用途校验
+	conflictColumns, err := wrg.Service.ResolveColumns(req.ConflictColumns, service.UsageFilter)
+	if err != nil {
+		abortWithError(c, "invalid conflict_columns", err)
+		return
+	}
+
+	updateColumns, err := wrg.Service.ResolveColumns(req.UpdateColumns, service.UsageUpdate)
+	if err != nil {
+		abortWithError(c, "invalid update_columns", err)
+		return
+	}
+
+	err = wrg.Service.BatchUpsert(
 		c.Request.Context(),
 		req.Data,
-		req.ConflictColumns,
-		req.UpdateColumns,
+		conflictColumns,
+		updateColumns,
 		batchSize,
 	)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, WriteResponse{
-			Code:    500,
-			Message: fmt.Sprintf("batch upsert failed: %v", err),
-		})
+		abortWithError(c, "batch upsert failed", err)
 		return
 	}
 
@@ -599,36 +583,27 @@ func (wrg *WriteRouterGroup[T]) HandleBatchUpsert(c *gin.Context) {
 func (wrg *WriteRouterGroup[T]) HandleBatchIncrement(c *gin.Context) {
 	var req BatchIncrementRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: fmt.Sprintf("invalid request: %v", err),
-		})
+		abortInvalid(c, "invalid request", err)
 		return
 	}
 
 	if req.Column == "" {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: "column cannot be empty",
-		})
+		abortInvalid(c, "column cannot be empty", nil)
 		return
 	}
 
 	if len(req.IDs) == 0 {
-		c.JSON(http.StatusBadRequest, WriteResponse{
-			Code:    400,
-			Message: "ids cannot be empty",
-		})
+		abortInvalid(c, "ids cannot be empty", nil)
 		return
 	}
 
-	var rowsAffected int64
-	var err error
-
-	queryFunc := func(db *gorm.DB) *gorm.DB {
-		return db.Where("id IN ?", req.IDs)
+	queryFunc, err := wrg.Service.PrimaryKeysQuery(req.IDs)
+	if err != nil {
+		abortWithError(c, "invalid ids", err)
+		return
 	}
 
+	var rowsAffected int64
 	if req.IsDecr {
 		rowsAffected, err = wrg.Service.BatchDecrement(c.Request.Context(), req.Column, req.Value, queryFunc)
 	} else {
@@ -636,10 +611,7 @@ func (wrg *WriteRouterGroup[T]) HandleBatchIncrement(c *gin.Context) {
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, WriteResponse{
-			Code:    500,
-			Message: fmt.Sprintf("batch increment/decrement failed: %v", err),
-		})
+		abortWithError(c, "batch increment/decrement failed", err)
 		return
 	}
 
@@ -649,3 +621,59 @@ func (wrg *WriteRouterGroup[T]) HandleBatchIncrement(c *gin.Context) {
 		RowsAffected: rowsAffected,
 	})
 }
+
+// ========== 字段校验 ==========
+
+// validateData 按 create 分组校验单条数据，失败时登记 400 错误并返回 false
+func (wrg *WriteRouterGroup[T]) validateData(c *gin.Context, data *T) bool {
+	if wrg.Validator == nil {
+		return true
+	}
+	return checkValidation(c, wrg.Validator.Struct(data, field_validator.GroupCreate))
+}
+
+// validateItems 按 create 分组校验批量数据，字段名带 "data[i]" 前缀
+func (wrg *WriteRouterGroup[T]) validateItems(c *gin.Context, items []T) bool {
+	if wrg.Validator == nil {
+		return true
+	}
+	var errs field_validator.Errors
+	for i := range items {
+		if err := wrg.Validator.Struct(&items[i], field_validator.GroupCreate); err != nil {
+			var fieldErrs field_validator.Errors
+			if !errors.As(err, &fieldErrs) {
+				return checkValidation(c, err)
+			}
+			errs = append(errs, fieldErrs.WithPrefix(fmt.Sprintf("data[%d]", i))...)
+		}
+	}
+	if len(errs) == 0 {
+		return true
+	}
+	return checkValidation(c, errs)
+}
+
+// validateUpdates 按 update 分组校验更新字段，列名按数据库的命名策略解析
+func (wrg *WriteRouterGroup[T]) validateUpdates(c *gin.Context, updates map[string]interface{}) bool {
+	if wrg.Validator == nil {
+		return true
+	}
+	s, err := wrg.Service.ModelSchema()
+	if err != nil {
+		abortWithError(c, "validation failed", err)
+		return false
+	}
+	return checkValidation(c, wrg.Validator.MapSchema(s, updates, field_validator.GroupUpdate))
+}
+
+// checkValidation 字段校验失败时登记 400 错误（附带字段明细）；规则配置错误按 500 处理
+func checkValidation(c *gin.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+	if errors.As(err, new(field_validator.Errors)) {
+		err = service.NewValidationError("", "", err)
+	}
+	abortWithError(c, "validation failed", err)
+	return false
+}
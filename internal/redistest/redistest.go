@@ -0,0 +1,616 @@
+// Package redistest 提供测试用的进程内 Redis 服务器：实现 RESP2 协议与本仓库用到的字符串 / 键 / 事务命令，
+// 数据只保存在内存中，测试结束时随 Cleanup 关闭
+package redistest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Server 内存 Redis 服务器
+type Server struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	values   map[string]string
+	expires  map[string]time.Time
+	versions map[string]uint64 // 每次修改 / 删除键时递增，供 WATCH 检测
+	commands [][]string        // 已执行的命令（含事务中的命令），用于断言
+}
+
+// Run 启动服务器，测试结束时自动关闭
+func Run(tb testing.TB) *Server {
+	tb.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	s := &Server{
+		listener: l,
+		values:   make(map[string]string),
+		expires:  make(map[string]time.Time),
+		versions: make(map[string]uint64),
+	}
+	go s.serve()
+	tb.Cleanup(func() { _ = l.Close() })
+	return s
+}
+
+// NewClient 启动服务器并返回连接到它的客户端
+func NewClient(tb testing.TB) (*redis.Client, *Server) {
+	tb.Helper()
+	s := Run(tb)
+	client := redis.NewClient(&redis.Options{
+		Addr:            s.Addr(),
+		Protocol:        2,
+		DisableIdentity: true,
+		MaxRetries:      -1,
+	})
+	tb.Cleanup(func() { _ = client.Close() })
+	return client, s
+}
+
+// Addr 监听地址
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Get 直接读取键的值（不经过协议），键不存在时返回 false
+func (s *Server) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireLocked(key)
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// Set 直接写入键（不经过协议，不过期）
+func (s *Server) Set(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setLocked(key, value, time.Time{})
+}
+
+// Keys 返回全部未过期的键（已排序）
+func (s *Server) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.values))
+	for k := range s.values {
+		if !s.expireLocked(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Commands 返回已执行命令的名称（大写）
+func (s *Server) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, len(s.commands))
+	for i, c := range s.commands {
+		names[i] = c[0]
+	}
+	return names
+}
+
+// ResetCommands 清空命令记录
+func (s *Server) ResetCommands() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands = nil
+}
+
+// ========== 连接处理 ==========
+
+// conn 单个连接的事务状态
+type conn struct {
+	watched map[string]uint64
+	multi   bool
+	queued  [][]string
+}
+
+func (s *Server) serve() {
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(c)
+	}
+}
+
+func (s *Server) handle(nc net.Conn) {
+	defer nc.Close()
+	r := bufio.NewReader(nc)
+	w := bufio.NewWriter(nc)
+	state := &conn{}
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		args[0] = strings.ToUpper(args[0])
+		writeReply(w, s.dispatch(state, args))
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// dispatch 处理事务命令，其余命令直接执行或在 MULTI 中排队
+func (s *Server) dispatch(state *conn, args []string) interface{} {
+	switch args[0] {
+	case "MULTI":
+		state.multi = true
+		state.queued = nil
+		return status("OK")
+	case "DISCARD":
+		state.multi, state.queued, state.watched = false, nil, nil
+		return status("OK")
+	case "WATCH":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if state.watched == nil {
+			state.watched = make(map[string]uint64)
+		}
+		for _, k := range args[1:] {
+			s.expireLocked(k)
+			state.watched[k] = s.versions[k]
+		}
+		return status("OK")
+	case "UNWATCH":
+		state.watched = nil
+		return status("OK")
+	case "EXEC":
+		if !state.multi {
+			return errorReply("ERR EXEC without MULTI")
+		}
+		queued, watched := state.queued, state.watched
+		state.multi, state.queued, state.watched = false, nil, nil
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for k, v := range watched {
+			s.expireLocked(k)
+			if s.versions[k] != v {
+				return nilArray{}
+			}
+		}
+		replies := make([]interface{}, len(queued))
+		for i, cmd := range queued {
+			replies[i] = s.execLocked(cmd)
+		}
+		return replies
+	}
+
+	if state.multi {
+		state.queued = append(state.queued, args)
+		return status("QUEUED")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.execLocked(args)
+}
+
+// execLocked 执行单条命令（调用方持有锁）
+func (s *Server) execLocked(args []string) interface{} {
+	s.commands = append(s.commands, args)
+	switch args[0] {
+	case "PING":
+		return status("PONG")
+	case "SELECT", "FLUSHDB", "FLUSHALL":
+		if args[0] != "SELECT" {
+			for k := range s.values {
+				s.deleteLocked(k)
+			}
+		}
+		return status("OK")
+	case "GET":
+		if len(args) != 2 {
+			return wrongArgs(args[0])
+		}
+		if v, ok := s.lookupLocked(args[1]); ok {
+			return bulk(v)
+		}
+		return nil
+	case "MGET":
+		out := make([]interface{}, 0, len(args)-1)
+		for _, k := range args[1:] {
+			if v, ok := s.lookupLocked(k); ok {
+				out = append(out, bulk(v))
+			} else {
+				out = append(out, nil)
+			}
+		}
+		return out
+	case "SET":
+		return s.set(args)
+	case "SETNX":
+		if len(args) != 3 {
+			return wrongArgs(args[0])
+		}
+		if _, ok := s.lookupLocked(args[1]); ok {
+			return int64(0)
+		}
+		s.setLocked(args[1], args[2], time.Time{})
+		return int64(1)
+	case "MSET":
+		if len(args) < 3 || len(args)%2 != 1 {
+			return wrongArgs(args[0])
+		}
+		for i := 1; i < len(args); i += 2 {
+			s.setLocked(args[i], args[i+1], time.Time{})
+		}
+		return status("OK")
+	case "DEL", "UNLINK":
+		var n int64
+		for _, k := range args[1:] {
+			if _, ok := s.lookupLocked(k); ok {
+				s.deleteLocked(k)
+				n++
+			}
+		}
+		return n
+	case "EXISTS":
+		var n int64
+		for _, k := range args[1:] {
+			if _, ok := s.lookupLocked(k); ok {
+				n++
+			}
+		}
+		return n
+	case "INCR", "DECR", "INCRBY", "DECRBY":
+		return s.incr(args)
+	case "EXPIRE", "PEXPIRE":
+		if len(args) != 3 {
+			return wrongArgs(args[0])
+		}
+		n, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return errorReply("ERR value is not an integer or out of range")
+		}
+		if _, ok := s.lookupLocked(args[1]); !ok {
+			return int64(0)
+		}
+		unit := time.Second
+		if args[0] == "PEXPIRE" {
+			unit = time.Millisecond
+		}
+		s.expires[args[1]] = time.Now().Add(time.Duration(n) * unit)
+		s.versions[args[1]]++
+		return int64(1)
+	case "TTL", "PTTL":
+		if len(args) != 2 {
+			return wrongArgs(args[0])
+		}
+		if _, ok := s.lookupLocked(args[1]); !ok {
+			return int64(-2)
+		}
+		deadline, ok := s.expires[args[1]]
+		if !ok {
+			return int64(-1)
+		}
+		if args[0] == "PTTL" {
+			return time.Until(deadline).Milliseconds()
+		}
+		return int64((time.Until(deadline) + time.Second - 1) / time.Second)
+	case "KEYS":
+		if len(args) != 2 {
+			return wrongArgs(args[0])
+		}
+		return s.matchLocked(args[1])
+	case "SCAN":
+		// 一次返回全部匹配的键，游标总是 0
+		pattern := "*"
+		for i := 2; i+1 < len(args); i += 2 {
+			if strings.EqualFold(args[i], "MATCH") {
+				pattern = args[i+1]
+			}
+		}
+		return []interface{}{bulk("0"), s.matchLocked(pattern)}
+	default:
+		s.commands = s.commands[:len(s.commands)-1]
+		return errorReply(fmt.Sprintf("ERR unknown command '%s'", strings.ToLower(args[0])))
+	}
+}
+
+// set SET key value [EX s | PX ms] [NX | XX] [GET]
+func (s *Server) set(args []string) interface{} {
+	if len(args) < 3 {
+		return wrongArgs(args[0])
+	}
+	key, value := args[1], args[2]
+	var deadline time.Time
+	var nx, xx, get bool
+	for i := 3; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "EX", "PX":
+			if i+1 >= len(args) {
+				return errorReply("ERR syntax error")
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || n <= 0 {
+				return errorReply("ERR invalid expire time in 'set' command")
+			}
+			unit := time.Second
+			if strings.EqualFold(args[i], "PX") {
+				unit = time.Millisecond
+			}
+			deadline = time.Now().Add(time.Duration(n) * unit)
+			i++
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "GET":
+			get = true
+		case "KEEPTTL":
+			deadline = s.expires[key]
+		default:
+			return errorReply("ERR syntax error")
+		}
+	}
+
+	old, exists := s.lookupLocked(key)
+	if (nx && exists) || (xx && !exists) {
+		if get && exists {
+			return bulk(old)
+		}
+		return nil
+	}
+	s.setLocked(key, value, deadline)
+	if get {
+		if exists {
+			return bulk(old)
+		}
+		return nil
+	}
+	return status("OK")
+}
+
+// incr INCR / DECR / INCRBY / DECRBY
+func (s *Server) incr(args []string) interface{} {
+	delta := int64(1)
+	switch args[0] {
+	case "INCR", "DECR":
+		if len(args) != 2 {
+			return wrongArgs(args[0])
+		}
+	default:
+		if len(args) != 3 {
+			return wrongArgs(args[0])
+		}
+		n, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return errorReply("ERR value is not an integer or out of range")
+		}
+		delta = n
+	}
+	if strings.HasPrefix(args[0], "DECR") {
+		delta = -delta
+	}
+
+	var current int64
+	if v, ok := s.lookupLocked(args[1]); ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return errorReply("ERR value is not an integer or out of range")
+		}
+		current = n
+	}
+	current += delta
+	deadline := s.expires[args[1]]
+	s.setLocked(args[1], strconv.FormatInt(current, 10), deadline)
+	return current
+}
+
+// ========== 存储 ==========
+
+// expireLocked 删除已过期的键，返回是否已过期
+func (s *Server) expireLocked(key string) bool {
+	deadline, ok := s.expires[key]
+	if !ok || time.Now().Before(deadline) {
+		return false
+	}
+	s.deleteLocked(key)
+	return true
+}
+
+func (s *Server) lookupLocked(key string) (string, bool) {
+	s.expireLocked(key)
+	v, ok := s.values[key]
+	return v, ok
+}
+
+func (s *Server) setLocked(key, value string, deadline time.Time) {
+	s.values[key] = value
+	if deadline.IsZero() {
+		delete(s.expires, key)
+	} else {
+		s.expires[key] = deadline
+	}
+	s.versions[key]++
+}
+
+func (s *Server) deleteLocked(key string) {
+	delete(s.values, key)
+	delete(s.expires, key)
+	s.versions[key]++
+}
+
+func (s *Server) matchLocked(pattern string) []interface{} {
+	keys := make([]string, 0)
+	for k := range s.values {
+		if !s.expireLocked(k) && globMatch(pattern, k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	out := make([]interface{}, len(keys))
+	for i, k := range keys {
+		out[i] = bulk(k)
+	}
+	return out
+}
+
+// globMatch Redis glob 匹配：* ? [abc] [^a] [a-z] 与 \ 转义
+func globMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := len(s); i >= 0; i-- {
+				if globMatch(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if s == "" {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		case '[':
+			end := strings.IndexByte(pattern[1:], ']')
+			if end < 0 || s == "" {
+				return false
+			}
+			class := pattern[1 : end+1]
+			negate := strings.HasPrefix(class, "^")
+			if negate {
+				class = class[1:]
+			}
+			matched := false
+			for i := 0; i < len(class); i++ {
+				if i+2 < len(class) && class[i+1] == '-' {
+					if class[i] <= s[0] && s[0] <= class[i+2] {
+						matched = true
+					}
+					i += 2
+					continue
+				}
+				if class[i] == '\\' && i+1 < len(class) {
+					i++
+				}
+				if class[i] == s[0] {
+					matched = true
+				}
+			}
+			if matched == negate {
+				return false
+			}
+			pattern, s = pattern[end+2:], s[1:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if s == "" || pattern[0] != s[0] {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		}
+	}
+	return s == ""
+}
+
+// ========== RESP 编解码 ==========
+
+type status string
+
+type errorReply string
+
+type bulk string
+
+type nilArray struct{}
+
+func wrongArgs(cmd string) errorReply {
+	return errorReply(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(cmd)))
+}
+
+// readCommand 读取一条请求（RESP 数组或内联命令）
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid array length %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		header, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(header, "$") {
+			return nil, fmt.Errorf("expected bulk string, got %q", header)
+		}
+		size, err := strconv.Atoi(header[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid bulk length %q", header)
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasSuffix(line, "\r\n") {
+		return "", errors.New("line does not end with CRLF")
+	}
+	return line[:len(line)-2], nil
+}
+
+func writeReply(w *bufio.Writer, reply interface{}) {
+	switch v := reply.(type) {
+	case nil:
+		w.WriteString("$-1\r\n")
+	case nilArray:
+		w.WriteString("*-1\r\n")
+	case status:
+		fmt.Fprintf(w, "+%s\r\n", v)
+	case errorReply:
+		fmt.Fprintf(w, "-%s\r\n", v)
+	case int64:
+		fmt.Fprintf(w, ":%d\r\n", v)
+	case bulk:
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+	case []interface{}:
+		fmt.Fprintf(w, "*%d\r\n", len(v))
+		for _, item := range v {
+			writeReply(w, item)
+		}
+	default:
+		fmt.Fprintf(w, "-ERR unsupported reply %T\r\n", v)
+	}
+}
@@ -0,0 +1,160 @@
+// Package sqltest 提供测试用的 database/sql 驱动桩：查询结果由回调按 SQL 与参数给出，
+// 执行过的语句（含 BEGIN / COMMIT / ROLLBACK）与写语句的参数依次记录，供断言使用
+package sqltest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// QueryFunc 按 SQL 与参数返回查询结果的列名与各行
+type QueryFunc func(sql string, args []driver.Value) (columns []string, rows [][]driver.Value)
+
+// DB 驱动桩的状态
+type DB struct {
+	mu         sync.Mutex
+	statements []string
+	execArgs   [][]driver.Value
+	execErr    error // 非空时写语句返回该错误
+	query      QueryFunc
+}
+
+// Open 返回基于驱动桩的 MySQL 方言连接，测试结束时关闭
+func Open(tb testing.TB, query QueryFunc) (*gorm.DB, *DB) {
+	tb.Helper()
+	fdb := &DB{query: query}
+	sqlDB := sql.OpenDB(connector{db: fdb})
+	tb.Cleanup(func() { _ = sqlDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		&gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return db, fdb
+}
+
+// Statements 返回已执行的语句
+func (f *DB) Statements() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.statements...)
+}
+
+// ExecArgs 返回已执行写语句的参数
+func (f *DB) ExecArgs() [][]driver.Value {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]driver.Value(nil), f.execArgs...)
+}
+
+// SetExecErr 设置写语句返回的错误，nil 表示成功
+func (f *DB) SetExecErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.execErr = err
+}
+
+// Reset 清空语句记录
+func (f *DB) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statements = nil
+	f.execArgs = nil
+}
+
+func (f *DB) record(statement string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statements = append(f.statements, statement)
+}
+
+type connector struct{ db *DB }
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return &conn{db: c.db}, nil }
+func (c connector) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return nil, errors.New("use sqltest.Open") }
+
+type conn struct{ db *DB }
+
+func (c *conn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+func (c *conn) Close() error { return nil }
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.db.record("BEGIN")
+	return tx{db: c.db}, nil
+}
+
+func (c *conn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.record(query)
+	var columns []string
+	var rows [][]driver.Value
+	if c.db.query != nil {
+		columns, rows = c.db.query(query, values(args))
+	}
+	return &fakeRows{columns: columns, rows: rows}, nil
+}
+
+func (c *conn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.record(query)
+	c.db.mu.Lock()
+	c.db.execArgs = append(c.db.execArgs, values(args))
+	err := c.db.execErr
+	c.db.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return result{}, nil
+}
+
+// result 写语句结果：影响 1 行，无自增 ID
+type result struct{}
+
+func (result) LastInsertId() (int64, error) { return 0, nil }
+func (result) RowsAffected() (int64, error) { return 1, nil }
+
+type tx struct{ db *DB }
+
+func (t tx) Commit() error   { t.db.record("COMMIT"); return nil }
+func (t tx) Rollback() error { t.db.record("ROLLBACK"); return nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	out := make([]driver.Value, len(args))
+	for i, a := range args {
+		out[i] = a.Value
+	}
+	return out
+}
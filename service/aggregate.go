@@ -0,0 +1,368 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"AbstractManager/util/tracing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ========== 聚合查询 ==========
+// GetQuery 把结果扫描进 []T，无法返回聚合值；Aggregate 按分组字段与指标生成
+//
+//	SELECT `status`, COUNT(*) AS `count`, SUM(`amount`) AS `sum_amount` FROM ... GROUP BY `status` HAVING COUNT(*) > ?
+//
+// 并把每行扫描为带类型的分组键与指标值。分组字段与指标字段须通过列策略 Aggregate 检查，且不能是 json:"-" 的隐藏字段。
+
+// 聚合函数
+const (
+	AggCount         = "count"          // COUNT(*)，指定字段时为 COUNT(field)
+	AggCountDistinct = "count_distinct" // COUNT(DISTINCT field)
+	AggSum           = "sum"
+	AggAvg           = "avg"
+	AggMin           = "min"
+	AggMax           = "max"
+)
+
+// Metric 聚合指标
+type Metric struct {
+	Func  string `json:"func"`            // 聚合函数
+	Field string `json:"field,omitempty"` // 字段（json 名 / 列名 / Go 字段名），count 可省略
+	As    string `json:"as,omitempty"`    // 结果名，默认 func_列名（count 省略字段时为 count）
+}
+
+// HavingCondition 对聚合结果的过滤条件
+type HavingCondition struct {
+	Metric   string      `json:"metric"`   // 指标结果名（Metric.As）
+	Operator string      `json:"operator"` // = / != / > / >= / < / <=
+	Value    interface{} `json:"value"`
+}
+
+// AggregateOptions 聚合选项
+type AggregateOptions struct {
+	GroupBy []string          // 分组字段，为空时整体聚合为一行
+	Metrics []Metric          // 指标，至少一个
+	Having  []HavingCondition // 聚合结果过滤
+	Sorts   []SortParam       // 排序，字段为分组字段或指标结果名
+	Limit   int               // 最多返回的分组数，<= 0 不限制
+}
+
+// AggregateRow 一个分组的聚合结果，键为分组字段的 JSON 输出键与指标结果名
+// 分组键保持字段的 Go 类型；count / count_distinct 为 int64，avg 为 float64，
+// sum 对整数列为 int64、其余为 float64，min / max 与字段类型相同；NULL 为 nil
+type AggregateRow struct {
+	Keys    map[string]interface{} `json:"keys"`
+	Metrics map[string]interface{} `json:"metrics"`
+}
+
+// havingOperators Having 支持的比较运算符
+var havingOperators = map[string]string{
+	"=": "=", "!=": "<>", ">": ">", ">=": ">=", "<": "<", "<=": "<=",
+}
+
+// metricNamePattern 指标结果名（用作 SQL 列别名）
+var metricNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// aggregatePlan 校验后的聚合
+type aggregatePlan struct {
+	groups  []aggregateGroup
+	metrics []aggregateMetric
+	having  []HavingCondition
+	sorts   []sortKey // column 为分组列名或指标结果名
+	limit   int
+}
+
+type aggregateGroup struct {
+	column string
+	key    string // JSON 输出键
+	typ    reflect.Type
+}
+
+type aggregateMetric struct {
+	name string
+	expr clause.Expr
+	typ  reflect.Type
+}
+
+// Aggregate 分组聚合查询
+// queryFunc: 用于构建过滤条件的 lambda 函数（作用于 WHERE）
+func (sm *ServiceManager[T]) Aggregate(
+	ctx context.Context,
+	queryFunc func(*gorm.DB) *gorm.DB,
+	opts *AggregateOptions,
+) (_ []AggregateRow, err error) {
+	ctx, span := sm.startSpan(ctx, "Aggregate")
+	defer func() { tracing.End(span, err) }()
+
+	plan, err := sm.newAggregatePlan(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	op := &Operation[T]{Kind: OpGet, Method: "Aggregate", Query: queryFunc}
+	err = sm.runOp(ctx, op, func() error {
+		db := sm.applyTableName(GetDB().WithContext(ctx)).Model(&sm.Resource)
+
+		// 应用查询条件
+		if op.Query != nil {
+			db = op.Query(db)
+		}
+
+		rows, err := plan.find(db)
+		if err != nil {
+			return fmt.Errorf("failed to aggregate records: %w", err)
+		}
+		op.Aggregates = rows
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return op.Aggregates, nil
+}
+
+// newAggregatePlan 按列策略校验分组字段、指标与排序
+func (sm *ServiceManager[T]) newAggregatePlan(opts *AggregateOptions) (*aggregatePlan, error) {
+	if opts == nil || len(opts.Metrics) == 0 {
+		return nil, NewValidationError("metrics", "at least one metric is required", nil)
+	}
+	cs, err := sm.columnSet()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &aggregatePlan{having: opts.Having, limit: opts.Limit}
+	names := make(map[string]bool) // 结果集中的列名 / 别名，不能重复
+	for _, name := range opts.GroupBy {
+		col, err := sm.resolveAggregateColumn(cs, name)
+		if err != nil {
+			return nil, err
+		}
+		if names[col] {
+			return nil, NewValidationError(name, "duplicate group field", nil)
+		}
+		names[col] = true
+		key := cs.jsonKey[col]
+		plan.groups = append(plan.groups, aggregateGroup{column: col, key: key, typ: nullableType(cs.byName[col].FieldType)})
+	}
+
+	metrics := make(map[string]clause.Expr)
+	for _, m := range opts.Metrics {
+		metric, err := sm.resolveMetric(cs, m)
+		if err != nil {
+			return nil, err
+		}
+		if names[metric.name] {
+			return nil, NewValidationError(metric.name, "duplicate metric name", nil)
+		}
+		names[metric.name] = true
+		metrics[metric.name] = metric.expr
+		plan.metrics = append(plan.metrics, metric)
+	}
+
+	for _, h := range opts.Having {
+		if _, ok := metrics[h.Metric]; !ok {
+			return nil, NewValidationError("having", fmt.Sprintf("unknown metric %q", h.Metric), nil)
+		}
+		if _, ok := havingOperators[h.Operator]; !ok {
+			return nil, NewValidationError("having", fmt.Sprintf("invalid operator %q", h.Operator), nil)
+		}
+		if h.Value == nil {
+			return nil, NewValidationError("having", "value is required", nil)
+		}
+	}
+
+	for _, s := range opts.Sorts {
+		desc, err := parseOrder(s.Direction)
+		if err != nil {
+			return nil, err
+		}
+		column := s.Field
+		if _, ok := metrics[column]; !ok {
+			col, err := sm.ResolveColumn(s.Field, UsageAggregate)
+			if err != nil {
+				return nil, err
+			}
+			if !names[col] {
+				return nil, NewValidationError(s.Field, "sort field must be a group field or metric", nil)
+			}
+			column = col
+		}
+		plan.sorts = append(plan.sorts, sortKey{column: column, desc: desc})
+	}
+	return plan, nil
+}
+
+// resolveAggregateColumn 解析分组 / 指标字段；没有 JSON 输出键的字段（json:"-"）不能出现在聚合结果中
+func (sm *ServiceManager[T]) resolveAggregateColumn(cs *columnSet, name string) (string, error) {
+	col, err := sm.ResolveColumn(name, UsageAggregate)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := cs.jsonKey[col]; !ok {
+		return "", NewValidationError(name, "field is not aggregatable", nil)
+	}
+	return col, nil
+}
+
+// resolveMetric 校验指标并生成聚合表达式与结果类型
+func (sm *ServiceManager[T]) resolveMetric(cs *columnSet, m Metric) (aggregateMetric, error) {
+	fn := strings.ToLower(m.Func)
+	var field *schema.Field
+	if m.Field != "" {
+		col, err := sm.resolveAggregateColumn(cs, m.Field)
+		if err != nil {
+			return aggregateMetric{}, err
+		}
+		field = cs.byName[col]
+	}
+
+	metric := aggregateMetric{name: m.As}
+	switch fn {
+	case AggCount:
+		metric.typ = reflect.TypeOf(int64(0))
+		if field == nil {
+			metric.expr = clause.Expr{SQL: "COUNT(*)"}
+			if metric.name == "" {
+				metric.name = AggCount
+			}
+		} else {
+			metric.expr = clause.Expr{SQL: "COUNT(?)", Vars: []interface{}{clause.Column{Name: field.DBName}}}
+		}
+	case AggCountDistinct:
+		metric.typ = reflect.TypeOf(int64(0))
+		metric.expr = clause.Expr{SQL: "COUNT(DISTINCT ?)"}
+	case AggSum, AggAvg:
+		if field != nil && !isNumeric(field) {
+			return aggregateMetric{}, NewValidationError(m.Field, fmt.Sprintf("%s requires a numeric field", fn), nil)
+		}
+		metric.typ = reflect.TypeOf(float64(0))
+		if fn == AggSum && field != nil && (field.DataType == schema.Int || field.DataType == schema.Uint) {
+			metric.typ = reflect.TypeOf(int64(0))
+		}
+		metric.expr = clause.Expr{SQL: strings.ToUpper(fn) + "(?)"}
+	case AggMin, AggMax:
+		if field != nil {
+			metric.typ = field.FieldType
+		}
+		metric.expr = clause.Expr{SQL: strings.ToUpper(fn) + "(?)"}
+	default:
+		return aggregateMetric{}, NewValidationError("metrics", fmt.Sprintf("invalid aggregate function %q", m.Func), nil)
+	}
+
+	if metric.expr.Vars == nil && strings.Contains(metric.expr.SQL, "?") {
+		if field == nil {
+			return aggregateMetric{}, NewValidationError("metrics", fmt.Sprintf("%s requires a field", fn), nil)
+		}
+		metric.expr.Vars = []interface{}{clause.Column{Name: field.DBName}}
+	}
+	if metric.name == "" {
+		metric.name = fn + "_" + field.DBName
+	}
+	if !metricNamePattern.MatchString(metric.name) {
+		return aggregateMetric{}, NewValidationError("metrics", fmt.Sprintf("invalid metric name %q", metric.name), nil)
+	}
+	metric.typ = nullableType(metric.typ)
+	return metric, nil
+}
+
+// find 追加 SELECT / GROUP BY / HAVING / ORDER BY / LIMIT，扫描结果
+// 结果扫描进按分组键与指标动态构造的结构体切片，由 GORM 完成类型转换
+func (p *aggregatePlan) find(db *gorm.DB) ([]AggregateRow, error) {
+	var selects []string
+	var vars []interface{}
+	var fields []reflect.StructField
+	var groupBy []clause.Column
+	for _, g := range p.groups {
+		column := clause.Column{Name: g.column}
+		selects = append(selects, "?")
+		vars = append(vars, column)
+		groupBy = append(groupBy, column)
+		fields = append(fields, resultField(len(fields), g.column, g.typ))
+	}
+	metrics := make(map[string]clause.Expr, len(p.metrics))
+	for _, m := range p.metrics {
+		selects = append(selects, "? AS ?")
+		vars = append(vars, m.expr, clause.Column{Name: m.name})
+		fields = append(fields, resultField(len(fields), m.name, m.typ))
+		metrics[m.name] = m.expr
+	}
+
+	db = db.Select(strings.Join(selects, ", "), vars...)
+	if len(groupBy) > 0 {
+		db = db.Clauses(clause.GroupBy{Columns: groupBy})
+	}
+	for _, h := range p.having {
+		db = db.Having("? "+havingOperators[h.Operator]+" ?", metrics[h.Metric], h.Value)
+	}
+	for _, k := range p.sorts {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: k.column}, Desc: k.desc})
+	}
+	if p.limit > 0 {
+		db = db.Limit(p.limit)
+	}
+
+	dest := reflect.New(reflect.SliceOf(reflect.StructOf(fields)))
+	if err := db.Find(dest.Interface()).Error; err != nil {
+		return nil, err
+	}
+
+	slice := dest.Elem()
+	rows := make([]AggregateRow, slice.Len())
+	for i := range rows {
+		item := slice.Index(i)
+		rows[i] = AggregateRow{Keys: make(map[string]interface{}, len(p.groups)), Metrics: make(map[string]interface{}, len(p.metrics))}
+		for j, g := range p.groups {
+			rows[i].Keys[g.key] = derefValue(item.Field(j))
+		}
+		for j, m := range p.metrics {
+			rows[i].Metrics[m.name] = derefValue(item.Field(len(p.groups) + j))
+		}
+	}
+	return rows, nil
+}
+
+// resultField 动态结果结构体的字段，按列名 / 别名映射
+func resultField(i int, column string, typ reflect.Type) reflect.StructField {
+	return reflect.StructField{
+		Name: fmt.Sprintf("F%d", i),
+		Type: typ,
+		Tag:  reflect.StructTag(fmt.Sprintf(`gorm:"column:%s"`, column)),
+	}
+}
+
+// nullableType 聚合结果可能为 NULL（空分组、可空列），非指针类型改为指针
+func nullableType(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Pointer {
+		return typ
+	}
+	return reflect.PointerTo(typ)
+}
+
+// derefValue 取出指针字段的值，nil 指针返回 nil
+func derefValue(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+// isNumeric 是否为数值列
+func isNumeric(field *schema.Field) bool {
+	switch field.DataType {
+	case schema.Int, schema.Uint, schema.Float:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"AbstractManager/util/cache_key_builder"
+)
+
+// ========== 缓存键 ==========
+// 单条缓存键 = 前缀 + ":" + 键值：
+//   - 前缀：T 的 cache 标签中的 prefix，未声明时为 CacheKeyType:CacheKeyName（CacheKeyType 为 "none" 时只有 CacheKeyName）；
+//     启用键命名空间时前缀后带代数段
+//   - 键值：T 的 cache 标签声明的键字段（见 cache_key_builder.TagKeyBuilder），未声明时为主键
+//
+// WritedownQuery / RefreshCache 等方法的 buildKeyFunc 为 nil 时使用这个默认键，键字段可以是任意字段。
+// 按 ID 的方法（LookupSingleByID、LookupByIDs、带回源的 LookupQuery 等）以主键值（复合主键转义后以 ":" 连接）作为键值，
+// 标签键字段与主键字段不逐一对应（顺序相同）时这些方法返回错误（见 byIDKeys），查询缓存直接查询数据库，不会与写入的键错开。
+
+// CacheKey 返回一行数据的默认缓存键
+func (sm *ServiceManager[T]) CacheKey(ctx context.Context, data *T) (string, error) {
+	keyFunc, err := sm.defaultKeyFunc(ctx)
+	if err != nil {
+		return "", err
+	}
+	return keyFunc(data), nil
+}
+
+// keyBuilder 返回 T 的标签键构建器（按类型缓存），未声明键字段时返回 nil
+func (sm *ServiceManager[T]) keyBuilder() (*cache_key_builder.TagKeyBuilder[T], error) {
+	return cache_key_builder.TagKeyBuilderOf[T]()
+}
+
+// byIDKeys 检查单条缓存键能否由主键值拼出：标签键字段与主键字段不逐一对应时返回错误
+// 按 ID 读取、回源与失效的路径在拼键前调用
+func (sm *ServiceManager[T]) byIDKeys() error {
+	builder, err := sm.keyBuilder()
+	if err != nil || builder == nil {
+		return err
+	}
+	pks, err := sm.primaryFields()
+	if err != nil {
+		return err
+	}
+	fields := builder.Fields()
+	match := len(fields) == len(pks)
+	for i := 0; match && i < len(pks); i++ {
+		match = fields[i] == pks[i].Name
+	}
+	if match {
+		return nil
+	}
+	names := make([]string, len(pks))
+	for i, f := range pks {
+		names[i] = f.Name
+	}
+	return fmt.Errorf("cache keys of %s are built from %v, not the primary key %v: look them up by key instead of by ID", sm.ResourceName, fields, names)
+}
+
+// resolveKeyFunc buildKeyFunc 为 nil 时返回默认的键函数
+func (sm *ServiceManager[T]) resolveKeyFunc(ctx context.Context, buildKeyFunc func(*T) string) (func(*T) string, error) {
+	if buildKeyFunc != nil {
+		return buildKeyFunc, nil
+	}
+	return sm.defaultKeyFunc(ctx)
+}
+
+// defaultKeyFunc 默认的键函数，前缀（含代数段）在调用时确定
+func (sm *ServiceManager[T]) defaultKeyFunc(ctx context.Context) (func(*T) string, error) {
+	builder, err := sm.keyBuilder()
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := sm.cacheKeyPrefix(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if builder != nil {
+		return func(item *T) string { return prefix + ":" + builder.Suffix(item) }, nil
+	}
+
+	pks, err := sm.primaryFields()
+	if err != nil {
+		return nil, fmt.Errorf("no default cache key: declare cache key tags or pass a key function: %w", err)
+	}
+	return func(item *T) string { return itemCacheKey(ctx, prefix, pks, item) }, nil
+}
+
+// cacheKeyRoot 资源键的根前缀（不含代数段）
+func (sm *ServiceManager[T]) cacheKeyRoot() string {
+	if builder, err := sm.keyBuilder(); err == nil && builder != nil && builder.Prefix() != "" {
+		return builder.Prefix()
+	}
+	if sm.CacheKeyType == "none" {
+		return sm.CacheKeyName
+	}
+	return sm.CacheKeyType + ":" + sm.CacheKeyName
+}
+
+// buildCacheKey 构建单条缓存键
+func (sm *ServiceManager[T]) buildCacheKey(ctx context.Context, id interface{}) (string, error) {
+	prefix, err := sm.cacheKeyPrefix(ctx)
+	if err != nil {
+		return "", err
+	}
+	return joinCacheKey(prefix, id), nil
+}
+
+// joinCacheKey 前缀 + 键值
+func joinCacheKey(prefix string, id interface{}) string {
+	return fmt.Sprintf("%s:%v", prefix, id)
+}
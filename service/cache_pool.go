@@ -7,6 +7,8 @@ import (
 	"os"
 	"time"
 
+	"AbstractManager/util/tracing"
+
 	"github.com/redis/go-redis/v9"
 )
 
@@ -31,6 +33,9 @@ func InitRedis() (*RedisManager, error) {
 		WriteTimeout: 3 * time.Second,
 	})
 
+	// 注册链路追踪钩子，每条命令作为调用方 ctx 中 span 的子 span
+	client.AddHook(tracing.NewRedisHook())
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -42,6 +47,16 @@ func InitRedis() (*RedisManager, error) {
 	return globalRedisManager, nil
 }
 
+// UseRedis 使用已创建的 *redis.Client 作为全局连接（如复用外部客户端，或测试中的内存 Redis）；传入 nil 清除全局连接
+func UseRedis(client *redis.Client) *RedisManager {
+	if client == nil {
+		globalRedisManager = nil
+		return nil
+	}
+	globalRedisManager = &RedisManager{Client: client}
+	return globalRedisManager
+}
+
 // GetRedis 获取全局 Redis 实例（不变）
 func GetRedis() *redis.Client {
 	if globalRedisManager == nil {
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"AbstractManager/util/filter_translator"
+
+	"gorm.io/gorm/schema"
+)
+
+// ========== 列策略 ==========
+// 合法列集合来自 T 的 GORM schema；客户端传入的字段名（json 名 / 列名 / Go 字段名）
+// 必须先经 ResolveColumn 映射为数据库列名，未声明或被策略禁止的字段一律拒绝。
+// 过滤字段还可以是沿 GORM 关联的点路径（如 "profile.city"），由 filter_translator 生成 EXISTS 子查询。
+
+// ColumnUsage 列的用途
+type ColumnUsage string
+
+const (
+	UsageFilter    ColumnUsage = "filter"    // 过滤条件
+	UsageSort      ColumnUsage = "sort"      // 排序
+	UsageUpdate    ColumnUsage = "update"    // 更新 / 增减量
+	UsageSelect    ColumnUsage = "select"    // 字段投影（fields 参数）
+	UsageAggregate ColumnUsage = "aggregate" // 聚合的分组字段与指标字段
+)
+
+// FieldList 允许/禁止字段列表，字段可写 json 名、列名或 Go 字段名，Filter 中还可以写关联路径（如 "profile.city"）
+// Allow 为空时允许 schema 中的全部列与关联路径（更新默认排除主键，json:"-" 的隐藏字段须显式 Allow），Deny 优先于 Allow
+type FieldList struct {
+	Allow []string
+	Deny  []string
+}
+
+// ColumnPolicy 按用途划分的列策略
+type ColumnPolicy struct {
+	Filter    FieldList
+	Sort      FieldList
+	Update    FieldList
+	Select    FieldList
+	Aggregate FieldList
+}
+
+// columnSet 解析后的列信息
+type columnSet struct {
+	schema      *schema.Schema
+	byName      map[string]*schema.Field        // json 名 / 列名 / Go 字段名 -> 字段
+	allowed     map[ColumnUsage]map[string]bool // 用途 -> 允许的列名
+	paths       map[ColumnUsage]pathRule        // 用途 -> 关联路径规则
+	jsonKey     map[string]string               // 列名 -> JSON 输出键（json:"-" 的字段没有）
+	primaryKeys []string                        // 主键列名
+}
+
+// pathRule 关联路径的允许规则，路径以规范形式（关联 Go 名 + 列名，如 "Profile.city"）存储
+type pathRule struct {
+	allowAll bool
+	allow    map[string]bool
+	deny     map[string]bool
+}
+
+// SetColumnPolicy 设置列策略
+func (sm *ServiceManager[T]) SetColumnPolicy(policy ColumnPolicy) *ServiceManager[T] {
+	sm.columnMu.Lock()
+	defer sm.columnMu.Unlock()
+	sm.columnPolicy = policy
+	sm.columns = nil
+	return sm
+}
+
+// ResolveColumn 将字段名映射为数据库列名，并检查该列是否允许用于 usage
+// 过滤字段可以是关联路径，返回其规范形式（如 "profile.city" -> "Profile.city"）
+// 字段不存在或不被允许时返回 ValidationError
+func (sm *ServiceManager[T]) ResolveColumn(name string, usage ColumnUsage) (string, error) {
+	cs, err := sm.columnSet()
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(name, ".") {
+		return cs.resolvePath(name, usage)
+	}
+	field, ok := cs.byName[name]
+	if !ok {
+		return "", NewValidationError(name, "unknown field", nil)
+	}
+	if !cs.allowed[usage][field.DBName] {
+		return "", NewValidationError(name, fmt.Sprintf("field is not %s", usageAdjective(usage)), nil)
+	}
+	return field.DBName, nil
+}
+
+// ResolveColumns 批量映射字段名
+func (sm *ServiceManager[T]) ResolveColumns(names []string, usage ColumnUsage) ([]string, error) {
+	columns := make([]string, 0, len(names))
+	for _, name := range names {
+		col, err := sm.ResolveColumn(name, usage)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, col)
+	}
+	return columns, nil
+}
+
+// ResolveUpdates 将更新 map 的键映射为数据库列名，并检查是否允许更新
+func (sm *ServiceManager[T]) ResolveUpdates(updates map[string]interface{}) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(updates))
+	for name, value := range updates {
+		col, err := sm.ResolveColumn(name, UsageUpdate)
+		if err != nil {
+			return nil, err
+		}
+		resolved[col] = value
+	}
+	return resolved, nil
+}
+
+// ModelSchema 返回 T 的 GORM schema（含关联），用于绑定 GORM 翻译器解析关联字段
+func (sm *ServiceManager[T]) ModelSchema() (*schema.Schema, error) {
+	cs, err := sm.columnSet()
+	if err != nil {
+		return nil, err
+	}
+	return cs.schema, nil
+}
+
+// resolvePath 解析关联路径并检查策略，只有过滤支持关联路径
+// 默认规则（Allow 为空）不放行关联模型中 json:"-" 的隐藏字段
+func (cs *columnSet) resolvePath(name string, usage ColumnUsage) (string, error) {
+	relations, field, err := filter_translator.ResolveRelationPath(cs.schema, name)
+	if err != nil {
+		return "", NewValidationError(name, "unknown field", err)
+	}
+	canonical := canonicalName(relations, field)
+	rule := cs.paths[usage]
+	allowed := rule.allow[canonical] || (rule.allowAll && field.Tag.Get("json") != "-")
+	if usage != UsageFilter || !allowed || rule.deny[canonical] {
+		return "", NewValidationError(name, fmt.Sprintf("field is not %s", usageAdjective(usage)), nil)
+	}
+	return canonical, nil
+}
+
+// canonicalPath 关联路径的规范形式：各级关联的 Go 名 + 最后一级的列名
+func canonicalPath(s *schema.Schema, path string) (string, error) {
+	relations, field, err := filter_translator.ResolveRelationPath(s, path)
+	if err != nil {
+		return "", err
+	}
+	return canonicalName(relations, field), nil
+}
+
+func canonicalName(relations []*schema.Relationship, field *schema.Field) string {
+	parts := make([]string, 0, len(relations)+1)
+	for _, rel := range relations {
+		parts = append(parts, rel.Name)
+	}
+	return strings.Join(append(parts, field.DBName), ".")
+}
+
+func usageAdjective(usage ColumnUsage) string {
+	switch usage {
+	case UsageFilter:
+		return "filterable"
+	case UsageSort:
+		return "sortable"
+	case UsageUpdate:
+		return "updatable"
+	case UsageSelect:
+		return "selectable"
+	case UsageAggregate:
+		return "aggregatable"
+	default:
+		return string(usage)
+	}
+}
+
+// columnSet 解析 T 的 schema 并按策略计算允许的列（结果缓存到策略变更为止）
+func (sm *ServiceManager[T]) columnSet() (*columnSet, error) {
+	sm.columnMu.Lock()
+	defer sm.columnMu.Unlock()
+	if sm.columns != nil {
+		return sm.columns, nil
+	}
+
+	s, err := schema.Parse(&sm.Resource, &sync.Map{}, namingStrategy())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema of %s: %w", sm.ResourceName, err)
+	}
+
+	cs := &columnSet{
+		schema:      s,
+		byName:      make(map[string]*schema.Field),
+		allowed:     make(map[ColumnUsage]map[string]bool),
+		paths:       make(map[ColumnUsage]pathRule),
+		jsonKey:     make(map[string]string),
+		primaryKeys: s.PrimaryFieldDBNames,
+	}
+	var columns []*schema.Field
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		columns = append(columns, field)
+		cs.byName[field.Name] = field
+		cs.byName[field.DBName] = field
+		if name := jsonName(field); name != "" {
+			cs.byName[name] = field
+			cs.jsonKey[field.DBName] = name
+		} else if field.Tag.Get("json") != "-" {
+			cs.jsonKey[field.DBName] = field.Name
+		}
+	}
+
+	lists := map[ColumnUsage]FieldList{
+		UsageFilter:    sm.columnPolicy.Filter,
+		UsageSort:      sm.columnPolicy.Sort,
+		UsageUpdate:    sm.columnPolicy.Update,
+		UsageSelect:    sm.columnPolicy.Select,
+		UsageAggregate: sm.columnPolicy.Aggregate,
+	}
+	for usage, list := range lists {
+		allowed := make(map[string]bool)
+		rule := pathRule{allowAll: len(list.Allow) == 0, allow: make(map[string]bool), deny: make(map[string]bool)}
+		if len(list.Allow) == 0 {
+			for _, field := range columns {
+				if usage == UsageUpdate && field.PrimaryKey {
+					continue
+				}
+				// json:"-" 的隐藏字段（如密码哈希）不进入默认允许列表，否则可被当作过滤 / 排序探针或直接写入
+				if _, ok := cs.jsonKey[field.DBName]; !ok {
+					continue
+				}
+				allowed[field.DBName] = true
+			}
+		}
+		for _, name := range list.Allow {
+			if strings.Contains(name, ".") {
+				path, err := canonicalPath(s, name)
+				if err != nil {
+					return nil, fmt.Errorf("column policy of %s: %w", sm.ResourceName, err)
+				}
+				rule.allow[path] = true
+				continue
+			}
+			field, ok := cs.byName[name]
+			if !ok {
+				return nil, fmt.Errorf("column policy of %s: unknown field %q", sm.ResourceName, name)
+			}
+			allowed[field.DBName] = true
+		}
+		for _, name := range list.Deny {
+			if strings.Contains(name, ".") {
+				if path, err := canonicalPath(s, name); err == nil {
+					rule.deny[path] = true
+				}
+				continue
+			}
+			if field, ok := cs.byName[name]; ok {
+				delete(allowed, field.DBName)
+			}
+		}
+		cs.allowed[usage] = allowed
+		cs.paths[usage] = rule
+	}
+
+	sm.columns = cs
+	return cs, nil
+}
+
+// jsonName 返回字段的 json 名，未设置或为 "-" 时返回空
+func jsonName(field *schema.Field) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// namingStrategy 返回数据库使用的命名策略，数据库未初始化时使用 GORM 默认策略
+func namingStrategy() schema.Namer {
+	if globalDBManager != nil && globalDBManager.DB != nil {
+		return globalDBManager.DB.NamingStrategy
+	}
+	return schema.NamingStrategy{}
+}
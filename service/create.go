@@ -3,8 +3,12 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
+
+	"AbstractManager/util/tracing"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CreateOptions 创建表的配置选项
@@ -14,7 +18,10 @@ type CreateOptions struct {
 }
 
 // Create 创建数据表
-func (sm *ServiceManager[T]) Create(ctx context.Context, opts *CreateOptions) error {
+func (sm *ServiceManager[T]) Create(ctx context.Context, opts *CreateOptions) (err error) {
+	ctx, span := sm.startSpan(ctx, "Create")
+	defer func() { tracing.End(span, err) }()
+
 	db := GetDB().WithContext(ctx)
 
 	if opts == nil {
@@ -50,7 +57,10 @@ func (sm *ServiceManager[T]) Create(ctx context.Context, opts *CreateOptions) er
 }
 
 // CreateWithIndexes 创建数据表并添加索引
-func (sm *ServiceManager[T]) CreateWithIndexes(ctx context.Context, opts *CreateOptions, indexes []Index) error {
+func (sm *ServiceManager[T]) CreateWithIndexes(ctx context.Context, opts *CreateOptions, indexes []Index) (err error) {
+	ctx, span := sm.startSpan(ctx, "CreateWithIndexes")
+	defer func() { tracing.End(span, err) }()
+
 	// 先创建表
 	if err := sm.Create(ctx, opts); err != nil {
 		return err
@@ -70,26 +80,94 @@ func (sm *ServiceManager[T]) CreateWithIndexes(ctx context.Context, opts *Create
 
 // Index 索引定义
 type Index struct {
-	Name    string   // 索引名称
-	Columns []string // 索引字段
-	Unique  bool     // 是否唯一索引
+	Name     string   // 索引名称
+	Columns  []string // 索引字段（json 名 / 列名 / Go 字段名），为空时取结构体 gorm 标签中同名的索引
+	Unique   bool     // 是否唯一索引
+	Fulltext bool     // 是否 FULLTEXT 索引（MySQL），创建后供搜索使用全文检索
 }
 
-// createIndex 创建索引
+// createIndex 创建索引，索引已存在时跳过
 func (sm *ServiceManager[T]) createIndex(db *gorm.DB, idx Index) error {
 	tableName := sm.TableName
 	if sm.Schema != "" && sm.Schema != "public" {
 		tableName = fmt.Sprintf("%s.%s", sm.Schema, sm.TableName)
 	}
 
-	if idx.Unique {
-		return db.Table(tableName).Migrator().CreateIndex(&sm.Resource, idx.Name)
+	columns, err := sm.indexColumns(idx)
+	if err != nil {
+		return err
 	}
-	return db.Table(tableName).Migrator().CreateIndex(&sm.Resource, idx.Name)
+
+	migrator := db.Table(tableName).Migrator()
+	if !migrator.HasIndex(&sm.Resource, idx.Name) {
+		if len(idx.Columns) == 0 {
+			// 未指定列：由 gorm 标签中声明的同名索引创建
+			if err := migrator.CreateIndex(&sm.Resource, idx.Name); err != nil {
+				return err
+			}
+		} else {
+			kind := ""
+			switch {
+			case idx.Fulltext:
+				kind = "FULLTEXT "
+			case idx.Unique:
+				kind = "UNIQUE "
+			}
+			cols := make([]clause.Column, len(columns))
+			for i, c := range columns {
+				cols[i] = clause.Column{Name: c}
+			}
+			err := db.Exec("CREATE "+kind+"INDEX ? ON ? ?", clause.Column{Name: idx.Name}, clause.Table{Name: tableName}, cols).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if idx.Fulltext {
+		sm.registerFulltext(columns)
+	}
+	return nil
+}
+
+// indexColumns 将索引字段映射为列名；未指定字段时取 gorm 标签中同名索引的列
+func (sm *ServiceManager[T]) indexColumns(idx Index) ([]string, error) {
+	cs, err := sm.columnSet()
+	if err != nil {
+		return nil, err
+	}
+	if len(idx.Columns) == 0 {
+		tagged := cs.schema.LookIndex(idx.Name)
+		if tagged == nil {
+			return nil, fmt.Errorf("index %s: no columns given and no gorm tag index with that name", idx.Name)
+		}
+		if idx.Fulltext && !strings.EqualFold(tagged.Class, "FULLTEXT") {
+			return nil, fmt.Errorf("index %s: declared fulltext but the gorm tag index is not FULLTEXT", idx.Name)
+		}
+		columns := make([]string, 0, len(tagged.Fields))
+		for _, f := range tagged.Fields {
+			if f.Field != nil {
+				columns = append(columns, f.DBName)
+			}
+		}
+		return columns, nil
+	}
+	columns := make([]string, 0, len(idx.Columns))
+	for _, name := range idx.Columns {
+		field, ok := cs.byName[name]
+		if !ok {
+			return nil, fmt.Errorf("index %s: unknown field %q", idx.Name, name)
+		}
+		columns = append(columns, field.DBName)
+	}
+	return columns, nil
 }
 
 // DropTable 删除数据表
-func (sm *ServiceManager[T]) DropTable(ctx context.Context) error {
+func (sm *ServiceManager[T]) DropTable(ctx context.Context) (err error) {
+	ctx, span := sm.startSpan(ctx, "DropTable")
+	defer func() { tracing.End(span, err) }()
+
 	db := GetDB().WithContext(ctx)
 
 	tableName := sm.TableName
@@ -105,7 +183,10 @@ func (sm *ServiceManager[T]) DropTable(ctx context.Context) error {
 }
 
 // HasTable 检查表是否存在
-func (sm *ServiceManager[T]) HasTable(ctx context.Context) (bool, error) {
+func (sm *ServiceManager[T]) HasTable(ctx context.Context) (_ bool, err error) {
+	ctx, span := sm.startSpan(ctx, "HasTable")
+	defer func() { tracing.End(span, err) }()
+
 	db := GetDB().WithContext(ctx)
 
 	tableName := sm.TableName
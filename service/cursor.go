@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ========== 游标分页（keyset） ==========
+// 游标对调用方不透明，内容是排序键与最后（或第一）一行的排序键取值。
+// 下一页条件为 (k1, k2, ..., pk) 严格位于游标之后，不需要 OFFSET，也不受并发插入导致的翻页偏移影响。
+// 排序键末尾会自动追加主键作为唯一的决胜键；排序列必须不可为 NULL（指针与 sql.Null* 字段会被拒绝），游标分页不支持 Nulls 选项。
+
+// 游标方向
+const (
+	cursorNext = "next"
+	cursorPrev = "prev"
+)
+
+// cursorToken 游标内容
+type cursorToken struct {
+	Dir    string            `json:"d"` // next / prev
+	Order  []string          `json:"o"` // 排序键签名（列名 + 方向），用于校验游标与当前排序一致
+	Values []json.RawMessage `json:"v"` // 排序键取值
+}
+
+// keysetPage 一次游标分页查询的状态
+type keysetPage[T any] struct {
+	sm       *ServiceManager[T]
+	keys     []sortKey
+	limit    int
+	backward bool // 向前翻页（使用 prev 游标）
+	hasToken bool // 是否带有游标（首页为 false）
+}
+
+// usesCursor 是否启用游标分页
+func (opts *QueryOptions) usesCursor() bool {
+	return opts != nil && (opts.UseCursor || opts.Cursor != "")
+}
+
+// newKeysetPage 解析游标并补全主键决胜键
+func (sm *ServiceManager[T]) newKeysetPage(keys []sortKey, opts *QueryOptions) (*keysetPage[T], []interface{}, error) {
+	if opts.PageSize <= 0 {
+		return nil, nil, NewValidationError("page_size", "cursor pagination requires a positive page size", nil)
+	}
+	for _, k := range keys {
+		if k.nulls != "" {
+			return nil, nil, NewValidationError("sort", "nulls ordering is not supported with cursor pagination", nil)
+		}
+	}
+
+	cs, err := sm.columnSet()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(cs.primaryKeys) == 0 {
+		return nil, nil, fmt.Errorf("cursor pagination requires a primary key on %s", sm.ResourceName)
+	}
+	for _, k := range keys {
+		// NULL 不参与 < / > 比较，可空列上的游标条件会漏掉或重复行
+		if field := cs.byName[k.column]; field != nil && nullableField(field) {
+			return nil, nil, NewValidationError("sort", fmt.Sprintf("cursor pagination cannot sort by nullable column %s", k.column), nil)
+		}
+	}
+	for _, pk := range cs.primaryKeys {
+		if !slices.ContainsFunc(keys, func(k sortKey) bool { return k.column == pk }) {
+			keys = append(keys, sortKey{column: pk})
+		}
+	}
+
+	page := &keysetPage[T]{sm: sm, keys: keys, limit: opts.PageSize}
+	if opts.Cursor == "" {
+		return page, nil, nil
+	}
+
+	token, err := decodeCursor(opts.Cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !slices.Equal(token.Order, page.signature()) || len(token.Values) != len(keys) {
+		return nil, nil, NewValidationError("cursor", "cursor does not match the current sort order", nil)
+	}
+
+	values := make([]interface{}, len(keys))
+	for i, k := range keys {
+		field := cs.byName[k.column]
+		ptr := reflect.New(field.FieldType)
+		if err := json.Unmarshal(token.Values[i], ptr.Interface()); err != nil {
+			return nil, nil, NewValidationError("cursor", "malformed cursor", err)
+		}
+		values[i] = ptr.Elem().Interface()
+	}
+	page.backward = token.Dir == cursorPrev
+	page.hasToken = true
+	return page, values, nil
+}
+
+// nullableField 字段是否可为 NULL：指针，或带 Valid 标记的 sql.Null* / gorm.DeletedAt 之类的 Valuer
+func nullableField(field *schema.Field) bool {
+	typ := field.FieldType
+	if typ.Kind() == reflect.Pointer {
+		return true
+	}
+	if typ.Kind() != reflect.Struct || !typ.Implements(reflect.TypeOf((*driver.Valuer)(nil)).Elem()) {
+		return false
+	}
+	valid, ok := typ.FieldByName("Valid")
+	return ok && valid.Type.Kind() == reflect.Bool
+}
+
+// signature 排序键签名
+func (p *keysetPage[T]) signature() []string {
+	sig := make([]string, len(p.keys))
+	for i, k := range p.keys {
+		dir := SortAsc
+		if k.desc {
+			dir = SortDesc
+		}
+		sig[i] = k.column + ":" + dir
+	}
+	return sig
+}
+
+// apply 追加游标条件、排序与 LIMIT（多取一行用于判断是否还有更多）
+// 向前翻页时反转排序方向，取回后再反转结果
+func (p *keysetPage[T]) apply(db *gorm.DB, values []interface{}) *gorm.DB {
+	if values != nil {
+		// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ...
+		var ors []clause.Expression
+		for i, k := range p.keys {
+			var ands []clause.Expression
+			for j := 0; j < i; j++ {
+				ands = append(ands, clause.Eq{Column: clause.Column{Name: p.keys[j].column}, Value: values[j]})
+			}
+			column := clause.Column{Name: k.column}
+			if k.desc != p.backward {
+				ands = append(ands, clause.Lt{Column: column, Value: values[i]})
+			} else {
+				ands = append(ands, clause.Gt{Column: column, Value: values[i]})
+			}
+			ors = append(ors, clause.And(ands...))
+		}
+		db = db.Where(clause.Or(ors...))
+	}
+
+	for _, k := range p.keys {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: k.column}, Desc: k.desc != p.backward})
+	}
+	return db.Limit(p.limit + 1)
+}
+
+// finish 截断多取的一行并生成前后游标
+func (p *keysetPage[T]) finish(ctx context.Context, items []T) ([]T, string, string, error) {
+	hasMore := len(items) > p.limit
+	if hasMore {
+		items = items[:p.limit]
+	}
+	if p.backward {
+		slices.Reverse(items)
+	}
+	if len(items) == 0 {
+		return items, "", "", nil
+	}
+
+	// 正向：还有更多才有下一页，带游标时才有上一页；反向相反
+	hasNext, hasPrev := hasMore, p.hasToken
+	if p.backward {
+		hasNext, hasPrev = p.hasToken, hasMore
+	}
+
+	var next, prev string
+	var err error
+	if hasNext {
+		if next, err = p.encode(ctx, cursorNext, &items[len(items)-1]); err != nil {
+			return nil, "", "", err
+		}
+	}
+	if hasPrev {
+		if prev, err = p.encode(ctx, cursorPrev, &items[0]); err != nil {
+			return nil, "", "", err
+		}
+	}
+	return items, next, prev, nil
+}
+
+// encode 用一行数据的排序键取值生成游标
+func (p *keysetPage[T]) encode(ctx context.Context, dir string, item *T) (string, error) {
+	cs, err := p.sm.columnSet()
+	if err != nil {
+		return "", err
+	}
+	token := cursorToken{Dir: dir, Order: p.signature(), Values: make([]json.RawMessage, len(p.keys))}
+	rv := reflect.ValueOf(item).Elem()
+	for i, k := range p.keys {
+		value, _ := cs.byName[k.column].ValueOf(ctx, rv)
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode cursor: %w", err)
+		}
+		token.Values[i] = raw
+	}
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor 解析游标
+func decodeCursor(cursor string) (*cursorToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, NewValidationError("cursor", "malformed cursor", err)
+	}
+	var token cursorToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, NewValidationError("cursor", "malformed cursor", err)
+	}
+	if token.Dir != cursorNext && token.Dir != cursorPrev {
+		return nil, NewValidationError("cursor", "malformed cursor", nil)
+	}
+	return &token, nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	mysqlDriver "github.com/go-sql-driver/mysql"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// ========== 错误分类 ==========
+// 所有 service 方法返回的错误都可以用 errors.Is 判断属于以下哪一类，
+// http_router 的错误映射中间件据此输出统一的状态码与错误码
+
+var (
+	// ErrNotFound 记录或缓存键不存在
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict 唯一键冲突等数据冲突
+	ErrConflict = errors.New("conflict")
+	// ErrVersionOutdated 带版本写缓存时提供的版本不高于当前版本
+	ErrVersionOutdated = errors.New("version outdated")
+	// ErrLockNotAcquired 未能获取分布式锁
+	ErrLockNotAcquired = errors.New("lock not acquired")
+	// ErrValidation 参数校验失败
+	ErrValidation = errors.New("validation failed")
+	// ErrCacheUnavailable Redis 不可用（连接失败、超时等，不包括键不存在）
+	ErrCacheUnavailable = errors.New("cache unavailable")
+)
+
+// NotFoundError 记录不存在，errors.Is(err, ErrNotFound) 为 true
+// Err 保留底层错误（gorm.ErrRecordNotFound / redis.Nil），便于旧代码继续用 errors.Is 判断
+type NotFoundError struct {
+	Resource string
+	Key      string // 缓存键或查询描述，可为空
+	Err      error
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%s: record not found", e.Resource)
+	}
+	return fmt.Sprintf("%s: record not found: %s", e.Resource, e.Key)
+}
+
+func (e *NotFoundError) Is(target error) bool { return target == ErrNotFound }
+func (e *NotFoundError) Unwrap() error        { return e.Err }
+
+// VersionOutdatedError 版本冲突，errors.Is(err, ErrVersionOutdated) 为 true
+type VersionOutdatedError struct {
+	Key      string
+	Current  int64
+	Provided int64
+}
+
+func (e *VersionOutdatedError) Error() string {
+	return fmt.Sprintf("version outdated for key %s: current %d, provided %d", e.Key, e.Current, e.Provided)
+}
+
+func (e *VersionOutdatedError) Is(target error) bool { return target == ErrVersionOutdated }
+
+// ValidationError 参数校验失败，errors.Is(err, ErrValidation) 为 true
+type ValidationError struct {
+	Field   string // 出错字段，可为空
+	Message string
+	Err     error // 底层错误（如 JSON 解析错误），可为空
+}
+
+// NewValidationError 创建参数校验错误
+func NewValidationError(field, message string, err error) *ValidationError {
+	return &ValidationError{Field: field, Message: message, Err: err}
+}
+
+func (e *ValidationError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("validation failed")
+	if e.Field != "" {
+		sb.WriteString(": ")
+		sb.WriteString(e.Field)
+	}
+	if e.Message != "" {
+		sb.WriteString(": ")
+		sb.WriteString(e.Message)
+	}
+	if e.Err != nil {
+		sb.WriteString(": ")
+		sb.WriteString(e.Err.Error())
+	}
+	return sb.String()
+}
+
+func (e *ValidationError) Is(target error) bool { return target == ErrValidation }
+func (e *ValidationError) Unwrap() error        { return e.Err }
+
+// ========== 底层错误归类 ==========
+
+// mysqlDuplicateEntry MySQL 唯一键冲突错误码
+const mysqlDuplicateEntry = 1062
+
+// classifyDBError 将数据库错误归入错误分类，无法归类时原样返回
+func (sm *ServiceManager[T]) classifyDBError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &NotFoundError{Resource: sm.ResourceName, Err: err}
+	}
+	var mysqlErr *mysqlDriver.MySQLError
+	if errors.Is(err, gorm.ErrDuplicatedKey) ||
+		(errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry) {
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+	return err
+}
+
+// ClassifyCacheError 将 Redis 连接类错误（网络错误、连接池超时/耗尽、客户端已关闭）归入 ErrCacheUnavailable
+// redis.Nil（键不存在）及其它错误原样返回
+func ClassifyCacheError(err error) error {
+	if err == nil || errors.Is(err, ErrCacheUnavailable) {
+		return err
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) ||
+		errors.Is(err, redis.ErrClosed) || errors.Is(err, redis.ErrPoolTimeout) || errors.Is(err, redis.ErrPoolExhausted) {
+		return fmt.Errorf("%w: %w", ErrCacheUnavailable, err)
+	}
+	return err
+}
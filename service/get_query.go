@@ -3,6 +3,9 @@ package service
 import (
 	"context"
 	"fmt"
+	"slices"
+
+	"AbstractManager/util/tracing"
 
 	"gorm.io/gorm"
 )
@@ -11,22 +14,33 @@ import (
 type QueryOptions struct {
 	Page     int                    // 页码（从1开始）
 	PageSize int                    // 每页数量
-	OrderBy  string                 // 排序字段
+	OrderBy  string                 // 排序字段（Sorts 为空时生效）
 	Order    string                 // 排序方向（ASC/DESC）
+	Sorts    []SortParam            // 多列排序，按顺序应用，优先于 OrderBy
 	Preload  []string               // 预加载关联
 	Select   []string               // 指定查询字段
 	Distinct bool                   // 是否去重
 	Group    string                 // 分组字段
 	Having   map[string]interface{} // Having 条件
+
+	// 游标分页：Cursor 非空或 UseCursor 为 true 时使用 keyset 分页（忽略 Page），每页 PageSize 条
+	Cursor    string // 上一次结果中的 NextCursor / PrevCursor
+	UseCursor bool   // 首页（尚无游标）时置 true 以启用游标分页
+	SkipCount bool   // 跳过 COUNT 查询（Total 为 0）
+
+	Search *SearchOptions // 多字段搜索（Query 为空时忽略）
 }
 
 // QueryResult 查询结果
 type QueryResult[T any] struct {
-	Data       []T   // 数据列表
-	Total      int64 // 总数
-	Page       int   // 当前页
-	PageSize   int   // 每页数量
-	TotalPages int   // 总页数
+	Data       []T    // 数据列表
+	Total      int64  // 总数
+	Page       int    // 当前页
+	PageSize   int    // 每页数量
+	TotalPages int    // 总页数
+	NextCursor string // 下一页游标（游标分页且存在下一页时）
+	PrevCursor string // 上一页游标（游标分页且存在上一页时）
+	Cached     bool   // 结果来自查询结果缓存（见 GetQueryCached）
 }
 
 // GetQuery 条件查询（支持分页）
@@ -35,7 +49,10 @@ func (sm *ServiceManager[T]) GetQuery(
 	ctx context.Context,
 	queryFunc func(*gorm.DB) *gorm.DB,
 	opts *QueryOptions,
-) (*QueryResult[T], error) {
+) (_ *QueryResult[T], err error) {
+	ctx, span := sm.startSpan(ctx, "GetQuery")
+	defer func() { tracing.End(span, err) }()
+
 	db := GetDB().WithContext(ctx)
 
 	// 设置只读事务隔离级别（READ COMMITTED）
@@ -46,30 +63,13 @@ func (sm *ServiceManager[T]) GetQuery(
 		}
 	}()
 
-	// 应用表名
-	db = sm.applyTableName(db)
-
-	// 应用查询条件
-	if queryFunc != nil {
-		db = queryFunc(db)
-	}
-
-	// 统计总数
-	var total int64
-	countDB := db
-	if err := countDB.Model(&sm.Resource).Count(&total).Error; err != nil {
-		db.Rollback()
-		return nil, fmt.Errorf("failed to count records: %w", err)
-	}
-
-	// 应用查询选项
-	db = sm.applyQueryOptions(db, opts)
-
-	// 执行查询
-	var results []T
-	if err := db.Find(&results).Error; err != nil {
+	op := &Operation[T]{Kind: OpGet, Method: "GetQuery", Query: queryFunc, Tx: db}
+	err = sm.runOp(ctx, op, func() error {
+		return sm.findWithCount(db, op, opts)
+	})
+	if err != nil {
 		db.Rollback()
-		return nil, fmt.Errorf("failed to query records: %w", err)
+		return nil, err
 	}
 
 	// 提交只读事务
@@ -79,14 +79,16 @@ func (sm *ServiceManager[T]) GetQuery(
 
 	// 构建返回结果
 	result := &QueryResult[T]{
-		Data:  results,
-		Total: total,
+		Data:       op.Items,
+		Total:      op.Total,
+		NextCursor: op.NextCursor,
+		PrevCursor: op.PrevCursor,
 	}
 
 	if opts != nil && opts.PageSize > 0 {
 		result.Page = opts.Page
 		result.PageSize = opts.PageSize
-		result.TotalPages = int((total + int64(opts.PageSize) - 1) / int64(opts.PageSize))
+		result.TotalPages = int((op.Total + int64(opts.PageSize) - 1) / int64(opts.PageSize))
 	}
 
 	return result, nil
@@ -97,46 +99,84 @@ func (sm *ServiceManager[T]) GetQueryWithoutTransaction(
 	ctx context.Context,
 	queryFunc func(*gorm.DB) *gorm.DB,
 	opts *QueryOptions,
-) (*QueryResult[T], error) {
-	db := GetDB().WithContext(ctx)
+) (_ *QueryResult[T], err error) {
+	ctx, span := sm.startSpan(ctx, "GetQueryWithoutTransaction")
+	defer func() { tracing.End(span, err) }()
+
+	op := &Operation[T]{Kind: OpGet, Method: "GetQueryWithoutTransaction", Query: queryFunc}
+	err = sm.runOp(ctx, op, func() error {
+		return sm.findWithCount(GetDB().WithContext(ctx), op, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 构建返回结果
+	result := &QueryResult[T]{
+		Data:       op.Items,
+		Total:      op.Total,
+		NextCursor: op.NextCursor,
+		PrevCursor: op.PrevCursor,
+	}
+
+	if opts != nil && opts.PageSize > 0 {
+		result.Page = opts.Page
+		result.PageSize = opts.PageSize
+		result.TotalPages = int((op.Total + int64(opts.PageSize) - 1) / int64(opts.PageSize))
+	}
 
+	return result, nil
+}
+
+// findWithCount 统计总数并查询数据，结果写入 op.Total / op.Items
+func (sm *ServiceManager[T]) findWithCount(db *gorm.DB, op *Operation[T], opts *QueryOptions) error {
 	// 应用表名
 	db = sm.applyTableName(db)
 
 	// 应用查询条件
-	if queryFunc != nil {
-		db = queryFunc(db)
+	if op.Query != nil {
+		db = op.Query(db)
+	}
+
+	// 应用搜索条件（同时作用于计数与查询）
+	var search *searchPlan
+	if opts != nil && opts.Search.active() {
+		var err error
+		if search, err = sm.newSearchPlan(db, opts.Search); err != nil {
+			return err
+		}
+		db = search.apply(db)
 	}
 
+	// 条件构建完成后开启新会话，使 Count 与 Find 各自克隆语句，互不影响
+	db = db.Session(&gorm.Session{})
+
 	// 统计总数
-	var total int64
-	countDB := db
-	if err := countDB.Model(&sm.Resource).Count(&total).Error; err != nil {
-		return nil, fmt.Errorf("failed to count records: %w", err)
+	if opts == nil || !opts.SkipCount {
+		if err := db.Model(&sm.Resource).Count(&op.Total).Error; err != nil {
+			return fmt.Errorf("failed to count records: %w", err)
+		}
 	}
 
 	// 应用查询选项
-	db = sm.applyQueryOptions(db, opts)
+	db, page, err := sm.applyQueryOptions(db, opts, search)
+	if err != nil {
+		return err
+	}
 
 	// 执行查询
 	var results []T
 	if err := db.Find(&results).Error; err != nil {
-		return nil, fmt.Errorf("failed to query records: %w", err)
-	}
-
-	// 构建返回结果
-	result := &QueryResult[T]{
-		Data:  results,
-		Total: total,
+		return fmt.Errorf("failed to query records: %w", err)
 	}
-
-	if opts != nil && opts.PageSize > 0 {
-		result.Page = opts.Page
-		result.PageSize = opts.PageSize
-		result.TotalPages = int((total + int64(opts.PageSize) - 1) / int64(opts.PageSize))
+	if page != nil {
+		results, op.NextCursor, op.PrevCursor, err = page.finish(db.Statement.Context, results)
+		if err != nil {
+			return err
+		}
 	}
-
-	return result, nil
+	op.Items = results
+	return nil
 }
 
 // applyTableName 应用表名
@@ -148,15 +188,46 @@ func (sm *ServiceManager[T]) applyTableName(db *gorm.DB) *gorm.DB {
 	return db.Table(tableName)
 }
 
-// applyQueryOptions 应用查询选项
-func (sm *ServiceManager[T]) applyQueryOptions(db *gorm.DB, opts *QueryOptions) *gorm.DB {
+// applyQueryOptions 应用查询选项，游标分页时返回分页状态；search 非空且要求相关度排序时相关度作为首个排序键
+func (sm *ServiceManager[T]) applyQueryOptions(db *gorm.DB, opts *QueryOptions, search *searchPlan) (*gorm.DB, *keysetPage[T], error) {
 	if opts == nil {
-		return db
+		return db, nil, nil
+	}
+
+	// 解析排序（字段须通过列策略，方向只接受 ASC/DESC）
+	sorts := opts.Sorts
+	if len(sorts) == 0 && opts.OrderBy != "" {
+		sorts = []SortParam{{Field: opts.OrderBy, Direction: opts.Order}}
+	}
+	keys, err := sm.resolveSorts(sorts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byRelevance := search != nil && opts.Search.OrderByRelevance
+	var page *keysetPage[T]
+	var cursorValues []interface{}
+	if opts.usesCursor() {
+		if byRelevance {
+			return nil, nil, NewValidationError("cursor", "relevance ordering is not supported with cursor pagination", nil)
+		}
+		if page, cursorValues, err = sm.newKeysetPage(keys, opts); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	// 应用字段选择
+	// 应用字段选择（游标分页需要读取排序键，缺少时补上）
 	if len(opts.Select) > 0 {
-		db = db.Select(opts.Select)
+		selects := opts.Select
+		if page != nil {
+			selects = slices.Clone(selects)
+			for _, k := range page.keys {
+				if !slices.Contains(selects, k.column) {
+					selects = append(selects, k.column)
+				}
+			}
+		}
+		db = db.Select(selects)
 	}
 
 	// 应用去重
@@ -176,20 +247,23 @@ func (sm *ServiceManager[T]) applyQueryOptions(db *gorm.DB, opts *QueryOptions)
 		}
 	}
 
-	// 应用排序
-	if opts.OrderBy != "" {
-		order := "ASC"
-		if opts.Order != "" {
-			order = opts.Order
-		}
-		db = db.Order(fmt.Sprintf("%s %s", opts.OrderBy, order))
-	}
-
 	// 应用预加载
 	for _, preload := range opts.Preload {
 		db = db.Preload(preload)
 	}
 
+	// 游标分页：游标条件 + 排序 + LIMIT
+	if page != nil {
+		return page.apply(db, cursorValues), page, nil
+	}
+
+	// 应用排序
+	if byRelevance {
+		db = orderByRelevance(db, search.relevance(), keys)
+	} else {
+		db = applySorts(db, keys)
+	}
+
 	// 应用分页
 	if opts.PageSize > 0 {
 		page := opts.Page
@@ -200,37 +274,49 @@ func (sm *ServiceManager[T]) applyQueryOptions(db *gorm.DB, opts *QueryOptions)
 		db = db.Offset(offset).Limit(opts.PageSize)
 	}
 
-	return db
+	return db, nil, nil
 }
 
 // CountQuery 条件计数
 func (sm *ServiceManager[T]) CountQuery(
 	ctx context.Context,
 	queryFunc func(*gorm.DB) *gorm.DB,
-) (int64, error) {
-	db := GetDB().WithContext(ctx)
+) (_ int64, err error) {
+	ctx, span := sm.startSpan(ctx, "CountQuery")
+	defer func() { tracing.End(span, err) }()
 
-	// 应用表名
-	db = sm.applyTableName(db)
+	op := &Operation[T]{Kind: OpGet, Method: "CountQuery", Query: queryFunc}
+	err = sm.runOp(ctx, op, func() error {
+		db := GetDB().WithContext(ctx)
 
-	// 应用查询条件
-	if queryFunc != nil {
-		db = queryFunc(db)
-	}
+		// 应用表名
+		db = sm.applyTableName(db)
 
-	var count int64
-	if err := db.Model(&sm.Resource).Count(&count).Error; err != nil {
-		return 0, fmt.Errorf("failed to count records: %w", err)
+		// 应用查询条件
+		if op.Query != nil {
+			db = op.Query(db)
+		}
+
+		if err := db.Model(&sm.Resource).Count(&op.Total).Error; err != nil {
+			return fmt.Errorf("failed to count records: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	return count, nil
+	return op.Total, nil
 }
 
 // ExistsQuery 检查是否存在满足条件的记录
 func (sm *ServiceManager[T]) ExistsQuery(
 	ctx context.Context,
 	queryFunc func(*gorm.DB) *gorm.DB,
-) (bool, error) {
+) (_ bool, err error) {
+	ctx, span := sm.startSpan(ctx, "ExistsQuery")
+	defer func() { tracing.End(span, err) }()
+
 	count, err := sm.CountQuery(ctx, queryFunc)
 	if err != nil {
 		return false, err
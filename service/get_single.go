@@ -2,8 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"AbstractManager/util/tracing"
+
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -21,7 +24,10 @@ func (sm *ServiceManager[T]) GetSingle(
 	ctx context.Context,
 	queryFunc func(*gorm.DB) *gorm.DB,
 	opts *SingleQueryOptions,
-) (*T, error) {
+) (_ *T, err error) {
+	ctx, span := sm.startSpan(ctx, "GetSingle")
+	defer func() { tracing.End(span, err) }()
+
 	db := GetDB().WithContext(ctx)
 
 	// 如果需要加锁，使用更高的事务隔离级别
@@ -35,38 +41,49 @@ func (sm *ServiceManager[T]) GetSingle(
 		}()
 	}
 
-	// 应用表名
-	db = sm.applyTableName(db)
-
-	// 应用查询条件
-	if queryFunc != nil {
-		db = queryFunc(db)
+	op := &Operation[T]{Kind: OpGet, Method: "GetSingle", Query: queryFunc}
+	if opts != nil && opts.ForUpdate {
+		op.Tx = db
 	}
 
-	// 应用单个查询选项
-	db = sm.applySingleQueryOptions(db, opts)
+	err = sm.runOp(ctx, op, func() error {
+		// 应用表名
+		q := sm.applyTableName(db)
+
+		// 应用查询条件
+		if op.Query != nil {
+			q = op.Query(q)
+		}
 
-	var result T
-	err := db.First(&result).Error
+		// 应用单个查询选项
+		q = sm.applySingleQueryOptions(q, opts)
+
+		var result T
+		if err := q.First(&result).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &NotFoundError{Resource: sm.ResourceName, Err: err}
+			}
+			return fmt.Errorf("failed to query record: %w", err)
+		}
+		op.Data = &result
+		return nil
+	})
 
 	if err != nil {
 		if opts != nil && opts.ForUpdate {
 			db.Rollback()
 		}
-		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("record not found")
-		}
-		return nil, fmt.Errorf("failed to query record: %w", err)
+		return nil, err
 	}
 
 	// 如果是加锁查询，不提交事务（让调用者处理）
 	if opts != nil && opts.ForUpdate {
 		// 返回结果，但保持事务打开
 		// 注意：这里需要调用者在使用完数据后手动提交或回滚
-		return &result, nil
+		return op.Data, nil
 	}
 
-	return &result, nil
+	return op.Data, nil
 }
 
 // GetSingleByID 根据主键 ID 查询单个记录
@@ -74,10 +91,15 @@ func (sm *ServiceManager[T]) GetSingleByID(
 	ctx context.Context,
 	id interface{},
 	opts *SingleQueryOptions,
-) (*T, error) {
-	return sm.GetSingle(ctx, func(db *gorm.DB) *gorm.DB {
-		return db.Where("id = ?", id)
-	}, opts)
+) (_ *T, err error) {
+	ctx, span := sm.startSpan(ctx, "GetSingleByID", idAttr(id))
+	defer func() { tracing.End(span, err) }()
+
+	byID, err := sm.PrimaryKeyQuery(id)
+	if err != nil {
+		return nil, err
+	}
+	return sm.GetSingle(ctx, byID, opts)
 }
 
 // GetSingleOrCreate 查询单个记录，不存在则创建
@@ -85,7 +107,10 @@ func (sm *ServiceManager[T]) GetSingleOrCreate(
 	ctx context.Context,
 	queryFunc func(*gorm.DB) *gorm.DB,
 	createData *T,
-) (*T, bool, error) {
+) (_ *T, _ bool, err error) {
+	ctx, span := sm.startSpan(ctx, "GetSingleOrCreate")
+	defer func() { tracing.End(span, err) }()
+
 	db := GetDB().WithContext(ctx)
 
 	// 开启 REPEATABLE READ 事务
@@ -96,32 +121,48 @@ func (sm *ServiceManager[T]) GetSingleOrCreate(
 		}
 	}()
 
-	// 应用表名
-	db = sm.applyTableName(db)
+	getOp := &Operation[T]{Kind: OpGet, Method: "GetSingleOrCreate", Query: queryFunc, Tx: db}
+	err = sm.runOp(ctx, getOp, func() error {
+		// 应用表名
+		q := sm.applyTableName(db)
 
-	// 应用查询条件
-	if queryFunc != nil {
-		db = queryFunc(db)
-	}
+		// 应用查询条件
+		if getOp.Query != nil {
+			q = getOp.Query(q)
+		}
 
-	var result T
-	err := db.First(&result).Error
+		var result T
+		if err := q.First(&result).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &NotFoundError{Resource: sm.ResourceName, Err: err}
+			}
+			return err
+		}
+		getOp.Data = &result
+		return nil
+	})
 
 	if err == nil {
 		// 记录存在
 		if err := db.Commit().Error; err != nil {
 			return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
 		}
-		return &result, false, nil
+		return getOp.Data, false, nil
 	}
 
-	if err != gorm.ErrRecordNotFound {
+	if !errors.Is(err, ErrNotFound) {
 		db.Rollback()
 		return nil, false, fmt.Errorf("failed to query record: %w", err)
 	}
 
 	// 记录不存在，创建新记录
-	if err := db.Create(createData).Error; err != nil {
+	setOp := &Operation[T]{Kind: OpSet, Method: "GetSingleOrCreate", Data: createData, Tx: db}
+	err = sm.runOp(ctx, setOp, func() error {
+		result := sm.applyTableName(db).Create(setOp.Data)
+		setOp.RowsAffected = result.RowsAffected
+		return sm.classifyDBError(result.Error)
+	})
+	if err != nil {
 		db.Rollback()
 		return nil, false, fmt.Errorf("failed to create record: %w", err)
 	}
@@ -129,42 +170,54 @@ func (sm *ServiceManager[T]) GetSingleOrCreate(
 	if err := db.Commit().Error; err != nil {
 		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	sm.bumpQueryGeneration(ctx)
 
-	return createData, true, nil
+	return setOp.Data, true, nil
 }
 
 // GetSingleWithLock 加锁查询单个记录（用于后续更新）
 func (sm *ServiceManager[T]) GetSingleWithLock(
 	ctx context.Context,
 	queryFunc func(*gorm.DB) *gorm.DB,
-) (*T, *gorm.DB, error) {
+) (_ *T, _ *gorm.DB, err error) {
+	ctx, span := sm.startSpan(ctx, "GetSingleWithLock")
+	defer func() { tracing.End(span, err) }()
+
 	db := GetDB().WithContext(ctx)
 
 	// 开启事务
 	txDB := db.Begin()
 
-	// 应用表名
-	txDB = sm.applyTableName(txDB)
+	op := &Operation[T]{Kind: OpGet, Method: "GetSingleWithLock", Query: queryFunc, Tx: txDB}
+	err = sm.runOp(ctx, op, func() error {
+		// 应用表名
+		txDB = sm.applyTableName(txDB)
 
-	// 应用查询条件
-	if queryFunc != nil {
-		txDB = queryFunc(txDB)
-	}
+		// 应用查询条件
+		if op.Query != nil {
+			txDB = op.Query(txDB)
+		}
 
-	// 加行锁
-	txDB = txDB.Clauses(clause.Locking{Strength: "UPDATE"})
+		// 加行锁
+		txDB = txDB.Clauses(clause.Locking{Strength: "UPDATE"})
 
-	var result T
-	if err := txDB.First(&result).Error; err != nil {
-		txDB.Rollback()
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil, fmt.Errorf("record not found")
+		var result T
+		if err := txDB.First(&result).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &NotFoundError{Resource: sm.ResourceName, Err: err}
+			}
+			return fmt.Errorf("failed to query record with lock: %w", err)
 		}
-		return nil, nil, fmt.Errorf("failed to query record with lock: %w", err)
+		op.Data = &result
+		return nil
+	})
+	if err != nil {
+		txDB.Rollback()
+		return nil, nil, err
 	}
 
 	// 返回结果和事务 DB，由调用者负责提交或回滚
-	return &result, txDB, nil
+	return op.Data, txDB, nil
 }
 
 // applySingleQueryOptions 应用单个查询选项
@@ -195,50 +248,72 @@ func (sm *ServiceManager[T]) applySingleQueryOptions(db *gorm.DB, opts *SingleQu
 func (sm *ServiceManager[T]) GetFirst(
 	ctx context.Context,
 	queryFunc func(*gorm.DB) *gorm.DB,
-) (*T, error) {
-	db := GetDB().WithContext(ctx)
+) (_ *T, err error) {
+	ctx, span := sm.startSpan(ctx, "GetFirst")
+	defer func() { tracing.End(span, err) }()
 
-	// 应用表名
-	db = sm.applyTableName(db)
+	op := &Operation[T]{Kind: OpGet, Method: "GetFirst", Query: queryFunc}
+	err = sm.runOp(ctx, op, func() error {
+		db := GetDB().WithContext(ctx)
 
-	// 应用查询条件
-	if queryFunc != nil {
-		db = queryFunc(db)
-	}
+		// 应用表名
+		db = sm.applyTableName(db)
 
-	var result T
-	if err := db.Order("created_at ASC").First(&result).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("record not found")
+		// 应用查询条件
+		if op.Query != nil {
+			db = op.Query(db)
 		}
-		return nil, fmt.Errorf("failed to query first record: %w", err)
+
+		var result T
+		if err := db.Order("created_at ASC").First(&result).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &NotFoundError{Resource: sm.ResourceName, Err: err}
+			}
+			return fmt.Errorf("failed to query first record: %w", err)
+		}
+		op.Data = &result
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return &result, nil
+	return op.Data, nil
 }
 
 // GetLast 查询最后一条记录（按创建时间）
 func (sm *ServiceManager[T]) GetLast(
 	ctx context.Context,
 	queryFunc func(*gorm.DB) *gorm.DB,
-) (*T, error) {
-	db := GetDB().WithContext(ctx)
+) (_ *T, err error) {
+	ctx, span := sm.startSpan(ctx, "GetLast")
+	defer func() { tracing.End(span, err) }()
 
-	// 应用表名
-	db = sm.applyTableName(db)
+	op := &Operation[T]{Kind: OpGet, Method: "GetLast", Query: queryFunc}
+	err = sm.runOp(ctx, op, func() error {
+		db := GetDB().WithContext(ctx)
 
-	// 应用查询条件
-	if queryFunc != nil {
-		db = queryFunc(db)
-	}
+		// 应用表名
+		db = sm.applyTableName(db)
 
-	var result T
-	if err := db.Order("created_at DESC").First(&result).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("record not found")
+		// 应用查询条件
+		if op.Query != nil {
+			db = op.Query(db)
 		}
-		return nil, fmt.Errorf("failed to query last record: %w", err)
+
+		var result T
+		if err := db.Order("created_at DESC").First(&result).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &NotFoundError{Resource: sm.ResourceName, Err: err}
+			}
+			return fmt.Errorf("failed to query last record: %w", err)
+		}
+		op.Data = &result
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return &result, nil
+	return op.Data, nil
 }
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ========== 操作钩子 ==========
+// 钩子包裹 ServiceManager 的每一次 CRUD / 缓存操作，用于审计、鉴权、字段默认值、指标等横切逻辑。
+// Before 钩子返回错误即否决操作；也可以直接修改 Operation 中的输入（Query/Data/Updates/Keys 等）。
+// After 钩子在操作结束后执行（无论成功与否），可读取 Err 与结果。
+// 写操作的钩子在事务内执行：After 钩子返回错误会回滚事务；Operation.Tx 即当前事务。
+
+// OperationKind 操作类别
+type OperationKind string
+
+const (
+	OpGet        OperationKind = "get"        // 数据库读取
+	OpSet        OperationKind = "set"        // 新增 / Upsert / Save
+	OpUpdate     OperationKind = "update"     // 更新（含软删除、增减量）
+	OpDelete     OperationKind = "delete"     // 删除
+	OpLookup     OperationKind = "lookup"     // 缓存读取
+	OpWritedown  OperationKind = "writedown"  // 缓存写入
+	OpInvalidate OperationKind = "invalidate" // 缓存失效
+	OpAny        OperationKind = "*"          // 注册时使用，匹配所有类别
+)
+
+// ErrRejected 操作被钩子否决
+var ErrRejected = errors.New("operation rejected")
+
+// Reject 供 Before 钩子否决操作，errors.Is(err, ErrRejected) 为 true
+func Reject(reason string) error {
+	return fmt.Errorf("%w: %s", ErrRejected, reason)
+}
+
+// Operation 操作描述，按 Kind 使用对应字段
+type Operation[T any] struct {
+	Kind     OperationKind
+	Method   string   // 执行的 ServiceManager 方法名，如 "SetSingle"
+	Resource string   // 资源名
+	Tx       *gorm.DB // 所在事务，无事务时为 nil；钩子内的数据库读写应使用 Tx 以参与同一事务
+
+	// ---- 输入（Before 钩子可修改） ----
+	Query   func(*gorm.DB) *gorm.DB // 查询条件（Get/Update/Delete）
+	Data    *T                      // 单条数据（Set 的输入；GetSingle/LookupSingle 的结果）
+	Items   []T                     // 多条数据（批量 Set 的输入；GetQuery 的结果）
+	Updates map[string]interface{}  // 更新字段（Update）
+	Column  string                  // 增减量字段（Increment/Decrement）
+	Value   interface{}             // 增减量值
+	Keys    []string                // 缓存键（Lookup/Writedown/Invalidate）
+	Pattern string                  // 缓存键模式（InvalidateCacheByPattern）
+	TTL     time.Duration           // 缓存过期时间（Writedown）
+
+	// ---- 输出（After 钩子可读取/修改） ----
+	Values       map[string]*T  // LookupQuery 的结果（键 -> 数据）
+	Total        int64          // 查询总数 / 计数结果
+	RowsAffected int64          // 写操作影响行数
+	NextCursor   string         // 游标分页：下一页游标
+	PrevCursor   string         // 游标分页：上一页游标
+	Aggregates   []AggregateRow // Aggregate 的结果
+	Err          error          // 操作本身的错误
+}
+
+// Hook 操作钩子
+type Hook[T any] func(ctx context.Context, op *Operation[T]) error
+
+// hookRegistry 钩子注册表，按类别保存，注册顺序即执行顺序
+type hookRegistry[T any] struct {
+	mu     sync.RWMutex
+	before map[OperationKind][]Hook[T]
+	after  map[OperationKind][]Hook[T]
+}
+
+func newHookRegistry[T any]() *hookRegistry[T] {
+	return &hookRegistry[T]{
+		before: make(map[OperationKind][]Hook[T]),
+		after:  make(map[OperationKind][]Hook[T]),
+	}
+}
+
+// Before 注册前置钩子，kinds 为空时匹配所有类别
+func (sm *ServiceManager[T]) Before(h Hook[T], kinds ...OperationKind) *ServiceManager[T] {
+	sm.registry().add(sm.registry().before, h, kinds)
+	return sm
+}
+
+// After 注册后置钩子，kinds 为空时匹配所有类别
+func (sm *ServiceManager[T]) After(h Hook[T], kinds ...OperationKind) *ServiceManager[T] {
+	sm.registry().add(sm.registry().after, h, kinds)
+	return sm
+}
+
+// registry 返回钩子注册表（兼容未通过 NewServiceManager 创建的实例，并发调用时只创建一次）
+func (sm *ServiceManager[T]) registry() *hookRegistry[T] {
+	sm.hooksOnce.Do(func() {
+		if sm.hooks == nil {
+			sm.hooks = newHookRegistry[T]()
+		}
+	})
+	return sm.hooks
+}
+
+func (r *hookRegistry[T]) add(m map[OperationKind][]Hook[T], h Hook[T], kinds []OperationKind) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(kinds) == 0 {
+		kinds = []OperationKind{OpAny}
+	}
+	for _, kind := range kinds {
+		m[kind] = append(m[kind], h)
+	}
+}
+
+// matching 返回某类别需要执行的钩子（先通配，后具体类别）
+func (r *hookRegistry[T]) matching(m map[OperationKind][]Hook[T], kind OperationKind) []Hook[T] {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	hooks := make([]Hook[T], 0, len(m[OpAny])+len(m[kind]))
+	hooks = append(hooks, m[OpAny]...)
+	return append(hooks, m[kind]...)
+}
+
+// runOp 执行 Before 钩子 -> fn -> After 钩子
+// 操作失败时返回操作错误（After 钩子的错误被忽略）；操作成功时 After 钩子的错误会被返回
+func (sm *ServiceManager[T]) runOp(ctx context.Context, op *Operation[T], fn func() error) error {
+	op.Resource = sm.ResourceName
+
+	hooks := sm.registry()
+	before := hooks.matching(hooks.before, op.Kind)
+	after := hooks.matching(hooks.after, op.Kind)
+
+	for _, h := range before {
+		if err := h(ctx, op); err != nil {
+			return err
+		}
+	}
+
+	op.Err = fn()
+
+	for _, h := range after {
+		if err := h(ctx, op); err != nil && op.Err == nil {
+			return err
+		}
+	}
+	return op.Err
+}
+
+// writeOp 在写事务内执行带钩子的操作，提交成功后推进查询缓存代数
+func (sm *ServiceManager[T]) writeOp(ctx context.Context, op *Operation[T], fn func(tx *gorm.DB) error) error {
+	err := sm.writeTx(ctx, func(tx *gorm.DB) error {
+		op.Tx = tx
+		return sm.runOp(ctx, op, func() error { return fn(tx) })
+	})
+	if err == nil {
+		sm.bumpQueryGeneration(ctx)
+	}
+	return err
+}
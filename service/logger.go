@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ========== ServiceManager 日志 ==========
+
+// SetLogger 为当前 ServiceManager 注入日志记录器（为空时使用 slog.Default()）
+func (sm *ServiceManager[T]) SetLogger(l *slog.Logger) *ServiceManager[T] {
+	sm.Logger = l
+	return sm
+}
+
+// GetLogger 返回附带 resource 字段的日志记录器
+func (sm *ServiceManager[T]) GetLogger() *slog.Logger {
+	l := sm.Logger
+	if l == nil {
+		l = slog.Default()
+	}
+	return l.With(slog.String("resource", sm.ResourceName))
+}
+
+// ========== GORM 日志适配器 ==========
+
+// GormLogger 将 GORM 日志输出到 slog
+// 实现 gorm.io/gorm/logger.Interface
+type GormLogger struct {
+	Logger                    *slog.Logger
+	LogLevel                  logger.LogLevel // 日志级别（Silent/Error/Warn/Info）
+	SlowThreshold             time.Duration   // 慢查询阈值，0 表示不记录慢查询
+	IgnoreRecordNotFoundError bool            // 是否忽略 ErrRecordNotFound
+}
+
+// NewGormLogger 创建 GORM 日志适配器
+// level 推荐生产环境使用 logger.Warn：只记录错误和慢查询，不记录每条 SQL
+func NewGormLogger(l *slog.Logger, level logger.LogLevel, slowThreshold time.Duration) *GormLogger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &GormLogger{
+		Logger:                    l,
+		LogLevel:                  level,
+		SlowThreshold:             slowThreshold,
+		IgnoreRecordNotFoundError: true,
+	}
+}
+
+// LogMode 实现 logger.Interface
+func (gl *GormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	clone := *gl
+	clone.LogLevel = level
+	return &clone
+}
+
+// Info 实现 logger.Interface
+func (gl *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if gl.LogLevel >= logger.Info {
+		gl.Logger.InfoContext(ctx, fmt.Sprintf(msg, args...), slog.String("component", "gorm"))
+	}
+}
+
+// Warn 实现 logger.Interface
+func (gl *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if gl.LogLevel >= logger.Warn {
+		gl.Logger.WarnContext(ctx, fmt.Sprintf(msg, args...), slog.String("component", "gorm"))
+	}
+}
+
+// Error 实现 logger.Interface
+func (gl *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if gl.LogLevel >= logger.Error {
+		gl.Logger.ErrorContext(ctx, fmt.Sprintf(msg, args...), slog.String("component", "gorm"))
+	}
+}
+
+// Trace 实现 logger.Interface，每条 SQL 执行后调用
+func (gl *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if gl.LogLevel <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && gl.LogLevel >= logger.Error && (!errors.Is(err, gorm.ErrRecordNotFound) || !gl.IgnoreRecordNotFoundError):
+		sql, rows := fc()
+		gl.Logger.ErrorContext(ctx, "sql error",
+			slog.String("component", "gorm"),
+			slog.String("sql", sql),
+			slog.Int64("rows", rows),
+			slog.Duration("elapsed", elapsed),
+			slog.Any("error", err),
+		)
+	case gl.SlowThreshold > 0 && elapsed > gl.SlowThreshold && gl.LogLevel >= logger.Warn:
+		sql, rows := fc()
+		gl.Logger.WarnContext(ctx, "slow sql",
+			slog.String("component", "gorm"),
+			slog.String("sql", sql),
+			slog.Int64("rows", rows),
+			slog.Duration("elapsed", elapsed),
+			slog.Duration("threshold", gl.SlowThreshold),
+		)
+	case gl.LogLevel >= logger.Info:
+		sql, rows := fc()
+		gl.Logger.InfoContext(ctx, "sql",
+			slog.String("component", "gorm"),
+			slog.String("sql", sql),
+			slog.Int64("rows", rows),
+			slog.Duration("elapsed", elapsed),
+		)
+	}
+}
+
+// parseGormLogLevel 解析日志级别字符串（silent/error/warn/info），无法识别时返回 fallback
+func parseGormLogLevel(level string, fallback logger.LogLevel) logger.LogLevel {
+	switch level {
+	case "silent":
+		return logger.Silent
+	case "error":
+		return logger.Error
+	case "warn":
+		return logger.Warn
+	case "info":
+		return logger.Info
+	default:
+		return fallback
+	}
+}
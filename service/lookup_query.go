@@ -4,16 +4,23 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"maps"
+	"reflect"
+	"strings"
 	"time"
 
+	"AbstractManager/util/tracing"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
 // LookupQueryOptions 缓存查询配置选项
 type LookupQueryOptions struct {
 	KeyPattern   string        // 键模式（用于批量查询）
 	CacheExpire  time.Duration // 缓存过期时间
-	FallbackToDB bool          // 缓存未命中时是否回源数据库
+	FallbackToDB bool          // 缓存未命中时是否回源数据库（只能回源 buildCacheKey 格式的单条缓存键）
 }
 
 // LookupQuery 从缓存中查询系列数据
@@ -22,17 +29,34 @@ func (sm *ServiceManager[T]) LookupQuery(
 	ctx context.Context,
 	keys []string,
 	opts *LookupQueryOptions,
-) (map[string]*T, error) {
-	redis := GetRedis()
+) (_ map[string]*T, err error) {
+	ctx, span := sm.startSpan(ctx, "LookupQuery", tracing.AttrKeyCount.Int(len(keys)))
+	defer func() { tracing.End(span, err) }()
 
 	if len(keys) == 0 {
 		return make(map[string]*T), nil
 	}
 
+	op := &Operation[T]{Kind: OpLookup, Method: "LookupQuery", Keys: keys}
+	err = sm.runOp(ctx, op, func() (err error) {
+		op.Values, err = sm.lookupKeys(ctx, op.Keys, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return op.Values, nil
+}
+
+// lookupKeys 批量读取缓存，未命中时按配置回源数据库
+func (sm *ServiceManager[T]) lookupKeys(ctx context.Context, keys []string, opts *LookupQueryOptions) (map[string]*T, error) {
+	redis := GetRedis()
+
 	// 批量获取缓存
 	dataMap, err := redis.MGet(ctx, keys...).Result()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get multiple cache: %w", err)
+		return nil, fmt.Errorf("failed to get multiple cache: %w", ClassifyCacheError(err))
 	}
 
 	result := make(map[string]*T)
@@ -79,13 +103,103 @@ func (sm *ServiceManager[T]) LookupQuery(
 	return result, nil
 }
 
+// LookupByIDsResult 按 ID 批量查询的结果
+type LookupByIDsResult[T any] struct {
+	Items   []T           // 按输入顺序排列的数据（重复的 ID 只保留第一次出现）
+	Missing []interface{} // 缓存与数据库中都不存在的 ID，按输入顺序
+}
+
+// LookupByIDs 按 ID 批量查询：先 MGET 单条缓存（键同 LookupSingleByID），
+// 未命中的 ID 用一次查询（单主键为 IN）回源并通过一个 pipeline 回写缓存；opts 只使用 CacheExpire（默认 1 小时）
+// ID 的形式同 GetSingleByID（复合主键传切片、map 或结构体）
+func (sm *ServiceManager[T]) LookupByIDs(
+	ctx context.Context,
+	ids []interface{},
+	opts *LookupQueryOptions,
+) (_ *LookupByIDsResult[T], err error) {
+	ctx, span := sm.startSpan(ctx, "LookupByIDs", tracing.AttrKeyCount.Int(len(ids)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := sm.byIDKeys(); err != nil {
+		return nil, err
+	}
+	pks, err := sm.primaryFields()
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := sm.cacheKeyPrefix(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按缓存键去重，保留输入顺序
+	keys := make([]string, 0, len(ids))
+	idByKey := make(map[string]interface{}, len(ids))
+	for _, id := range ids {
+		values, err := primaryKeyValues(pks, id)
+		if err != nil {
+			return nil, err
+		}
+		key := joinCacheKey(prefix, primaryKeySuffix(values))
+		if _, dup := idByKey[key]; dup {
+			continue
+		}
+		idByKey[key] = id
+		keys = append(keys, key)
+	}
+
+	result := &LookupByIDsResult[T]{Items: make([]T, 0, len(keys))}
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	op := &Operation[T]{Kind: OpLookup, Method: "LookupByIDs", Keys: keys}
+	err = sm.runOp(ctx, op, func() error {
+		values, err := sm.lookupKeys(ctx, op.Keys, nil)
+		if err != nil {
+			return err
+		}
+
+		var missing []interface{}
+		for _, key := range op.Keys {
+			if id, ok := idByKey[key]; ok && values[key] == nil {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			loaded, err := sm.loadByIDs(ctx, missing, lookupExpiration(opts))
+			if err != nil {
+				return fmt.Errorf("failed to fallback to database: %w", err)
+			}
+			maps.Copy(values, loaded)
+		}
+		op.Values = values
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		if item := op.Values[key]; item != nil {
+			result.Items = append(result.Items, *item)
+		} else {
+			result.Missing = append(result.Missing, idByKey[key])
+		}
+	}
+	return result, nil
+}
+
 // LookupQueryByPattern 根据键模式从缓存中查询数据
 // LookupQueryByPattern 改进版：使用 SCAN 代替 KEYS
 func (sm *ServiceManager[T]) LookupQueryByPattern(
 	ctx context.Context,
 	pattern string,
 	opts *LookupQueryOptions,
-) (map[string]*T, error) {
+) (_ map[string]*T, err error) {
+	ctx, span := sm.startSpan(ctx, "LookupQueryByPattern", tracing.AttrPattern.String(pattern))
+	defer func() { tracing.End(span, err) }()
+
 	redis := GetRedis()
 	var allKeys []string
 	var cursor uint64
@@ -94,7 +208,7 @@ func (sm *ServiceManager[T]) LookupQueryByPattern(
 	for {
 		keys, nextCursor, err := redis.Scan(ctx, cursor, pattern, 100).Result()
 		if err != nil {
-			return nil, fmt.Errorf("scan keys failed: %w", err)
+			return nil, fmt.Errorf("scan keys failed: %w", ClassifyCacheError(err))
 		}
 		allKeys = append(allKeys, keys...)
 		cursor = nextCursor
@@ -117,7 +231,14 @@ func (sm *ServiceManager[T]) LookupQueryWithRefresh(
 	queryFunc func(*gorm.DB, []string) *gorm.DB, // 自定义数据库查询函数
 	buildKeyFunc func(*T) string, // 根据数据生成缓存键的函数
 	expiration time.Duration,
-) (map[string]*T, error) {
+) (_ map[string]*T, err error) {
+	ctx, span := sm.startSpan(ctx, "LookupQueryWithRefresh", tracing.AttrKeyCount.Int(len(keys)))
+	defer func() { tracing.End(span, err) }()
+
+	if buildKeyFunc, err = sm.resolveKeyFunc(ctx, buildKeyFunc); err != nil {
+		return nil, err
+	}
+
 	// 先从缓存查询
 	result, err := sm.LookupQuery(ctx, keys, &LookupQueryOptions{
 		FallbackToDB: false,
@@ -149,15 +270,15 @@ func (sm *ServiceManager[T]) LookupQueryWithRefresh(
 		}
 
 		// 将数据库结果写入缓存并添加到返回结果
-		redis := GetRedis()
 		for i := range dbResults {
 			item := &dbResults[i]
 			key := buildKeyFunc(item)
 
 			// 写入缓存
-			if err := redis.Set(ctx, key, item, expiration); err != nil {
+			if err := sm.cacheItem(ctx, key, item, expiration); err != nil {
 				// 记录错误但不中断流程
-				fmt.Printf("warning: failed to cache item with key %s: %v\n", key, err)
+				sm.GetLogger().WarnContext(ctx, "failed to cache item",
+					slog.String("operation", "LookupQueryWithRefresh"), slog.String("key", key), slog.Any("error", err))
 			}
 
 			result[key] = item
@@ -167,54 +288,105 @@ func (sm *ServiceManager[T]) LookupQueryWithRefresh(
 	return result, nil
 }
 
-// lookupFromDB 从数据库查询缓存未命中的数据
+// lookupFromDB 从数据库加载缓存未命中的键：键按 buildCacheKey 的格式解析出主键（复合主键按 ":" 拆分后还原转义），
+// 不是该资源单条缓存键的键无法回源，直接跳过
 func (sm *ServiceManager[T]) lookupFromDB(
 	ctx context.Context,
 	keys []string,
 	opts *LookupQueryOptions,
 ) (map[string]*T, error) {
-	// 这是一个简化版本，实际使用时需要根据业务逻辑实现
-	// 通常需要将缓存键转换为数据库查询条件
-
-	db := GetDB().WithContext(ctx)
-	db = sm.applyTableName(db)
-
-	// 这里假设缓存键格式为 "资源名:ID"
-	// 实际使用时需要根据具体的键格式来解析
-	var ids []interface{}
+	if err := sm.byIDKeys(); err != nil {
+		return nil, err
+	}
+	pks, err := sm.primaryFields()
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := sm.buildCacheKey(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]interface{}, 0, len(keys))
 	for _, key := range keys {
-		// 解析键获取 ID（需要根据实际键格式实现）
-		// 这里只是示例
-		ids = append(ids, key)
+		suffix, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		parts, ok := splitPrimaryKeySuffix(suffix, len(pks))
+		if !ok {
+			continue
+		}
+		if _, err := primaryKeyValues(pks, parts); err == nil {
+			ids = append(ids, parts)
+		}
 	}
-
-	var results []T
-	if err := db.Where("id IN ?", ids).Find(&results).Error; err != nil {
-		return nil, fmt.Errorf("failed to query from database: %w", err)
+	if len(ids) == 0 {
+		return make(map[string]*T), nil
 	}
+	return sm.loadByIDs(ctx, ids, lookupExpiration(opts))
+}
 
-	// 构建结果映射并写入缓存
-	resultMap := make(map[string]*T)
-	redis := GetRedis()
+// loadByIDs 用一次查询按主键加载数据（单主键为 IN），并通过一个 pipeline 回写单条缓存（回写失败只记录警告）
+// 返回单条缓存键 -> 数据
+func (sm *ServiceManager[T]) loadByIDs(ctx context.Context, ids []interface{}, expiration time.Duration) (map[string]*T, error) {
+	pks, err := sm.primaryFields()
+	if err != nil {
+		return nil, err
+	}
+	byIDs, err := sm.PrimaryKeysQuery(ids)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := sm.cacheKeyPrefix(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	for i := range results {
-		item := &results[i]
-		key := fmt.Sprintf("%s:%v", sm.CacheKeyName, item) // 需要根据实际情况实现
+	var rows []T
+	db := sm.applyTableName(GetDB().WithContext(ctx))
+	if err := byIDs(db).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to query from database: %w", err)
+	}
 
-		// 写入缓存
-		expiration := 1 * time.Hour
-		if opts != nil && opts.CacheExpire > 0 {
-			expiration = opts.CacheExpire
-		}
+	result := make(map[string]*T, len(rows))
+	if len(rows) == 0 {
+		return result, nil
+	}
 
-		if err := redis.Set(ctx, key, item, expiration); err != nil {
-			fmt.Printf("warning: failed to cache item: %v\n", err)
+	pipe := GetRedis().Pipeline()
+	for i := range rows {
+		item := &rows[i]
+		key := itemCacheKey(ctx, prefix, pks, item)
+		data, err := marshalForRedis(item)
+		if err != nil {
+			return nil, err
 		}
+		pipe.Set(ctx, key, data, expiration)
+		result[key] = item
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		sm.GetLogger().WarnContext(ctx, "failed to backfill cache",
+			slog.String("operation", "loadByIDs"), slog.Int("count", len(rows)), slog.Any("error", ClassifyCacheError(err)))
+	}
+	return result, nil
+}
 
-		resultMap[key] = item
+// itemCacheKey 一行数据的单条缓存键，prefix 来自 cacheKeyPrefix
+func itemCacheKey[T any](ctx context.Context, prefix string, pks []*schema.Field, item *T) string {
+	row := reflect.ValueOf(item).Elem()
+	values := make([]interface{}, len(pks))
+	for i, f := range pks {
+		values[i], _ = f.ValueOf(ctx, row)
 	}
+	return joinCacheKey(prefix, primaryKeySuffix(values))
+}
 
-	return resultMap, nil
+// lookupExpiration 回源后写入缓存的过期时间，默认 1 小时
+func lookupExpiration(opts *LookupQueryOptions) time.Duration {
+	if opts != nil && opts.CacheExpire > 0 {
+		return opts.CacheExpire
+	}
+	return time.Hour
 }
 
 // RefreshCache 刷新缓存（从数据库重新加载）
@@ -224,74 +396,106 @@ func (sm *ServiceManager[T]) RefreshCache(
 	queryFunc func(*gorm.DB, []string) *gorm.DB,
 	buildKeyFunc func(*T) string,
 	expiration time.Duration,
-) error {
-	db := GetDB().WithContext(ctx)
-	db = sm.applyTableName(db)
+) (err error) {
+	ctx, span := sm.startSpan(ctx, "RefreshCache", tracing.AttrKeyCount.Int(len(keys)))
+	defer func() { tracing.End(span, err) }()
 
-	if queryFunc != nil {
-		db = queryFunc(db, keys)
+	if buildKeyFunc, err = sm.resolveKeyFunc(ctx, buildKeyFunc); err != nil {
+		return err
 	}
 
-	var results []T
-	if err := db.Find(&results).Error; err != nil {
-		return fmt.Errorf("failed to query from database: %w", err)
-	}
+	op := &Operation[T]{Kind: OpWritedown, Method: "RefreshCache", Keys: keys, TTL: expiration}
+	return sm.runOp(ctx, op, func() error {
+		db := GetDB().WithContext(ctx)
+		db = sm.applyTableName(db)
 
-	// 批量写入缓存
-	redis := GetRedis()
-	cacheItems := make(map[string]interface{})
+		if queryFunc != nil {
+			db = queryFunc(db, op.Keys)
+		}
 
-	for i := range results {
-		item := &results[i]
-		key := buildKeyFunc(item)
-		cacheItems[key] = item
-	}
+		var results []T
+		if err := db.Find(&results).Error; err != nil {
+			return fmt.Errorf("failed to query from database: %w", err)
+		}
+		op.Items = results
 
-	pipe := redis.Pipeline()
-	for key, item := range cacheItems {
-		data, _ := json.Marshal(item)
-		pipe.Set(ctx, key, data, expiration)
-	}
-	_, err := pipe.Exec(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to refresh cache: %w", err)
-	}
+		// 批量写入缓存
+		redis := GetRedis()
+		cacheItems := make(map[string]interface{})
 
-	return nil
+		for i := range results {
+			item := &results[i]
+			key := buildKeyFunc(item)
+			cacheItems[key] = item
+		}
+
+		pipe := redis.Pipeline()
+		for key, item := range cacheItems {
+			data, _ := json.Marshal(item)
+			pipe.Set(ctx, key, data, op.TTL)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to refresh cache: %w", ClassifyCacheError(err))
+		}
+
+		return nil
+	})
 }
 
 // InvalidateCache 使缓存失效
-func (sm *ServiceManager[T]) InvalidateCache(ctx context.Context, keys ...string) error {
+func (sm *ServiceManager[T]) InvalidateCache(ctx context.Context, keys ...string) (err error) {
+	ctx, span := sm.startSpan(ctx, "InvalidateCache", tracing.AttrKeyCount.Int(len(keys)))
+	defer func() { tracing.End(span, err) }()
+
 	if len(keys) == 0 {
 		return nil
 	}
 
-	redis := GetRedis()
-	if err := redis.Del(ctx, keys...).Err(); err != nil {
-		return fmt.Errorf("failed to invalidate cache: %w", err)
-	}
+	op := &Operation[T]{Kind: OpInvalidate, Method: "InvalidateCache", Keys: keys}
+	return sm.runOp(ctx, op, func() error {
+		redis := GetRedis()
+		if err := redis.Del(ctx, op.Keys...).Err(); err != nil {
+			return fmt.Errorf("failed to invalidate cache: %w", ClassifyCacheError(err))
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // InvalidateCacheByPattern 根据模式使缓存失效
-func (sm *ServiceManager[T]) InvalidateCacheByPattern(ctx context.Context, pattern string) error {
-	redis := GetRedis()
+func (sm *ServiceManager[T]) InvalidateCacheByPattern(ctx context.Context, pattern string) (err error) {
+	ctx, span := sm.startSpan(ctx, "InvalidateCacheByPattern", tracing.AttrPattern.String(pattern))
+	defer func() { tracing.End(span, err) }()
 
-	// 获取匹配的键
-	keys, err := redis.Keys(ctx, pattern).Result()
-	if err != nil {
-		return fmt.Errorf("failed to scan keys with pattern %s: %w", pattern, err)
-	}
+	op := &Operation[T]{Kind: OpInvalidate, Method: "InvalidateCacheByPattern", Pattern: pattern}
+	return sm.runOp(ctx, op, func() error {
+		redis := GetRedis()
+
+		// 获取匹配的键
+		keys, err := redis.Keys(ctx, op.Pattern).Result()
+		if err != nil {
+			return fmt.Errorf("failed to scan keys with pattern %s: %w", op.Pattern, ClassifyCacheError(err))
+		}
+
+		if len(keys) == 0 {
+			return nil
+		}
+		op.Keys = keys
+
+		// 批量删除
+		if err := redis.Del(ctx, keys...).Err(); err != nil {
+			return fmt.Errorf("failed to invalidate cache by pattern: %w", ClassifyCacheError(err))
+		}
 
-	if len(keys) == 0 {
 		return nil
-	}
+	})
+}
 
-	// 批量删除
-	if err := redis.Del(ctx, keys...).Err(); err != nil {
-		return fmt.Errorf("failed to invalidate cache by pattern: %w", err)
+// cacheItem 将单条数据序列化后写入缓存
+func (sm *ServiceManager[T]) cacheItem(ctx context.Context, key string, item *T, expiration time.Duration) error {
+	data, err := marshalForRedis(item)
+	if err != nil {
+		return err
 	}
-
-	return nil
+	return GetRedis().Set(ctx, key, data, expiration).Err()
 }
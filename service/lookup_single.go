@@ -3,8 +3,11 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"AbstractManager/util/tracing"
+
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
@@ -21,31 +24,44 @@ func (sm *ServiceManager[T]) LookupSingle(
 	ctx context.Context,
 	key string,
 	opts *LookupSingleOptions,
-) (*T, error) {
-	rdb := GetRedis() // 🛠️ 保持使用 rdb 避免遮蔽包名
-
-	// 1. 检查是否需要从缓存读取
-	if opts == nil || !opts.Refresh {
-		var result T
-		// 🛠️ 优化：直接使用 Scan 自动处理 JSON 解码
-		err := rdb.Get(ctx, key).Scan(&result)
-		if err == nil {
-			return &result, nil
+) (_ *T, err error) {
+	ctx, span := sm.startSpan(ctx, "LookupSingle", tracing.AttrCacheKey.String(key))
+	defer func() { tracing.End(span, err) }()
+
+	op := &Operation[T]{Kind: OpLookup, Method: "LookupSingle", Keys: []string{key}}
+	err = sm.runOp(ctx, op, func() error {
+		rdb := GetRedis() // 🛠️ 保持使用 rdb 避免遮蔽包名
+		key := op.Keys[0]
+
+		// 1. 检查是否需要从缓存读取
+		if opts == nil || !opts.Refresh {
+			var result T
+			// 🛠️ 优化：直接使用 Scan 自动处理 JSON 解码
+			err := rdb.Get(ctx, key).Scan(&result)
+			if err == nil {
+				op.Data = &result
+				return nil
+			}
+
+			// 如果是真正的错误（非 key 不存在），则返回
+			if err != redis.Nil {
+				return fmt.Errorf("redis lookup failed: %w", ClassifyCacheError(err))
+			}
 		}
 
-		// 如果是真正的错误（非 key 不存在），则返回
-		if err != redis.Nil {
-			return nil, fmt.Errorf("redis lookup failed: %w", err)
+		// 2. 缓存未命中且允许回源
+		if opts != nil && opts.FallbackToDB {
+			// 注意：这里的 queryFunc 在通用 lookup 中较难确定，建议配合 ID 使用
+			return fmt.Errorf("fallback requested but no query logic provided for key: %s", key)
 		}
-	}
 
-	// 2. 缓存未命中且允许回源
-	if opts != nil && opts.FallbackToDB {
-		// 注意：这里的 queryFunc 在通用 lookup 中较难确定，建议配合 ID 使用
-		return nil, fmt.Errorf("fallback requested but no query logic provided for key: %s", key)
+		// 显式返回未命中，errors.Is(err, ErrNotFound) 与 errors.Is(err, redis.Nil) 均成立
+		return &NotFoundError{Resource: sm.ResourceName, Key: key, Err: redis.Nil}
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	return nil, redis.Nil // 显式返回未命中
+	return op.Data, nil
 }
 
 // LookupSingleWithFallback 核心方法：带自动回填的查询
@@ -54,17 +70,29 @@ func (sm *ServiceManager[T]) LookupSingleWithFallback(
 	key string,
 	queryFunc func(*gorm.DB) *gorm.DB,
 	expiration time.Duration,
-) (*T, error) {
-	rdb := GetRedis()
+) (_ *T, err error) {
+	ctx, span := sm.startSpan(ctx, "LookupSingleWithFallback", tracing.AttrCacheKey.String(key))
+	defer func() { tracing.End(span, err) }()
 
-	// 1. 尝试缓存
-	var result T
-	err := rdb.Get(ctx, key).Scan(&result)
-	if err == nil {
-		return &result, nil
+	// 1. 尝试缓存（未命中时 op.Data 为 nil）
+	op := &Operation[T]{Kind: OpLookup, Method: "LookupSingleWithFallback", Keys: []string{key}}
+	err = sm.runOp(ctx, op, func() error {
+		var result T
+		err := GetRedis().Get(ctx, op.Keys[0]).Scan(&result)
+		if err == nil {
+			op.Data = &result
+			return nil
+		}
+		if err != redis.Nil {
+			return fmt.Errorf("cache error: %w", ClassifyCacheError(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	if err != redis.Nil {
-		return nil, fmt.Errorf("cache error: %w", err)
+	if op.Data != nil {
+		return op.Data, nil
 	}
 
 	// 2. 缓存未命中，回源数据库
@@ -80,60 +108,98 @@ func (sm *ServiceManager[T]) LookupSingleWithFallback(
 }
 
 // InvalidateSingleCache 使单个缓存失效
-func (sm *ServiceManager[T]) InvalidateSingleCache(ctx context.Context, key string) error {
-	rdb := GetRedis()
-	// 🛠️ 修复：.Err() 获取错误，修复 %w 类型报错
-	if err := rdb.Del(ctx, key).Err(); err != nil {
-		return fmt.Errorf("failed to invalidate cache: %w", err)
-	}
-	return nil
+func (sm *ServiceManager[T]) InvalidateSingleCache(ctx context.Context, key string) (err error) {
+	ctx, span := sm.startSpan(ctx, "InvalidateSingleCache", tracing.AttrCacheKey.String(key))
+	defer func() { tracing.End(span, err) }()
+
+	op := &Operation[T]{Kind: OpInvalidate, Method: "InvalidateSingleCache", Keys: []string{key}}
+	return sm.runOp(ctx, op, func() error {
+		rdb := GetRedis()
+		// 🛠️ 修复：.Err() 获取错误，修复 %w 类型报错
+		if err := rdb.Del(ctx, op.Keys...).Err(); err != nil {
+			return fmt.Errorf("failed to invalidate cache: %w", ClassifyCacheError(err))
+		}
+		return nil
+	})
 }
 
 // ExistsInCache 检查缓存中是否存在
-func (sm *ServiceManager[T]) ExistsInCache(ctx context.Context, key string) (bool, error) {
+func (sm *ServiceManager[T]) ExistsInCache(ctx context.Context, key string) (_ bool, err error) {
+	ctx, span := sm.startSpan(ctx, "ExistsInCache", tracing.AttrCacheKey.String(key))
+	defer func() { tracing.End(span, err) }()
+
 	rdb := GetRedis()
 	n, err := rdb.Exists(ctx, key).Result()
 	if err != nil {
-		return false, fmt.Errorf("exists check failed: %w", err)
+		return false, fmt.Errorf("exists check failed: %w", ClassifyCacheError(err))
 	}
 	return n > 0, nil
 }
 
 // ExtendCacheTTL 延长缓存的过期时间
-func (sm *ServiceManager[T]) ExtendCacheTTL(ctx context.Context, key string, expiration time.Duration) error {
+func (sm *ServiceManager[T]) ExtendCacheTTL(ctx context.Context, key string, expiration time.Duration) (err error) {
+	ctx, span := sm.startSpan(ctx, "ExtendCacheTTL", tracing.AttrCacheKey.String(key))
+	defer func() { tracing.End(span, err) }()
+
 	rdb := GetRedis()
 	// 🛠️ 修复：使用 .Err() 确保传给 %w的是 error 类型
 	if err := rdb.Expire(ctx, key, expiration).Err(); err != nil {
-		return fmt.Errorf("failed to extend TTL: %w", err)
+		return fmt.Errorf("failed to extend TTL: %w", ClassifyCacheError(err))
 	}
 	return nil
 }
 
 // --- 便捷封装 ---
 
-func (sm *ServiceManager[T]) LookupSingleByID(ctx context.Context, id interface{}, expiration time.Duration) (*T, error) {
-	key := sm.buildCacheKey(id)
-	return sm.LookupSingleWithFallback(ctx, key, func(db *gorm.DB) *gorm.DB {
-		return db.Where("id = ?", id)
-	}, expiration)
+func (sm *ServiceManager[T]) LookupSingleByID(ctx context.Context, id interface{}, expiration time.Duration) (_ *T, err error) {
+	ctx, span := sm.startSpan(ctx, "LookupSingleByID", idAttr(id))
+	defer func() { tracing.End(span, err) }()
+
+	key, err := sm.CacheKeyByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	byID, err := sm.PrimaryKeyQuery(id)
+	if err != nil {
+		return nil, err
+	}
+	return sm.LookupSingleWithFallback(ctx, key, byID, expiration)
 }
 
 // InvalidateSingleCacheByID 根据 ID 使单个缓存失效
-func (sm *ServiceManager[T]) InvalidateSingleCacheByID(ctx context.Context, id interface{}) error {
-	key := sm.buildCacheKey(id)
+func (sm *ServiceManager[T]) InvalidateSingleCacheByID(ctx context.Context, id interface{}) (err error) {
+	ctx, span := sm.startSpan(ctx, "InvalidateSingleCacheByID", idAttr(id))
+	defer func() { tracing.End(span, err) }()
+
+	key, err := sm.CacheKeyByID(ctx, id)
+	if err != nil {
+		return err
+	}
 	return sm.InvalidateSingleCache(ctx, key)
 }
 
 // GetCacheTTL 获取缓存的剩余过期时间
-func (sm *ServiceManager[T]) GetCacheTTL(ctx context.Context, key string) (time.Duration, error) {
+func (sm *ServiceManager[T]) GetCacheTTL(ctx context.Context, key string) (_ time.Duration, err error) {
+	ctx, span := sm.startSpan(ctx, "GetCacheTTL", tracing.AttrCacheKey.String(key))
+	defer func() { tracing.End(span, err) }()
+
 	redisManager := GetRedis()
 	return redisManager.TTL(ctx, key).Result()
 }
 
-// buildCacheKey 构建缓存键
-func (sm *ServiceManager[T]) buildCacheKey(id interface{}) string {
-	if sm.CacheKeyType == "none" {
-		return fmt.Sprintf("%s:%v", sm.CacheKeyName, id)
+// CacheKeyTemplate 单条缓存键的模板（如 "cache:user:{id}"），可交给 cache_key_builder.NewKeyParser（多个占位符时值经过转义，解析器需 EscapeValues）
+// 占位符为 cache 标签声明的键字段，未声明时为主键列名（复合主键以 ":" 分隔）；启用键命名空间时模板带当前代数段（如 "cache:user:g3:{id}"），代数推进后需要重新获取
+func (sm *ServiceManager[T]) CacheKeyTemplate(ctx context.Context) (string, error) {
+	builder, err := sm.keyBuilder()
+	if err != nil {
+		return "", err
+	}
+	if builder != nil {
+		return sm.buildCacheKey(ctx, builder.SuffixTemplate())
+	}
+	names := []string{"id"}
+	if pks, err := sm.PrimaryKeys(); err == nil {
+		names = pks
 	}
-	return fmt.Sprintf("%s:%s:%v", sm.CacheKeyType, sm.CacheKeyName, id)
+	return sm.buildCacheKey(ctx, "{"+strings.Join(names, "}:{")+"}")
 }
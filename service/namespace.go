@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"AbstractManager/util/cache_key_builder"
+	"AbstractManager/util/tracing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ========== 版本化键命名空间 ==========
+// 启用后（EnableKeyNamespace），单条缓存键带上资源的代数段："cache:user:42" -> "cache:user:g3:42"。
+// InvalidateNamespace 推进代数即可 O(1) 地使资源（或某个子命名空间）的全部缓存失效，代替按模式扫描删除；
+// 旧代数的键随 TTL 过期，也可以用 StartNamespaceReaper 在后台清理。
+//
+// 受影响的是 ServiceManager 自己生成的键（LookupSingleByID / LookupByIDs / WritedownSingleByID / 查询结果缓存等）；
+// 自定义键（WritedownQuery 的 buildKeyFunc 等）需通过 NamespacedKey 生成才能随命名空间失效。
+
+// defaultNamespaceLocalTTL 代数在进程内的默认缓存时间
+const defaultNamespaceLocalTTL = time.Second
+
+// redisGenerations 基于全局 Redis 的代数存储
+type redisGenerations struct{}
+
+func (redisGenerations) Get(ctx context.Context, counterKey string) (int64, error) {
+	gen, err := GetRedis().Get(ctx, counterKey).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return gen, ClassifyCacheError(err)
+}
+
+func (redisGenerations) Incr(ctx context.Context, counterKey string) (int64, error) {
+	gen, err := GetRedis().Incr(ctx, counterKey).Result()
+	return gen, ClassifyCacheError(err)
+}
+
+// EnableKeyNamespace 启用版本化键命名空间（需要 Redis），localTTL 为代数在进程内的缓存时间（默认 1 秒）
+// 其他进程推进代数后，本进程最多延迟 localTTL 才使用新代数
+func (sm *ServiceManager[T]) EnableKeyNamespace(localTTL time.Duration) *ServiceManager[T] {
+	if localTTL <= 0 {
+		localTTL = defaultNamespaceLocalTTL
+	}
+	sm.generations = cache_key_builder.NewGenerations(redisGenerations{}, localTTL)
+	return sm
+}
+
+// KeyNamespaceEnabled 是否已启用版本化键命名空间
+func (sm *ServiceManager[T]) KeyNamespaceEnabled() bool {
+	return sm.generations != nil
+}
+
+// InvalidateNamespace 推进代数，使资源（sub 为空）或子命名空间（如 "tenant:7"）下的全部键失效
+func (sm *ServiceManager[T]) InvalidateNamespace(ctx context.Context, sub ...string) (err error) {
+	ctx, span := sm.startSpan(ctx, "InvalidateNamespace")
+	defer func() { tracing.End(span, err) }()
+
+	if sm.generations == nil {
+		return fmt.Errorf("key namespace is not enabled for %s", sm.ResourceName)
+	}
+	path := sm.namespacePath(sub)
+	op := &Operation[T]{Kind: OpInvalidate, Method: "InvalidateNamespace", Keys: []string{cache_key_builder.CounterKey(path...)}}
+	return sm.runOp(ctx, op, func() error {
+		if _, err := sm.generations.Bump(ctx, path...); err != nil {
+			return err
+		}
+		if len(sub) == 0 {
+			// 缓存的查询结果保存的是主键列表，资源级失效时一并作废
+			sm.bumpQueryGeneration(ctx)
+		}
+		return nil
+	})
+}
+
+// NamespacedKey 返回资源（或子命名空间）下的自定义键，如 NamespacedKey(ctx, "list:hot", "tenant:7") ->
+// "cache:user:g3:tenant:7:g1:list:hot"；未启用命名空间时不带代数段
+func (sm *ServiceManager[T]) NamespacedKey(ctx context.Context, key string, sub ...string) (string, error) {
+	if sm.generations == nil {
+		return strings.Join(append(sm.namespacePath(sub), key), ":"), nil
+	}
+	return sm.generations.Key(ctx, key, sm.namespacePath(sub)...)
+}
+
+// StartNamespaceReaper 启动后台任务，每隔 interval 删除资源旧代数的键（只看资源级代数，子命名空间的旧键随 TTL 过期）
+// 返回的函数用于停止任务；ctx 结束时任务也会停止
+func (sm *ServiceManager[T]) StartNamespaceReaper(ctx context.Context, interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				removed, err := sm.ReapNamespace(ctx)
+				if err != nil && ctx.Err() == nil {
+					sm.GetLogger().WarnContext(ctx, "namespace reaper failed", slog.Any("error", err))
+				} else if removed > 0 {
+					sm.GetLogger().DebugContext(ctx, "namespace reaper removed stale keys", slog.Int("count", removed))
+				}
+			}
+		}
+	}()
+	return cancel
+}
+
+// ReapNamespace 扫描并删除资源旧代数的键，返回删除的数量
+func (sm *ServiceManager[T]) ReapNamespace(ctx context.Context) (_ int, err error) {
+	ctx, span := sm.startSpan(ctx, "ReapNamespace")
+	defer func() { tracing.End(span, err) }()
+
+	if sm.generations == nil {
+		return 0, fmt.Errorf("key namespace is not enabled for %s", sm.ResourceName)
+	}
+	root := sm.cacheKeyRoot()
+	current, err := sm.generations.Current(ctx, root)
+	if err != nil {
+		return 0, err
+	}
+
+	rdb := GetRedis()
+	pattern := cache_key_builder.EscapeGlob(root) + ":g*"
+	removed := 0
+	var cursor uint64
+	for {
+		keys, next, err := rdb.Scan(ctx, cursor, pattern, 500).Result()
+		if err != nil {
+			return removed, fmt.Errorf("scan keys failed: %w", ClassifyCacheError(err))
+		}
+
+		stale := make([]string, 0, len(keys))
+		for _, key := range keys {
+			segment, _, _ := strings.Cut(strings.TrimPrefix(key, root+":"), ":")
+			if gen, ok := cache_key_builder.ParseGenerationSegment(segment); ok && gen < current {
+				stale = append(stale, key)
+			}
+		}
+		if len(stale) > 0 {
+			if err := rdb.Unlink(ctx, stale...).Err(); err != nil {
+				return removed, fmt.Errorf("failed to delete stale keys: %w", ClassifyCacheError(err))
+			}
+			removed += len(stale)
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return removed, nil
+		}
+	}
+}
+
+// namespacePath 资源根前缀 + 子命名空间
+func (sm *ServiceManager[T]) namespacePath(sub []string) []string {
+	return append([]string{sm.cacheKeyRoot()}, sub...)
+}
+
+// cacheKeyPrefix 单条缓存键的前缀，启用命名空间时带资源的当前代数段
+func (sm *ServiceManager[T]) cacheKeyPrefix(ctx context.Context) (string, error) {
+	if sm.generations == nil {
+		return sm.cacheKeyRoot(), nil
+	}
+	return sm.generations.Prefix(ctx, sm.cacheKeyRoot())
+}
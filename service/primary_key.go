@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"AbstractManager/util/cache_key_builder"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ========== 主键 ==========
+// 按 ID 的方法（GetSingleByID、LookupSingleByID、UpdateByID、DeleteByID、WritedownSingleByID 等）
+// 从 T 的 GORM schema 读取主键列，不假定主键为 id。id 参数可以是：
+//   - 单主键：标量值，如 42、"3f2c9a…"、"SKU-1"
+//   - 复合主键：T / *T（取其主键字段）、其他结构体或 map（按列名、Go 字段名或 json 名匹配主键）、
+//     按主键声明顺序排列的切片（如路由路径段 []string{"acme", "SKU-1"}）
+//
+// 取值按主键字段类型转换（"42" / float64(42) -> uint(42)），缓存键的键值为各主键值以 ":" 连接
+// （如 "cache:sku:acme:SKU-1"），单主键时与原来的 "前缀:id" 相同。复合主键的各值按 cache_key_builder.EscapeKeyPart 转义
+// （"acme:eu" -> "acme%3Aeu"），值中含 ":" 的不同主键不会得到同一个键。
+
+// PrimaryKeys 返回主键列名（按声明顺序）
+func (sm *ServiceManager[T]) PrimaryKeys() ([]string, error) {
+	pks, err := sm.primaryFields()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(pks))
+	for i, f := range pks {
+		names[i] = f.DBName
+	}
+	return names, nil
+}
+
+// PrimaryKeyValues 将 id 参数规范化为按主键顺序排列的值，id 与主键不匹配时返回 ValidationError
+func (sm *ServiceManager[T]) PrimaryKeyValues(id interface{}) ([]interface{}, error) {
+	pks, err := sm.primaryFields()
+	if err != nil {
+		return nil, err
+	}
+	return primaryKeyValues(pks, id)
+}
+
+// PrimaryKeyQuery 返回按主键定位一行的查询条件
+func (sm *ServiceManager[T]) PrimaryKeyQuery(id interface{}) (func(*gorm.DB) *gorm.DB, error) {
+	pks, err := sm.primaryFields()
+	if err != nil {
+		return nil, err
+	}
+	values, err := primaryKeyValues(pks, id)
+	if err != nil {
+		return nil, err
+	}
+	where := primaryKeyCondition(pks, values)
+	return func(db *gorm.DB) *gorm.DB { return db.Where(where) }, nil
+}
+
+// PrimaryKeysQuery 返回按多个主键定位多行的查询条件：单主键为 IN，复合主键为各行条件的 OR
+func (sm *ServiceManager[T]) PrimaryKeysQuery(ids []interface{}) (func(*gorm.DB) *gorm.DB, error) {
+	pks, err := sm.primaryFields()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, NewValidationError("ids", "ids cannot be empty", nil)
+	}
+
+	rows := make([][]interface{}, len(ids))
+	for i, id := range ids {
+		if rows[i], err = primaryKeyValues(pks, id); err != nil {
+			return nil, err
+		}
+	}
+
+	var where clause.Expression
+	if len(pks) == 1 {
+		values := make([]interface{}, len(rows))
+		for i, row := range rows {
+			values[i] = row[0]
+		}
+		where = clause.IN{Column: clause.Column{Name: pks[0].DBName}, Values: values}
+	} else {
+		conds := make([]clause.Expression, len(rows))
+		for i, row := range rows {
+			conds[i] = primaryKeyCondition(pks, row)
+		}
+		where = clause.Or(conds...)
+	}
+	return func(db *gorm.DB) *gorm.DB { return db.Where(where) }, nil
+}
+
+// primaryFields 返回主键字段，模型没有主键时返回错误
+func (sm *ServiceManager[T]) primaryFields() ([]*schema.Field, error) {
+	s, err := sm.ModelSchema()
+	if err != nil {
+		return nil, err
+	}
+	if len(s.PrimaryFields) == 0 {
+		return nil, fmt.Errorf("%s has no primary key", sm.ResourceName)
+	}
+	return s.PrimaryFields, nil
+}
+
+// CacheKeyByID 按主键生成单条缓存键（与 LookupSingleByID / WritedownSingleByID 使用的键相同）
+func (sm *ServiceManager[T]) CacheKeyByID(ctx context.Context, id interface{}) (string, error) {
+	if err := sm.byIDKeys(); err != nil {
+		return "", err
+	}
+	values, err := sm.PrimaryKeyValues(id)
+	if err != nil {
+		return "", err
+	}
+	return sm.buildCacheKey(ctx, primaryKeySuffix(values))
+}
+
+// primaryKeySuffix 主键值在缓存键中的部分，复合主键的各值转义后以 ":" 连接
+func primaryKeySuffix(values []interface{}) string {
+	if len(values) == 1 {
+		return fmt.Sprintf("%v", values[0])
+	}
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = cache_key_builder.EscapeKeyPart(fmt.Sprintf("%v", v))
+	}
+	return strings.Join(parts, ":")
+}
+
+// splitPrimaryKeySuffix 与 primaryKeySuffix 互逆：按主键数量拆分并还原各值，段数不符时返回 false
+func splitPrimaryKeySuffix(suffix string, n int) ([]string, bool) {
+	if n == 1 {
+		return []string{suffix}, suffix != ""
+	}
+	parts := strings.Split(suffix, ":")
+	if len(parts) != n {
+		return nil, false
+	}
+	for i, part := range parts {
+		parts[i] = cache_key_builder.UnescapeKeyPart(part)
+	}
+	return parts, true
+}
+
+// rowPrimaryKey 读取一行的主键：单主键为值本身，复合主键为按主键顺序排列的切片（可再作为 id 参数）
+func rowPrimaryKey[T any](ctx context.Context, pks []*schema.Field, item *T) interface{} {
+	row := reflect.ValueOf(item).Elem()
+	if len(pks) == 1 {
+		id, _ := pks[0].ValueOf(ctx, row)
+		return id
+	}
+	values := make([]interface{}, len(pks))
+	for i, f := range pks {
+		values[i], _ = f.ValueOf(ctx, row)
+	}
+	return values
+}
+
+// primaryKeyCondition 一行主键的等值条件
+func primaryKeyCondition(pks []*schema.Field, values []interface{}) clause.Expression {
+	if len(pks) == 1 {
+		return clause.Eq{Column: clause.Column{Name: pks[0].DBName}, Value: values[0]}
+	}
+	eqs := make([]clause.Expression, len(pks))
+	for i, f := range pks {
+		eqs[i] = clause.Eq{Column: clause.Column{Name: f.DBName}, Value: values[i]}
+	}
+	return clause.And(eqs...)
+}
+
+// primaryKeyValues 按主键字段解析 id 参数
+func primaryKeyValues(pks []*schema.Field, id interface{}) ([]interface{}, error) {
+	v := reflect.ValueOf(id)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, NewValidationError("id", "id is required", nil)
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, NewValidationError("id", "id is required", nil)
+	}
+
+	var raw []interface{}
+	switch {
+	case v.Kind() == reflect.Struct && v.Type() == pks[0].Schema.ModelType:
+		for _, f := range pks {
+			value, _ := f.ValueOf(context.Background(), v)
+			raw = append(raw, value)
+		}
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		for _, f := range pks {
+			value, ok := mapKeyPart(v, f)
+			if !ok {
+				return nil, NewValidationError("id", fmt.Sprintf("missing primary key %s", f.DBName), nil)
+			}
+			raw = append(raw, value)
+		}
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8:
+		if v.Len() != len(pks) {
+			return nil, NewValidationError("id", fmt.Sprintf("expected %d primary key values, got %d", len(pks), v.Len()), nil)
+		}
+		for i := 0; i < v.Len(); i++ {
+			raw = append(raw, v.Index(i).Interface())
+		}
+	case v.Kind() == reflect.Struct && len(pks) > 1:
+		for _, f := range pks {
+			value, ok := structKeyPart(v, f)
+			if !ok {
+				return nil, NewValidationError("id", fmt.Sprintf("missing primary key %s", f.DBName), nil)
+			}
+			raw = append(raw, value)
+		}
+	default:
+		if len(pks) > 1 {
+			return nil, NewValidationError("id", fmt.Sprintf("expected %d primary key values", len(pks)), nil)
+		}
+		raw = []interface{}{v.Interface()}
+	}
+
+	values := make([]interface{}, len(pks))
+	for i, f := range pks {
+		value, err := convertKeyPart(f, raw[i])
+		if err != nil {
+			return nil, NewValidationError("id", fmt.Sprintf("invalid primary key %s", f.DBName), err)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// matchesKeyPart 名称是否指向主键字段（列名、Go 字段名或 json 名，大小写不敏感）
+func matchesKeyPart(f *schema.Field, name string) bool {
+	if strings.EqualFold(name, f.DBName) || strings.EqualFold(name, f.Name) {
+		return true
+	}
+	jsonName, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return jsonName != "" && strings.EqualFold(name, jsonName)
+}
+
+// mapKeyPart 从 map 中取主键字段的值
+func mapKeyPart(m reflect.Value, f *schema.Field) (interface{}, bool) {
+	iter := m.MapRange()
+	for iter.Next() {
+		if matchesKeyPart(f, iter.Key().String()) {
+			return iter.Value().Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// structKeyPart 从任意结构体中取主键字段的值
+func structKeyPart(v reflect.Value, f *schema.Field) (interface{}, bool) {
+	for _, sf := range reflect.VisibleFields(v.Type()) {
+		if !sf.IsExported() || sf.Anonymous {
+			continue
+		}
+		jsonName, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if matchesKeyPart(f, sf.Name) || (jsonName != "" && matchesKeyPart(f, jsonName)) {
+			field, err := v.FieldByIndexErr(sf.Index)
+			if err != nil {
+				return nil, false
+			}
+			return field.Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// convertKeyPart 将取值转换为主键字段的类型：整数主键接受路径段等字符串与 JSON 数字，字符串主键接受字符串，其余保持原样
+// 超出整数主键类型范围的值（如 uint8 主键的 300、float64(1e30)）返回错误，不会截断为另一行的主键
+func convertKeyPart(f *schema.Field, value interface{}) (interface{}, error) {
+	typ := f.FieldType
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if value == nil {
+		return nil, fmt.Errorf("value is empty")
+	}
+	if s, ok := value.(json.Number); ok {
+		value = string(s)
+	}
+	v := reflect.ValueOf(value)
+	out := reflect.New(typ).Elem()
+
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch {
+		case v.Kind() == reflect.String:
+			parsed, err := strconv.ParseInt(v.String(), 10, typ.Bits())
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a valid %s", v.String(), typ)
+			}
+			n = parsed
+		case v.CanInt():
+			n = v.Int()
+		case v.CanUint() && v.Uint() <= math.MaxInt64:
+			n = int64(v.Uint())
+		case v.CanFloat() && isIntegral(v.Float()) && v.Float() >= math.MinInt64 && v.Float() < math.MaxInt64:
+			n = int64(v.Float())
+		default:
+			return nil, fmt.Errorf("%v is not a valid %s", value, typ)
+		}
+		if out.OverflowInt(n) {
+			return nil, fmt.Errorf("%v overflows %s", value, typ)
+		}
+		out.SetInt(n)
+		return out.Interface(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		switch {
+		case v.Kind() == reflect.String:
+			parsed, err := strconv.ParseUint(v.String(), 10, typ.Bits())
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a valid %s", v.String(), typ)
+			}
+			n = parsed
+		case v.CanUint():
+			n = v.Uint()
+		case v.CanInt() && v.Int() >= 0:
+			n = uint64(v.Int())
+		case v.CanFloat() && isIntegral(v.Float()) && v.Float() >= 0 && v.Float() < math.MaxUint64:
+			n = uint64(v.Float())
+		default:
+			return nil, fmt.Errorf("%v is not a valid %s", value, typ)
+		}
+		if out.OverflowUint(n) {
+			return nil, fmt.Errorf("%v overflows %s", value, typ)
+		}
+		out.SetUint(n)
+		return out.Interface(), nil
+	case reflect.String:
+		if v.Kind() == reflect.String {
+			return v.Convert(typ).Interface(), nil
+		}
+	}
+	return value, nil
+}
+
+// isIntegral 浮点数是否为有限的整数值
+func isIntegral(f float64) bool {
+	return f == math.Trunc(f) && !math.IsInf(f, 0)
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ========== 字段投影 ==========
+// 客户端通过 fields 参数只取部分字段：数据库查询用 Columns 缩小 SELECT，
+// 输出时再按 Fields 裁剪 JSON。缓存中始终保存完整对象，投影在解码之后进行，
+// 因此同一个缓存条目可以服务不同的投影。
+
+// Projection 字段投影
+type Projection struct {
+	Columns []string // 数据库列名（用于 SELECT）
+	Fields  []string // JSON 输出键
+}
+
+// NewProjection 校验 fields（json 名 / 列名 / Go 字段名）并生成投影
+// fields 为空时返回 nil，表示不投影
+func (sm *ServiceManager[T]) NewProjection(fields []string) (*Projection, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	cs, err := sm.columnSet()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Projection{}
+	seen := make(map[string]bool, len(fields))
+	for _, name := range fields {
+		col, err := sm.ResolveColumn(name, UsageSelect)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := cs.jsonKey[col]
+		if !ok {
+			return nil, NewValidationError(name, "field is not selectable", nil)
+		}
+		if seen[col] {
+			continue
+		}
+		seen[col] = true
+		p.Columns = append(p.Columns, col)
+		p.Fields = append(p.Fields, key)
+	}
+	return p, nil
+}
+
+// Apply 将单个对象裁剪为只包含投影字段的 JSON 对象
+func (p *Projection) Apply(v interface{}) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal for projection: %w", err)
+	}
+	var full map[string]json.RawMessage
+	if err := json.Unmarshal(data, &full); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal for projection: %w", err)
+	}
+
+	out := make(map[string]json.RawMessage, len(p.Fields))
+	for _, key := range p.Fields {
+		if raw, ok := full[key]; ok {
+			out[key] = raw
+		}
+	}
+	return out, nil
+}
+
+// ProjectSlice 对列表逐项投影
+func ProjectSlice[T any](p *Projection, items []T) ([]map[string]json.RawMessage, error) {
+	out := make([]map[string]json.RawMessage, 0, len(items))
+	for i := range items {
+		projected, err := p.Apply(&items[i])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, projected)
+	}
+	return out, nil
+}
+
+// ProjectMap 对键值结果逐项投影（nil 值保持为 nil）
+func ProjectMap[T any](p *Projection, values map[string]*T) (map[string]map[string]json.RawMessage, error) {
+	out := make(map[string]map[string]json.RawMessage, len(values))
+	for key, value := range values {
+		if value == nil {
+			out[key] = nil
+			continue
+		}
+		projected, err := p.Apply(value)
+		if err != nil {
+			return nil, err
+		}
+		out[key] = projected
+	}
+	return out, nil
+}
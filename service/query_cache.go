@@ -0,0 +1,453 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"AbstractManager/util/filter_translator"
+	"AbstractManager/util/tracing"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ========== 查询结果缓存 ==========
+// 相同的列表请求（方法名 + 条件 + 排序 + 分页 + 搜索）在两次写入之间结果不变。启用后（EnableQueryCache），
+// GetQueryCached 以规范化请求的哈希为键缓存该页的主键列表与总数；命中时通过 LookupByIDs 从单条缓存
+// （键同 LookupSingleByID）取行，单条缓存缺失的按主键回源数据库并回写。
+//
+// 失效依赖资源级的代数计数器：每次成功提交的写操作（writeOp）都会 INCR 计数器，结果键包含读取时的代数，
+// 旧代数的结果不会再被读到，随 TTL 自然过期。
+// 未命中时回写在 WATCH 代数键的事务中进行：读取期间代数已推进（有写入提交）则放弃回写；
+// 否则读到的行不旧于任何已提交的写入，用它们覆盖该页各行的单条缓存（SET）。写入后代数推进，
+// 下一次读取必然未命中并重写单条缓存，命中时补齐的行因此不会是写入前的旧行。
+//
+// 游标分页、Preload、Distinct / Group / Having 的查询以及单条缓存键不由主键拼出（cache 标签键字段不是主键）时不走缓存；Select 不参与缓存键，缓存路径总是加载完整的行。
+
+// QueryCacheOptions 查询结果缓存配置
+type QueryCacheOptions struct {
+	TTL     time.Duration // 结果（主键列表 + 总数）的过期时间，默认 1 分钟
+	ItemTTL time.Duration // 回写单条缓存的过期时间，默认与 TTL 相同
+
+	// Scope 返回额外的缓存键成分（如租户 ID）
+	// Before 钩子按上下文改写查询条件时必须提供，否则不同上下文会共用同一份结果
+	Scope func(ctx context.Context) string
+}
+
+// defaultQueryCacheTTL 查询结果默认过期时间
+const defaultQueryCacheTTL = time.Minute
+
+// queryCacheEntry 缓存的一页查询结果
+type queryCacheEntry struct {
+	IDs   []interface{} `json:"ids"`
+	Total int64         `json:"total"`
+}
+
+// EnableQueryCache 启用查询结果缓存（需要 Redis）
+// 写入同一资源的所有进程都必须启用，否则它们的写操作不会推进代数
+func (sm *ServiceManager[T]) EnableQueryCache(opts QueryCacheOptions) *ServiceManager[T] {
+	if opts.TTL <= 0 {
+		opts.TTL = defaultQueryCacheTTL
+	}
+	if opts.ItemTTL <= 0 {
+		opts.ItemTTL = opts.TTL
+	}
+	sm.queryCache = &opts
+	return sm
+}
+
+// QueryCacheEnabled 是否已启用查询结果缓存
+func (sm *ServiceManager[T]) QueryCacheEnabled() bool {
+	return sm.queryCache != nil
+}
+
+// GetQueryCached 带结果缓存的条件查询；未启用查询缓存或查询不可缓存时等同于 GetQueryWithoutTransaction
+// method 与 filters 标识 queryFunc 生成的条件（queryFunc 本身无法参与缓存键），相同的 method + filters 必须总是生成相同的条件
+// Redis 不可用时记录警告并直接查询数据库
+func (sm *ServiceManager[T]) GetQueryCached(
+	ctx context.Context,
+	method string,
+	filters *filter_translator.FilterNode,
+	queryFunc func(*gorm.DB) *gorm.DB,
+	opts *QueryOptions,
+) (_ *QueryResult[T], err error) {
+	ctx, span := sm.startSpan(ctx, "GetQueryCached", tracing.AttrQueryName.String(method))
+	defer func() { tracing.End(span, err) }()
+
+	pks := sm.queryCachePrimaryFields(opts)
+	if pks == nil {
+		return sm.GetQueryWithoutTransaction(ctx, queryFunc, opts)
+	}
+
+	// 缓存路径总是加载完整的行，与单条缓存保持一致
+	if opts != nil && len(opts.Select) > 0 {
+		clone := *opts
+		clone.Select = nil
+		opts = &clone
+	}
+
+	key, gen, err := sm.queryResultKey(ctx, method, filters, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var hit bool
+	op := &Operation[T]{Kind: OpGet, Method: "GetQueryCached", Query: queryFunc}
+	err = sm.runOp(ctx, op, func() error {
+		if key != "" && sm.loadCachedQuery(ctx, key, op) {
+			hit = true
+			return nil
+		}
+		if err := sm.findWithCount(GetDB().WithContext(ctx), op, opts); err != nil {
+			return err
+		}
+		if key != "" {
+			sm.storeCachedQuery(ctx, key, gen, pks, op)
+		}
+		return nil
+	})
+	span.SetAttributes(tracing.AttrCacheHit.Bool(hit))
+	if err != nil {
+		return nil, err
+	}
+
+	// 构建返回结果
+	result := &QueryResult[T]{
+		Data:   op.Items,
+		Total:  op.Total,
+		Cached: hit,
+	}
+
+	if opts != nil && opts.PageSize > 0 {
+		result.Page = opts.Page
+		result.PageSize = opts.PageSize
+		result.TotalPages = int((op.Total + int64(opts.PageSize) - 1) / int64(opts.PageSize))
+	}
+
+	return result, nil
+}
+
+// QueryFingerprint 返回查询请求的稳定哈希（sha256 十六进制），即查询结果缓存键的主体
+// 条件树先规范化：and / or 的子条件与 in / not_in 的取值与顺序无关，嵌套的同类分组展开，双重 not 抵消；
+// 排序字段经列策略解析为列名，json 名 / Go 名 / 列名写法得到相同的哈希
+func (sm *ServiceManager[T]) QueryFingerprint(method string, filters *filter_translator.FilterNode, opts *QueryOptions) (string, error) {
+	if opts == nil {
+		opts = &QueryOptions{}
+	}
+
+	sorts := opts.Sorts
+	if len(sorts) == 0 && opts.OrderBy != "" {
+		sorts = []SortParam{{Field: opts.OrderBy, Direction: opts.Order}}
+	}
+	keys, err := sm.resolveSorts(sorts)
+	if err != nil {
+		return "", err
+	}
+	order := make([]string, len(keys))
+	for i, k := range keys {
+		order[i] = k.column
+		if k.desc {
+			order[i] += " " + SortDesc
+		}
+		if k.nulls != "" {
+			order[i] += " nulls " + k.nulls
+		}
+	}
+
+	tree, err := canonicalFilter(filters)
+	if err != nil {
+		return "", err
+	}
+
+	page := 0
+	if opts.PageSize > 0 {
+		page = max(opts.Page, 1)
+	}
+	canonical := map[string]interface{}{
+		"method":     method,
+		"filters":    json.RawMessage(tree),
+		"sort":       order,
+		"page":       page,
+		"page_size":  opts.PageSize,
+		"skip_count": opts.SkipCount,
+	}
+	if opts.Search.active() {
+		canonical["search"] = map[string]interface{}{
+			"query":     strings.Join(strings.Fields(opts.Search.Query), " "),
+			"fields":    opts.Search.Fields,
+			"mode":      opts.Search.Mode,
+			"relevance": opts.Search.OrderByRelevance,
+		}
+	}
+
+	data, err := json.Marshal(canonical)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode query fingerprint: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// queryCachePrimaryFields 查询可缓存时返回用于定位单条缓存的主键字段，否则返回 nil
+func (sm *ServiceManager[T]) queryCachePrimaryFields(opts *QueryOptions) []*schema.Field {
+	if sm.queryCache == nil || globalRedisManager == nil {
+		return nil
+	}
+	if opts != nil && (opts.usesCursor() || len(opts.Preload) > 0 || opts.Distinct || opts.Group != "" || len(opts.Having) > 0) {
+		return nil
+	}
+	// 命中时按主键补齐单条缓存，单条缓存键不由主键拼出时不使用缓存
+	if err := sm.byIDKeys(); err != nil {
+		return nil
+	}
+	pks, err := sm.primaryFields()
+	if err != nil {
+		return nil
+	}
+	return pks
+}
+
+// queryGenerationKey 资源的查询缓存代数键
+func (sm *ServiceManager[T]) queryGenerationKey() string {
+	return fmt.Sprintf("qcache:%s:gen", sm.CacheKeyName)
+}
+
+// queryResultKey 生成当前代数下的结果键，同时返回该代数；读取代数失败时记录警告并返回空串（本次不使用缓存）
+func (sm *ServiceManager[T]) queryResultKey(ctx context.Context, method string, filters *filter_translator.FilterNode, opts *QueryOptions) (string, int64, error) {
+	fingerprint, err := sm.QueryFingerprint(method, filters, opts)
+	if err != nil {
+		return "", 0, err
+	}
+
+	gen, err := GetRedis().Get(ctx, sm.queryGenerationKey()).Int64()
+	if err != nil && err != redis.Nil {
+		sm.GetLogger().WarnContext(ctx, "failed to read query cache generation",
+			slog.String("operation", "GetQueryCached"), slog.Any("error", ClassifyCacheError(err)))
+		return "", 0, nil
+	}
+
+	if sm.queryCache.Scope != nil {
+		if scope := sm.queryCache.Scope(ctx); scope != "" {
+			fingerprint = scope + ":" + fingerprint
+		}
+	}
+	return fmt.Sprintf("qcache:%s:%d:%s", sm.CacheKeyName, gen, fingerprint), gen, nil
+}
+
+// InvalidateQueryCache 推进代数，使该资源已缓存的查询结果全部失效
+// 不经过 ServiceManager 的写入（如 GetSingleWithLock 返回的事务、直接使用 GetDB）提交后需要手动调用
+func (sm *ServiceManager[T]) InvalidateQueryCache(ctx context.Context) error {
+	if err := GetRedis().Incr(ctx, sm.queryGenerationKey()).Err(); err != nil {
+		return fmt.Errorf("failed to bump query cache generation: %w", ClassifyCacheError(err))
+	}
+	return nil
+}
+
+// bumpQueryGeneration 写操作提交后推进代数，失败只记录警告（旧结果最多保留 TTL）
+func (sm *ServiceManager[T]) bumpQueryGeneration(ctx context.Context) {
+	if sm.queryCache == nil || globalRedisManager == nil {
+		return
+	}
+	if err := sm.InvalidateQueryCache(ctx); err != nil {
+		sm.GetLogger().WarnContext(ctx, "failed to invalidate query cache",
+			slog.String("key", sm.queryGenerationKey()), slog.Any("error", err))
+	}
+}
+
+// loadCachedQuery 读取缓存的结果并补齐行，未命中或无法补齐时返回 false
+func (sm *ServiceManager[T]) loadCachedQuery(ctx context.Context, key string, op *Operation[T]) bool {
+	data, err := GetRedis().Get(ctx, key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			sm.GetLogger().WarnContext(ctx, "failed to read cached query",
+				slog.String("key", key), slog.Any("error", ClassifyCacheError(err)))
+		}
+		return false
+	}
+
+	// 主键保留为 json.Number，大整数不会被格式化为科学计数法
+	var entry queryCacheEntry
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&entry); err != nil {
+		sm.GetLogger().WarnContext(ctx, "failed to decode cached query", slog.String("key", key), slog.Any("error", err))
+		return false
+	}
+
+	// 单条缓存缺失的行按主键回源；仍有行不存在说明结果已过期（如绕过 ServiceManager 的删除），按未命中处理
+	rows, err := sm.LookupByIDs(ctx, entry.IDs, &LookupQueryOptions{CacheExpire: sm.queryCache.ItemTTL})
+	if err != nil {
+		sm.GetLogger().WarnContext(ctx, "failed to hydrate cached query", slog.String("key", key), slog.Any("error", err))
+		return false
+	}
+	if len(rows.Items) != len(entry.IDs) {
+		return false
+	}
+	op.Items = rows.Items
+	op.Total = entry.Total
+	return true
+}
+
+// errQueryGenerationMoved 回写前发现代数已推进
+var errQueryGenerationMoved = errors.New("query cache generation moved")
+
+// storeCachedQuery 缓存一页结果的主键列表与总数，并覆盖各行的单条缓存；失败只记录警告
+// gen 为读取前的代数：WATCH 代数键并在事务中写入，代数在读取或回写期间推进时放弃回写，
+// 避免与写入重叠的慢读把旧行写回单条缓存
+func (sm *ServiceManager[T]) storeCachedQuery(ctx context.Context, key string, gen int64, pks []*schema.Field, op *Operation[T]) {
+	prefix, err := sm.cacheKeyPrefix(ctx)
+	if err != nil {
+		sm.GetLogger().WarnContext(ctx, "failed to cache query result", slog.String("key", key), slog.Any("error", err))
+		return
+	}
+
+	entry := queryCacheEntry{IDs: make([]interface{}, len(op.Items)), Total: op.Total}
+	itemKeys := make([]string, len(op.Items))
+	items := make([][]byte, len(op.Items))
+	for i := range op.Items {
+		item := &op.Items[i]
+		entry.IDs[i] = rowPrimaryKey(ctx, pks, item)
+		itemKeys[i] = itemCacheKey(ctx, prefix, pks, item)
+		if items[i], err = marshalForRedis(item); err != nil {
+			sm.GetLogger().WarnContext(ctx, "failed to cache query result", slog.String("key", key), slog.Any("error", err))
+			return
+		}
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		sm.GetLogger().WarnContext(ctx, "failed to cache query result", slog.String("key", key), slog.Any("error", err))
+		return
+	}
+
+	genKey := sm.queryGenerationKey()
+	err = GetRedis().Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, genKey).Int64()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		if current != gen {
+			return errQueryGenerationMoved
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, itemKey := range itemKeys {
+				pipe.Set(ctx, itemKey, items[i], sm.queryCache.ItemTTL)
+			}
+			pipe.Set(ctx, key, data, sm.queryCache.TTL)
+			return nil
+		})
+		return err
+	}, genKey)
+
+	switch {
+	case err == nil:
+	case errors.Is(err, errQueryGenerationMoved), errors.Is(err, redis.TxFailedErr):
+		sm.GetLogger().DebugContext(ctx, "query cache generation moved, result not cached", slog.String("key", key))
+	default:
+		sm.GetLogger().WarnContext(ctx, "failed to cache query result",
+			slog.String("key", key), slog.Any("error", ClassifyCacheError(err)))
+	}
+}
+
+// ========== 条件树规范化 ==========
+
+// canonicalFilter 返回条件树的规范 JSON，语义相同的树得到相同的结果
+func canonicalFilter(n *filter_translator.FilterNode) (string, error) {
+	if n == nil {
+		return "null", nil
+	}
+
+	switch logic := n.Logic(); logic {
+	case filter_translator.LogicAnd, filter_translator.LogicOr:
+		parts, err := canonicalGroup(logic, n)
+		if err != nil {
+			return "", err
+		}
+		slices.Sort(parts)
+		parts = slices.Compact(parts)
+		if len(parts) == 1 {
+			return parts[0], nil
+		}
+		return fmt.Sprintf(`{%q:[%s]}`, logic, strings.Join(parts, ",")), nil
+	case filter_translator.LogicNot:
+		if n.Not.Logic() == filter_translator.LogicNot {
+			return canonicalFilter(n.Not.Not)
+		}
+		inner, err := canonicalFilter(n.Not)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(`{"not":%s}`, inner), nil
+	}
+
+	value := n.Value
+	if n.Operator == "in" || n.Operator == "not_in" {
+		if values, ok := n.Value.([]interface{}); ok {
+			set, err := canonicalSet(values)
+			if err != nil {
+				return "", err
+			}
+			value = set
+		}
+	}
+	data, err := json.Marshal([]interface{}{n.Field, n.Operator, value})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode filter %s: %w", n.Field, err)
+	}
+	return string(data), nil
+}
+
+// canonicalGroup 规范化分组的子条件，同类的嵌套分组展开到当前层
+func canonicalGroup(logic string, n *filter_translator.FilterNode) ([]string, error) {
+	children := n.And
+	if logic == filter_translator.LogicOr {
+		children = n.Or
+	}
+	parts := make([]string, 0, len(children))
+	for i := range children {
+		child := &children[i]
+		if child.Logic() == logic {
+			nested, err := canonicalGroup(logic, child)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, nested...)
+			continue
+		}
+		part, err := canonicalFilter(child)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// canonicalSet 将 in / not_in 的取值编码后排序去重
+func canonicalSet(values []interface{}) ([]json.RawMessage, error) {
+	encoded := make([]string, len(values))
+	for i, v := range values {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode filter value: %w", err)
+		}
+		encoded[i] = string(data)
+	}
+	slices.Sort(encoded)
+	encoded = slices.Compact(encoded)
+
+	set := make([]json.RawMessage, len(encoded))
+	for i, e := range encoded {
+		set[i] = json.RawMessage(e)
+	}
+	return set, nil
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+
+	"AbstractManager/util/filter_translator"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========== 多字段搜索 ==========
+// 默认对每个搜索词生成一组 LIKE OR 条件（词之间为 AND）：
+//
+//	(name LIKE '%foo%' OR bio LIKE '%foo%') AND (name LIKE '%bar%' OR bio LIKE '%bar%')
+//
+// MySQL 下若搜索字段恰好被一个 FULLTEXT 索引覆盖（CreateWithIndexes / DeclareIndexes 声明），
+// 改用 MATCH ... AGAINST 自然语言模式。相关度排序：LIKE 按命中的（字段, 词）数，FULLTEXT 按 MATCH 得分。
+
+// 搜索模式
+const (
+	SearchAuto     = ""         // 有匹配的 FULLTEXT 索引时用全文检索，否则 LIKE
+	SearchLike     = "like"     // 强制 LIKE
+	SearchFulltext = "fulltext" // 强制全文检索（没有匹配的索引时报错）
+)
+
+// SearchOptions 搜索选项
+type SearchOptions struct {
+	Query            string   // 搜索词，按空白拆分
+	Fields           []string // 搜索字段（json 名 / 列名 / Go 字段名），须通过列策略 Filter 检查
+	Mode             string   // 搜索模式，默认 SearchAuto
+	OrderByRelevance bool     // 按相关度降序排序，其余排序键作为次级排序（不支持游标分页）
+
+	PreTag  string // 高亮前缀，默认 <em>
+	PostTag string // 高亮后缀，默认 </em>
+}
+
+// searchPlan 解析后的搜索
+type searchPlan struct {
+	columns  []string
+	jsonKeys []string
+	terms    []string
+	query    string
+	fulltext bool
+}
+
+// active 是否需要搜索
+func (s *SearchOptions) active() bool {
+	return s != nil && strings.TrimSpace(s.Query) != ""
+}
+
+// newSearchPlan 校验搜索字段并选择检索方式
+func (sm *ServiceManager[T]) newSearchPlan(db *gorm.DB, s *SearchOptions) (*searchPlan, error) {
+	if len(s.Fields) == 0 {
+		return nil, NewValidationError("q", "search fields are not configured", nil)
+	}
+	cs, err := sm.columnSet()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &searchPlan{query: strings.TrimSpace(s.Query), terms: strings.Fields(s.Query)}
+	for _, name := range s.Fields {
+		col, err := sm.ResolveColumn(name, UsageFilter)
+		if err != nil {
+			return nil, err
+		}
+		if strings.Contains(col, ".") {
+			return nil, NewValidationError(name, "search field must be a column of the resource", nil)
+		}
+		plan.columns = append(plan.columns, col)
+		plan.jsonKeys = append(plan.jsonKeys, cs.jsonKey[col])
+	}
+
+	switch s.Mode {
+	case SearchAuto:
+		plan.fulltext = db.Dialector.Name() == "mysql" && sm.hasFulltextIndex(plan.columns)
+	case SearchLike:
+	case SearchFulltext:
+		if !sm.hasFulltextIndex(plan.columns) {
+			return nil, fmt.Errorf("no fulltext index covers %v on %s", plan.columns, sm.ResourceName)
+		}
+		plan.fulltext = true
+	default:
+		return nil, NewValidationError("mode", fmt.Sprintf("invalid search mode %q", s.Mode), nil)
+	}
+	return plan, nil
+}
+
+// columnList 搜索列（作为切片参数时生成 (`a`,`b`)）
+func (p *searchPlan) columnList() []clause.Column {
+	cols := make([]clause.Column, len(p.columns))
+	for i, c := range p.columns {
+		cols[i] = clause.Column{Name: c}
+	}
+	return cols
+}
+
+// apply 追加搜索条件
+func (p *searchPlan) apply(db *gorm.DB) *gorm.DB {
+	if p.fulltext {
+		return db.Where("MATCH ? AGAINST (? IN NATURAL LANGUAGE MODE)", p.columnList(), p.query)
+	}
+	for _, term := range p.terms {
+		pattern := "%" + filter_translator.EscapeLike(term) + "%"
+		var ors []clause.Expression
+		for _, col := range p.columns {
+			ors = append(ors, clause.Like{Column: clause.Column{Name: col}, Value: pattern})
+		}
+		db = db.Where(clause.Or(ors...))
+	}
+	return db
+}
+
+// relevance 相关度表达式
+func (p *searchPlan) relevance() clause.Expression {
+	if p.fulltext {
+		return clause.Expr{SQL: "MATCH ? AGAINST (? IN NATURAL LANGUAGE MODE)", Vars: []interface{}{p.columnList(), p.query}}
+	}
+	var parts []string
+	var vars []interface{}
+	for _, term := range p.terms {
+		pattern := "%" + filter_translator.EscapeLike(term) + "%"
+		for _, col := range p.columns {
+			parts = append(parts, "CASE WHEN ? LIKE ? THEN 1 ELSE 0 END")
+			vars = append(vars, clause.Column{Name: col}, pattern)
+		}
+	}
+	return clause.Expr{SQL: "(" + strings.Join(parts, " + ") + ")", Vars: vars}
+}
+
+// orderByRelevance 生成 "相关度 DESC, 其余排序键" 的完整 ORDER BY
+// 相关度是带参数的表达式，无法与 OrderByColumn 合并，因此整体作为一个表达式构建
+func orderByRelevance(db *gorm.DB, relevance clause.Expression, keys []sortKey) *gorm.DB {
+	sql := []string{"? DESC"}
+	vars := []interface{}{relevance}
+	for _, k := range keys {
+		column := clause.Column{Name: k.column}
+		if k.nulls != "" {
+			isNull := "? IS NULL"
+			if k.nulls == NullsFirst {
+				isNull += " DESC"
+			}
+			sql = append(sql, isNull)
+			vars = append(vars, column)
+		}
+		if k.desc {
+			sql = append(sql, "? DESC")
+		} else {
+			sql = append(sql, "?")
+		}
+		vars = append(vars, column)
+	}
+	return db.Order(clause.OrderBy{Expression: clause.Expr{SQL: strings.Join(sql, ","), Vars: vars}})
+}
+
+// ========== FULLTEXT 索引登记 ==========
+
+// DeclareIndexes 声明已存在的索引（例如由外部迁移创建），FULLTEXT 索引供搜索选择全文检索
+func (sm *ServiceManager[T]) DeclareIndexes(indexes ...Index) *ServiceManager[T] {
+	for _, idx := range indexes {
+		if !idx.Fulltext {
+			continue
+		}
+		columns, err := sm.indexColumns(idx)
+		if err != nil {
+			sm.GetLogger().Warn("ignore index declaration", "resource", sm.ResourceName, "index", idx.Name, "error", err)
+			continue
+		}
+		sm.registerFulltext(columns)
+	}
+	return sm
+}
+
+// registerFulltext 登记 FULLTEXT 索引覆盖的列
+func (sm *ServiceManager[T]) registerFulltext(columns []string) {
+	sm.indexMu.Lock()
+	defer sm.indexMu.Unlock()
+	sm.fulltextIndexes = append(sm.fulltextIndexes, columns)
+}
+
+// hasFulltextIndex 是否有 FULLTEXT 索引恰好覆盖 columns（MATCH 的列必须与索引一致，顺序无关）
+func (sm *ServiceManager[T]) hasFulltextIndex(columns []string) bool {
+	sm.indexMu.Lock()
+	defer sm.indexMu.Unlock()
+	want := slices.Sorted(slices.Values(columns))
+	for _, idx := range sm.fulltextIndexes {
+		if slices.Equal(slices.Sorted(slices.Values(idx)), want) {
+			return true
+		}
+	}
+	return false
+}
+
+// ========== 高亮 ==========
+
+// Highlight 为每条结果生成命中字段的高亮文本（与 items 一一对应，键为 JSON 输出键）
+// 文本先做 HTML 转义再插入标签，只包含至少命中一个搜索词的字符串字段
+func (sm *ServiceManager[T]) Highlight(items []T, s *SearchOptions) ([]map[string]string, error) {
+	if !s.active() {
+		return nil, nil
+	}
+	cs, err := sm.columnSet()
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, name := range s.Fields {
+		col, err := sm.ResolveColumn(name, UsageFilter)
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := cs.jsonKey[col]; ok {
+			keys = append(keys, key)
+		}
+	}
+	pre, post := s.PreTag, s.PostTag
+	if pre == "" && post == "" {
+		pre, post = "<em>", "</em>"
+	}
+	terms := strings.Fields(strings.ToLower(s.Query))
+
+	out := make([]map[string]string, len(items))
+	for i := range items {
+		data, err := json.Marshal(&items[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal for highlight: %w", err)
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal for highlight: %w", err)
+		}
+		out[i] = make(map[string]string)
+		for _, key := range keys {
+			text, ok := fields[key].(string)
+			if !ok {
+				continue
+			}
+			if marked, hit := highlightText(text, terms, pre, post); hit {
+				out[i][key] = marked
+			}
+		}
+	}
+	return out, nil
+}
+
+// highlightText 不区分大小写地标出所有搜索词（优先匹配更长的词，不重叠）
+func highlightText(text string, terms []string, pre, post string) (string, bool) {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// 大小写转换改变了字节长度（少数 Unicode 字符），无法按位置对应，放弃高亮
+		return "", false
+	}
+	terms = slices.Clone(terms)
+	slices.SortFunc(terms, func(a, b string) int { return len(b) - len(a) })
+
+	var b strings.Builder
+	hit := false
+	last := 0
+	for i := 0; i < len(lower); {
+		matched := 0
+		for _, term := range terms {
+			if strings.HasPrefix(lower[i:], term) {
+				matched = len(term)
+				break
+			}
+		}
+		if matched == 0 {
+			i++
+			continue
+		}
+		hit = true
+		b.WriteString(html.EscapeString(text[last:i]))
+		b.WriteString(pre)
+		b.WriteString(html.EscapeString(text[i : i+matched]))
+		b.WriteString(post)
+		i += matched
+		last = i
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String(), hit
+}
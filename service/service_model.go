@@ -1,6 +1,12 @@
 package service
 
-import "reflect"
+import (
+	"log/slog"
+	"reflect"
+	"sync"
+
+	"AbstractManager/util/cache_key_builder"
+)
 
 type ServiceManager[T any] struct {
 	Resource     T      // 被管理的资源
@@ -9,6 +15,21 @@ type ServiceManager[T any] struct {
 	Schema       string // 数据库模式
 	CacheKeyType string // 缓存键
 	CacheKeyName string // 缓存键名称
+
+	Logger *slog.Logger // 日志记录器（为空时使用 slog.Default()）
+
+	hooksOnce sync.Once
+	hooks     *hookRegistry[T] // 操作钩子
+
+	columnMu     sync.Mutex
+	columnPolicy ColumnPolicy // 列策略
+	columns      *columnSet   // 按列策略解析后的列（懒加载）
+
+	indexMu         sync.Mutex
+	fulltextIndexes [][]string // FULLTEXT 索引覆盖的列（见 CreateWithIndexes / DeclareIndexes）
+
+	queryCache  *QueryCacheOptions             // 查询结果缓存配置，为空时未启用（见 EnableQueryCache）
+	generations *cache_key_builder.Generations // 键命名空间代数，为空时未启用（见 EnableKeyNamespace）
 }
 
 func getTypeName[T any](value T) string {
@@ -31,5 +52,6 @@ func NewServiceManager[T any](resource T) *ServiceManager[T] {
 		Schema:       "public",
 		CacheKeyType: "none",
 		CacheKeyName: getTypeName(resource) + "_key",
+		hooks:        newHookRegistry[T](),
 	}
 }
@@ -0,0 +1,104 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"AbstractManager/service"
+
+	"gorm.io/gorm"
+)
+
+type order struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Status    string         `json:"status"`
+	UserID    uint           `json:"user_id"`
+	Amount    float64        `json:"amount"`
+	Note      string         `json:"note"`
+	Secret    string         `json:"-"`
+	DeletedAt gorm.DeletedAt `json:"-"`
+}
+
+func TestAggregateSQL(t *testing.T) {
+	statements := useDryRunDB(t)
+	sm := service.NewServiceManager(order{})
+	sm.TableName = "orders"
+
+	rows, err := sm.Aggregate(context.Background(), func(db *gorm.DB) *gorm.DB {
+		return db.Where("amount > ?", 0)
+	}, &service.AggregateOptions{
+		GroupBy: []string{"status"},
+		Metrics: []service.Metric{
+			{Func: service.AggCount},
+			{Func: service.AggSum, Field: "amount"},
+			{Func: service.AggCountDistinct, Field: "user_id", As: "buyers"},
+		},
+		Having: []service.HavingCondition{{Metric: "count", Operator: ">=", Value: 10}},
+		Sorts:  []service.SortParam{{Field: "sum_amount", Direction: "desc"}},
+		Limit:  5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 0 {
+		t.Fatalf("expected no rows in dry run, got %v", rows)
+	}
+
+	want := "SELECT `status`, COUNT(*) AS `count`, SUM(`amount`) AS `sum_amount`, COUNT(DISTINCT `user_id`) AS `buyers` " +
+		"FROM `orders` WHERE amount > ? AND `orders`.`deleted_at` IS NULL " +
+		"GROUP BY `status` HAVING COUNT(*) >= ? ORDER BY `sum_amount` DESC LIMIT ?"
+	if sql := (*statements)[len(*statements)-1]; sql != want {
+		t.Errorf("got SQL\n%s\nwant\n%s", sql, want)
+	}
+}
+
+func TestAggregateValidation(t *testing.T) {
+	useDryRunDB(t)
+	sm := service.NewServiceManager(order{}).SetColumnPolicy(service.ColumnPolicy{
+		Aggregate: service.FieldList{Deny: []string{"user_id"}},
+	})
+	sm.TableName = "orders"
+
+	cases := map[string]*service.AggregateOptions{
+		"no metrics":     {GroupBy: []string{"status"}},
+		"bad func":       {Metrics: []service.Metric{{Func: "median", Field: "amount"}}},
+		"missing field":  {Metrics: []service.Metric{{Func: service.AggSum}}},
+		"non-numeric":    {Metrics: []service.Metric{{Func: service.AggAvg, Field: "note"}}},
+		"denied field":   {GroupBy: []string{"user_id"}, Metrics: []service.Metric{{Func: service.AggCount}}},
+		"hidden group":   {GroupBy: []string{"secret"}, Metrics: []service.Metric{{Func: service.AggCount}}},
+		"hidden metric":  {GroupBy: []string{"id"}, Metrics: []service.Metric{{Func: service.AggMax, Field: "secret"}}},
+		"hidden go name": {Metrics: []service.Metric{{Func: service.AggMin, Field: "Secret"}}},
+		"bad alias":      {Metrics: []service.Metric{{Func: service.AggCount, As: "x; DROP"}}},
+		"alias clash":    {GroupBy: []string{"status"}, Metrics: []service.Metric{{Func: service.AggCount, As: "status"}}},
+		"unknown having": {Metrics: []service.Metric{{Func: service.AggCount}}, Having: []service.HavingCondition{{Metric: "total", Operator: ">", Value: 1}}},
+		"bad operator":   {Metrics: []service.Metric{{Func: service.AggCount}}, Having: []service.HavingCondition{{Metric: "count", Operator: "like", Value: 1}}},
+		"bad sort":       {Metrics: []service.Metric{{Func: service.AggCount}}, Sorts: []service.SortParam{{Field: "amount"}}},
+	}
+	for name, opts := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := sm.Aggregate(context.Background(), nil, opts)
+			if !errors.Is(err, service.ErrValidation) {
+				t.Fatalf("expected validation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAggregateNoGroup(t *testing.T) {
+	statements := useDryRunDB(t)
+	sm := service.NewServiceManager(order{})
+	sm.TableName = "orders"
+
+	_, err := sm.Aggregate(context.Background(), nil, &service.AggregateOptions{
+		Metrics: []service.Metric{{Func: service.AggAvg, Field: "amount"}, {Func: service.AggMax, Field: "amount"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql := (*statements)[len(*statements)-1]
+	if !strings.HasPrefix(sql, "SELECT AVG(`amount`) AS `avg_amount`, MAX(`amount`) AS `max_amount` FROM `orders`") || strings.Contains(sql, "GROUP BY") {
+		t.Errorf("unexpected SQL %q", sql)
+	}
+}
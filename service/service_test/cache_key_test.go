@@ -0,0 +1,143 @@
+package service_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"AbstractManager/service"
+)
+
+type sku struct {
+	_        struct{} `cache:"prefix=cache:sku"`
+	Tenant   string   `gorm:"primaryKey" json:"tenant" cache:"key"`
+	Code     string   `gorm:"primaryKey" json:"code" cache:"key,order=1"`
+	Quantity int      `json:"quantity"`
+}
+
+// 键字段不是主键：默认键可用，按 ID 的方法无法由主键拼出键
+type voucher struct {
+	_    struct{} `cache:"prefix=cache:voucher"`
+	ID   uint     `gorm:"primaryKey" json:"id"`
+	Code string   `json:"code" cache:"key"`
+}
+
+// 键字段是主键但顺序不同
+type shelf struct {
+	Aisle string `gorm:"primaryKey" json:"aisle" cache:"key,order=1"`
+	Slot  int    `gorm:"primaryKey" json:"slot" cache:"key"`
+}
+
+func TestCacheKeyDefaults(t *testing.T) {
+	ctx := context.Background()
+
+	// 未声明 cache 标签时使用 CacheKeyName + 主键
+	accounts := service.NewServiceManager(account{})
+	key, err := accounts.CacheKey(ctx, &account{ID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "account_key:7"; key != want {
+		t.Errorf("CacheKey = %q, want %q", key, want)
+	}
+
+	// 声明了 cache 标签时使用标签的前缀与键字段
+	skus := service.NewServiceManager(sku{})
+	key, err = skus.CacheKey(ctx, &sku{Tenant: "acme", Code: "A-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "cache:sku:acme:A-1"; key != want {
+		t.Errorf("CacheKey = %q, want %q", key, want)
+	}
+	template, err := skus.CacheKeyTemplate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "cache:sku:{tenant}:{code}"; template != want {
+		t.Errorf("CacheKeyTemplate = %q, want %q", template, want)
+	}
+
+	// 按 ID 生成的键与标签键一致
+	byID, err := skus.CacheKeyByID(ctx, []string{"acme", "A-1"})
+	if err != nil || byID != key {
+		t.Errorf("CacheKeyByID = %q, %v; want %q", byID, err, key)
+	}
+}
+
+func TestCacheKeyTagsOnNonPrimaryKey(t *testing.T) {
+	ctx := context.Background()
+	useFakeDB(t, func(query string, _ []driver.Value) ([]string, [][]driver.Value) {
+		if strings.Contains(query, "count(") {
+			return []string{"count(*)"}, [][]driver.Value{{int64(1)}}
+		}
+		return []string{"id", "code"}, [][]driver.Value{{int64(1), "SAVE10"}}
+	})
+	_, server := useRedis(t)
+	vouchers := service.NewServiceManager(voucher{}).EnableQueryCache(service.QueryCacheOptions{})
+	vouchers.TableName = "vouchers"
+
+	// 默认键与模板使用标签键字段
+	key, err := vouchers.CacheKey(ctx, &voucher{ID: 1, Code: "SAVE10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "cache:voucher:SAVE10"; key != want {
+		t.Errorf("CacheKey = %q, want %q", key, want)
+	}
+	if template, err := vouchers.CacheKeyTemplate(ctx); err != nil || template != "cache:voucher:{code}" {
+		t.Errorf("CacheKeyTemplate = %q, %v", template, err)
+	}
+	if err := vouchers.WritedownQuery(ctx, []voucher{{ID: 1, Code: "SAVE10"}}, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := server.Get("cache:voucher:SAVE10"); !ok {
+		t.Error("default key not written")
+	}
+	items, err := vouchers.LookupQuery(ctx, []string{"cache:voucher:SAVE10"}, nil)
+	if err != nil || items["cache:voucher:SAVE10"] == nil {
+		t.Errorf("LookupQuery by key = %v, %v", items, err)
+	}
+
+	// 按 ID 的方法返回错误，不访问错开的键
+	if _, err := vouchers.CacheKeyByID(ctx, 1); err == nil {
+		t.Error("CacheKeyByID: expected error")
+	}
+	if _, err := vouchers.LookupSingleByID(ctx, 1, 0); err == nil {
+		t.Error("LookupSingleByID: expected error")
+	}
+	if _, err := vouchers.LookupByIDs(ctx, []interface{}{1}, nil); err == nil {
+		t.Error("LookupByIDs: expected error")
+	}
+	if err := vouchers.InvalidateSingleCacheByID(ctx, 1); err == nil {
+		t.Error("InvalidateSingleCacheByID: expected error")
+	}
+	if _, err := vouchers.LookupQuery(ctx, []string{"cache:voucher:OTHER"}, &service.LookupQueryOptions{FallbackToDB: true}); err == nil {
+		t.Error("LookupQuery fallback: expected error")
+	}
+
+	// 查询缓存无法按主键补齐单条缓存，直接查询数据库
+	server.ResetCommands()
+	result, err := vouchers.GetQueryCached(ctx, "list", nil, nil, &service.QueryOptions{Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Cached || len(result.Data) != 1 || result.Data[0].Code != "SAVE10" {
+		t.Errorf("GetQueryCached = %+v", result)
+	}
+	if commands := server.Commands(); len(commands) != 0 {
+		t.Errorf("query cache used with non primary key item keys: %v", commands)
+	}
+}
+
+func TestCacheKeyByIDNeedsPrimaryKeyOrder(t *testing.T) {
+	ctx := context.Background()
+	shelves := service.NewServiceManager(shelf{})
+	if key, err := shelves.CacheKey(ctx, &shelf{Aisle: "a", Slot: 3}); err != nil || key != "shelf_key:3:a" {
+		t.Errorf("CacheKey = %q, %v", key, err)
+	}
+	if _, err := shelves.CacheKeyByID(ctx, []interface{}{"a", 3}); err == nil {
+		t.Error("expected error for cache key fields in a different order than the primary key")
+	}
+}
@@ -0,0 +1,119 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"AbstractManager/service"
+)
+
+type account struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	UserName string `json:"user_name"`
+	Balance  int64  `gorm:"column:balance_cents" json:"balance"`
+	Password string `json:"-"`
+	Secret   string `gorm:"-" json:"secret"`
+}
+
+func TestResolveColumnDefaults(t *testing.T) {
+	sm := service.NewServiceManager(account{})
+
+	// json 名、列名、Go 字段名都映射为列名
+	for _, name := range []string{"balance", "balance_cents", "Balance"} {
+		col, err := sm.ResolveColumn(name, service.UsageFilter)
+		if err != nil || col != "balance_cents" {
+			t.Errorf("ResolveColumn(%q) = %q, %v", name, col, err)
+		}
+	}
+
+	// 不在 schema 中的字段与注入尝试都被拒绝
+	for _, name := range []string{"secret", "missing", "id; DROP TABLE account", ""} {
+		if _, err := sm.ResolveColumn(name, service.UsageFilter); !errors.Is(err, service.ErrValidation) {
+			t.Errorf("ResolveColumn(%q) error = %v, want ErrValidation", name, err)
+		}
+	}
+
+	// 主键默认不可更新
+	if _, err := sm.ResolveColumn("id", service.UsageUpdate); !errors.Is(err, service.ErrValidation) {
+		t.Errorf("primary key should not be updatable, got %v", err)
+	}
+
+	// json:"-" 的隐藏字段默认不可用于任何用途，只能显式 Allow
+	for _, usage := range []service.ColumnUsage{service.UsageFilter, service.UsageSort, service.UsageUpdate, service.UsageSelect} {
+		if _, err := sm.ResolveColumn("Password", usage); !errors.Is(err, service.ErrValidation) {
+			t.Errorf("hidden field accepted for %s by default, got %v", usage, err)
+		}
+	}
+	if _, err := sm.ResolveUpdates(map[string]interface{}{"password": "x"}); !errors.Is(err, service.ErrValidation) {
+		t.Errorf("hidden field updatable by default, got %v", err)
+	}
+	sm.SetColumnPolicy(service.ColumnPolicy{Update: service.FieldList{Allow: []string{"password"}}})
+	if col, err := sm.ResolveColumn("password", service.UsageUpdate); err != nil || col != "password" {
+		t.Errorf("explicitly allowed hidden field: %q, %v", col, err)
+	}
+}
+
+func TestColumnPolicy(t *testing.T) {
+	sm := service.NewServiceManager(account{}).SetColumnPolicy(service.ColumnPolicy{
+		Filter: service.FieldList{Deny: []string{"password"}},
+		Sort:   service.FieldList{Allow: []string{"id", "user_name"}},
+		Update: service.FieldList{Allow: []string{"user_name", "balance"}, Deny: []string{"balance"}},
+	})
+
+	if _, err := sm.ResolveColumn("password", service.UsageFilter); err == nil {
+		t.Error("denied filter field accepted")
+	}
+	if _, err := sm.ResolveColumn("balance", service.UsageSort); err == nil {
+		t.Error("field outside sort allow list accepted")
+	}
+	if col, err := sm.ResolveColumn("UserName", service.UsageSort); err != nil || col != "user_name" {
+		t.Errorf("sort user_name: %q, %v", col, err)
+	}
+
+	updates, err := sm.ResolveUpdates(map[string]interface{}{"user_name": "tom"})
+	if err != nil || updates["user_name"] != "tom" {
+		t.Errorf("ResolveUpdates = %v, %v", updates, err)
+	}
+	if _, err := sm.ResolveUpdates(map[string]interface{}{"balance": 1}); err == nil {
+		t.Error("deny should take precedence over allow")
+	}
+}
+
+type wallet struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	AccountID uint   `json:"account_id"`
+	Currency  string `json:"currency"`
+	PIN       string `gorm:"column:pin" json:"pin"`
+	Seed      string `json:"-"`
+}
+
+type customer struct {
+	ID      uint     `gorm:"primaryKey" json:"id"`
+	Name    string   `json:"name"`
+	Wallets []wallet `gorm:"foreignKey:AccountID" json:"wallets"`
+}
+
+func TestResolveRelationPath(t *testing.T) {
+	sm := service.NewServiceManager(customer{}).SetColumnPolicy(service.ColumnPolicy{
+		Filter: service.FieldList{Deny: []string{"wallets.pin"}},
+	})
+
+	if col, err := sm.ResolveColumn("wallets.currency", service.UsageFilter); err != nil || col != "Wallets.currency" {
+		t.Errorf("ResolveColumn(wallets.currency) = %q, %v", col, err)
+	}
+	for _, name := range []string{"wallets.pin", "wallets.seed", "wallets.missing", "missing.currency"} {
+		if _, err := sm.ResolveColumn(name, service.UsageFilter); !errors.Is(err, service.ErrValidation) {
+			t.Errorf("ResolveColumn(%q) error = %v, want ErrValidation", name, err)
+		}
+	}
+	// 关联路径只能用于过滤
+	if _, err := sm.ResolveColumn("wallets.currency", service.UsageSort); err == nil {
+		t.Error("relation path accepted for sort")
+	}
+
+	// Allow 非空时关联路径须显式列出
+	sm.SetColumnPolicy(service.ColumnPolicy{Filter: service.FieldList{Allow: []string{"name"}}})
+	if _, err := sm.ResolveColumn("wallets.currency", service.UsageFilter); err == nil {
+		t.Error("relation path outside allow list accepted")
+	}
+}
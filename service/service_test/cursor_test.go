@@ -0,0 +1,122 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"AbstractManager/service"
+
+	"gorm.io/gorm"
+)
+
+// cursorFor 按游标格式构造测试用游标（游标对调用方不透明，这里仅用于断言生成的 SQL）
+func cursorFor(raw string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func TestGetQueryCursor(t *testing.T) {
+	statements := useDryRunDB(t)
+	sm := service.NewServiceManager(account{})
+	sm.TableName = "accounts"
+	sorts := []service.SortParam{{Field: "balance", Direction: "desc"}}
+
+	cases := []struct {
+		name   string
+		opts   service.QueryOptions
+		want   string
+		counts int
+	}{
+		{
+			name:   "first page",
+			opts:   service.QueryOptions{PageSize: 10, Sorts: sorts, UseCursor: true, SkipCount: true},
+			want:   "FROM `accounts` ORDER BY `balance_cents` DESC,`id` LIMIT ?",
+			counts: 0,
+		},
+		{
+			name:   "next page",
+			opts:   service.QueryOptions{PageSize: 10, Sorts: sorts, Cursor: cursorFor(`{"d":"next","o":["balance_cents:desc","id:asc"],"v":[100,5]}`)},
+			want:   "WHERE (`balance_cents` < ? OR (`balance_cents` = ? AND `id` > ?)) ORDER BY `balance_cents` DESC,`id` LIMIT ?",
+			counts: 1,
+		},
+		{
+			name:   "previous page",
+			opts:   service.QueryOptions{PageSize: 10, Sorts: sorts, Cursor: cursorFor(`{"d":"prev","o":["balance_cents:desc","id:asc"],"v":[100,5]}`), SkipCount: true},
+			want:   "WHERE (`balance_cents` > ? OR (`balance_cents` = ? AND `id` < ?)) ORDER BY `balance_cents`,`id` DESC LIMIT ?",
+			counts: 0,
+		},
+	}
+	for _, tc := range cases {
+		*statements = nil
+		opts := tc.opts
+		if _, err := sm.GetQueryWithoutTransaction(context.Background(), nil, &opts); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		var counts int
+		for _, s := range *statements {
+			if strings.Contains(s, "count(*)") {
+				counts++
+			}
+		}
+		if counts != tc.counts {
+			t.Errorf("%s: %d count queries, want %d", tc.name, counts, tc.counts)
+		}
+		sql := (*statements)[len(*statements)-1]
+		if !strings.Contains(sql, tc.want) {
+			t.Errorf("%s: SQL %q does not contain %q", tc.name, sql, tc.want)
+		}
+	}
+}
+
+func TestGetQueryCursorRejected(t *testing.T) {
+	useDryRunDB(t)
+	sm := service.NewServiceManager(account{})
+
+	cases := map[string]service.QueryOptions{
+		"malformed":     {PageSize: 10, Cursor: "%%%"},
+		"sort mismatch": {PageSize: 10, Cursor: cursorFor(`{"d":"next","o":["balance_cents:desc","id:asc"],"v":[100,5]}`)},
+		"bad value":     {PageSize: 10, Sorts: []service.SortParam{{Field: "id"}}, Cursor: cursorFor(`{"d":"next","o":["id:asc"],"v":["x"]}`)},
+		"no page size":  {UseCursor: true},
+		"nulls":         {PageSize: 10, UseCursor: true, Sorts: []service.SortParam{{Field: "id", Nulls: "last"}}},
+	}
+	for name, opts := range cases {
+		if _, err := sm.GetQueryWithoutTransaction(context.Background(), nil, &opts); !errors.Is(err, service.ErrValidation) {
+			t.Errorf("%s: error = %v, want ErrValidation", name, err)
+		}
+	}
+}
+
+// ledger 含可空列
+type ledger struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Amount    int64          `json:"amount"`
+	SettledAt *time.Time     `json:"settled_at"`
+	Memo      sql.NullString `json:"memo"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at"`
+}
+
+func TestGetQueryCursorRejectsNullableSort(t *testing.T) {
+	useDryRunDB(t)
+	sm := service.NewServiceManager(ledger{})
+
+	for _, field := range []string{"settled_at", "memo", "deleted_at"} {
+		opts := &service.QueryOptions{PageSize: 10, UseCursor: true, Sorts: []service.SortParam{{Field: field}}}
+		if _, err := sm.GetQueryWithoutTransaction(context.Background(), nil, opts); !errors.Is(err, service.ErrValidation) {
+			t.Errorf("%s: error = %v, want ErrValidation", field, err)
+		}
+		// 普通分页仍可按可空列排序
+		opts = &service.QueryOptions{Page: 1, PageSize: 10, Sorts: []service.SortParam{{Field: field}}}
+		if _, err := sm.GetQueryWithoutTransaction(context.Background(), nil, opts); err != nil {
+			t.Errorf("%s: offset pagination failed: %v", field, err)
+		}
+	}
+
+	opts := &service.QueryOptions{PageSize: 10, UseCursor: true, Sorts: []service.SortParam{{Field: "amount"}}}
+	if _, err := sm.GetQueryWithoutTransaction(context.Background(), nil, opts); err != nil {
+		t.Errorf("NOT NULL sort column rejected: %v", err)
+	}
+}
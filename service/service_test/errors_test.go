@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"AbstractManager/service"
+
+	mysqlDriver "github.com/go-sql-driver/mysql"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+func TestClassifyCacheError(t *testing.T) {
+	other := errors.New("WRONGTYPE")
+	cases := []struct {
+		name        string
+		err         error
+		unavailable bool
+	}{
+		{"net", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{"eof", fmt.Errorf("read: %w", io.EOF), true},
+		{"closed", redis.ErrClosed, true},
+		{"pool timeout", redis.ErrPoolTimeout, true},
+		{"pool exhausted", redis.ErrPoolExhausted, true},
+		{"already classified", fmt.Errorf("%w: x", service.ErrCacheUnavailable), true},
+		{"nil value", redis.Nil, false},
+		{"other", other, false},
+	}
+	for _, tc := range cases {
+		got := service.ClassifyCacheError(tc.err)
+		if errors.Is(got, service.ErrCacheUnavailable) != tc.unavailable {
+			t.Errorf("%s: %v classified as unavailable = %v", tc.name, got, !tc.unavailable)
+		}
+		if !errors.Is(got, tc.err) {
+			t.Errorf("%s: classified error %v lost the cause", tc.name, got)
+		}
+		if !tc.unavailable && got != tc.err {
+			t.Errorf("%s: unclassified error was wrapped: %v", tc.name, got)
+		}
+	}
+	if service.ClassifyCacheError(nil) != nil {
+		t.Error("nil error classified")
+	}
+}
+
+func TestServiceErrorSentinels(t *testing.T) {
+	ctx := context.Background()
+	db := useFakeDB(t, accountTable())
+	sm := service.NewServiceManager(account{})
+	sm.TableName = "accounts"
+
+	_, notFound := sm.GetSingleByID(ctx, 1, nil)
+	_, outOfRange := sm.GetSingleByID(ctx, "18446744073709551616", nil)
+	dupEntry := &mysqlDriver.MySQLError{Number: 1062, Message: "Duplicate entry"}
+	db.SetExecErr(dupEntry)
+	duplicate := sm.Insert(ctx, &account{ID: 1})
+	db.SetExecErr(errors.New("deadlock"))
+	deadlock := sm.Insert(ctx, &account{ID: 1})
+
+	cases := []struct {
+		name   string
+		err    error
+		target error
+		cause  error
+	}{
+		{"not found", notFound, service.ErrNotFound, gorm.ErrRecordNotFound},
+		{"pk out of range", outOfRange, service.ErrValidation, nil},
+		{"duplicate entry", duplicate, service.ErrConflict, dupEntry},
+		{"version", &service.VersionOutdatedError{Key: "k", Current: 2, Provided: 1}, service.ErrVersionOutdated, nil},
+		{"lookup miss", &service.NotFoundError{Resource: "account", Key: "k", Err: redis.Nil}, service.ErrNotFound, redis.Nil},
+		{"rejected", service.Reject("read only"), service.ErrRejected, nil},
+	}
+	sentinels := []error{service.ErrNotFound, service.ErrConflict, service.ErrVersionOutdated, service.ErrLockNotAcquired,
+		service.ErrValidation, service.ErrCacheUnavailable, service.ErrRejected}
+	for _, tc := range cases {
+		if tc.err == nil {
+			t.Errorf("%s: no error", tc.name)
+			continue
+		}
+		for _, s := range sentinels {
+			if errors.Is(tc.err, s) != (s == tc.target) {
+				t.Errorf("%s: errors.Is(%v, %v) = %v", tc.name, tc.err, s, !(s == tc.target))
+			}
+		}
+		if tc.cause != nil && !errors.Is(tc.err, tc.cause) {
+			t.Errorf("%s: cause %v lost", tc.name, tc.cause)
+		}
+	}
+
+	// 无法归类的数据库错误原样返回
+	for _, s := range sentinels {
+		if errors.Is(deadlock, s) {
+			t.Errorf("unclassified database error matches %v", s)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package service_test
+
+import (
+	"testing"
+
+	"AbstractManager/internal/redistest"
+	"AbstractManager/internal/sqltest"
+	"AbstractManager/service"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// useDryRunDB 安装 DryRun 数据库作为全局连接，返回记录已生成 SQL 的切片
+// DryRun 模式下回调照常执行，但不会真正访问数据库
+func useDryRunDB(t *testing.T) *[]string {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:1)/db",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var statements []string
+	capture := func(tx *gorm.DB) { statements = append(statements, tx.Statement.SQL.String()) }
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture", capture); err != nil {
+		t.Fatal(err)
+	}
+	service.UseDB(db)
+	return &statements
+}
+
+// ========== 内存 SQL 驱动 ==========
+
+// useFakeDB 安装基于 sqltest 驱动桩的 MySQL 方言连接作为全局连接
+func useFakeDB(t *testing.T, query sqltest.QueryFunc) *sqltest.DB {
+	t.Helper()
+	db, fdb := sqltest.Open(t, query)
+	service.UseDB(db)
+	return fdb
+}
+
+// useRedis 安装内存 Redis 作为全局连接，测试结束时清除
+func useRedis(t *testing.T) (*redis.Client, *redistest.Server) {
+	t.Helper()
+	client, server := redistest.NewClient(t)
+	service.UseRedis(client)
+	t.Cleanup(func() { service.UseRedis(nil) })
+	return client, server
+}
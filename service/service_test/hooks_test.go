@@ -0,0 +1,202 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"AbstractManager/service"
+
+	"gorm.io/gorm"
+)
+
+// containsStatement 是否有语句包含 part
+func containsStatement(statements []string, part string) bool {
+	for _, s := range statements {
+		if strings.Contains(s, part) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestBeforeHookVetoesWrite(t *testing.T) {
+	ctx := context.Background()
+	db := useFakeDB(t, accountTable())
+	sm := service.NewServiceManager(account{})
+	sm.TableName = "accounts"
+
+	var afterCalled bool
+	sm.Before(func(ctx context.Context, op *service.Operation[account]) error {
+		return service.Reject("read only")
+	}, service.OpSet).After(func(ctx context.Context, op *service.Operation[account]) error {
+		afterCalled = true
+		return nil
+	})
+
+	err := sm.SetSingle(ctx, &account{ID: 1, UserName: "ann"}, nil)
+	if !errors.Is(err, service.ErrRejected) {
+		t.Fatalf("expected ErrRejected, got %v", err)
+	}
+	if afterCalled {
+		t.Error("after hook ran for a vetoed operation")
+	}
+	statements := db.Statements()
+	if containsStatement(statements, "INSERT") || !reflect.DeepEqual(statements, []string{"BEGIN", "ROLLBACK"}) {
+		t.Errorf("vetoed write executed %q", statements)
+	}
+}
+
+func TestBeforeHookRewritesInput(t *testing.T) {
+	ctx := context.Background()
+	db := useFakeDB(t, accountTable())
+	sm := service.NewServiceManager(account{})
+	sm.TableName = "accounts"
+
+	// 替换数据：实际写入的是钩子给出的数据
+	sm.Before(func(ctx context.Context, op *service.Operation[account]) error {
+		op.Data = &account{ID: 9, UserName: "from-hook"}
+		return nil
+	}, service.OpSet)
+	if err := sm.SetSingle(ctx, &account{ID: 1, UserName: "ann"}, &service.SetSingleOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	args := db.ExecArgs()
+	if len(args) != 1 || !reflect.DeepEqual(args[0][0], "from-hook") {
+		t.Errorf("insert args = %v", args)
+	}
+
+	// 收窄条件并追加更新字段
+	sm.Before(func(ctx context.Context, op *service.Operation[account]) error {
+		query := op.Query
+		op.Query = func(db *gorm.DB) *gorm.DB { return query(db).Where("balance_cents > ?", 0) }
+		op.Updates["balance_cents"] = 0
+		return nil
+	}, service.OpUpdate)
+	db.Reset()
+	if err := sm.Update(ctx, map[string]interface{}{"user_name": "amy"}, func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ?", 1)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !containsStatement(db.Statements(), "SET `balance_cents`=?,`user_name`=? WHERE id = ? AND balance_cents > ?") {
+		t.Errorf("hook changes not applied: %q", db.Statements())
+	}
+}
+
+func TestAfterHookErrorRollsBackWrite(t *testing.T) {
+	ctx := context.Background()
+	db := useFakeDB(t, accountTable())
+	sm := service.NewServiceManager(account{})
+	sm.TableName = "accounts"
+
+	audit := errors.New("audit log unavailable")
+	var rows int64
+	sm.After(func(ctx context.Context, op *service.Operation[account]) error {
+		if op.Tx == nil {
+			t.Error("write hook runs outside the transaction")
+		}
+		rows = op.RowsAffected
+		return audit
+	}, service.OpUpdate)
+
+	err := sm.UpdateByID(ctx, 1, map[string]interface{}{"user_name": "amy"})
+	if !errors.Is(err, audit) {
+		t.Fatalf("expected after hook error, got %v", err)
+	}
+	if rows != 1 {
+		t.Errorf("after hook saw %d affected rows", rows)
+	}
+	statements := db.Statements()
+	if len(statements) != 3 || statements[0] != "BEGIN" || !strings.HasPrefix(statements[1], "UPDATE") || statements[2] != "ROLLBACK" {
+		t.Errorf("expected BEGIN, UPDATE, ROLLBACK, got %q", statements)
+	}
+}
+
+func TestHooksRunOnReads(t *testing.T) {
+	ctx := context.Background()
+	useFakeDB(t, accountTable(account{ID: 5, UserName: "ann"}, account{ID: 7, UserName: "bob"}))
+	_, server := useRedis(t)
+	sm := service.NewServiceManager(account{})
+	sm.TableName = "accounts"
+
+	var calls []string
+	sm.Before(func(ctx context.Context, op *service.Operation[account]) error {
+		calls = append(calls, "before "+string(op.Kind)+" "+op.Method)
+		if op.Kind == service.OpGet {
+			// 读取路径同样可以改写条件
+			op.Query = func(db *gorm.DB) *gorm.DB { return db.Where("`id` = ?", 7) }
+		}
+		return nil
+	}).After(func(ctx context.Context, op *service.Operation[account]) error {
+		result := "miss"
+		if op.Data != nil {
+			result = op.Data.UserName
+		} else if !errors.Is(op.Err, service.ErrNotFound) {
+			result = "unexpected " + fmt.Sprint(op.Err)
+		}
+		calls = append(calls, "after "+string(op.Kind)+" "+op.Method+" "+result)
+		return nil
+	})
+
+	got, err := sm.GetSingle(ctx, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != 7 {
+		t.Errorf("GetSingle returned %+v, want the row selected by the hook", got)
+	}
+	if _, err := sm.LookupSingle(ctx, "account_key:7", nil); !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("expected cache miss, got %v", err)
+	}
+	want := []string{
+		"before get GetSingle", "after get GetSingle bob",
+		"before lookup LookupSingle", "after lookup LookupSingle miss",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+
+	// 否决缓存读取：不访问 Redis
+	sm.Before(func(ctx context.Context, op *service.Operation[account]) error {
+		return service.Reject("no cache access")
+	}, service.OpLookup)
+	server.ResetCommands()
+	if _, err := sm.LookupSingle(ctx, "account_key:7", nil); !errors.Is(err, service.ErrRejected) {
+		t.Fatalf("expected ErrRejected, got %v", err)
+	}
+	if commands := server.Commands(); len(commands) != 0 {
+		t.Errorf("vetoed lookup ran %v", commands)
+	}
+}
+
+func TestHooksOnZeroValueManager(t *testing.T) {
+	// 未通过 NewServiceManager 创建的实例可以并发注册钩子
+	useFakeDB(t, accountTable())
+	sm := &service.ServiceManager[account]{}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sm.Before(func(ctx context.Context, op *service.Operation[account]) error { return nil })
+		}()
+	}
+	wg.Wait()
+
+	var n int
+	sm.Before(func(ctx context.Context, op *service.Operation[account]) error {
+		n++
+		return service.Reject("stop")
+	})
+	if _, err := sm.GetSingle(context.Background(), nil, nil); !errors.Is(err, service.ErrRejected) {
+		t.Fatalf("expected ErrRejected, got %v", err)
+	}
+	if n != 1 {
+		t.Errorf("hook ran %d times", n)
+	}
+}
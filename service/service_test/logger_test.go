@@ -0,0 +1,118 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"AbstractManager/service"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// captureHandler 记录日志条目的 slog.Handler
+type captureHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *captureHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r.Clone())
+	return nil
+}
+
+// attrs 返回条目的属性
+func attrs(r slog.Record) map[string]slog.Value {
+	out := make(map[string]slog.Value)
+	r.Attrs(func(a slog.Attr) bool {
+		out[a.Key] = a.Value
+		return true
+	})
+	return out
+}
+
+func TestGormLoggerTrace(t *testing.T) {
+	ctx := context.Background()
+	failed := errors.New("syntax error")
+	fast, slow := time.Now(), time.Now().Add(-time.Second)
+
+	cases := []struct {
+		name  string
+		level logger.LogLevel
+		begin time.Time
+		err   error
+		want  string // 期望的消息，空表示不记录
+		lvl   slog.Level
+	}{
+		{"silent error", logger.Silent, slow, failed, "", 0},
+		{"error", logger.Error, fast, failed, "sql error", slog.LevelError},
+		{"error level skips slow", logger.Error, slow, nil, "", 0},
+		{"error level skips sql", logger.Error, fast, nil, "", 0},
+		{"record not found ignored", logger.Error, fast, gorm.ErrRecordNotFound, "", 0},
+		{"slow", logger.Warn, slow, nil, "slow sql", slog.LevelWarn},
+		{"error wins over slow", logger.Warn, slow, failed, "sql error", slog.LevelError},
+		{"warn skips fast", logger.Warn, fast, nil, "", 0},
+		{"info", logger.Info, fast, nil, "sql", slog.LevelInfo},
+		{"info slow", logger.Info, slow, nil, "slow sql", slog.LevelWarn},
+		{"info record not found", logger.Info, fast, gorm.ErrRecordNotFound, "sql", slog.LevelInfo},
+	}
+	for _, tc := range cases {
+		h := &captureHandler{}
+		gl := service.NewGormLogger(slog.New(h), logger.Silent, 100*time.Millisecond).LogMode(tc.level)
+		calls := 0
+		fc := func() (string, int64) {
+			calls++
+			return "SELECT 1", 3
+		}
+		gl.Trace(ctx, tc.begin, fc, tc.err)
+
+		if tc.want == "" {
+			if len(h.records) != 0 || calls != 0 {
+				t.Errorf("%s: logged %d records, built SQL %d times", tc.name, len(h.records), calls)
+			}
+			continue
+		}
+		if len(h.records) != 1 {
+			t.Errorf("%s: logged %d records", tc.name, len(h.records))
+			continue
+		}
+		r := h.records[0]
+		a := attrs(r)
+		if r.Message != tc.want || r.Level != tc.lvl || a["sql"].String() != "SELECT 1" || a["rows"].Int64() != 3 || a["component"].String() != "gorm" {
+			t.Errorf("%s: record %q %v %v", tc.name, r.Message, r.Level, a)
+		}
+		if tc.want == "slow sql" && (a["threshold"].Duration() != 100*time.Millisecond || a["elapsed"].Duration() < time.Second) {
+			t.Errorf("%s: slow record attrs %v", tc.name, a)
+		}
+		if tc.want == "sql error" && a["error"].Any() != failed {
+			t.Errorf("%s: error attr %v", tc.name, a["error"])
+		}
+	}
+
+	// 慢查询阈值为 0 时不记录慢查询
+	h := &captureHandler{}
+	service.NewGormLogger(slog.New(h), logger.Warn, 0).Trace(ctx, slow, func() (string, int64) { return "", 0 }, nil)
+	if len(h.records) != 0 {
+		t.Errorf("slow query logged without threshold: %v", h.records)
+	}
+}
+
+func TestGormLoggerReportsRecordNotFound(t *testing.T) {
+	h := &captureHandler{}
+	gl := service.NewGormLogger(slog.New(h), logger.Error, 0)
+	gl.IgnoreRecordNotFoundError = false
+	gl.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 0 }, gorm.ErrRecordNotFound)
+	if len(h.records) != 1 || h.records[0].Message != "sql error" {
+		t.Errorf("record not found not logged: %v", h.records)
+	}
+}
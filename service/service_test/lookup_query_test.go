@@ -0,0 +1,66 @@
+package service_test
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"AbstractManager/service"
+)
+
+func TestLookupByIDs(t *testing.T) {
+	ctx := context.Background()
+	db := useFakeDB(t, accountTable(account{ID: 1, UserName: "ann"}, account{ID: 2, UserName: "bob"}, account{ID: 3, UserName: "stale"}))
+	_, server := useRedis(t)
+	server.Set("account_key:3", `{"id":3,"user_name":"cat"}`)
+	sm := service.NewServiceManager(account{})
+	sm.TableName = "accounts"
+
+	result, err := sm.LookupByIDs(ctx, []interface{}{3, 1, 99, 2, 1}, &service.LookupQueryOptions{CacheExpire: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 按输入顺序返回（重复 ID 只出现一次），缓存命中的行不回源
+	var names []string
+	for _, item := range result.Items {
+		names = append(names, item.UserName)
+	}
+	if !reflect.DeepEqual(names, []string{"cat", "ann", "bob"}) {
+		t.Errorf("items = %v", names)
+	}
+	if !reflect.DeepEqual(result.Missing, []interface{}{99}) {
+		t.Errorf("missing = %v", result.Missing)
+	}
+
+	// 未命中的 ID 用一次 IN 查询回源
+	statements := db.Statements()
+	if len(statements) != 1 || !strings.Contains(statements[0], "`id` IN (?,?,?)") {
+		t.Errorf("expected one IN query, got %q", statements)
+	}
+
+	// 回源的行通过一个 pipeline 回写，不存在的 ID 不写
+	if commands := server.Commands(); !reflect.DeepEqual(commands, []string{"MGET", "SET", "SET"}) {
+		t.Errorf("redis commands = %v", commands)
+	}
+	for _, key := range []string{"account_key:1", "account_key:2"} {
+		if _, ok := server.Get(key); !ok {
+			t.Errorf("%s not backfilled", key)
+		}
+	}
+	if slices.Contains(server.Keys(), "account_key:99") {
+		t.Error("missing id cached")
+	}
+
+	// 再次读取全部命中缓存
+	db.Reset()
+	if _, err := sm.LookupByIDs(ctx, []interface{}{1, 2, 3}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if statements := db.Statements(); len(statements) != 0 {
+		t.Errorf("cached lookup queried the database: %q", statements)
+	}
+}
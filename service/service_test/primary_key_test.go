@@ -0,0 +1,182 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"AbstractManager/service"
+	"AbstractManager/util/cache_key_builder"
+)
+
+type stock struct {
+	Tenant   string `gorm:"primaryKey" json:"tenant"`
+	SKU      string `gorm:"primaryKey;column:sku" json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+type coupon struct {
+	Code  string `gorm:"primaryKey" json:"code"`
+	Value int    `json:"value"`
+}
+
+type region struct {
+	ID   uint8  `gorm:"primaryKey" json:"id"`
+	Name string `json:"name"`
+}
+
+func TestPrimaryKeyValues(t *testing.T) {
+	stocks := service.NewServiceManager(stock{})
+	if names, err := stocks.PrimaryKeys(); err != nil || !reflect.DeepEqual(names, []string{"tenant", "sku"}) {
+		t.Fatalf("PrimaryKeys = %v, %v", names, err)
+	}
+
+	want := []interface{}{"acme", "A-1"}
+	for name, id := range map[string]interface{}{
+		"path segments": []string{"acme", "A-1"},
+		"map":           map[string]interface{}{"SKU": "A-1", "tenant": "acme"},
+		"model":         &stock{Tenant: "acme", SKU: "A-1", Quantity: 3},
+		"struct":        struct{ Tenant, Sku string }{"acme", "A-1"},
+	} {
+		got, err := stocks.PrimaryKeyValues(id)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: PrimaryKeyValues = %v, %v", name, got, err)
+		}
+	}
+
+	for name, id := range map[string]interface{}{
+		"scalar":      "acme",
+		"short slice": []string{"acme"},
+		"missing key": map[string]interface{}{"tenant": "acme"},
+		"nil":         nil,
+	} {
+		if _, err := stocks.PrimaryKeyValues(id); !errors.Is(err, service.ErrValidation) {
+			t.Errorf("%s: expected validation error, got %v", name, err)
+		}
+	}
+
+	// 整数主键接受路径段与 JSON 数字
+	accounts := service.NewServiceManager(account{})
+	for _, id := range []interface{}{"42", float64(42), 42, []string{"42"}} {
+		got, err := accounts.PrimaryKeyValues(id)
+		if err != nil || !reflect.DeepEqual(got, []interface{}{uint(42)}) {
+			t.Errorf("PrimaryKeyValues(%#v) = %v, %v", id, got, err)
+		}
+	}
+	if _, err := accounts.PrimaryKeyValues("abc"); !errors.Is(err, service.ErrValidation) {
+		t.Errorf("expected validation error for non-numeric id, got %v", err)
+	}
+
+	// 超出主键类型范围的值应报错而不是截断为另一行的主键
+	regions := service.NewServiceManager(region{})
+	if got, err := regions.PrimaryKeyValues(float64(255)); err != nil || !reflect.DeepEqual(got, []interface{}{uint8(255)}) {
+		t.Errorf("PrimaryKeyValues(255) = %v, %v", got, err)
+	}
+	for _, id := range []interface{}{"300", 300, float64(1e30), float64(-1)} {
+		if _, err := regions.PrimaryKeyValues(id); !errors.Is(err, service.ErrValidation) {
+			t.Errorf("PrimaryKeyValues(%#v): expected validation error, got %v", id, err)
+		}
+	}
+	if _, err := accounts.PrimaryKeyValues(float64(1e30)); !errors.Is(err, service.ErrValidation) {
+		t.Errorf("expected validation error for out-of-range float id, got %v", err)
+	}
+}
+
+func TestPrimaryKeyQueries(t *testing.T) {
+	useDryRunDB(t)
+	stocks := service.NewServiceManager(stock{})
+
+	byID, err := stocks.PrimaryKeyQuery([]string{"acme", "A-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := byID(service.GetDB().Model(&stock{})).Find(&[]stock{}).Statement
+	if sql := stmt.SQL.String(); !strings.HasSuffix(sql, "WHERE `tenant` = ? AND `sku` = ?") {
+		t.Errorf("unexpected SQL: %s", sql)
+	}
+
+	byIDs, err := stocks.PrimaryKeysQuery([]interface{}{[]string{"acme", "A-1"}, map[string]interface{}{"tenant": "beta", "sku": "B-2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt = byIDs(service.GetDB().Model(&stock{})).Find(&[]stock{}).Statement
+	if sql := stmt.SQL.String(); !strings.Contains(sql, "(`tenant` = ? AND `sku` = ?) OR (`tenant` = ? AND `sku` = ?)") {
+		t.Errorf("unexpected SQL: %s", sql)
+	}
+	if want := []interface{}{"acme", "A-1", "beta", "B-2"}; !reflect.DeepEqual(stmt.Vars, want) {
+		t.Errorf("Vars = %v, want %v", stmt.Vars, want)
+	}
+
+	coupons := service.NewServiceManager(coupon{})
+	byIDs, err = coupons.PrimaryKeysQuery([]interface{}{"SAVE10", "SAVE20"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt = byIDs(service.GetDB().Model(&coupon{})).Find(&[]coupon{}).Statement
+	if sql := stmt.SQL.String(); !strings.Contains(sql, "`code` IN (?,?)") {
+		t.Errorf("unexpected SQL: %s", sql)
+	}
+}
+
+func TestCacheKeyByID(t *testing.T) {
+	ctx := context.Background()
+	stocks := service.NewServiceManager(stock{})
+
+	key, err := stocks.CacheKeyByID(ctx, map[string]string{"tenant": "acme", "sku": "A-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "stock_key:acme:A-1"; key != want {
+		t.Errorf("CacheKeyByID = %q, want %q", key, want)
+	}
+	if template, _ := stocks.CacheKeyTemplate(ctx); template != "stock_key:{tenant}:{sku}" {
+		t.Errorf("CacheKeyTemplate = %q", template)
+	}
+
+	// 行的默认缓存键与按主键生成的键一致
+	rowKey, err := stocks.CacheKey(ctx, &stock{Tenant: "acme", SKU: "A-1"})
+	if err != nil || rowKey != key {
+		t.Errorf("CacheKey = %q, %v; want %q", rowKey, err, key)
+	}
+}
+
+func TestCacheKeyByIDEscapesSeparators(t *testing.T) {
+	ctx := context.Background()
+	stocks := service.NewServiceManager(stock{})
+
+	a, err := stocks.CacheKeyByID(ctx, []string{"acme:eu", "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := stocks.CacheKeyByID(ctx, []string{"acme", "eu:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Fatalf("different primary keys share cache key %q", a)
+	}
+	if want := "stock_key:acme%3Aeu:1"; a != want {
+		t.Errorf("CacheKeyByID = %q, want %q", a, want)
+	}
+
+	// 转义的键可以按模板还原出原来的主键
+	template, err := stocks.CacheKeyTemplate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser := cache_key_builder.MustKeyParser[stock](template).EscapeValues()
+	for key, want := range map[string][2]string{a: {"acme:eu", "1"}, b: {"acme", "eu:1"}} {
+		got, err := parser.Parse(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got["tenant"] != want[0] || got["sku"] != want[1] {
+			t.Errorf("Parse(%q) = %v, want %v", key, got, want)
+		}
+		if built := parser.BuildKey(&stock{Tenant: want[0], SKU: want[1]}); built != key {
+			t.Errorf("BuildKey = %q, want %q", built, key)
+		}
+	}
+}
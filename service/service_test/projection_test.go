@@ -0,0 +1,49 @@
+package service_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"AbstractManager/service"
+)
+
+func TestProjection(t *testing.T) {
+	sm := service.NewServiceManager(account{}).SetColumnPolicy(service.ColumnPolicy{
+		Select: service.FieldList{Deny: []string{"UserName"}},
+	})
+
+	if p, err := sm.NewProjection(nil); p != nil || err != nil {
+		t.Fatalf("empty fields: %v, %v", p, err)
+	}
+
+	p, err := sm.NewProjection([]string{"balance", "ID", "balance_cents"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.Columns, []string{"balance_cents", "id"}) || !reflect.DeepEqual(p.Fields, []string{"balance", "id"}) {
+		t.Errorf("projection = %+v", p)
+	}
+
+	// json:"-" 的字段不会出现在输出中，被策略禁止的字段不可选
+	for _, name := range []string{"password", "user_name", "nope"} {
+		if _, err := sm.NewProjection([]string{name}); !errors.Is(err, service.ErrValidation) {
+			t.Errorf("NewProjection(%q) error = %v, want ErrValidation", name, err)
+		}
+	}
+
+	out, err := service.ProjectMap(p, map[string]*account{
+		"account:1": {ID: 1, UserName: "tom", Balance: 100},
+		"account:2": nil,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := out["account:1"]
+	if len(got) != 2 || string(got["id"]) != "1" || string(got["balance"]) != "100" {
+		t.Errorf("projected = %s", got)
+	}
+	if v, ok := out["account:2"]; !ok || v != nil {
+		t.Errorf("nil value should stay nil, got %v", v)
+	}
+}
@@ -0,0 +1,291 @@
+package service_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"AbstractManager/service"
+	"AbstractManager/util/filter_translator"
+
+	"gorm.io/gorm"
+)
+
+func leaf(field, operator string, value interface{}) filter_translator.FilterNode {
+	return filter_translator.FilterNode{FilterParam: filter_translator.FilterParam{Field: field, Operator: operator, Value: value}}
+}
+
+func TestQueryFingerprintNormalizes(t *testing.T) {
+	sm := service.NewServiceManager(account{})
+	opts := &service.QueryOptions{Page: 1, PageSize: 20, Sorts: []service.SortParam{{Field: "balance", Direction: "desc"}}}
+
+	positive := leaf("balance_cents", ">", 10)
+	a := &filter_translator.FilterNode{And: []filter_translator.FilterNode{
+		leaf("user_name", "=", "bob"),
+		{And: []filter_translator.FilterNode{
+			leaf("id", "in", []interface{}{3, 1, 2}),
+			{Not: &filter_translator.FilterNode{Not: &positive}},
+		}},
+	}}
+	b := &filter_translator.FilterNode{And: []filter_translator.FilterNode{
+		leaf("balance_cents", ">", 10),
+		leaf("id", "in", []interface{}{1, 2, 3, 2}),
+		leaf("user_name", "=", "bob"),
+	}}
+
+	want, err := sm.QueryFingerprint("list", a, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := sm.QueryFingerprint("list", b, &service.QueryOptions{
+		Page: 1, PageSize: 20, Sorts: []service.SortParam{{Field: "Balance", Direction: "DESC"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("equivalent queries have different fingerprints: %s != %s", got, want)
+	}
+
+	// 方法名、条件、排序、分页、搜索任一不同都得到不同的哈希
+	variants := map[string]func() (string, error){
+		"method": func() (string, error) { return sm.QueryFingerprint("active_list", a, opts) },
+		"filter": func() (string, error) {
+			return sm.QueryFingerprint("list", &filter_translator.FilterNode{Or: b.And}, opts)
+		},
+		"sort": func() (string, error) {
+			return sm.QueryFingerprint("list", a, &service.QueryOptions{Page: 1, PageSize: 20, Sorts: []service.SortParam{{Field: "balance"}}})
+		},
+		"page": func() (string, error) {
+			return sm.QueryFingerprint("list", a, &service.QueryOptions{Page: 2, PageSize: 20, Sorts: opts.Sorts})
+		},
+		"search": func() (string, error) {
+			return sm.QueryFingerprint("list", a, &service.QueryOptions{
+				Page: 1, PageSize: 20, Sorts: opts.Sorts,
+				Search: &service.SearchOptions{Query: "bob", Fields: []string{"user_name"}},
+			})
+		},
+	}
+	for name, fingerprint := range variants {
+		got, err := fingerprint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == want {
+			t.Errorf("%s change did not change the fingerprint", name)
+		}
+	}
+}
+
+func TestQueryFingerprintRejectsInvalidSort(t *testing.T) {
+	sm := service.NewServiceManager(account{})
+	_, err := sm.QueryFingerprint("list", nil, &service.QueryOptions{Sorts: []service.SortParam{{Field: "missing"}}})
+	if err == nil {
+		t.Error("expected error for unknown sort field")
+	}
+}
+
+func TestGetQueryCachedWithoutRedis(t *testing.T) {
+	statements := useDryRunDB(t)
+	sm := service.NewServiceManager(account{}).EnableQueryCache(service.QueryCacheOptions{})
+	sm.TableName = "accounts"
+
+	// Redis 未初始化时直接查询数据库
+	result, err := sm.GetQueryCached(context.Background(), "list", nil, nil, &service.QueryOptions{Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Cached {
+		t.Error("result should not come from cache")
+	}
+	if len(*statements) == 0 {
+		t.Error("expected database query")
+	}
+}
+
+// accountTable 返回按 accounts 表回答查询的回调：count 查询返回行数，带参数的查询按主键过滤
+func accountTable(rows ...account) func(string, []driver.Value) ([]string, [][]driver.Value) {
+	return func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		if strings.Contains(query, "count(") {
+			return []string{"count(*)"}, [][]driver.Value{{int64(len(rows))}}
+		}
+		ids := make(map[int64]bool, len(args))
+		for _, a := range args {
+			if id, ok := a.(int64); ok {
+				ids[id] = true
+			}
+		}
+		var out [][]driver.Value
+		for _, r := range rows {
+			if strings.Contains(query, "`id`") && !ids[int64(r.ID)] {
+				continue
+			}
+			out = append(out, []driver.Value{int64(r.ID), r.UserName, r.Balance})
+		}
+		return []string{"id", "user_name", "balance_cents"}, out
+	}
+}
+
+// countQueries 统计语句中的查询数
+func countQueries(statements []string) int {
+	n := 0
+	for _, s := range statements {
+		if strings.HasPrefix(s, "SELECT") {
+			n++
+		}
+	}
+	return n
+}
+
+func TestGetQueryCachedInvalidatedByWrite(t *testing.T) {
+	ctx := context.Background()
+	db := useFakeDB(t, accountTable(account{ID: 1, UserName: "ann"}, account{ID: 2, UserName: "bob"}))
+	useRedis(t)
+	sm := service.NewServiceManager(account{}).EnableQueryCache(service.QueryCacheOptions{})
+	sm.TableName = "accounts"
+	opts := &service.QueryOptions{Page: 1, PageSize: 10}
+
+	// 未命中：查询数据库并缓存结果
+	result, err := sm.GetQueryCached(ctx, "list", nil, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Cached || len(result.Data) != 2 || result.Total != 2 {
+		t.Fatalf("first read = %+v", result)
+	}
+
+	// 命中：不再访问数据库
+	db.Reset()
+	result, err = sm.GetQueryCached(ctx, "list", nil, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Cached || len(result.Data) != 2 || result.Data[1].UserName != "bob" {
+		t.Fatalf("second read = %+v", result)
+	}
+	if n := countQueries(db.Statements()); n != 0 {
+		t.Errorf("cache hit ran %d queries", n)
+	}
+
+	// 写入提交后代数推进，同一请求不再命中
+	if err := sm.UpdateByID(ctx, 1, map[string]interface{}{"user_name": "amy"}); err != nil {
+		t.Fatal(err)
+	}
+	db.Reset()
+	result, err = sm.GetQueryCached(ctx, "list", nil, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Cached {
+		t.Error("result cached across a write")
+	}
+	if n := countQueries(db.Statements()); n != 2 {
+		t.Errorf("expected count + select after write, got %d queries", n)
+	}
+}
+
+func TestGetQueryCachedHydratesMissingItems(t *testing.T) {
+	ctx := context.Background()
+	db := useFakeDB(t, accountTable(account{ID: 1, UserName: "ann"}, account{ID: 2, UserName: "bob"}))
+	client, _ := useRedis(t)
+	sm := service.NewServiceManager(account{}).EnableQueryCache(service.QueryCacheOptions{})
+	sm.TableName = "accounts"
+	opts := &service.QueryOptions{Page: 1, PageSize: 10}
+
+	if _, err := sm.GetQueryCached(ctx, "list", nil, nil, opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Del(ctx, "account_key:2").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 结果命中，缺失的行按主键回源并回写
+	db.Reset()
+	result, err := sm.GetQueryCached(ctx, "list", nil, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Cached || len(result.Data) != 2 || result.Data[0].ID != 1 || result.Data[1].ID != 2 {
+		t.Fatalf("hydrated read = %+v", result)
+	}
+	statements := db.Statements()
+	if len(statements) != 1 || !strings.Contains(statements[0], "`id` = ?") {
+		t.Errorf("expected one primary key query, got %q", statements)
+	}
+	if n, err := client.Exists(ctx, "account_key:2").Result(); err != nil || n != 1 {
+		t.Errorf("missing item not backfilled: %d, %v", n, err)
+	}
+}
+
+func TestGetQueryCachedSkipsStoreWhenGenerationMoves(t *testing.T) {
+	ctx := context.Background()
+	useFakeDB(t, accountTable(account{ID: 1, UserName: "ann"}, account{ID: 2, UserName: "bob"}))
+	client, server := useRedis(t)
+	sm := service.NewServiceManager(account{}).EnableQueryCache(service.QueryCacheOptions{})
+	sm.TableName = "accounts"
+	opts := &service.QueryOptions{Page: 1, PageSize: 10}
+
+	// 读取期间有写入提交：代数推进，读到的行可能早于该写入
+	server.Set("account_key:1", `{"id":1,"user_name":"cached"}`)
+	overlapping := func(db *gorm.DB) *gorm.DB {
+		if err := sm.InvalidateQueryCache(ctx); err != nil {
+			t.Error(err)
+		}
+		return db
+	}
+	if _, err := sm.GetQueryCached(ctx, "list", nil, overlapping, opts); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := server.Get("account_key:1"); !strings.Contains(v, "cached") {
+		t.Errorf("item written although the generation moved: %s", v)
+	}
+	if keys, _ := client.Keys(ctx, "qcache:account_key:0:*").Result(); len(keys) != 0 {
+		t.Errorf("result stored under a stale generation: %v", keys)
+	}
+	if n, _ := client.Exists(ctx, "account_key:2").Result(); n != 0 {
+		t.Error("items backfilled although the generation moved")
+	}
+
+	// 代数不变时用读到的行覆盖单条缓存
+	if _, err := sm.GetQueryCached(ctx, "list", nil, nil, opts); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := server.Get("account_key:1"); !strings.Contains(v, "ann") {
+		t.Errorf("existing item not refreshed: %s", v)
+	}
+	if _, ok := server.Get("account_key:2"); !ok {
+		t.Error("missing item not backfilled")
+	}
+}
+
+func TestGetQueryCachedServesUpdatedRows(t *testing.T) {
+	ctx := context.Background()
+	rows := []account{{ID: 1, UserName: "ann"}, {ID: 2, UserName: "bob"}}
+	useFakeDB(t, func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		return accountTable(rows...)(query, args)
+	})
+	useRedis(t)
+	sm := service.NewServiceManager(account{}).EnableQueryCache(service.QueryCacheOptions{})
+	sm.TableName = "accounts"
+	opts := &service.QueryOptions{Page: 1, PageSize: 10}
+
+	if _, err := sm.GetQueryCached(ctx, "list", nil, nil, opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.UpdateByID(ctx, 1, map[string]interface{}{"user_name": "amy"}); err != nil {
+		t.Fatal(err)
+	}
+	rows[0].UserName = "amy"
+
+	// 写入后的首次读取回源并重写单条缓存，之后命中时补齐的是新行
+	for _, cached := range []bool{false, true} {
+		result, err := sm.GetQueryCached(ctx, "list", nil, nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Cached != cached || len(result.Data) != 2 || result.Data[0].UserName != "amy" {
+			t.Errorf("read after update (cached=%v) = %+v", cached, result)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package service_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"AbstractManager/service"
+)
+
+type article struct {
+	ID    uint   `gorm:"primaryKey" json:"id"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Views int    `json:"views"`
+}
+
+func TestSearchLike(t *testing.T) {
+	statements := useDryRunDB(t)
+	sm := service.NewServiceManager(article{})
+	sm.TableName = "articles"
+
+	_, err := sm.GetQueryWithoutTransaction(context.Background(), nil, &service.QueryOptions{
+		Sorts: []service.SortParam{{Field: "views", Direction: "desc"}},
+		Search: &service.SearchOptions{
+			Query:            "go 100%",
+			Fields:           []string{"title", "body"},
+			OrderByRelevance: true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sql := (*statements)[len(*statements)-1]
+	for _, want := range []string{
+		"WHERE (`title` LIKE ? OR `body` LIKE ?) AND (`title` LIKE ? OR `body` LIKE ?)",
+		"ORDER BY (CASE WHEN `title` LIKE ? THEN 1 ELSE 0 END + CASE WHEN `body` LIKE ? THEN 1 ELSE 0 END + " +
+			"CASE WHEN `title` LIKE ? THEN 1 ELSE 0 END + CASE WHEN `body` LIKE ? THEN 1 ELSE 0 END) DESC,`views` DESC",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("SQL %q does not contain %q", sql, want)
+		}
+	}
+}
+
+func TestSearchFulltext(t *testing.T) {
+	statements := useDryRunDB(t)
+	sm := service.NewServiceManager(article{}).DeclareIndexes(service.Index{Name: "ft_article", Columns: []string{"body", "title"}, Fulltext: true})
+	sm.TableName = "articles"
+
+	_, err := sm.GetQueryWithoutTransaction(context.Background(), nil, &service.QueryOptions{
+		Search: &service.SearchOptions{Query: "gorm search", Fields: []string{"title", "body"}, OrderByRelevance: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sql := (*statements)[len(*statements)-1]
+	for _, want := range []string{
+		"WHERE MATCH (`title`,`body`) AGAINST (? IN NATURAL LANGUAGE MODE)",
+		"ORDER BY MATCH (`title`,`body`) AGAINST (? IN NATURAL LANGUAGE MODE) DESC",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("SQL %q does not contain %q", sql, want)
+		}
+	}
+
+	// 搜索字段与索引不一致时退回 LIKE
+	_, err = sm.GetQueryWithoutTransaction(context.Background(), nil, &service.QueryOptions{
+		Search: &service.SearchOptions{Query: "gorm", Fields: []string{"title"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql := (*statements)[len(*statements)-1]; !strings.Contains(sql, "`title` LIKE ?") {
+		t.Errorf("expected LIKE fallback, got %q", sql)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	sm := service.NewServiceManager(article{})
+	items := []article{
+		{Title: "Learning Go <fast>", Body: "nothing here"},
+		{Title: "Rust", Body: "GOrm and go"},
+	}
+	highlights, err := sm.Highlight(items, &service.SearchOptions{Query: "go gorm", Fields: []string{"title", "body"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := highlights[0]["title"]; got != "Learning <em>Go</em> &lt;fast&gt;" {
+		t.Errorf("title highlight = %q", got)
+	}
+	if _, ok := highlights[0]["body"]; ok {
+		t.Error("unmatched field highlighted")
+	}
+	if got := highlights[1]["body"]; got != "<em>GOrm</em> and <em>go</em>" {
+		t.Errorf("body highlight = %q", got)
+	}
+}
+
+// post 通过 gorm 标签声明 FULLTEXT 索引
+type post struct {
+	ID    uint   `gorm:"primaryKey" json:"id"`
+	Title string `gorm:"index:ft_post,class:FULLTEXT" json:"title"`
+	Body  string `gorm:"index:ft_post,class:FULLTEXT" json:"body"`
+	Slug  string `gorm:"index:idx_slug" json:"slug"`
+}
+
+func TestTagFulltextIndex(t *testing.T) {
+	ctx := context.Background()
+	// 表与索引都已存在：只登记索引
+	useFakeDB(t, func(query string, _ []driver.Value) ([]string, [][]driver.Value) {
+		if strings.Contains(query, "DATABASE()") {
+			return []string{"DATABASE()"}, [][]driver.Value{{"db"}}
+		}
+		return []string{"count(*)"}, [][]driver.Value{{int64(1)}}
+	})
+	sm := service.NewServiceManager(post{})
+	sm.TableName = "posts"
+
+	err := sm.CreateWithIndexes(ctx, &service.CreateOptions{IfNotExists: true}, []service.Index{{Name: "ft_post", Fulltext: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 标签中不存在或不是 FULLTEXT 的索引被拒绝
+	for _, idx := range []service.Index{{Name: "idx_slug", Fulltext: true}, {Name: "missing", Fulltext: true}} {
+		if err := sm.CreateWithIndexes(ctx, &service.CreateOptions{IfNotExists: true}, []service.Index{idx}); err == nil {
+			t.Errorf("%s: expected error", idx.Name)
+		}
+	}
+
+	statements := useDryRunDB(t)
+	if _, err := sm.GetQueryWithoutTransaction(ctx, nil, &service.QueryOptions{
+		Search: &service.SearchOptions{Query: "gorm", Fields: []string{"title", "body"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if sql := (*statements)[len(*statements)-1]; !strings.Contains(sql, "MATCH (`title`,`body`) AGAINST") {
+		t.Errorf("tag index not used for search: %q", sql)
+	}
+
+	// DeclareIndexes 同样按标签解析
+	declared := service.NewServiceManager(post{}).DeclareIndexes(service.Index{Name: "ft_post", Fulltext: true})
+	declared.TableName = "posts"
+	if _, err := declared.GetQueryWithoutTransaction(ctx, nil, &service.QueryOptions{
+		Search: &service.SearchOptions{Query: "gorm", Fields: []string{"body", "title"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if sql := (*statements)[len(*statements)-1]; !strings.Contains(sql, "MATCH (`body`,`title`) AGAINST") {
+		t.Errorf("declared tag index not used for search: %q", sql)
+	}
+}
@@ -0,0 +1,52 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"AbstractManager/service"
+)
+
+func TestGetQuerySorts(t *testing.T) {
+	statements := useDryRunDB(t)
+	sm := service.NewServiceManager(account{})
+	sm.TableName = "accounts"
+
+	_, err := sm.GetQueryWithoutTransaction(context.Background(), nil, &service.QueryOptions{
+		Sorts: []service.SortParam{
+			{Field: "balance", Direction: "desc", Nulls: "last"},
+			{Field: "user_name"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sql := (*statements)[len(*statements)-1]
+	want := "ORDER BY `balance_cents` IS NULL,`balance_cents` DESC,`user_name`"
+	if !strings.Contains(sql, want) {
+		t.Errorf("SQL %q does not contain %q", sql, want)
+	}
+}
+
+func TestGetQuerySortsRejected(t *testing.T) {
+	useDryRunDB(t)
+	sm := service.NewServiceManager(account{}).SetColumnPolicy(service.ColumnPolicy{
+		Sort: service.FieldList{Allow: []string{"id"}},
+	})
+
+	cases := []service.SortParam{
+		{Field: "user_name"},                  // 不在排序白名单
+		{Field: "id", Direction: "sideways"},  // 非法方向
+		{Field: "id", Nulls: "middle"},        // 非法空值位置
+		{Field: "id) DESC; DROP TABLE x; --"}, // 注入尝试
+	}
+	for _, s := range cases {
+		_, err := sm.GetQueryWithoutTransaction(context.Background(), nil, &service.QueryOptions{Sorts: []service.SortParam{s}})
+		if !errors.Is(err, service.ErrValidation) {
+			t.Errorf("sort %+v: error = %v, want ErrValidation", s, err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"AbstractManager/service"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// useExporter 安装内存 exporter 作为全局 TracerProvider，测试结束后恢复
+func useExporter(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previous)
+	})
+	return exporter
+}
+
+func TestWritedownSingleAsyncSpanCoversWrite(t *testing.T) {
+	exporter := useExporter(t)
+	_, server := useRedis(t)
+	sm := service.NewServiceManager(account{})
+
+	sm.WritedownSingleAsync(context.Background(), "account_key:1", &account{ID: 1, UserName: "ann"}, time.Minute)
+
+	// 异步 span 在写入完成后才结束
+	deadline := time.Now().Add(5 * time.Second)
+	var parent, child *tracetest.SpanStub
+	for parent == nil && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		for _, s := range exporter.GetSpans() {
+			switch s.Name {
+			case "ServiceManager.WritedownSingleAsync":
+				parent = &s
+			case "ServiceManager.WritedownSingle":
+				child = &s
+			}
+		}
+	}
+	if parent == nil || child == nil {
+		t.Fatalf("spans not exported: parent %v, child %v", parent != nil, child != nil)
+	}
+	if child.Parent.SpanID() != parent.SpanContext.SpanID() {
+		t.Error("async write is not a child of the async span")
+	}
+	if parent.EndTime.Before(child.EndTime) {
+		t.Errorf("async span ended at %v before the write finished at %v", parent.EndTime, child.EndTime)
+	}
+	if _, ok := server.Get("account_key:1"); !ok {
+		t.Error("item not written")
+	}
+}
@@ -2,8 +2,10 @@ package service
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"log/slog"
+
+	"AbstractManager/util/tracing"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -21,7 +23,10 @@ func (sm *ServiceManager[T]) SetQuery(
 	ctx context.Context,
 	data []T,
 	opts *SetQueryOptions,
-) error {
+) (err error) {
+	ctx, span := sm.startSpan(ctx, "SetQuery")
+	defer func() { tracing.End(span, err) }()
+
 	if len(data) == 0 {
 		return nil
 	}
@@ -36,7 +41,8 @@ func (sm *ServiceManager[T]) SetQuery(
 	}
 
 	// 使用 Transaction 闭包自动管理提交和回滚
-	err := GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	op := &Operation[T]{Kind: OpSet, Method: "SetQuery", Items: data}
+	err = sm.writeOp(ctx, op, func(tx *gorm.DB) error {
 		tx = sm.applyTableName(tx)
 
 		batchSize := opts.BatchSize
@@ -44,6 +50,7 @@ func (sm *ServiceManager[T]) SetQuery(
 			batchSize = 100
 		}
 
+		data := op.Items
 		for i := 0; i < len(data); i += batchSize {
 			end := i + batchSize
 			if end > len(data) {
@@ -62,7 +69,7 @@ func (sm *ServiceManager[T]) SetQuery(
 			}
 		}
 		return nil
-	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	})
 
 	if err != nil {
 		return fmt.Errorf("set query failed: %w", err)
@@ -70,8 +77,9 @@ func (sm *ServiceManager[T]) SetQuery(
 
 	// 使缓存失效
 	if opts.InvalidateCache {
-		if err := sm.invalidateCacheForBatch(ctx, data); err != nil {
-			fmt.Printf("warning: failed to invalidate cache: %v\n", err)
+		if err := sm.invalidateCacheForBatch(ctx, op.Items); err != nil {
+			sm.GetLogger().WarnContext(ctx, "failed to invalidate cache",
+				slog.String("operation", "SetQuery"), slog.Any("error", err))
 		}
 	}
 
@@ -83,23 +91,26 @@ func (sm *ServiceManager[T]) BatchUpdate(
 	ctx context.Context,
 	updates map[string]interface{},
 	queryFunc func(*gorm.DB) *gorm.DB,
-) (int64, error) {
-	var rowsAffected int64
-	err := GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+) (_ int64, err error) {
+	ctx, span := sm.startSpan(ctx, "BatchUpdate")
+	defer func() { tracing.End(span, err) }()
+
+	op := &Operation[T]{Kind: OpUpdate, Method: "BatchUpdate", Query: queryFunc, Updates: updates}
+	err = sm.writeOp(ctx, op, func(tx *gorm.DB) error {
 		tx = sm.applyTableName(tx)
-		if queryFunc != nil {
-			tx = queryFunc(tx)
+		if op.Query != nil {
+			tx = op.Query(tx)
 		}
 
-		result := tx.Model(&sm.Resource).Updates(updates)
+		result := tx.Model(&sm.Resource).Updates(op.Updates)
 		if result.Error != nil {
 			return result.Error
 		}
-		rowsAffected = result.RowsAffected
+		op.RowsAffected = result.RowsAffected
 		return nil
-	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	})
 
-	return rowsAffected, err
+	return op.RowsAffected, err
 }
 
 // BatchUpsert 批量 Upsert 操作
@@ -109,17 +120,22 @@ func (sm *ServiceManager[T]) BatchUpsert(
 	conflictColumns []string,
 	updateColumns []string,
 	batchSize int,
-) error {
+) (err error) {
+	ctx, span := sm.startSpan(ctx, "BatchUpsert")
+	defer func() { tracing.End(span, err) }()
+
 	if len(data) == 0 {
 		return nil
 	}
 
-	return GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	op := &Operation[T]{Kind: OpSet, Method: "BatchUpsert", Items: data}
+	return sm.writeOp(ctx, op, func(tx *gorm.DB) error {
 		tx = sm.applyTableName(tx)
 		if batchSize <= 0 {
 			batchSize = 100
 		}
 
+		data := op.Items
 		for i := 0; i < len(data); i += batchSize {
 			end := i + batchSize
 			if end > len(data) {
@@ -141,30 +157,33 @@ func (sm *ServiceManager[T]) BatchUpsert(
 			}
 		}
 		return nil
-	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	})
 }
 
 // BatchDelete 批量删除数据
 func (sm *ServiceManager[T]) BatchDelete(
 	ctx context.Context,
 	queryFunc func(*gorm.DB) *gorm.DB,
-) (int64, error) {
-	var rowsAffected int64
-	err := GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+) (_ int64, err error) {
+	ctx, span := sm.startSpan(ctx, "BatchDelete")
+	defer func() { tracing.End(span, err) }()
+
+	op := &Operation[T]{Kind: OpDelete, Method: "BatchDelete", Query: queryFunc}
+	err = sm.writeOp(ctx, op, func(tx *gorm.DB) error {
 		tx = sm.applyTableName(tx)
-		if queryFunc != nil {
-			tx = queryFunc(tx)
+		if op.Query != nil {
+			tx = op.Query(tx)
 		}
 
 		result := tx.Delete(&sm.Resource)
 		if result.Error != nil {
 			return result.Error
 		}
-		rowsAffected = result.RowsAffected
+		op.RowsAffected = result.RowsAffected
 		return nil
-	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	})
 
-	return rowsAffected, err
+	return op.RowsAffected, err
 }
 
 // BatchIncrement 批量增加字段值
@@ -173,23 +192,30 @@ func (sm *ServiceManager[T]) BatchIncrement(
 	column string,
 	value interface{},
 	queryFunc func(*gorm.DB) *gorm.DB,
-) (int64, error) {
-	var rowsAffected int64
-	err := GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+) (_ int64, err error) {
+	ctx, span := sm.startSpan(ctx, "BatchIncrement")
+	defer func() { tracing.End(span, err) }()
+
+	op := &Operation[T]{Kind: OpUpdate, Method: "BatchIncrement", Query: queryFunc, Column: column, Value: value}
+	err = sm.writeOp(ctx, op, func(tx *gorm.DB) error {
 		tx = sm.applyTableName(tx)
-		if queryFunc != nil {
-			tx = queryFunc(tx)
+		if op.Query != nil {
+			tx = op.Query(tx)
 		}
 
-		result := tx.Model(&sm.Resource).UpdateColumn(column, gorm.Expr(fmt.Sprintf("%s + ?", column), value))
+		col, err := sm.ResolveColumn(op.Column, UsageUpdate)
+		if err != nil {
+			return err
+		}
+		result := tx.Model(&sm.Resource).UpdateColumn(col, gorm.Expr("? + ?", clause.Column{Name: col}, op.Value))
 		if result.Error != nil {
 			return result.Error
 		}
-		rowsAffected = result.RowsAffected
+		op.RowsAffected = result.RowsAffected
 		return nil
-	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	})
 
-	return rowsAffected, err
+	return op.RowsAffected, err
 }
 
 // BatchDecrement 批量减少字段值 (复用 Increment 逻辑)
@@ -198,33 +224,46 @@ func (sm *ServiceManager[T]) BatchDecrement(
 	column string,
 	value interface{},
 	queryFunc func(*gorm.DB) *gorm.DB,
-) (int64, error) {
+) (_ int64, err error) {
+	ctx, span := sm.startSpan(ctx, "BatchDecrement")
+	defer func() { tracing.End(span, err) }()
+
 	// 减量可以直接调用加量传入负值，或者保持原样
-	var rowsAffected int64
-	err := GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	op := &Operation[T]{Kind: OpUpdate, Method: "BatchDecrement", Query: queryFunc, Column: column, Value: value}
+	err = sm.writeOp(ctx, op, func(tx *gorm.DB) error {
 		tx = sm.applyTableName(tx)
-		if queryFunc != nil {
-			tx = queryFunc(tx)
+		if op.Query != nil {
+			tx = op.Query(tx)
 		}
 
-		result := tx.Model(&sm.Resource).UpdateColumn(column, gorm.Expr(fmt.Sprintf("%s - ?", column), value))
+		col, err := sm.ResolveColumn(op.Column, UsageUpdate)
+		if err != nil {
+			return err
+		}
+		result := tx.Model(&sm.Resource).UpdateColumn(col, gorm.Expr("? - ?", clause.Column{Name: col}, op.Value))
 		if result.Error != nil {
 			return result.Error
 		}
-		rowsAffected = result.RowsAffected
+		op.RowsAffected = result.RowsAffected
 		return nil
-	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	})
 
-	return rowsAffected, err
+	return op.RowsAffected, err
 }
 
 // --- 以下为未变动的辅助方法 ---
 
-func (sm *ServiceManager[T]) BatchInsert(ctx context.Context, data []T, batchSize int) error {
+func (sm *ServiceManager[T]) BatchInsert(ctx context.Context, data []T, batchSize int) (err error) {
+	ctx, span := sm.startSpan(ctx, "BatchInsert")
+	defer func() { tracing.End(span, err) }()
+
 	return sm.SetQuery(ctx, data, &SetQueryOptions{BatchSize: batchSize, OnConflictUpdate: false, InvalidateCache: false})
 }
 
-func (sm *ServiceManager[T]) BatchSoftDelete(ctx context.Context, queryFunc func(*gorm.DB) *gorm.DB) (int64, error) {
+func (sm *ServiceManager[T]) BatchSoftDelete(ctx context.Context, queryFunc func(*gorm.DB) *gorm.DB) (_ int64, err error) {
+	ctx, span := sm.startSpan(ctx, "BatchSoftDelete")
+	defer func() { tracing.End(span, err) }()
+
 	updates := map[string]interface{}{"deleted_at": gorm.Expr("NOW()")}
 	return sm.BatchUpdate(ctx, updates, queryFunc)
 }
@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
+
+	"AbstractManager/util/tracing"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -21,7 +24,10 @@ func (sm *ServiceManager[T]) SetSingle(
 	ctx context.Context,
 	data *T,
 	opts *SetSingleOptions,
-) error {
+) (err error) {
+	ctx, span := sm.startSpan(ctx, "SetSingle")
+	defer func() { tracing.End(span, err) }()
+
 	// 设置默认选项
 	if opts == nil {
 		opts = &SetSingleOptions{
@@ -32,16 +38,17 @@ func (sm *ServiceManager[T]) SetSingle(
 	}
 
 	// 开启事务闭包
-	err := GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	op := &Operation[T]{Kind: OpSet, Method: "SetSingle", Data: data}
+	err = sm.writeOp(ctx, op, func(tx *gorm.DB) error {
 		tx = sm.applyTableName(tx)
 
 		if opts.OnConflictUpdate {
 			// 使用 Upsert 操作
-			return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(data).Error
+			return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(op.Data).Error
 		}
 		// 仅插入
-		return tx.Create(data).Error
-	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+		return tx.Create(op.Data).Error
+	})
 
 	if err != nil {
 		return fmt.Errorf("set single failed: %w", err)
@@ -49,8 +56,9 @@ func (sm *ServiceManager[T]) SetSingle(
 
 	// 使缓存失效
 	if opts.InvalidateCache {
-		if err := sm.invalidateCacheForSingle(ctx, data); err != nil {
-			fmt.Printf("warning: failed to invalidate cache: %v\n", err)
+		if err := sm.invalidateCacheForSingle(ctx, op.Data); err != nil {
+			sm.GetLogger().WarnContext(ctx, "failed to invalidate cache",
+				slog.String("operation", "SetSingle"), slog.Any("error", err))
 		}
 	}
 
@@ -62,24 +70,36 @@ func (sm *ServiceManager[T]) Update(
 	ctx context.Context,
 	updates map[string]interface{},
 	queryFunc func(*gorm.DB) *gorm.DB,
-) error {
-	return GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+) (err error) {
+	ctx, span := sm.startSpan(ctx, "Update")
+	defer func() { tracing.End(span, err) }()
+
+	op := &Operation[T]{Kind: OpUpdate, Method: "Update", Query: queryFunc, Updates: updates}
+	return sm.writeOp(ctx, op, func(tx *gorm.DB) error {
 		tx = sm.applyTableName(tx)
 
-		if queryFunc != nil {
-			tx = queryFunc(tx)
+		if op.Query != nil {
+			tx = op.Query(tx)
 		}
 
-		return tx.Model(&sm.Resource).Updates(updates).Error
-	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+		result := tx.Model(&sm.Resource).Updates(op.Updates)
+		op.RowsAffected = result.RowsAffected
+		return result.Error
+	})
 }
 
 // Save 保存单个数据（GORM 的 Save 方法，会保存所有字段）
-func (sm *ServiceManager[T]) Save(ctx context.Context, data *T) error {
-	return GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+func (sm *ServiceManager[T]) Save(ctx context.Context, data *T) (err error) {
+	ctx, span := sm.startSpan(ctx, "Save")
+	defer func() { tracing.End(span, err) }()
+
+	op := &Operation[T]{Kind: OpSet, Method: "Save", Data: data}
+	return sm.writeOp(ctx, op, func(tx *gorm.DB) error {
 		tx = sm.applyTableName(tx)
-		return tx.Save(data).Error
-	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+		result := tx.Save(op.Data)
+		op.RowsAffected = result.RowsAffected
+		return result.Error
+	})
 }
 
 // Upsert 单个 Upsert 操作（插入或更新）
@@ -88,8 +108,12 @@ func (sm *ServiceManager[T]) Upsert(
 	data *T,
 	conflictColumns []string,
 	updateColumns []string,
-) error {
-	return GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+) (err error) {
+	ctx, span := sm.startSpan(ctx, "Upsert")
+	defer func() { tracing.End(span, err) }()
+
+	op := &Operation[T]{Kind: OpSet, Method: "Upsert", Data: data}
+	return sm.writeOp(ctx, op, func(tx *gorm.DB) error {
 		tx = sm.applyTableName(tx)
 
 		onConflict := clause.OnConflict{}
@@ -103,24 +127,32 @@ func (sm *ServiceManager[T]) Upsert(
 			onConflict.UpdateAll = true
 		}
 
-		return tx.Clauses(onConflict).Create(data).Error
-	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+		result := tx.Clauses(onConflict).Create(op.Data)
+		op.RowsAffected = result.RowsAffected
+		return result.Error
+	})
 }
 
 // Delete 删除单个数据
 func (sm *ServiceManager[T]) Delete(
 	ctx context.Context,
 	queryFunc func(*gorm.DB) *gorm.DB,
-) error {
-	return GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+) (err error) {
+	ctx, span := sm.startSpan(ctx, "Delete")
+	defer func() { tracing.End(span, err) }()
+
+	op := &Operation[T]{Kind: OpDelete, Method: "Delete", Query: queryFunc}
+	return sm.writeOp(ctx, op, func(tx *gorm.DB) error {
 		tx = sm.applyTableName(tx)
 
-		if queryFunc != nil {
-			tx = queryFunc(tx)
+		if op.Query != nil {
+			tx = op.Query(tx)
 		}
 
-		return tx.Delete(&sm.Resource).Error
-	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+		result := tx.Delete(&sm.Resource)
+		op.RowsAffected = result.RowsAffected
+		return result.Error
+	})
 }
 
 // Increment 增加字段值
@@ -129,16 +161,26 @@ func (sm *ServiceManager[T]) Increment(
 	column string,
 	value interface{},
 	queryFunc func(*gorm.DB) *gorm.DB,
-) error {
-	return GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+) (err error) {
+	ctx, span := sm.startSpan(ctx, "Increment")
+	defer func() { tracing.End(span, err) }()
+
+	op := &Operation[T]{Kind: OpUpdate, Method: "Increment", Query: queryFunc, Column: column, Value: value}
+	return sm.writeOp(ctx, op, func(tx *gorm.DB) error {
 		tx = sm.applyTableName(tx)
 
-		if queryFunc != nil {
-			tx = queryFunc(tx)
+		if op.Query != nil {
+			tx = op.Query(tx)
 		}
 
-		return tx.Model(&sm.Resource).UpdateColumn(column, gorm.Expr(fmt.Sprintf("%s + ?", column), value)).Error
-	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+		col, err := sm.ResolveColumn(op.Column, UsageUpdate)
+		if err != nil {
+			return err
+		}
+		result := tx.Model(&sm.Resource).UpdateColumn(col, gorm.Expr("? + ?", clause.Column{Name: col}, op.Value))
+		op.RowsAffected = result.RowsAffected
+		return result.Error
+	})
 }
 
 // Decrement 减少字段值
@@ -147,57 +189,104 @@ func (sm *ServiceManager[T]) Decrement(
 	column string,
 	value interface{},
 	queryFunc func(*gorm.DB) *gorm.DB,
-) error {
-	return GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+) (err error) {
+	ctx, span := sm.startSpan(ctx, "Decrement")
+	defer func() { tracing.End(span, err) }()
+
+	op := &Operation[T]{Kind: OpUpdate, Method: "Decrement", Query: queryFunc, Column: column, Value: value}
+	return sm.writeOp(ctx, op, func(tx *gorm.DB) error {
 		tx = sm.applyTableName(tx)
 
-		if queryFunc != nil {
-			tx = queryFunc(tx)
+		if op.Query != nil {
+			tx = op.Query(tx)
 		}
 
-		return tx.Model(&sm.Resource).UpdateColumn(column, gorm.Expr(fmt.Sprintf("%s - ?", column), value)).Error
-	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+		col, err := sm.ResolveColumn(op.Column, UsageUpdate)
+		if err != nil {
+			return err
+		}
+		result := tx.Model(&sm.Resource).UpdateColumn(col, gorm.Expr("? - ?", clause.Column{Name: col}, op.Value))
+		op.RowsAffected = result.RowsAffected
+		return result.Error
+	})
 }
 
 // --- 封装方法（逻辑不变，直接调用上述重构后的方法） ---
 
-func (sm *ServiceManager[T]) Insert(ctx context.Context, data *T) error {
+func (sm *ServiceManager[T]) Insert(ctx context.Context, data *T) (err error) {
+	ctx, span := sm.startSpan(ctx, "Insert")
+	defer func() { tracing.End(span, err) }()
+
 	return sm.SetSingle(ctx, data, &SetSingleOptions{OnConflictUpdate: false, InvalidateCache: false})
 }
 
-func (sm *ServiceManager[T]) UpdateByID(ctx context.Context, id interface{}, updates map[string]interface{}) error {
-	return sm.Update(ctx, updates, func(db *gorm.DB) *gorm.DB {
-		return db.Where("id = ?", id)
-	})
+func (sm *ServiceManager[T]) UpdateByID(ctx context.Context, id interface{}, updates map[string]interface{}) (err error) {
+	ctx, span := sm.startSpan(ctx, "UpdateByID", idAttr(id))
+	defer func() { tracing.End(span, err) }()
+
+	byID, err := sm.PrimaryKeyQuery(id)
+	if err != nil {
+		return err
+	}
+	return sm.Update(ctx, updates, byID)
 }
 
-func (sm *ServiceManager[T]) DeleteByID(ctx context.Context, id interface{}) error {
-	return sm.Delete(ctx, func(db *gorm.DB) *gorm.DB {
-		return db.Where("id = ?", id)
-	})
+func (sm *ServiceManager[T]) DeleteByID(ctx context.Context, id interface{}) (err error) {
+	ctx, span := sm.startSpan(ctx, "DeleteByID", idAttr(id))
+	defer func() { tracing.End(span, err) }()
+
+	byID, err := sm.PrimaryKeyQuery(id)
+	if err != nil {
+		return err
+	}
+	return sm.Delete(ctx, byID)
 }
 
-func (sm *ServiceManager[T]) SoftDelete(ctx context.Context, queryFunc func(*gorm.DB) *gorm.DB) error {
+func (sm *ServiceManager[T]) SoftDelete(ctx context.Context, queryFunc func(*gorm.DB) *gorm.DB) (err error) {
+	ctx, span := sm.startSpan(ctx, "SoftDelete")
+	defer func() { tracing.End(span, err) }()
+
 	updates := map[string]interface{}{"deleted_at": gorm.Expr("NOW()")}
 	return sm.Update(ctx, updates, queryFunc)
 }
 
-func (sm *ServiceManager[T]) SoftDeleteByID(ctx context.Context, id interface{}) error {
-	return sm.SoftDelete(ctx, func(db *gorm.DB) *gorm.DB {
-		return db.Where("id = ?", id)
-	})
+func (sm *ServiceManager[T]) SoftDeleteByID(ctx context.Context, id interface{}) (err error) {
+	ctx, span := sm.startSpan(ctx, "SoftDeleteByID", idAttr(id))
+	defer func() { tracing.End(span, err) }()
+
+	byID, err := sm.PrimaryKeyQuery(id)
+	if err != nil {
+		return err
+	}
+	return sm.SoftDelete(ctx, byID)
 }
 
-func (sm *ServiceManager[T]) IncrementByID(ctx context.Context, id interface{}, column string, value interface{}) error {
-	return sm.Increment(ctx, column, value, func(db *gorm.DB) *gorm.DB {
-		return db.Where("id = ?", id)
-	})
+func (sm *ServiceManager[T]) IncrementByID(ctx context.Context, id interface{}, column string, value interface{}) (err error) {
+	ctx, span := sm.startSpan(ctx, "IncrementByID", idAttr(id))
+	defer func() { tracing.End(span, err) }()
+
+	byID, err := sm.PrimaryKeyQuery(id)
+	if err != nil {
+		return err
+	}
+	return sm.Increment(ctx, column, value, byID)
 }
 
-func (sm *ServiceManager[T]) DecrementByID(ctx context.Context, id interface{}, column string, value interface{}) error {
-	return sm.Decrement(ctx, column, value, func(db *gorm.DB) *gorm.DB {
-		return db.Where("id = ?", id)
-	})
+func (sm *ServiceManager[T]) DecrementByID(ctx context.Context, id interface{}, column string, value interface{}) (err error) {
+	ctx, span := sm.startSpan(ctx, "DecrementByID", idAttr(id))
+	defer func() { tracing.End(span, err) }()
+
+	byID, err := sm.PrimaryKeyQuery(id)
+	if err != nil {
+		return err
+	}
+	return sm.Decrement(ctx, column, value, byID)
+}
+
+// writeTx 以可重复读隔离级别执行写事务，返回的错误经过 classifyDBError 归类（如唯一键冲突归为 ErrConflict）
+func (sm *ServiceManager[T]) writeTx(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	err := GetDB().WithContext(ctx).Transaction(fn, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	return sm.classifyDBError(err)
 }
 
 func (sm *ServiceManager[T]) invalidateCacheForSingle(ctx context.Context, data *T) error {
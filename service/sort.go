@@ -0,0 +1,81 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========== 排序 ==========
+
+// 排序方向与空值位置
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+
+	NullsFirst = "first"
+	NullsLast  = "last"
+)
+
+// SortParam 单个排序键
+type SortParam struct {
+	Field     string `json:"field"`               // 字段名（json 名 / 列名 / Go 字段名）
+	Direction string `json:"direction,omitempty"` // asc（默认）/ desc
+	Nulls     string `json:"nulls,omitempty"`     // first / last，为空时使用数据库默认行为
+}
+
+// sortKey 校验后的排序键
+type sortKey struct {
+	column string
+	desc   bool
+	nulls  string
+}
+
+// resolveSorts 按列策略校验排序字段并规范化方向与空值位置
+func (sm *ServiceManager[T]) resolveSorts(sorts []SortParam) ([]sortKey, error) {
+	keys := make([]sortKey, 0, len(sorts))
+	for _, s := range sorts {
+		col, err := sm.ResolveColumn(s.Field, UsageSort)
+		if err != nil {
+			return nil, err
+		}
+		desc, err := parseOrder(s.Direction)
+		if err != nil {
+			return nil, err
+		}
+		nulls := strings.ToLower(s.Nulls)
+		if nulls != "" && nulls != NullsFirst && nulls != NullsLast {
+			return nil, NewValidationError(s.Field, fmt.Sprintf("invalid nulls position %q", s.Nulls), nil)
+		}
+		keys = append(keys, sortKey{column: col, desc: desc, nulls: nulls})
+	}
+	return keys, nil
+}
+
+// applySorts 依次追加排序键
+// MySQL 不支持 NULLS FIRST/LAST，用 "col IS NULL" 作为前置排序键模拟
+func applySorts(db *gorm.DB, keys []sortKey) *gorm.DB {
+	for _, k := range keys {
+		column := clause.Column{Name: k.column}
+		if k.nulls != "" {
+			isNull := clause.Column{Name: db.Statement.Quote(column) + " IS NULL", Raw: true}
+			db = db.Order(clause.OrderByColumn{Column: isNull, Desc: k.nulls == NullsFirst})
+		}
+		db = db.Order(clause.OrderByColumn{Column: column, Desc: k.desc})
+	}
+	return db
+}
+
+// parseOrder 解析排序方向，空值为升序
+func parseOrder(order string) (desc bool, err error) {
+	switch strings.ToLower(order) {
+	case "", SortAsc:
+		return false, nil
+	case SortDesc:
+		return true, nil
+	default:
+		return false, NewValidationError("order", fmt.Sprintf("invalid order direction %q", order), nil)
+	}
+}
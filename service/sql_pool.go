@@ -3,9 +3,13 @@ package service
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
+	"strconv"
 	"time"
 
+	"AbstractManager/util/tracing"
+
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -18,8 +22,32 @@ type DBManager struct {
 
 var globalDBManager *DBManager
 
+// DBConfig 数据库初始化配置（可选）
+type DBConfig struct {
+	Logger        *slog.Logger    // GORM 日志输出目标（为空时使用 slog.Default()）
+	LogLevel      logger.LogLevel // GORM 日志级别（为 0 时读取环境变量 DB_LOG_LEVEL，默认 Warn）
+	SlowThreshold time.Duration   // 慢查询阈值（为 0 时读取环境变量 DB_SLOW_THRESHOLD_MS，默认 200ms）
+}
+
 // InitDB 初始化数据库连接
-func InitDB() (*DBManager, error) {
+func InitDB(cfg ...*DBConfig) (*DBManager, error) {
+	conf := &DBConfig{}
+	if len(cfg) > 0 && cfg[0] != nil {
+		conf = cfg[0]
+	}
+
+	logLevel := conf.LogLevel
+	if logLevel == 0 {
+		logLevel = parseGormLogLevel(os.Getenv("DB_LOG_LEVEL"), logger.Warn)
+	}
+	slowThreshold := conf.SlowThreshold
+	if slowThreshold == 0 {
+		slowThreshold = 200 * time.Millisecond
+		if ms, err := strconv.Atoi(os.Getenv("DB_SLOW_THRESHOLD_MS")); err == nil && ms > 0 {
+			slowThreshold = time.Duration(ms) * time.Millisecond
+		}
+	}
+
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		os.Getenv("DB_USER"),
 		os.Getenv("DB_PASSWORD"),
@@ -29,17 +57,24 @@ func InitDB() (*DBManager, error) {
 	)
 
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: NewGormLogger(conf.Logger, logLevel, slowThreshold),
 		// 准备语句执行，提高性能
 		PrepareStmt: true,
 		// 命名策略
 		NamingStrategy: nil,
+		// 将方言错误翻译为 gorm 通用错误（如唯一键冲突 -> gorm.ErrDuplicatedKey）
+		TranslateError: true,
 	})
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect database: %w", err)
 	}
 
+	// 注册链路追踪插件，每条 SQL 语句作为 db.WithContext(ctx) 中 span 的子 span
+	if err := db.Use(tracing.NewGormPlugin()); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
+
 	// 获取底层的 *sql.DB
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -63,6 +98,12 @@ func InitDB() (*DBManager, error) {
 	return globalDBManager, nil
 }
 
+// UseDB 使用已创建的 *gorm.DB 作为全局连接（如复用外部连接池，或测试中的 DryRun 连接）
+func UseDB(db *gorm.DB) *DBManager {
+	globalDBManager = &DBManager{DB: db}
+	return globalDBManager
+}
+
 // GetDB 获取全局数据库实例
 func GetDB() *gorm.DB {
 	if globalDBManager == nil {
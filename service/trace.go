@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"AbstractManager/util/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSpan 为 ServiceManager 方法开启 span，统一附带资源名与方法名
+// 调用方式：ctx, span := sm.startSpan(ctx, "GetSingle"); defer func() { tracing.End(span, err) }()
+func (sm *ServiceManager[T]) startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs,
+		tracing.AttrResource.String(sm.ResourceName),
+		tracing.AttrMethod.String(method),
+	)
+	return tracing.Start(ctx, "ServiceManager."+method, attrs...)
+}
+
+// idAttr 主键属性
+func idAttr(id interface{}) attribute.KeyValue {
+	return tracing.AttrID.String(fmt.Sprintf("%v", id))
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"time"
 
+	"AbstractManager/util/tracing"
+
 	"gorm.io/gorm"
 )
 
@@ -22,11 +24,18 @@ func (sm *ServiceManager[T]) WritedownQuery(
 	data []T,
 	buildKeyFunc func(*T) string,
 	opts *WritedownQueryOptions,
-) error {
+) (err error) {
+	ctx, span := sm.startSpan(ctx, "WritedownQuery")
+	defer func() { tracing.End(span, err) }()
+
 	if len(data) == 0 {
 		return nil
 	}
 
+	if buildKeyFunc, err = sm.resolveKeyFunc(ctx, buildKeyFunc); err != nil {
+		return err
+	}
+
 	if opts == nil {
 		opts = &WritedownQueryOptions{
 			Expiration: 1 * time.Hour,
@@ -35,52 +44,57 @@ func (sm *ServiceManager[T]) WritedownQuery(
 		}
 	}
 
-	redis := GetRedis() // 假设返回的是 *redis.Client
-	batchSize := opts.BatchSize
-	if batchSize <= 0 {
-		batchSize = 100
-	}
+	op := &Operation[T]{Kind: OpWritedown, Method: "WritedownQuery", Items: data, TTL: opts.Expiration}
+	return sm.runOp(ctx, op, func() error {
+		data := op.Items
 
-	for i := 0; i < len(data); i += batchSize {
-		end := i + batchSize
-		if end > len(data) {
-			end = len(data)
+		redis := GetRedis() // 假设返回的是 *redis.Client
+		batchSize := opts.BatchSize
+		if batchSize <= 0 {
+			batchSize = 100
 		}
 
-		batch := data[i:end]
-		cacheItems := make(map[string]interface{})
+		for i := 0; i < len(data); i += batchSize {
+			end := i + batchSize
+			if end > len(data) {
+				end = len(data)
+			}
 
-		for j := range batch {
-			item := &batch[j]
-			key := buildKeyFunc(item)
+			batch := data[i:end]
+			cacheItems := make(map[string]interface{})
 
-			if !opts.Overwrite {
-				// 🛠️ 修复 1: Exists 返回的是 *IntCmd，需要调用 .Val() 获取结果
-				if redis.Exists(ctx, key).Val() > 0 {
-					continue
-				}
-			}
-			valueBytes, err := json.Marshal(item)
-			if err != nil {
-				return fmt.Errorf("failed to marshal item for key %s: %w", key, err)
-			}
+			for j := range batch {
+				item := &batch[j]
+				key := buildKeyFunc(item)
 
-			cacheItems[key] = valueBytes // 存 []byte
-		}
+				if !opts.Overwrite {
+					// 🛠️ 修复 1: Exists 返回的是 *IntCmd，需要调用 .Val() 获取结果
+					if redis.Exists(ctx, key).Val() > 0 {
+						continue
+					}
+				}
+				valueBytes, err := json.Marshal(item)
+				if err != nil {
+					return fmt.Errorf("failed to marshal item for key %s: %w", key, err)
+				}
 
-		if len(cacheItems) > 0 {
-			// 🛠️ 修复 2: go-redis 标准方法是 MSet，而不是 SetMultiple
-			if err := redis.MSet(ctx, cacheItems).Err(); err != nil {
-				return fmt.Errorf("failed to write batch to cache: %w", err)
+				cacheItems[key] = valueBytes // 存 []byte
 			}
-			// 💡 注意：MSet 不支持在同一条命令设置过期时间，需要后续配合 Expire 处理或改用 Pipeline
-			for key := range cacheItems {
-				redis.Expire(ctx, key, opts.Expiration)
+
+			if len(cacheItems) > 0 {
+				// 🛠️ 修复 2: go-redis 标准方法是 MSet，而不是 SetMultiple
+				if err := redis.MSet(ctx, cacheItems).Err(); err != nil {
+					return fmt.Errorf("failed to write batch to cache: %w", ClassifyCacheError(err))
+				}
+				// 💡 注意：MSet 不支持在同一条命令设置过期时间，需要后续配合 Expire 处理或改用 Pipeline
+				for key := range cacheItems {
+					redis.Expire(ctx, key, op.TTL)
+				}
 			}
 		}
-	}
 
-	return nil
+		return nil
+	})
 }
 
 // WritedownWithPipeline 修复了 Pipeline 的调用错误
@@ -89,44 +103,56 @@ func (sm *ServiceManager[T]) WritedownWithPipeline(
 	data []T,
 	buildKeyFunc func(*T) string,
 	opts *WritedownQueryOptions,
-) error {
+) (err error) {
+	ctx, span := sm.startSpan(ctx, "WritedownWithPipeline")
+	defer func() { tracing.End(span, err) }()
+
 	if len(data) == 0 {
 		return nil
 	}
 
+	if buildKeyFunc, err = sm.resolveKeyFunc(ctx, buildKeyFunc); err != nil {
+		return err
+	}
+
 	if opts == nil {
 		opts = &WritedownQueryOptions{Expiration: 1 * time.Hour, BatchSize: 1000, Overwrite: true}
 	}
 
-	rdb := GetRedis()
+	op := &Operation[T]{Kind: OpWritedown, Method: "WritedownWithPipeline", Items: data, TTL: opts.Expiration}
+	return sm.runOp(ctx, op, func() error {
+		data := op.Items
 
-	for i := 0; i < len(data); i += opts.BatchSize {
-		end := i + opts.BatchSize
-		if end > len(data) {
-			end = len(data)
-		}
+		rdb := GetRedis()
 
-		pipe := rdb.Pipeline()
+		for i := 0; i < len(data); i += opts.BatchSize {
+			end := i + opts.BatchSize
+			if end > len(data) {
+				end = len(data)
+			}
 
-		for j := i; j < end; j++ {
-			item := &data[j]
-			key := buildKeyFunc(item)
+			pipe := rdb.Pipeline()
 
-			// ★★★ 核心修复：先 marshal
-			valueBytes, err := json.Marshal(item)
-			if err != nil {
-				return fmt.Errorf("failed to marshal item for key %s: %w", key, err)
-			}
+			for j := i; j < end; j++ {
+				item := &data[j]
+				key := buildKeyFunc(item)
 
-			pipe.Set(ctx, key, valueBytes, opts.Expiration)
-		}
+				// ★★★ 核心修复：先 marshal
+				valueBytes, err := json.Marshal(item)
+				if err != nil {
+					return fmt.Errorf("failed to marshal item for key %s: %w", key, err)
+				}
+
+				pipe.Set(ctx, key, valueBytes, op.TTL)
+			}
 
-		if _, err := pipe.Exec(ctx); err != nil {
-			return fmt.Errorf("failed to execute pipeline: %w", err)
+			if _, err := pipe.Exec(ctx); err != nil {
+				return fmt.Errorf("failed to execute pipeline: %w", ClassifyCacheError(err))
+			}
 		}
-	}
 
-	return nil
+		return nil
+	})
 }
 
 // WritedownIncremental 修复了 Get 和 Set 的返回值错误
@@ -136,35 +162,58 @@ func (sm *ServiceManager[T]) WritedownIncremental(
 	buildKeyFunc func(*T) string,
 	compareFunc func(*T, *T) bool,
 	opts *WritedownQueryOptions,
-) error {
+) (err error) {
+	ctx, span := sm.startSpan(ctx, "WritedownIncremental")
+	defer func() { tracing.End(span, err) }()
+
 	if len(data) == 0 {
 		return nil
 	}
 
-	redis := GetRedis()
+	if buildKeyFunc, err = sm.resolveKeyFunc(ctx, buildKeyFunc); err != nil {
+		return err
+	}
+
+	if opts == nil {
+		opts = &WritedownQueryOptions{Expiration: 1 * time.Hour}
+	}
 
-	for i := range data {
-		item := &data[i]
-		key := buildKeyFunc(item)
+	op := &Operation[T]{Kind: OpWritedown, Method: "WritedownIncremental", Items: data, TTL: opts.Expiration}
+	return sm.runOp(ctx, op, func() error {
+		data := op.Items
 
-		var cachedItem T
-		// 修复 4: Get 只有两个参数，结果需要通过 .Scan() 注入结构体
-		err := redis.Get(ctx, key).Scan(&cachedItem)
+		redis := GetRedis()
 
-		if err == nil && compareFunc != nil && !compareFunc(item, &cachedItem) {
-			continue
-		}
+		for i := range data {
+			item := &data[i]
+			key := buildKeyFunc(item)
 
-		// 修复 5: Set 返回的是 *StatusCmd，需要调用 .Err() 转换为 error 接口
-		if err := redis.Set(ctx, key, item, opts.Expiration).Err(); err != nil {
-			return fmt.Errorf("failed to write cache for key %s: %w", key, err)
+			var cachedItem T
+			// 修复 4: Get 只有两个参数，结果需要通过 .Scan() 注入结构体
+			err := redis.Get(ctx, key).Scan(&cachedItem)
+
+			if err == nil && compareFunc != nil && !compareFunc(item, &cachedItem) {
+				continue
+			}
+
+			valueBytes, err := marshalForRedis(item)
+			if err != nil {
+				return fmt.Errorf("failed to marshal item for key %s: %w", key, err)
+			}
+			// 修复 5: Set 返回的是 *StatusCmd，需要调用 .Err() 转换为 error 接口
+			if err := redis.Set(ctx, key, valueBytes, op.TTL).Err(); err != nil {
+				return fmt.Errorf("failed to write cache for key %s: %w", key, ClassifyCacheError(err))
+			}
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 // --- 辅助方法保持不变 ---
-func (sm *ServiceManager[T]) WritedownQueryFromDB(ctx context.Context, queryFunc func(*gorm.DB) *gorm.DB, buildKeyFunc func(*T) string, opts *WritedownQueryOptions) error {
+func (sm *ServiceManager[T]) WritedownQueryFromDB(ctx context.Context, queryFunc func(*gorm.DB) *gorm.DB, buildKeyFunc func(*T) string, opts *WritedownQueryOptions) (err error) {
+	ctx, span := sm.startSpan(ctx, "WritedownQueryFromDB")
+	defer func() { tracing.End(span, err) }()
+
 	result, err := sm.GetQueryWithoutTransaction(ctx, queryFunc, nil)
 	if err != nil || len(result.Data) == 0 {
 		return err
@@ -172,15 +221,28 @@ func (sm *ServiceManager[T]) WritedownQueryFromDB(ctx context.Context, queryFunc
 	return sm.WritedownQuery(ctx, result.Data, buildKeyFunc, opts)
 }
 
-func (sm *ServiceManager[T]) WritedownQueryByIDs(ctx context.Context, ids []interface{}, buildKeyFunc func(*T) string, opts *WritedownQueryOptions) error {
-	return sm.WritedownQueryFromDB(ctx, func(db *gorm.DB) *gorm.DB { return db.Where("id IN ?", ids) }, buildKeyFunc, opts)
+func (sm *ServiceManager[T]) WritedownQueryByIDs(ctx context.Context, ids []interface{}, buildKeyFunc func(*T) string, opts *WritedownQueryOptions) (err error) {
+	ctx, span := sm.startSpan(ctx, "WritedownQueryByIDs", tracing.AttrKeyCount.Int(len(ids)))
+	defer func() { tracing.End(span, err) }()
+
+	byIDs, err := sm.PrimaryKeysQuery(ids)
+	if err != nil {
+		return err
+	}
+	return sm.WritedownQueryFromDB(ctx, byIDs, buildKeyFunc, opts)
 }
 
-func (sm *ServiceManager[T]) WritedownAllToCache(ctx context.Context, buildKeyFunc func(*T) string, opts *WritedownQueryOptions) error {
+func (sm *ServiceManager[T]) WritedownAllToCache(ctx context.Context, buildKeyFunc func(*T) string, opts *WritedownQueryOptions) (err error) {
+	ctx, span := sm.startSpan(ctx, "WritedownAllToCache")
+	defer func() { tracing.End(span, err) }()
+
 	return sm.WritedownQueryFromDB(ctx, nil, buildKeyFunc, opts)
 }
 
-func (sm *ServiceManager[T]) WarmupCache(ctx context.Context, queryFunc func(*gorm.DB) *gorm.DB, buildKeyFunc func(*T) string, expiration time.Duration) error {
+func (sm *ServiceManager[T]) WarmupCache(ctx context.Context, queryFunc func(*gorm.DB) *gorm.DB, buildKeyFunc func(*T) string, expiration time.Duration) (err error) {
+	ctx, span := sm.startSpan(ctx, "WarmupCache")
+	defer func() { tracing.End(span, err) }()
+
 	result, err := sm.GetQueryWithoutTransaction(ctx, queryFunc, &QueryOptions{
 		OrderBy: "id", Order: "DESC", Page: 1, PageSize: 1000,
 	})
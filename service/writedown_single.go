@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"time"
 
+	"AbstractManager/util/tracing"
+
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
@@ -23,7 +26,7 @@ type WritedownSingleOptions struct {
 // marshalForRedis 统一处理序列化
 func marshalForRedis[T any](data *T) ([]byte, error) {
 	if data == nil {
-		return nil, fmt.Errorf("cannot marshal nil data")
+		return nil, NewValidationError("data", "cannot marshal nil data", nil)
 	}
 	// 使用 JSON 序列化，避免 BinaryMarshaler 错误
 	return json.Marshal(data)
@@ -35,31 +38,38 @@ func (sm *ServiceManager[T]) WritedownSingle(
 	key string,
 	data *T,
 	opts *WritedownSingleOptions,
-) error {
+) (err error) {
+	ctx, span := sm.startSpan(ctx, "WritedownSingle", tracing.AttrCacheKey.String(key))
+	defer func() { tracing.End(span, err) }()
+
 	if opts == nil {
 		opts = &WritedownSingleOptions{Expiration: 1 * time.Hour, Overwrite: true}
 	}
 
-	rdb := GetRedis()
+	op := &Operation[T]{Kind: OpWritedown, Method: "WritedownSingle", Keys: []string{key}, Data: data, TTL: opts.Expiration}
+	return sm.runOp(ctx, op, func() error {
+		rdb := GetRedis()
+		key := op.Keys[0]
 
-	valueBytes, err := marshalForRedis(data)
-	if err != nil {
-		return fmt.Errorf("failed to marshal data for key %s: %w", key, err)
-	}
+		valueBytes, err := marshalForRedis(op.Data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal data for key %s: %w", key, err)
+		}
 
-	var cmdErr error
-	if opts.NX {
-		cmdErr = rdb.SetNX(ctx, key, valueBytes, opts.Expiration).Err()
-	} else if opts.XX {
-		cmdErr = rdb.SetXX(ctx, key, valueBytes, opts.Expiration).Err()
-	} else {
-		cmdErr = rdb.Set(ctx, key, valueBytes, opts.Expiration).Err()
-	}
+		var cmdErr error
+		if opts.NX {
+			cmdErr = rdb.SetNX(ctx, key, valueBytes, op.TTL).Err()
+		} else if opts.XX {
+			cmdErr = rdb.SetXX(ctx, key, valueBytes, op.TTL).Err()
+		} else {
+			cmdErr = rdb.Set(ctx, key, valueBytes, op.TTL).Err()
+		}
 
-	if cmdErr != nil {
-		return fmt.Errorf("failed to write cache for key %s: %w", key, cmdErr)
-	}
-	return nil
+		if cmdErr != nil {
+			return fmt.Errorf("failed to write cache for key %s: %w", key, ClassifyCacheError(cmdErr))
+		}
+		return nil
+	})
 }
 
 // ----------------- 带锁写缓存 -----------------
@@ -70,7 +80,10 @@ func (sm *ServiceManager[T]) WritedownSingleWithLock(
 	queryFunc func(*gorm.DB) *gorm.DB,
 	expiration time.Duration,
 	lockTimeout time.Duration,
-) (*T, error) {
+) (_ *T, err error) {
+	ctx, span := sm.startSpan(ctx, "WritedownSingleWithLock", tracing.AttrCacheKey.String(key))
+	defer func() { tracing.End(span, err) }()
+
 	rdb := GetRedis()
 	var result T
 
@@ -84,7 +97,10 @@ func (sm *ServiceManager[T]) WritedownSingleWithLock(
 
 	lockKey := fmt.Sprintf("lock:%s", key)
 	lockValue := fmt.Sprintf("%d", time.Now().UnixNano())
-	locked, _ := rdb.SetNX(ctx, lockKey, lockValue, lockTimeout).Result()
+	locked, err := rdb.SetNX(ctx, lockKey, lockValue, lockTimeout).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock for %s: %w", key, ClassifyCacheError(err))
+	}
 	if !locked {
 		time.Sleep(50 * time.Millisecond)
 		val, err := rdb.Get(ctx, key).Bytes()
@@ -93,7 +109,7 @@ func (sm *ServiceManager[T]) WritedownSingleWithLock(
 				return &result, nil
 			}
 		}
-		return nil, fmt.Errorf("failed to acquire lock and cache miss for %s", key)
+		return nil, fmt.Errorf("%w: cache miss for %s", ErrLockNotAcquired, key)
 	}
 	defer rdb.Del(ctx, lockKey)
 
@@ -116,32 +132,40 @@ func (sm *ServiceManager[T]) WritedownSingleWithVersion(
 	data *T,
 	version int64,
 	expiration time.Duration,
-) error {
-	rdb := GetRedis()
-	versionKey := key + ":version"
+) (err error) {
+	ctx, span := sm.startSpan(ctx, "WritedownSingleWithVersion", tracing.AttrCacheKey.String(key))
+	defer func() { tracing.End(span, err) }()
+
+	op := &Operation[T]{Kind: OpWritedown, Method: "WritedownSingleWithVersion", Keys: []string{key}, Data: data, TTL: expiration}
+	return sm.runOp(ctx, op, func() error {
+		rdb := GetRedis()
+		key := op.Keys[0]
+		versionKey := key + ":version"
+
+		valueBytes, err := marshalForRedis(op.Data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal data for key %s: %w", key, err)
+		}
 
-	valueBytes, err := marshalForRedis(data)
-	if err != nil {
-		return fmt.Errorf("failed to marshal data for key %s: %w", key, err)
-	}
+		// 使用 Watch 保证原子性
+		err = rdb.Watch(ctx, func(tx *redis.Tx) error {
+			currentVersion, err := tx.Get(ctx, versionKey).Int64()
+			if err != nil && err != redis.Nil {
+				return err
+			}
+			if err != redis.Nil && currentVersion >= version {
+				return &VersionOutdatedError{Key: key, Current: currentVersion, Provided: version}
+			}
 
-	// 使用 Watch 保证原子性
-	return rdb.Watch(ctx, func(tx *redis.Tx) error {
-		currentVersion, err := tx.Get(ctx, versionKey).Int64()
-		if err != nil && err != redis.Nil {
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, key, valueBytes, op.TTL)
+				pipe.Set(ctx, versionKey, version, op.TTL)
+				return nil
+			})
 			return err
-		}
-		if err != redis.Nil && currentVersion >= version {
-			return fmt.Errorf("version outdated: current %d, provided %d", currentVersion, version)
-		}
-
-		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
-			pipe.Set(ctx, key, valueBytes, expiration)
-			pipe.Set(ctx, versionKey, version, expiration)
-			return nil
-		})
-		return err
-	}, key, versionKey)
+		}, key, versionKey)
+		return ClassifyCacheError(err)
+	})
 }
 
 // ----------------- 异步写缓存 -----------------
@@ -152,27 +176,46 @@ func (sm *ServiceManager[T]) WritedownSingleAsync(
 	data *T,
 	expiration time.Duration,
 ) {
+	ctx, span := sm.startSpan(ctx, "WritedownSingleAsync", tracing.AttrCacheKey.String(key))
+
 	go func() {
-		asyncCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		// 脱离请求的取消信号，但保留 ctx 中的链路信息，异步写入仍挂在同一条 trace 上；span 在写入完成后结束
+		asyncCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
 		defer cancel()
-		if err := sm.WritedownSingle(asyncCtx, key, data, &WritedownSingleOptions{Expiration: expiration}); err != nil {
-			fmt.Printf("[AsyncCache] Failed for key %s: %v\n", key, err)
+		err := sm.WritedownSingle(asyncCtx, key, data, &WritedownSingleOptions{Expiration: expiration})
+		if err != nil {
+			sm.GetLogger().WarnContext(asyncCtx, "async cache write failed",
+				slog.String("operation", "WritedownSingleAsync"), slog.String("key", key), slog.Any("error", err))
 		}
+		tracing.End(span, err)
 	}()
 }
 
 // ----------------- 便捷方法 -----------------
 
-func (sm *ServiceManager[T]) WritedownSingleByID(ctx context.Context, id interface{}, opts *WritedownSingleOptions) error {
-	key := sm.buildCacheKey(id)
-	data, err := sm.GetSingle(ctx, func(db *gorm.DB) *gorm.DB { return db.Where("id = ?", id) }, nil)
+func (sm *ServiceManager[T]) WritedownSingleByID(ctx context.Context, id interface{}, opts *WritedownSingleOptions) (err error) {
+	ctx, span := sm.startSpan(ctx, "WritedownSingleByID", idAttr(id))
+	defer func() { tracing.End(span, err) }()
+
+	key, err := sm.CacheKeyByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	byID, err := sm.PrimaryKeyQuery(id)
+	if err != nil {
+		return err
+	}
+	data, err := sm.GetSingle(ctx, byID, nil)
 	if err != nil {
 		return err
 	}
 	return sm.WritedownSingle(ctx, key, data, opts)
 }
 
-func (sm *ServiceManager[T]) RefreshSingleCacheFromDB(ctx context.Context, key string, queryFunc func(*gorm.DB) *gorm.DB, expiration time.Duration) error {
+func (sm *ServiceManager[T]) RefreshSingleCacheFromDB(ctx context.Context, key string, queryFunc func(*gorm.DB) *gorm.DB, expiration time.Duration) (err error) {
+	ctx, span := sm.startSpan(ctx, "RefreshSingleCacheFromDB", tracing.AttrCacheKey.String(key))
+	defer func() { tracing.End(span, err) }()
+
 	data, err := sm.GetSingle(ctx, queryFunc, nil)
 	if err != nil {
 		return err
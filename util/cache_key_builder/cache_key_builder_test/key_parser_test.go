@@ -0,0 +1,107 @@
+package cache_key_builder_test
+
+import (
+	"reflect"
+	"testing"
+
+	"AbstractManager/util/cache_key_builder"
+)
+
+type product struct {
+	ID       uint    `json:"id"`
+	Category string  `json:"category"`
+	ShopID   int32   `json:"shop"`
+	Price    float64 `json:"price"`
+	Active   bool    `json:"active"`
+}
+
+func TestKeyParserParse(t *testing.T) {
+	p := cache_key_builder.MustKeyParser[product]("cache:product:{id}:{category}:{shop_id}:{price}:{active}")
+
+	got, err := p.Parse("cache:product:42:books:7:9.5:true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"id":       uint(42),
+		"category": "books",
+		"shop_id":  int32(7),
+		"price":    9.5,
+		"active":   true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+	if name := p.FieldName("shop_id"); name != "ShopID" {
+		t.Errorf("FieldName(shop_id) = %q, want ShopID", name)
+	}
+
+	for _, key := range []string{
+		"cache:product:42:books",            // 段数不足
+		"cache:user:42:books:7:9.5:true",    // 前缀不同
+		"cache:product:x:books:7:9.5:true",  // id 不是整数
+		"cache:product:-1:books:7:9.5:true", // uint 不能为负
+	} {
+		if _, err := p.Parse(key); err == nil {
+			t.Errorf("Parse(%q): expected error", key)
+		}
+	}
+}
+
+func TestKeyParserRoundTrip(t *testing.T) {
+	p := cache_key_builder.MustKeyParser[product]("product:{id}:{category}")
+	item := &product{ID: 9, Category: "a:b"}
+
+	key := p.BuildKey(item)
+	if key != "product:9:a:b" {
+		t.Fatalf("BuildKey = %q", key)
+	}
+	// 最后一个占位符可以包含分隔符
+	got, err := p.Parse(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["id"] != uint(9) || got["category"] != "a:b" {
+		t.Errorf("Parse(%q) = %#v", key, got)
+	}
+}
+
+func TestKeyParserPattern(t *testing.T) {
+	p := cache_key_builder.MustKeyParser[product]("cache:product:{id}:{category}")
+
+	tests := []struct {
+		bound map[string]interface{}
+		want  string
+	}{
+		{nil, "cache:product:*:*"},
+		{map[string]interface{}{"category": "books"}, "cache:product:*:books"},
+		{map[string]interface{}{"id": 7, "category": "a*b?"}, `cache:product:7:a\*b\?`},
+	}
+	for _, tt := range tests {
+		if got := p.Pattern(tt.bound); got != tt.want {
+			t.Errorf("Pattern(%v) = %q, want %q", tt.bound, got, tt.want)
+		}
+	}
+}
+
+func TestKeyParserInvalidTemplates(t *testing.T) {
+	for _, template := range []string{"cache:product", "cache:{id}{category}", "cache:{id}:{id}"} {
+		if _, err := cache_key_builder.NewKeyParser[product](template); err == nil {
+			t.Errorf("NewKeyParser(%q): expected error", template)
+		}
+	}
+}
+
+func TestEscapeGlob(t *testing.T) {
+	cases := map[string]string{
+		"user:42": "user:42",
+		"a*b?c":   `a\*b\?c`,
+		`[x]\y`:   `\[x\]\\y`,
+		"名字:[1]":  `名字:\[1\]`,
+	}
+	for in, want := range cases {
+		if got := cache_key_builder.EscapeGlob(in); got != want {
+			t.Errorf("EscapeGlob(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package cache_key_builder_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"AbstractManager/util/cache_key_builder"
+)
+
+// memoryStore 内存代数存储，记录读取次数；fail 为 true 时读写都返回错误
+type memoryStore struct {
+	counters map[string]int64
+	gets     int
+	fail     bool
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{counters: make(map[string]int64)}
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) (int64, error) {
+	s.gets++
+	if s.fail {
+		return 0, errors.New("store unavailable")
+	}
+	return s.counters[key], nil
+}
+
+func (s *memoryStore) Incr(_ context.Context, key string) (int64, error) {
+	if s.fail {
+		return 0, errors.New("store unavailable")
+	}
+	s.counters[key]++
+	return s.counters[key], nil
+}
+
+func TestGenerationsPrefix(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	store.counters["gen:cache:user"] = 3
+	g := cache_key_builder.NewGenerations(store, 0)
+
+	got, err := g.Key(ctx, "42", "cache:user", "tenant:7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "cache:user:g3:tenant:7:g0:42"; got != want {
+		t.Errorf("Key = %q, want %q", got, want)
+	}
+}
+
+func TestGenerationsBumpInvalidatesChildren(t *testing.T) {
+	ctx := context.Background()
+	g := cache_key_builder.NewGenerations(newMemoryStore(), time.Minute)
+
+	tenant7, _ := g.Prefix(ctx, "cache:user", "tenant:7")
+	tenant8, _ := g.Prefix(ctx, "cache:user", "tenant:8")
+
+	// 推进子命名空间只影响该租户
+	if _, err := g.Bump(ctx, "cache:user", "tenant:7"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := g.Prefix(ctx, "cache:user", "tenant:7"); got == tenant7 {
+		t.Errorf("tenant:7 prefix unchanged after bump: %q", got)
+	}
+	if got, _ := g.Prefix(ctx, "cache:user", "tenant:8"); got != tenant8 {
+		t.Errorf("tenant:8 prefix changed: %q -> %q", tenant8, got)
+	}
+
+	// 推进资源级代数使所有租户失效（本进程立即可见，不等本地缓存过期）
+	if _, err := g.Bump(ctx, "cache:user"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := g.Prefix(ctx, "cache:user", "tenant:8"); got != "cache:user:g1:tenant:8:g0" {
+		t.Errorf("tenant:8 prefix after resource bump = %q", got)
+	}
+}
+
+func TestGenerationsLocalCache(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	g := cache_key_builder.NewGenerations(store, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if _, err := g.Current(ctx, "cache:user"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if store.gets != 1 {
+		t.Errorf("store read %d times, want 1", store.gets)
+	}
+
+	// 其他进程推进的代数在本地缓存过期前不可见
+	store.counters["gen:cache:user"] = 5
+	if gen, _ := g.Current(ctx, "cache:user"); gen != 0 {
+		t.Errorf("Current = %d before local TTL expired, want 0", gen)
+	}
+}
+
+func TestGenerationsStaleFallback(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	store.counters["gen:cache:user"] = 2
+	g := cache_key_builder.NewGenerations(store, time.Nanosecond)
+
+	if _, err := g.Current(ctx, "cache:user"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+
+	// 存储不可用时沿用本地的旧代数
+	store.fail = true
+	if gen, err := g.Current(ctx, "cache:user"); err != nil || gen != 2 {
+		t.Errorf("Current = %d, %v; want 2, nil", gen, err)
+	}
+	// 从未读取过的命名空间返回错误
+	if _, err := g.Current(ctx, "cache:order"); err == nil {
+		t.Error("expected error without a local value")
+	}
+	if _, err := g.Bump(ctx, "cache:user"); err == nil {
+		t.Error("expected bump error")
+	}
+}
+
+func TestParseGenerationSegment(t *testing.T) {
+	cases := map[string]struct {
+		gen int64
+		ok  bool
+	}{
+		"g0":   {0, true},
+		"g12":  {12, true},
+		"g":    {0, false},
+		"g-1":  {0, false},
+		"42":   {0, false},
+		"gold": {0, false},
+	}
+	for segment, want := range cases {
+		gen, ok := cache_key_builder.ParseGenerationSegment(segment)
+		if gen != want.gen || ok != want.ok {
+			t.Errorf("ParseGenerationSegment(%q) = %d, %v; want %d, %v", segment, gen, ok, want.gen, want.ok)
+		}
+	}
+	if got := cache_key_builder.GenerationSegment(7); got != "g7" {
+		t.Errorf("GenerationSegment(7) = %q", got)
+	}
+}
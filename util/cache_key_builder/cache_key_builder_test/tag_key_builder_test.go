@@ -0,0 +1,137 @@
+package cache_key_builder_test
+
+import (
+	"testing"
+	"time"
+
+	"AbstractManager/util/cache_key_builder"
+)
+
+type base struct {
+	Tenant string `json:"tenant" cache:"key"`
+}
+
+type taggedProduct struct {
+	_ struct{} `cache:"prefix=cache:product"`
+	base
+	ID        uint      `json:"id" cache:"key,order=1"`
+	Price     float64   `json:"price" cache:"key,order=3"`
+	Active    bool      `json:"active" cache:"key,order=2"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type untagged struct {
+	ID uint
+}
+
+func TestTagKeyBuilder(t *testing.T) {
+	b, err := cache_key_builder.NewTagKeyBuilder[taggedProduct]()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &taggedProduct{base: base{Tenant: "acme"}, ID: 42, Price: 9.5, Active: true}
+	if got, want := b.BuildKey(p), "cache:product:acme:42:true:9.5"; got != want {
+		t.Errorf("BuildKey = %q, want %q", got, want)
+	}
+	if got, want := b.Template(), "cache:product:{tenant}:{id}:{active}:{price}"; got != want {
+		t.Errorf("Template = %q, want %q", got, want)
+	}
+	if got, want := b.WithPrefix("p").Suffix(p), "acme:42:true:9.5"; got != want {
+		t.Errorf("Suffix = %q, want %q", got, want)
+	}
+
+	// 模板可以交给 KeyParser 反向解析
+	parser, err := b.Parser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := parser.Parse(b.BuildKey(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed["tenant"] != "acme" || parsed["id"] != uint(42) || parsed["price"] != 9.5 || parsed["active"] != true {
+		t.Errorf("Parse = %v", parsed)
+	}
+}
+
+func TestTagKeyBuilderFormatsOtherTypes(t *testing.T) {
+	type event struct {
+		At   time.Time `cache:"key"`
+		Name *string   `cache:"key,order=1"`
+	}
+	name := "deploy"
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	b, err := cache_key_builder.NewTagKeyBuilder[event]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 多个键字段时值中的 ":" 与 "%" 被转义
+	if got, want := b.BuildKey(&event{At: at, Name: &name}), cache_key_builder.EscapeKeyPart(at.String())+":deploy"; got != want {
+		t.Errorf("BuildKey = %q, want %q", got, want)
+	}
+}
+
+func TestTagKeyBuilderEscapesParts(t *testing.T) {
+	type stockKey struct {
+		Tenant string `json:"tenant" cache:"key"`
+		SKU    string `json:"sku" cache:"key,order=1"`
+	}
+	b, err := cache_key_builder.NewTagKeyBuilder[stockKey]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := &stockKey{Tenant: "acme:eu", SKU: "50%"}
+	key := b.WithPrefix("cache:stock").BuildKey(item)
+	if want := "cache:stock:acme%3Aeu:50%25"; key != want {
+		t.Errorf("BuildKey = %q, want %q", key, want)
+	}
+
+	parser, err := b.WithPrefix("cache:stock").Parser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := parser.Parse(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed["tenant"] != "acme:eu" || parsed["sku"] != "50%" {
+		t.Errorf("Parse = %v", parsed)
+	}
+
+	// 单个键字段不转义，与单列主键的缓存键一致
+	type single struct {
+		Code string `cache:"key"`
+	}
+	sb, err := cache_key_builder.NewTagKeyBuilder[single]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sb.Suffix(&single{Code: "a:b"}); got != "a:b" {
+		t.Errorf("Suffix = %q", got)
+	}
+}
+
+func TestTagKeyBuilderUndeclared(t *testing.T) {
+	b, err := cache_key_builder.TagKeyBuilderOf[untagged]()
+	if b != nil || err != nil {
+		t.Errorf("TagKeyBuilderOf = %v, %v; want nil, nil", b, err)
+	}
+	if _, err := cache_key_builder.NewTagKeyBuilder[untagged](); err == nil {
+		t.Error("expected error for type without key fields")
+	}
+
+	type badOption struct {
+		ID uint `cache:"key,ttl=1"`
+	}
+	if _, err := cache_key_builder.TagKeyBuilderOf[badOption](); err == nil {
+		t.Error("expected error for unknown tag option")
+	}
+	type prefixOnly struct {
+		_  struct{} `cache:"prefix=x"`
+		ID uint
+	}
+	if _, err := cache_key_builder.TagKeyBuilderOf[prefixOnly](); err == nil {
+		t.Error("expected error for prefix without key fields")
+	}
+}
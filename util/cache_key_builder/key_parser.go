@@ -0,0 +1,332 @@
+package cache_key_builder
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ========== 可逆键模板 ==========
+// KeyParser 把 "cache:product:{id}:{category}" 这样的模板编译为匹配器，与 TemplateKeyBuilder 互逆：
+//   - Parse 从具体键中取出各占位符的值，并按 T 的字段类型转换（如 "42" -> uint(42)）
+//   - Pattern 用部分已知的字段值生成 SCAN 模式，未绑定的占位符为 *
+//
+// 占位符之间必须有字面量分隔（"{a}{b}" 无法区分边界）；最后一个占位符之前的值不能包含紧随其后的分隔符，
+// 值可能包含 ":" 时使用 EscapeValues 转义。
+// 占位符按 Go 字段名（大小写不敏感）、json 名或蛇形列名（user_id -> UserID）匹配 T 的字段，
+// 匹配不到字段的占位符值保留为字符串。
+
+// placeholderRegex 模板占位符
+var placeholderRegex = regexp.MustCompile(`\{([^}]+)\}`)
+
+// KeyParser 可逆的键模板
+type KeyParser[T any] struct {
+	template string
+	literals []string   // 占位符之间的字面量，比 fields 多一个（首尾可为空）
+	fields   []keyField // 按出现顺序的占位符
+	matcher  *regexp.Regexp
+	escape   bool // 值按 EscapeKeyPart 转义
+}
+
+// keyField 模板中的一个占位符
+type keyField struct {
+	name string       // 占位符名，如 "id"
+	path []string     // 对应的 Go 字段路径，匹配不到时为空
+	typ  reflect.Type // 字段类型（已去掉指针），匹配不到时为 nil
+}
+
+// NewKeyParser 编译键模板，模板没有占位符、占位符相邻或重复时返回错误
+func NewKeyParser[T any](template string) (*KeyParser[T], error) {
+	locs := placeholderRegex.FindAllStringSubmatchIndex(template, -1)
+	if len(locs) == 0 {
+		return nil, fmt.Errorf("key template %q has no placeholders", template)
+	}
+
+	var zero T
+	typ := reflect.TypeOf(zero)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	p := &KeyParser[T]{template: template}
+	var expr strings.Builder
+	expr.WriteString("^")
+	seen := make(map[string]bool, len(locs))
+	last := 0
+	for i, loc := range locs {
+		literal := template[last:loc[0]]
+		if i > 0 && literal == "" {
+			return nil, fmt.Errorf("key template %q: placeholders must be separated by literals", template)
+		}
+		name := template[loc[2]:loc[3]]
+		if seen[name] {
+			return nil, fmt.Errorf("key template %q: duplicate placeholder {%s}", template, name)
+		}
+		seen[name] = true
+
+		p.literals = append(p.literals, literal)
+		p.fields = append(p.fields, resolveKeyField(typ, name))
+		expr.WriteString(regexp.QuoteMeta(literal))
+		expr.WriteString("(.+?)")
+		last = loc[1]
+	}
+	trailing := template[last:]
+	p.literals = append(p.literals, trailing)
+	expr.WriteString(regexp.QuoteMeta(trailing))
+	expr.WriteString("$")
+
+	p.matcher = regexp.MustCompile(expr.String())
+	return p, nil
+}
+
+// MustKeyParser 同 NewKeyParser，模板非法时 panic（用于初始化阶段的固定模板）
+func MustKeyParser[T any](template string) *KeyParser[T] {
+	p, err := NewKeyParser[T](template)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Parser 返回同一模板的 KeyParser，用于把键解析回字段值
+func (kb *TemplateKeyBuilder[T]) Parser() (*KeyParser[T], error) {
+	return NewKeyParser[T](kb.template)
+}
+
+// EscapeValues 返回转义占位符值的同模板解析器：BuildKey / Pattern 按 EscapeKeyPart 写入值，Parse 先还原再转换类型
+// 与 ServiceManager 复合主键缓存键的格式一致，值中含 ":" 时也能无歧义地解析
+func (p *KeyParser[T]) EscapeValues() *KeyParser[T] {
+	escaped := *p
+	escaped.escape = true
+	return &escaped
+}
+
+// Template 返回原始模板
+func (p *KeyParser[T]) Template() string {
+	return p.template
+}
+
+// Placeholders 按出现顺序返回占位符名
+func (p *KeyParser[T]) Placeholders() []string {
+	names := make([]string, len(p.fields))
+	for i, f := range p.fields {
+		names[i] = f.name
+	}
+	return names
+}
+
+// FieldName 返回占位符对应的 Go 字段名（嵌套字段以 . 连接），匹配不到字段时返回空串
+func (p *KeyParser[T]) FieldName(placeholder string) string {
+	for _, f := range p.fields {
+		if f.name == placeholder {
+			return strings.Join(f.path, ".")
+		}
+	}
+	return ""
+}
+
+// BuildKey 实现 KeyBuilder 接口，字段匹配规则与 Parse 相同；取不到值的占位符原样保留
+func (p *KeyParser[T]) BuildKey(data *T) string {
+	if data == nil {
+		return p.template
+	}
+	val := reflect.ValueOf(data).Elem()
+	for val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+
+	var b strings.Builder
+	for i, f := range p.fields {
+		b.WriteString(p.literals[i])
+		if v, ok := f.value(val); ok {
+			b.WriteString(p.formatValue(v))
+		} else {
+			b.WriteString("{" + f.name + "}")
+		}
+	}
+	b.WriteString(p.literals[len(p.fields)])
+	return b.String()
+}
+
+// Parse 从具体键中解析各占位符的值（占位符名 -> 按字段类型转换后的值）
+func (p *KeyParser[T]) Parse(key string) (map[string]interface{}, error) {
+	m := p.matcher.FindStringSubmatch(key)
+	if m == nil {
+		return nil, fmt.Errorf("key %q does not match template %q", key, p.template)
+	}
+	values := make(map[string]interface{}, len(p.fields))
+	for i, f := range p.fields {
+		text := m[i+1]
+		if p.escape {
+			text = UnescapeKeyPart(text)
+		}
+		v, err := f.convert(text)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: placeholder {%s}: %w", key, f.name, err)
+		}
+		values[f.name] = v
+	}
+	return values, nil
+}
+
+// Match 键是否符合模板（不检查值的类型）
+func (p *KeyParser[T]) Match(key string) bool {
+	return p.matcher.MatchString(key)
+}
+
+// Pattern 生成 Redis SCAN 模式：bound 中给出的占位符代入其值（转义通配符），其余为 *
+func (p *KeyParser[T]) Pattern(bound map[string]interface{}) string {
+	var b strings.Builder
+	for i, f := range p.fields {
+		b.WriteString(EscapeGlob(p.literals[i]))
+		if v, ok := bound[f.name]; ok {
+			b.WriteString(EscapeGlob(p.formatValue(v)))
+		} else {
+			b.WriteString("*")
+		}
+	}
+	b.WriteString(EscapeGlob(p.literals[len(p.fields)]))
+	return b.String()
+}
+
+// formatValue 占位符值在键中的文本
+func (p *KeyParser[T]) formatValue(v interface{}) string {
+	text := fmt.Sprintf("%v", v)
+	if p.escape {
+		return EscapeKeyPart(text)
+	}
+	return text
+}
+
+// keyPartEscaper / keyPartUnescaper 键段中的 "%" 与 ":" 按百分号编码转义
+var (
+	keyPartEscaper   = strings.NewReplacer("%", "%25", ":", "%3A")
+	keyPartUnescaper = strings.NewReplacer("%3A", ":", "%25", "%")
+)
+
+// EscapeKeyPart 转义多段键中的一段，使其不含分隔符 ":"（"acme:eu" -> "acme%3Aeu"）
+func EscapeKeyPart(s string) string {
+	return keyPartEscaper.Replace(s)
+}
+
+// UnescapeKeyPart 还原 EscapeKeyPart 转义的键段
+func UnescapeKeyPart(s string) string {
+	return keyPartUnescaper.Replace(s)
+}
+
+// EscapeGlob 转义 Redis glob 特殊字符（* ? [ ] \），用于把字面量拼进 SCAN / KEYS 模式
+func EscapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// resolveKeyField 按占位符名（支持 "user.id" 嵌套路径）查找字段
+func resolveKeyField(typ reflect.Type, name string) keyField {
+	f := keyField{name: name}
+	current := typ
+	for _, part := range strings.Split(name, ".") {
+		if current == nil || current.Kind() != reflect.Struct {
+			return keyField{name: name}
+		}
+		sf, ok := findStructField(current, part)
+		if !ok {
+			return keyField{name: name}
+		}
+		f.path = append(f.path, sf.Name)
+		current = sf.Type
+		for current.Kind() == reflect.Ptr {
+			current = current.Elem()
+		}
+	}
+	f.typ = current
+	return f
+}
+
+// findStructField 按 Go 名（大小写不敏感）、json 名或蛇形列名查找字段（含嵌入结构体提升的字段）
+func findStructField(typ reflect.Type, name string) (reflect.StructField, bool) {
+	compact := strings.ReplaceAll(name, "_", "")
+	for _, field := range reflect.VisibleFields(typ) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		if strings.EqualFold(field.Name, name) || strings.EqualFold(field.Name, compact) {
+			return field, true
+		}
+		if jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ","); jsonName != "" && strings.EqualFold(jsonName, name) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// value 沿字段路径取值，路径为空或经过 nil 指针时返回 false
+func (f keyField) value(val reflect.Value) (interface{}, bool) {
+	if len(f.path) == 0 || val.Kind() != reflect.Struct {
+		return nil, false
+	}
+	for _, name := range f.path {
+		for val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				return nil, false
+			}
+			val = val.Elem()
+		}
+		val = val.FieldByName(name)
+	}
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil, false
+		}
+		val = val.Elem()
+	}
+	return val.Interface(), true
+}
+
+// convert 将键中的文本转换为字段类型的值
+func (f keyField) convert(s string) (interface{}, error) {
+	if f.typ == nil {
+		return s, nil
+	}
+	v := reflect.New(f.typ).Elem()
+	switch f.typ.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, f.typ.Bits())
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid %s", s, f.typ)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, f.typ.Bits())
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid %s", s, f.typ)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, f.typ.Bits())
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid %s", s, f.typ)
+		}
+		v.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid %s", s, f.typ)
+		}
+		v.SetBool(b)
+	default:
+		// 其他类型（如 time.Time）的 %v 文本不可逆，保留原始字符串
+		return s, nil
+	}
+	return v.Interface(), nil
+}
@@ -0,0 +1,151 @@
+package cache_key_builder
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ========== 命名空间代数 ==========
+// 键中为命名空间的每一级嵌入代数段（如 "cache:user:g3:42"），推进某一级的代数计数器即可一次性使
+// 该命名空间下的所有键失效，不需要按模式扫描删除：旧代数的键不再被读到，随 TTL 自然过期（或由清理任务删除）。
+//
+// 子命名空间的前缀包含各上级的代数：Prefix(ctx, "cache:user", "tenant:7") -> "cache:user:g3:tenant:7:g1"，
+// 推进 "cache:user" 使所有租户的键失效，推进 "cache:user" + "tenant:7" 只影响该租户。
+//
+// 代数在进程内缓存 localTTL：本进程推进后立即可见，其他进程最多延迟 localTTL 才读到新代数。
+
+// GenerationStore 代数计数器存储（通常由 Redis GET / INCR 实现）
+type GenerationStore interface {
+	// Get 读取计数器，不存在时返回 0
+	Get(ctx context.Context, counterKey string) (int64, error)
+	// Incr 计数器加一并返回新值
+	Incr(ctx context.Context, counterKey string) (int64, error)
+}
+
+// Generations 带进程内缓存的命名空间代数表
+type Generations struct {
+	store    GenerationStore
+	localTTL time.Duration
+
+	mu     sync.Mutex
+	cached map[string]cachedGeneration // 计数器键 -> 代数
+}
+
+type cachedGeneration struct {
+	value   int64
+	expires time.Time
+}
+
+// NewGenerations 创建代数表，localTTL 为进程内缓存时间（<= 0 时每次都读取存储）
+func NewGenerations(store GenerationStore, localTTL time.Duration) *Generations {
+	return &Generations{
+		store:    store,
+		localTTL: localTTL,
+		cached:   make(map[string]cachedGeneration),
+	}
+}
+
+// CounterKey 命名空间路径的代数计数器键，如 CounterKey("cache:user", "tenant:7") -> "gen:cache:user:tenant:7"
+// 计数器不在命名空间内，按命名空间前缀 SCAN 时不会扫到
+func CounterKey(path ...string) string {
+	return "gen:" + strings.Join(path, ":")
+}
+
+// GenerationSegment 代数段，如 3 -> "g3"
+func GenerationSegment(gen int64) string {
+	return "g" + strconv.FormatInt(gen, 10)
+}
+
+// ParseGenerationSegment 解析代数段，不是代数段时返回 false
+func ParseGenerationSegment(segment string) (int64, bool) {
+	digits, ok := strings.CutPrefix(segment, "g")
+	if !ok || digits == "" {
+		return 0, false
+	}
+	gen, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || gen < 0 {
+		return 0, false
+	}
+	return gen, true
+}
+
+// Current 返回命名空间路径最后一级的当前代数
+func (g *Generations) Current(ctx context.Context, path ...string) (int64, error) {
+	if len(path) == 0 {
+		return 0, fmt.Errorf("namespace path is empty")
+	}
+	return g.get(ctx, CounterKey(path...))
+}
+
+// Prefix 返回命名空间路径每一级后追加代数段的键前缀
+func (g *Generations) Prefix(ctx context.Context, path ...string) (string, error) {
+	if len(path) == 0 {
+		return "", fmt.Errorf("namespace path is empty")
+	}
+	parts := make([]string, 0, len(path)*2)
+	for i, segment := range path {
+		gen, err := g.get(ctx, CounterKey(path[:i+1]...))
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, segment, GenerationSegment(gen))
+	}
+	return strings.Join(parts, ":"), nil
+}
+
+// Key 返回命名空间下的键：Prefix(path...) + ":" + key
+func (g *Generations) Key(ctx context.Context, key string, path ...string) (string, error) {
+	prefix, err := g.Prefix(ctx, path...)
+	if err != nil {
+		return "", err
+	}
+	return prefix + ":" + key, nil
+}
+
+// Bump 推进命名空间路径最后一级的代数，使其下所有键失效，返回新代数
+func (g *Generations) Bump(ctx context.Context, path ...string) (int64, error) {
+	if len(path) == 0 {
+		return 0, fmt.Errorf("namespace path is empty")
+	}
+	counterKey := CounterKey(path...)
+	gen, err := g.store.Incr(ctx, counterKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to bump generation %s: %w", counterKey, err)
+	}
+	g.remember(counterKey, gen)
+	return gen, nil
+}
+
+// get 读取代数：优先使用未过期的本地缓存；存储读取失败但有旧的本地值时沿用旧值
+func (g *Generations) get(ctx context.Context, counterKey string) (int64, error) {
+	g.mu.Lock()
+	cached, ok := g.cached[counterKey]
+	g.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.value, nil
+	}
+
+	gen, err := g.store.Get(ctx, counterKey)
+	if err != nil {
+		if ok {
+			return cached.value, nil
+		}
+		return 0, fmt.Errorf("failed to read generation %s: %w", counterKey, err)
+	}
+	g.remember(counterKey, gen)
+	return gen, nil
+}
+
+// remember 写入本地缓存；不会用较小的值覆盖本进程刚推进的代数
+func (g *Generations) remember(counterKey string, gen int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if cached, ok := g.cached[counterKey]; ok && cached.value > gen && time.Now().Before(cached.expires) {
+		return
+	}
+	g.cached[counterKey] = cachedGeneration{value: gen, expires: time.Now().Add(g.localTTL)}
+}
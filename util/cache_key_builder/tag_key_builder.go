@@ -0,0 +1,325 @@
+package cache_key_builder
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// ========== 结构体标签声明的键 ==========
+// T 可以用 cache 标签声明自己的缓存键，不必在每个路由组 / service 调用处传模板或函数：
+//
+//	type Product struct {
+//	    _        struct{} `cache:"prefix=cache:product"`
+//	    ID       uint     `json:"id" cache:"key"`
+//	    Category string   `json:"category" cache:"key,order=2"`
+//	}
+//
+// 生成的键为 "cache:product:42:books"。键字段按 order 升序拼接（多个键字段时各值中的 ":" 与 "%" 按 EscapeKeyPart 转义），未写 order 的为 0、相同 order 按声明顺序；
+// 嵌入结构体（非指针）的字段也可以作为键字段。prefix 可省略，此时由使用方决定前缀（ServiceManager 使用 CacheKeyType:CacheKeyName）。
+//
+// 每个类型只解析一次标签：键字段编译为按偏移量读取的访问器，BuildKey 对基本类型不再走反射；
+// 实现了 fmt.Stringer 的类型与其他类型（如 time.Time、uuid）按 %v 格式化。
+
+// TagKeyBuilder 按 cache 标签生成键的构建器
+type TagKeyBuilder[T any] struct {
+	layout *tagLayout
+	prefix string
+}
+
+// tagLayout 一个类型解析后的键布局（按类型缓存）
+type tagLayout struct {
+	prefix string
+	fields []tagKeyField
+}
+
+// tagKeyField 一个键字段
+type tagKeyField struct {
+	name     string // 模板中的占位符名（json 名，没有时为 Go 字段名）
+	goName   string
+	order    int
+	offset   uintptr
+	appendTo func(b []byte, p unsafe.Pointer) []byte
+}
+
+// tagLayoutEntry 缓存项：类型没有声明键字段时 layout 为 nil
+type tagLayoutEntry struct {
+	layout *tagLayout
+	err    error
+}
+
+// tagLayouts reflect.Type -> tagLayoutEntry
+var tagLayouts sync.Map
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// NewTagKeyBuilder 按 T 的 cache 标签创建键构建器，T 没有声明键字段或标签非法时返回错误
+func NewTagKeyBuilder[T any]() (*TagKeyBuilder[T], error) {
+	b, err := TagKeyBuilderOf[T]()
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		var zero T
+		return nil, fmt.Errorf("type %T declares no cache key fields", zero)
+	}
+	return b, nil
+}
+
+// TagKeyBuilderOf 返回 T 的标签键构建器；T 没有声明键字段时返回 nil, nil（调用方使用自己的默认键）
+func TagKeyBuilderOf[T any]() (*TagKeyBuilder[T], error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	cached, ok := tagLayouts.Load(typ)
+	if !ok {
+		layout, err := parseTagLayout(typ)
+		cached, _ = tagLayouts.LoadOrStore(typ, tagLayoutEntry{layout: layout, err: err})
+	}
+	entry := cached.(tagLayoutEntry)
+	if entry.err != nil || entry.layout == nil {
+		return nil, entry.err
+	}
+	return &TagKeyBuilder[T]{layout: entry.layout, prefix: entry.layout.prefix}, nil
+}
+
+// WithPrefix 返回使用另一个前缀的构建器（覆盖标签中的 prefix）
+func (b *TagKeyBuilder[T]) WithPrefix(prefix string) *TagKeyBuilder[T] {
+	return &TagKeyBuilder[T]{layout: b.layout, prefix: prefix}
+}
+
+// Prefix 返回键前缀，未声明时为空串
+func (b *TagKeyBuilder[T]) Prefix() string {
+	return b.prefix
+}
+
+// Fields 按拼接顺序返回键字段的 Go 字段名
+func (b *TagKeyBuilder[T]) Fields() []string {
+	names := make([]string, len(b.layout.fields))
+	for i, f := range b.layout.fields {
+		names[i] = f.goName
+	}
+	return names
+}
+
+// BuildKey 实现 KeyBuilder 接口：前缀 + 各键字段的值，以 ":" 分隔；data 为 nil 时返回 Template()
+func (b *TagKeyBuilder[T]) BuildKey(data *T) string {
+	if data == nil {
+		return b.Template()
+	}
+	buf := make([]byte, 0, len(b.prefix)+16*len(b.layout.fields))
+	if b.prefix != "" {
+		buf = append(buf, b.prefix...)
+		buf = append(buf, ':')
+	}
+	return string(b.layout.appendSuffix(buf, unsafe.Pointer(data)))
+}
+
+// Suffix 返回不含前缀的键部分（各键字段的值），供使用方拼接自己的前缀
+func (b *TagKeyBuilder[T]) Suffix(data *T) string {
+	return string(b.layout.appendSuffix(nil, unsafe.Pointer(data)))
+}
+
+// Template 返回等价的键模板（如 "cache:product:{id}:{category}"）；解析键请使用 Parser，多个键字段时值经过转义
+func (b *TagKeyBuilder[T]) Template() string {
+	if b.prefix == "" {
+		return b.SuffixTemplate()
+	}
+	return b.prefix + ":" + b.SuffixTemplate()
+}
+
+// SuffixTemplate 返回不含前缀的模板部分（如 "{id}:{category}"）
+func (b *TagKeyBuilder[T]) SuffixTemplate() string {
+	parts := make([]string, len(b.layout.fields))
+	for i, f := range b.layout.fields {
+		parts[i] = "{" + f.name + "}"
+	}
+	return strings.Join(parts, ":")
+}
+
+// Parser 返回按 Template 解析键的 KeyParser，多个键字段时按 EscapeValues 还原转义的值
+func (b *TagKeyBuilder[T]) Parser() (*KeyParser[T], error) {
+	p, err := NewKeyParser[T](b.Template())
+	if err != nil {
+		return nil, err
+	}
+	if len(b.layout.fields) > 1 {
+		p = p.EscapeValues()
+	}
+	return p, nil
+}
+
+// appendSuffix 依次追加各键字段的值；多个键字段时各值按 EscapeKeyPart 转义，与复合主键缓存键的格式一致
+func (l *tagLayout) appendSuffix(buf []byte, p unsafe.Pointer) []byte {
+	if len(l.fields) == 1 {
+		f := l.fields[0]
+		return f.appendTo(buf, unsafe.Add(p, f.offset))
+	}
+	for i, f := range l.fields {
+		if i > 0 {
+			buf = append(buf, ':')
+		}
+		start := len(buf)
+		buf = f.appendTo(buf, unsafe.Add(p, f.offset))
+		if bytes.ContainsAny(buf[start:], "%:") {
+			part := EscapeKeyPart(string(buf[start:]))
+			buf = append(buf[:start], part...)
+		}
+	}
+	return buf
+}
+
+// parseTagLayout 解析类型的 cache 标签，没有键字段时返回 nil
+func parseTagLayout(typ reflect.Type) (*tagLayout, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	layout := &tagLayout{}
+	for _, sf := range reflect.VisibleFields(typ) {
+		tag, ok := sf.Tag.Lookup("cache")
+		if !ok || tag == "-" {
+			continue
+		}
+		isKey, order, prefix, err := parseCacheTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", typ, sf.Name, err)
+		}
+		if prefix != "" {
+			if layout.prefix != "" && layout.prefix != prefix {
+				return nil, fmt.Errorf("%s: conflicting cache prefixes %q and %q", typ, layout.prefix, prefix)
+			}
+			layout.prefix = prefix
+		}
+		if !isKey {
+			continue
+		}
+		if !sf.IsExported() {
+			return nil, fmt.Errorf("%s.%s: cache key field must be exported", typ, sf.Name)
+		}
+
+		offset, err := fieldOffset(typ, sf.Index)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", typ, sf.Name, err)
+		}
+		name := sf.Name
+		if jsonName, _, _ := strings.Cut(sf.Tag.Get("json"), ","); jsonName != "" && jsonName != "-" {
+			name = jsonName
+		}
+		layout.fields = append(layout.fields, tagKeyField{
+			name:     name,
+			goName:   sf.Name,
+			order:    order,
+			offset:   offset,
+			appendTo: fieldAppender(sf.Type),
+		})
+	}
+
+	if len(layout.fields) == 0 {
+		if layout.prefix != "" {
+			return nil, fmt.Errorf("%s declares a cache prefix but no key fields", typ)
+		}
+		return nil, nil
+	}
+	slices.SortStableFunc(layout.fields, func(a, b tagKeyField) int { return a.order - b.order })
+	return layout, nil
+}
+
+// parseCacheTag 解析 cache 标签：key、order=N、prefix=xxx，以逗号分隔
+func parseCacheTag(tag string) (isKey bool, order int, prefix string, err error) {
+	for _, part := range strings.Split(tag, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch name {
+		case "key":
+			isKey = true
+		case "order":
+			if order, err = strconv.Atoi(value); err != nil {
+				return false, 0, "", fmt.Errorf("invalid cache tag order %q", value)
+			}
+		case "prefix":
+			if value == "" {
+				return false, 0, "", fmt.Errorf("empty cache tag prefix")
+			}
+			prefix = value
+		case "":
+		default:
+			return false, 0, "", fmt.Errorf("unknown cache tag option %q", name)
+		}
+	}
+	if order != 0 && !isKey {
+		return false, 0, "", fmt.Errorf("cache tag order requires key")
+	}
+	return isKey, order, prefix, nil
+}
+
+// fieldOffset 计算（可能经由嵌入结构体提升的）字段相对外层结构体的偏移量，不支持经过指针嵌入的字段
+func fieldOffset(typ reflect.Type, index []int) (uintptr, error) {
+	var offset uintptr
+	current := typ
+	for i, idx := range index {
+		if current.Kind() != reflect.Struct {
+			return 0, fmt.Errorf("cache key field is promoted through an embedded pointer")
+		}
+		sf := current.Field(idx)
+		offset += sf.Offset
+		if i < len(index)-1 {
+			current = sf.Type
+		}
+	}
+	return offset, nil
+}
+
+// fieldAppender 按字段类型生成追加函数：基本类型直接读内存，其余类型按 %v 格式化
+func fieldAppender(typ reflect.Type) func([]byte, unsafe.Pointer) []byte {
+	if typ.Implements(stringerType) || reflect.PointerTo(typ).Implements(stringerType) {
+		return formatAppender(typ)
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		return func(b []byte, p unsafe.Pointer) []byte { return append(b, *(*string)(p)...) }
+	case reflect.Int:
+		return func(b []byte, p unsafe.Pointer) []byte { return strconv.AppendInt(b, int64(*(*int)(p)), 10) }
+	case reflect.Int8:
+		return func(b []byte, p unsafe.Pointer) []byte { return strconv.AppendInt(b, int64(*(*int8)(p)), 10) }
+	case reflect.Int16:
+		return func(b []byte, p unsafe.Pointer) []byte { return strconv.AppendInt(b, int64(*(*int16)(p)), 10) }
+	case reflect.Int32:
+		return func(b []byte, p unsafe.Pointer) []byte { return strconv.AppendInt(b, int64(*(*int32)(p)), 10) }
+	case reflect.Int64:
+		return func(b []byte, p unsafe.Pointer) []byte { return strconv.AppendInt(b, *(*int64)(p), 10) }
+	case reflect.Uint:
+		return func(b []byte, p unsafe.Pointer) []byte { return strconv.AppendUint(b, uint64(*(*uint)(p)), 10) }
+	case reflect.Uint8:
+		return func(b []byte, p unsafe.Pointer) []byte { return strconv.AppendUint(b, uint64(*(*uint8)(p)), 10) }
+	case reflect.Uint16:
+		return func(b []byte, p unsafe.Pointer) []byte { return strconv.AppendUint(b, uint64(*(*uint16)(p)), 10) }
+	case reflect.Uint32:
+		return func(b []byte, p unsafe.Pointer) []byte { return strconv.AppendUint(b, uint64(*(*uint32)(p)), 10) }
+	case reflect.Uint64:
+		return func(b []byte, p unsafe.Pointer) []byte { return strconv.AppendUint(b, *(*uint64)(p), 10) }
+	case reflect.Float32:
+		return func(b []byte, p unsafe.Pointer) []byte {
+			return strconv.AppendFloat(b, float64(*(*float32)(p)), 'g', -1, 32)
+		}
+	case reflect.Float64:
+		return func(b []byte, p unsafe.Pointer) []byte { return strconv.AppendFloat(b, *(*float64)(p), 'g', -1, 64) }
+	case reflect.Bool:
+		return func(b []byte, p unsafe.Pointer) []byte { return strconv.AppendBool(b, *(*bool)(p)) }
+	default:
+		return formatAppender(typ)
+	}
+}
+
+// formatAppender 按 %v 格式化（指针字段取其指向的值，nil 为 "<nil>"）
+func formatAppender(typ reflect.Type) func([]byte, unsafe.Pointer) []byte {
+	return func(b []byte, p unsafe.Pointer) []byte {
+		v := reflect.NewAt(typ, p).Elem()
+		for v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+		return fmt.Append(b, v.Interface())
+	}
+}
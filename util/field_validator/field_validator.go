@@ -0,0 +1,349 @@
+package field_validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm/schema"
+)
+
+// ========== 校验分组 ==========
+
+// Group 校验分组，不同写操作使用不同的规则
+type Group string
+
+const (
+	GroupCreate Group = "create" // 新增 / Upsert：读取 validate_create 标签，缺省时使用 validate
+	GroupUpdate Group = "update" // 部分更新：读取 validate_update 标签，缺省时使用 validate
+)
+
+// 结构体标签名
+const (
+	TagValidate       = "validate"
+	TagValidateCreate = "validate_create"
+	TagValidateUpdate = "validate_update"
+)
+
+// tagFor 返回分组对应的标签名
+func tagFor(group Group) string {
+	switch group {
+	case GroupCreate:
+		return TagValidateCreate
+	case GroupUpdate:
+		return TagValidateUpdate
+	default:
+		return ""
+	}
+}
+
+// ========== 错误 ==========
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`           // 字段名（json 名，批量时带下标前缀，如 "data[2].email"）
+	Rule    string `json:"rule"`            // 未通过的规则，如 "required"、"min"
+	Param   string `json:"param,omitempty"` // 规则参数，如 min=3 中的 "3"
+	Message string `json:"message"`
+}
+
+// Errors 字段校验错误列表
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, fe := range e {
+		parts = append(parts, fe.Message)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// WithPrefix 为所有字段名加上前缀（用于批量数据，如 "data[2]"）
+func (e Errors) WithPrefix(prefix string) Errors {
+	out := make(Errors, len(e))
+	for i, fe := range e {
+		fe.Field = prefix + "." + fe.Field
+		out[i] = fe
+	}
+	return out
+}
+
+// ========== 校验器 ==========
+
+// fieldRule 单个字段解析后的规则
+type fieldRule struct {
+	index  []int        // reflect 字段索引（支持嵌入结构体）
+	typ    reflect.Type // 字段类型
+	name   string       // json 名；json:"-" 的字段为 Go 字段名
+	goName string       // Go 字段名
+	hidden bool         // json:"-"：不会从请求体绑定，只能经更新 map 按列名 / Go 字段名写入
+	rules  string       // 当前分组生效的规则
+}
+
+// Validator 基于结构体标签的字段校验器，可并发使用
+type Validator struct {
+	validate *validator.Validate
+	rules    sync.Map // ruleCacheKey -> []fieldRule
+	schemas  sync.Map // Map 解析的 GORM schema 缓存（默认命名策略）
+}
+
+type ruleCacheKey struct {
+	typ   reflect.Type
+	group Group
+}
+
+// New 创建校验器
+func New() *Validator {
+	return &Validator{validate: validator.New()}
+}
+
+// Default 默认校验器
+var Default = New()
+
+// RegisterValidation 注册自定义校验规则，注册后即可在标签中使用，如 `validate:"mobile"`
+func (v *Validator) RegisterValidation(tag string, fn validator.Func, callValidationEvenIfNull ...bool) error {
+	if err := v.validate.RegisterValidation(tag, fn, callValidationEvenIfNull...); err != nil {
+		return fmt.Errorf("register validation %q failed: %w", tag, err)
+	}
+	return nil
+}
+
+// Struct 按分组校验结构体（或结构体指针），返回 Errors 或 nil
+func (v *Validator) Struct(data interface{}, group Group) error {
+	val := reflect.ValueOf(data)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return Errors{{Field: "data", Rule: "required", Message: "data is required"}}
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return fmt.Errorf("field_validator: expected struct, got %s", val.Kind())
+	}
+
+	var errs Errors
+	for _, fr := range v.fieldRules(val.Type(), group) {
+		// 隐藏字段不会从请求体绑定，创建时由服务端填充，不在这里校验
+		if fr.rules == "" || fr.hidden {
+			continue
+		}
+		fieldErrs, err := v.check(fr, val.FieldByIndex(fr.index).Interface())
+		if err != nil {
+			return err
+		}
+		errs = append(errs, fieldErrs...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Map 按分组校验更新字段 map（如 UpdateRequest.Updates）
+// model 为目标 GORM 模型（或其指针），列名按 GORM 默认命名策略解析；
+// 数据库使用自定义命名策略时请改用 MapSchema 传入数据库解析出的 schema。
+func (v *Validator) Map(model interface{}, updates map[string]interface{}, group Group) error {
+	s, err := schema.Parse(model, &v.schemas, schema.NamingStrategy{})
+	if err != nil {
+		return fmt.Errorf("field_validator: parse model failed: %w", err)
+	}
+	return v.MapSchema(s, updates, group)
+}
+
+// MapSchema 按分组校验更新字段 map，s 为目标模型的 GORM schema
+// map 的键可以是 json 名、列名（含嵌入前缀）或 Go 字段名；json:"-" 的字段同样按列名 / Go 字段名校验。
+// 值会先按字段类型解码，类型不匹配时报告 "type" 错误。未在结构体中声明的键不做校验。
+func (v *Validator) MapSchema(s *schema.Schema, updates map[string]interface{}, group Group) error {
+	rules := v.fieldRules(s.ModelType, group)
+	byIndex := make(map[string]fieldRule, len(rules))
+	byKey := make(map[string]fieldRule, len(rules)*3)
+	for _, fr := range rules {
+		byIndex[indexKey(fr.index)] = fr
+		if !fr.hidden {
+			byKey[fr.name] = fr
+		}
+		byKey[fr.goName] = fr
+	}
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		if fr, ok := byIndex[indexKey(field.StructField.Index)]; ok {
+			byKey[field.DBName] = fr
+		}
+	}
+
+	var errs Errors
+	for key, raw := range updates {
+		fr, ok := byKey[key]
+		if !ok {
+			continue
+		}
+		value, err := decodeAs(raw, fr.typ)
+		if err != nil {
+			errs = append(errs, FieldError{
+				Field:   fr.name,
+				Rule:    "type",
+				Param:   fr.typ.String(),
+				Message: fmt.Sprintf("%s must be of type %s", fr.name, fr.typ),
+			})
+			continue
+		}
+		if fr.rules != "" {
+			fieldErrs, err := v.check(fr, value)
+			if err != nil {
+				return err
+			}
+			errs = append(errs, fieldErrs...)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// indexKey 字段索引的字符串形式；GORM 用负数（-i-1）标记指针嵌入，这里统一还原
+func indexKey(index []int) string {
+	var b strings.Builder
+	for _, i := range index {
+		if i < 0 {
+			i = -i - 1
+		}
+		fmt.Fprintf(&b, "%d.", i)
+	}
+	return b.String()
+}
+
+// check 用字段规则校验单个值
+// 返回的 error 表示规则本身有误（如未注册的规则名），属于配置错误而非数据错误
+func (v *Validator) check(fr fieldRule, value interface{}) (fieldErrs Errors, err error) {
+	defer func() {
+		// validator 遇到未注册的规则会 panic
+		if r := recover(); r != nil {
+			fieldErrs, err = nil, fmt.Errorf("field_validator: invalid rules %q on field %s: %v", fr.rules, fr.goName, r)
+		}
+	}()
+
+	verr := v.validate.Var(value, fr.rules)
+	if verr == nil {
+		return nil, nil
+	}
+	verrs, ok := verr.(validator.ValidationErrors)
+	if !ok {
+		return nil, fmt.Errorf("field_validator: validate field %s failed: %w", fr.goName, verr)
+	}
+	out := make(Errors, 0, len(verrs))
+	for _, fe := range verrs {
+		out = append(out, FieldError{
+			Field:   fr.name,
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: message(fr.name, fe.Tag(), fe.Param()),
+		})
+	}
+	return out, nil
+}
+
+// fieldRules 解析并缓存结构体的字段规则
+func (v *Validator) fieldRules(t reflect.Type, group Group) []fieldRule {
+	key := ruleCacheKey{typ: t, group: group}
+	if cached, ok := v.rules.Load(key); ok {
+		return cached.([]fieldRule)
+	}
+	rules := collectRules(t, group, nil)
+	v.rules.Store(key, rules)
+	return rules
+}
+
+func collectRules(t reflect.Type, group Group, parent []int) []fieldRule {
+	var out []fieldRule
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		index := append(append([]int{}, parent...), i)
+
+		// 嵌入结构体（如 gorm.Model）展开处理
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			out = append(out, collectRules(f.Type, group, index)...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		name, hidden := f.Name, false
+		if tag := f.Tag.Get("json"); tag == "-" {
+			hidden = true
+		} else if n := strings.Split(tag, ",")[0]; n != "" {
+			name = n
+		}
+
+		rules, ok := f.Tag.Lookup(tagFor(group))
+		if !ok {
+			rules = f.Tag.Get(TagValidate)
+		}
+
+		out = append(out, fieldRule{
+			index:  index,
+			typ:    f.Type,
+			name:   name,
+			goName: f.Name,
+			hidden: hidden,
+			rules:  rules,
+		})
+	}
+	return out
+}
+
+// decodeAs 将 JSON 解码得到的任意值转换为字段类型
+func decodeAs(raw interface{}, t reflect.Type) (interface{}, error) {
+	if raw == nil {
+		return reflect.Zero(t).Interface(), nil
+	}
+	if reflect.TypeOf(raw) == t {
+		return raw, nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	ptr := reflect.New(t)
+	if err := json.Unmarshal(b, ptr.Interface()); err != nil {
+		return nil, err
+	}
+	return ptr.Elem().Interface(), nil
+}
+
+// message 生成可读的错误信息
+func message(field, rule, param string) string {
+	switch rule {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "email":
+		return fmt.Sprintf("%s must be a valid email", field)
+	case "url":
+		return fmt.Sprintf("%s must be a valid url", field)
+	case "uuid":
+		return fmt.Sprintf("%s must be a valid uuid", field)
+	case "min", "gte":
+		return fmt.Sprintf("%s must be at least %s", field, param)
+	case "max", "lte":
+		return fmt.Sprintf("%s must be at most %s", field, param)
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, param)
+	case "lt":
+		return fmt.Sprintf("%s must be less than %s", field, param)
+	case "len":
+		return fmt.Sprintf("%s must have length %s", field, param)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s]", field, param)
+	default:
+		if param != "" {
+			return fmt.Sprintf("%s failed on rule %s=%s", field, rule, param)
+		}
+		return fmt.Sprintf("%s failed on rule %s", field, rule)
+	}
+}
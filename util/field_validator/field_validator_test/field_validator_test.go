@@ -0,0 +1,165 @@
+package field_validator_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"AbstractManager/util/field_validator"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm/schema"
+)
+
+type user struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name" validate:"required,min=2"`
+	Email string `json:"email" validate:"required,email" validate_update:"omitempty,email"`
+	Age   int    `json:"age" gorm:"column:user_age" validate:"gte=0,lte=150"`
+}
+
+type member struct {
+	Base     `gorm:"embedded;embeddedPrefix:base_"`
+	Password string `json:"-" validate:"required,min=8"`
+}
+
+type Base struct {
+	Nick string `json:"nick" validate:"omitempty,min=2"`
+}
+
+type contact struct {
+	Phone string `json:"phone" validate:"omitempty,mobile"`
+}
+
+func fieldErrors(t *testing.T, err error) field_validator.Errors {
+	t.Helper()
+	var errs field_validator.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected field_validator.Errors, got %v", err)
+	}
+	return errs
+}
+
+func hasField(errs field_validator.Errors, field, rule string) bool {
+	for _, fe := range errs {
+		if fe.Field == field && fe.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func TestStructCreateGroup(t *testing.T) {
+	v := field_validator.New()
+
+	if err := v.Struct(&user{Name: "tom", Email: "tom@example.com", Age: 20}, field_validator.GroupCreate); err != nil {
+		t.Fatalf("valid user rejected: %v", err)
+	}
+
+	errs := fieldErrors(t, v.Struct(&user{Name: "t", Age: 200}, field_validator.GroupCreate))
+	for _, want := range [][2]string{{"name", "min"}, {"email", "required"}, {"age", "lte"}} {
+		if !hasField(errs, want[0], want[1]) {
+			t.Errorf("missing error %s/%s in %v", want[0], want[1], errs)
+		}
+	}
+
+	errs = fieldErrors(t, v.Struct((*user)(nil), field_validator.GroupCreate))
+	if !hasField(errs, "data", "required") {
+		t.Errorf("nil data: got %v", errs)
+	}
+}
+
+func TestMapUpdateGroup(t *testing.T) {
+	v := field_validator.New()
+
+	// update 分组下 email 可为空；未声明的键不校验
+	if err := v.Map(user{}, map[string]interface{}{"email": "", "unknown": 1}, field_validator.GroupUpdate); err != nil {
+		t.Fatalf("valid updates rejected: %v", err)
+	}
+
+	// 列名与 Go 字段名都能匹配到规则；JSON 数字解码为 float64 后按字段类型转换
+	errs := fieldErrors(t, v.Map(&user{}, map[string]interface{}{
+		"user_age": float64(-1),
+		"Name":     "x",
+		"email":    "bad",
+	}, field_validator.GroupUpdate))
+	for _, want := range [][2]string{{"age", "gte"}, {"name", "min"}, {"email", "email"}} {
+		if !hasField(errs, want[0], want[1]) {
+			t.Errorf("missing error %s/%s in %v", want[0], want[1], errs)
+		}
+	}
+
+	errs = fieldErrors(t, v.Map(user{}, map[string]interface{}{"age": "old"}, field_validator.GroupUpdate))
+	if !hasField(errs, "age", "type") {
+		t.Errorf("type mismatch: got %v", errs)
+	}
+}
+
+func TestMapHiddenAndEmbeddedFields(t *testing.T) {
+	v := field_validator.New()
+
+	// json:"-" 的字段按 Go 字段名与列名校验，不会因为不在请求体中而被跳过
+	for _, key := range []string{"Password", "password"} {
+		errs := fieldErrors(t, v.Map(member{}, map[string]interface{}{key: ""}, field_validator.GroupUpdate))
+		if !hasField(errs, "Password", "required") {
+			t.Errorf("%s: got %v", key, errs)
+		}
+	}
+	if err := v.Map(member{}, map[string]interface{}{"password": "long-enough"}, field_validator.GroupUpdate); err != nil {
+		t.Errorf("valid password rejected: %v", err)
+	}
+
+	// 创建时隐藏字段不从请求体绑定，不校验
+	if err := v.Struct(member{}, field_validator.GroupCreate); err != nil {
+		t.Errorf("hidden field validated on create: %v", err)
+	}
+
+	// 嵌入前缀的列名来自 GORM schema
+	errs := fieldErrors(t, v.Map(member{}, map[string]interface{}{"base_nick": "x"}, field_validator.GroupUpdate))
+	if !hasField(errs, "nick", "min") {
+		t.Errorf("embedded prefix column: got %v", errs)
+	}
+
+	// 自定义命名策略：列名按传入的 schema 解析
+	s, err := schema.Parse(&member{}, &sync.Map{}, schema.NamingStrategy{TablePrefix: "t_", NoLowerCase: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs = fieldErrors(t, v.MapSchema(s, map[string]interface{}{"base_Nick": "x"}, field_validator.GroupUpdate))
+	if !hasField(errs, "nick", "min") {
+		t.Errorf("custom naming: got %v", errs)
+	}
+}
+
+func TestRegisterValidation(t *testing.T) {
+	v := field_validator.New()
+	err := v.RegisterValidation("mobile", func(fl validator.FieldLevel) bool {
+		s := fl.Field().String()
+		return len(s) == 11 && strings.HasPrefix(s, "1")
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	// 未注册的规则属于配置错误，不是字段错误
+	if err := field_validator.New().Struct(contact{Phone: "1"}, field_validator.GroupCreate); err == nil {
+		t.Fatal("expected error for unregistered rule")
+	} else if errors.As(err, new(field_validator.Errors)) {
+		t.Fatalf("unregistered rule reported as field error: %v", err)
+	}
+
+	valid := contact{Phone: "13800000000"}
+	if err := v.Struct(valid, field_validator.GroupCreate); err != nil {
+		t.Fatalf("valid phone rejected: %v", err)
+	}
+
+	valid.Phone = "12345"
+	errs := fieldErrors(t, v.Struct(valid, field_validator.GroupCreate))
+	if !hasField(errs, "phone", "mobile") {
+		t.Errorf("custom rule: got %v", errs)
+	}
+	if got := errs.WithPrefix("data[3]")[0].Field; got != "data[3].phone" {
+		t.Errorf("WithPrefix: got %q", got)
+	}
+}
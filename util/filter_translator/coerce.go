@@ -0,0 +1,304 @@
+package filter_translator
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// ========== 按字段类型转换过滤值 ==========
+// 注册表绑定模型 schema 后（ForSchema），翻译前先把 JSON / 查询字符串中的值转换为目标字段的 Go 类型：
+//
+//   - 整数 / 无符号整数：接受整数值的数字与数字字符串，检查溢出与符号（"18" -> int64(18)，1.5 报错）
+//   - 浮点：数字或数字字符串；decimal / numeric 列保留为规范化的数字字符串，避免精度损失
+//   - 布尔：true / false、"true" / "false" / "1" / "0"、0 / 1
+//   - 时间：time.Time、RFC3339、"2006-01-02 15:04:05"、"2006-01-02T15:04:05"、"2006-01-02"（本地时区）或 Unix 秒
+//   - 字符串：字符串原样保留，数字与布尔转为其文本形式
+//   - 枚举：字段类型实现 Enum 时，值必须是 EnumValues 之一
+//
+// in / not_in / between / not_between 逐个转换数组元素；like 等字符串操作符只要求值为字符串；
+// 找不到字段（如原始 SQL 表达式）时不做转换。
+
+// Enum 枚举类型（通常是具名 string / int 类型）实现该接口后，过滤值会校验是否在取值范围内
+type Enum interface {
+	EnumValues() []string
+}
+
+// timeLayouts 时间字符串支持的格式
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+
+// enumType Enum 接口类型
+var enumType = reflect.TypeOf((*Enum)(nil)).Elem()
+
+// CoerceParam 按字段类型转换过滤参数的值，返回副本
+func CoerceParam(field *schema.Field, param FilterParam) (FilterParam, error) {
+	switch param.Operator {
+	case "=", "!=", ">", ">=", "<", "<=":
+		v, err := CoerceValue(field, param.Value)
+		if err != nil {
+			return param, err
+		}
+		param.Value = v
+	case "in", "not_in", "between", "not_between":
+		values, ok := param.Value.([]interface{})
+		if !ok {
+			return param, nil // 由翻译器校验数组形状
+		}
+		coerced := make([]interface{}, len(values))
+		for i, item := range values {
+			v, err := CoerceValue(field, item)
+			if err != nil {
+				return param, err
+			}
+			coerced[i] = v
+		}
+		param.Value = coerced
+	case "like", "starts_with", "ends_with", "ieq", "ilike", "regex":
+		if _, ok := param.Value.(string); !ok {
+			return param, fmt.Errorf("value must be string for %s operator", param.Operator)
+		}
+	}
+	return param, nil
+}
+
+// CoerceValue 将值转换为字段的 Go 类型（nil 保持为 nil），无法转换时返回错误
+func CoerceValue(field *schema.Field, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	coerced, err := coerceByType(field, v)
+	if err != nil {
+		return nil, fmt.Errorf("field %s: %w", field.Name, err)
+	}
+	if err := checkEnum(field, coerced); err != nil {
+		return nil, fmt.Errorf("field %s: %w", field.Name, err)
+	}
+	return coerced, nil
+}
+
+func coerceByType(field *schema.Field, v interface{}) (interface{}, error) {
+	kind := field.IndirectFieldType.Kind()
+	switch field.DataType {
+	case schema.Int:
+		n, err := coerceInt(v)
+		if err != nil {
+			return nil, err
+		}
+		if isIntKind(kind) && reflect.Zero(field.IndirectFieldType).OverflowInt(n) {
+			return nil, fmt.Errorf("value %d overflows %s", n, field.IndirectFieldType)
+		}
+		return n, nil
+	case schema.Uint:
+		n, err := coerceInt(v)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("value %d must not be negative", n)
+		}
+		if isUintKind(kind) && reflect.Zero(field.IndirectFieldType).OverflowUint(uint64(n)) {
+			return nil, fmt.Errorf("value %d overflows %s", n, field.IndirectFieldType)
+		}
+		return uint64(n), nil
+	case schema.Float:
+		return coerceFloat(v)
+	case schema.Bool:
+		return coerceBool(v)
+	case schema.Time:
+		return coerceTime(v)
+	case schema.String:
+		return coerceString(v)
+	}
+
+	// 自定义列类型，如 `gorm:"type:decimal(10,2)"`
+	dataType := strings.ToLower(string(field.DataType))
+	if strings.HasPrefix(dataType, "decimal") || strings.HasPrefix(dataType, "numeric") {
+		return coerceDecimal(v)
+	}
+	return v, nil
+}
+
+func isIntKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUintKind(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uint64
+}
+
+// coerceInt 转换为 int64：接受整数、整数值的浮点（JSON 数字）与整数字符串
+func coerceInt(v interface{}) (int64, error) {
+	rv := reflect.ValueOf(v)
+	switch {
+	case isIntKind(rv.Kind()):
+		return rv.Int(), nil
+	case isUintKind(rv.Kind()):
+		if rv.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("value %d is out of range", rv.Uint())
+		}
+		return int64(rv.Uint()), nil
+	case rv.Kind() == reflect.Float32 || rv.Kind() == reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, fmt.Errorf("value %v is not an integer", f)
+		}
+		return int64(f), nil
+	case rv.Kind() == reflect.String:
+		n, err := strconv.ParseInt(strings.TrimSpace(rv.String()), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("value %q is not an integer", rv.String())
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("value of type %T is not an integer", v)
+	}
+}
+
+// coerceFloat 转换为 float64
+func coerceFloat(v interface{}) (float64, error) {
+	rv := reflect.ValueOf(v)
+	switch {
+	case isIntKind(rv.Kind()):
+		return float64(rv.Int()), nil
+	case isUintKind(rv.Kind()):
+		return float64(rv.Uint()), nil
+	case rv.Kind() == reflect.Float32 || rv.Kind() == reflect.Float64:
+		return rv.Float(), nil
+	case rv.Kind() == reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(rv.String()), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return 0, fmt.Errorf("value %q is not a number", rv.String())
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("value of type %T is not a number", v)
+	}
+}
+
+// coerceDecimal 转换为十进制数字字符串（数字按最短表示格式化，字符串须为合法数字）
+func coerceDecimal(v interface{}) (string, error) {
+	if s, ok := v.(string); ok {
+		s = strings.TrimSpace(s)
+		if f, err := strconv.ParseFloat(s, 64); err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", fmt.Errorf("value %q is not a decimal", s)
+		}
+		return s, nil
+	}
+	f, err := coerceFloat(v)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64), nil
+}
+
+// coerceBool 转换为 bool
+func coerceBool(v interface{}) (bool, error) {
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.Kind() == reflect.Bool:
+		return rv.Bool(), nil
+	case rv.Kind() == reflect.String:
+		b, err := strconv.ParseBool(strings.TrimSpace(rv.String()))
+		if err != nil {
+			return false, fmt.Errorf("value %q is not a boolean", rv.String())
+		}
+		return b, nil
+	}
+	if n, err := coerceInt(v); err == nil && (n == 0 || n == 1) {
+		return n == 1, nil
+	}
+	return false, fmt.Errorf("value %v is not a boolean", v)
+}
+
+// coerceTime 转换为 time.Time：时间字符串（无时区的按本地时区解析）或 Unix 秒
+func coerceTime(v interface{}) (time.Time, error) {
+	switch val := v.(type) {
+	case time.Time:
+		return val, nil
+	case string:
+		s := strings.TrimSpace(val)
+		for _, layout := range timeLayouts {
+			if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("value %q is not a time (use RFC3339, \"2006-01-02 15:04:05\" or \"2006-01-02\")", val)
+	}
+	n, err := coerceInt(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("value %v is not a time", v)
+	}
+	return time.Unix(n, 0), nil
+}
+
+// coerceString 转换为 string：数字与布尔转为文本形式
+func coerceString(v interface{}) (string, error) {
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.Kind() == reflect.String:
+		return rv.String(), nil
+	case rv.Kind() == reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case isIntKind(rv.Kind()):
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case isUintKind(rv.Kind()):
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case rv.Kind() == reflect.Float32 || rv.Kind() == reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("value of type %T is not a string", v)
+	}
+}
+
+// checkEnum 字段类型实现 Enum 时校验取值
+func checkEnum(field *schema.Field, v interface{}) error {
+	t := field.IndirectFieldType
+	var enum Enum
+	switch {
+	case t.Implements(enumType):
+		enum = reflect.Zero(t).Interface().(Enum)
+	case reflect.PointerTo(t).Implements(enumType):
+		enum = reflect.New(t).Interface().(Enum)
+	default:
+		return nil
+	}
+	allowed := enum.EnumValues()
+	if !slices.Contains(allowed, fmt.Sprint(v)) {
+		return fmt.Errorf("value %v is not one of %s", v, strings.Join(allowed, ", "))
+	}
+	return nil
+}
+
+// ========== 字段查找 ==========
+
+// coerceWithSchema 在 s 中查找过滤字段（列名 / Go 名 / json 名 / "表名.列名" / 关联路径）并转换值
+func coerceWithSchema(s *schema.Schema, param FilterParam) (FilterParam, error) {
+	field := findFilterField(s, param.Field)
+	if field == nil {
+		return param, nil
+	}
+	return CoerceParam(field, param)
+}
+
+// findFilterField 查找过滤字段对应的 schema 字段，找不到时返回 nil
+func findFilterField(s *schema.Schema, name string) *schema.Field {
+	if head, column, found := strings.Cut(name, "."); found {
+		if FindRelation(s, head) != nil {
+			_, field, err := ResolveRelationPath(s, name)
+			if err != nil {
+				return nil
+			}
+			return field
+		}
+		if head == s.Table {
+			name = column
+		}
+	}
+	return lookupSchemaField(s, name)
+}
@@ -0,0 +1,544 @@
+package filter_translator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ========== Elasticsearch / OpenSearch 过滤器 ==========
+// 将 FilterParam / 条件树翻译为 bool 查询 DSL，纯翻译、不依赖客户端：
+//
+//	{"field": "age", "operator": ">", "value": 18}  ->  {"range": {"age": {"gt": 18}}}
+//
+//   - = / ieq -> term（ieq 带 case_insensitive），in -> terms，> >= < <= / between -> range，
+//     isnull / isnotnull -> exists，like / starts_with / ends_with / ilike -> wildcard，regex -> regexp，
+//     within_last / older_than / before_now / after_now -> range + 日期运算（now-7d）
+//   - match 为 ES 独有的操作符，生成全文检索的 match 查询
+//   - != / not_in / not_between 与 SQL 一致，不匹配缺少该字段的文档（附带 exists 条件）
+//   - and -> bool.filter，or -> bool.should（minimum_should_match 为 1），not -> bool.must_not
+//   - 字段名原样输出，关联路径（如 "profile.city"）对应文档中的对象字段；term 类查询应使用 keyword 字段
+
+// ESFilter ES 过滤器接口
+type ESFilter interface {
+	BaseFilter
+	// ToES 生成查询子句，如 {"term": {"status": "active"}}
+	ToES() map[string]interface{}
+}
+
+// ========== ES Filter 实现 ==========
+
+// ESQueryFilter 单个条件，Query 为翻译后的查询子句
+type ESQueryFilter struct {
+	*GenericFilter
+	Query map[string]interface{}
+}
+
+func (f *ESQueryFilter) ToES() map[string]interface{} {
+	return f.Query
+}
+
+// ESFilterGroup and / or / not 条件组，生成 bool 查询
+type ESFilterGroup struct {
+	Logic    string
+	Children []ESFilter
+}
+
+func (g *ESFilterGroup) GetField() string      { return "" }
+func (g *ESFilterGroup) GetValue() interface{} { return g.Children }
+func (g *ESFilterGroup) GetOperator() string   { return g.Logic }
+
+func (g *ESFilterGroup) ToES() map[string]interface{} {
+	clauses := make([]interface{}, 0, len(g.Children))
+	for _, child := range g.Children {
+		clauses = append(clauses, child.ToES())
+	}
+	switch g.Logic {
+	case LogicOr:
+		return esBool("should", clauses, "minimum_should_match", 1)
+	case LogicNot:
+		return esBool("must_not", clauses)
+	default:
+		return esBool("filter", clauses)
+	}
+}
+
+// esBool 生成 {"bool": {occur: clauses, ...}}，extra 为额外的键值对
+func esBool(occur string, clauses []interface{}, extra ...interface{}) map[string]interface{} {
+	body := map[string]interface{}{occur: clauses}
+	for i := 0; i+1 < len(extra); i += 2 {
+		body[extra[i].(string)] = extra[i+1]
+	}
+	return map[string]interface{}{"bool": body}
+}
+
+// esLeaf 生成 {kind: {field: body}}
+func esLeaf(kind, field string, body interface{}) map[string]interface{} {
+	return map[string]interface{}{kind: map[string]interface{}{field: body}}
+}
+
+// esExists 生成 {"exists": {"field": field}}
+func esExists(field string) map[string]interface{} {
+	return map[string]interface{}{"exists": map[string]interface{}{"field": field}}
+}
+
+// esNegate 否定条件且要求字段存在（与 SQL 中 NULL 不满足 != / NOT IN 一致）
+func esNegate(field string, query map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"bool": map[string]interface{}{
+		"filter":   []interface{}{esExists(field)},
+		"must_not": []interface{}{query},
+	}}
+}
+
+// ========== ES FilterTranslator 实现 ==========
+
+// ESCompareTranslator 比较翻译器（= != > >= < <=）
+type ESCompareTranslator struct {
+	Operator string
+}
+
+// esRangeKeys 比较操作符 -> range 参数
+var esRangeKeys = map[string]string{">": "gt", ">=": "gte", "<": "lt", "<=": "lte"}
+
+func (t *ESCompareTranslator) Translate(param FilterParam) (BaseFilter, error) {
+	var query map[string]interface{}
+	switch t.Operator {
+	case "=":
+		query = esLeaf("term", param.Field, param.Value)
+	case "!=":
+		query = esNegate(param.Field, esLeaf("term", param.Field, param.Value))
+	default:
+		key, ok := esRangeKeys[t.Operator]
+		if !ok {
+			return nil, fmt.Errorf("unsupported compare operator: %s", t.Operator)
+		}
+		query = esLeaf("range", param.Field, map[string]interface{}{key: param.Value})
+	}
+	return newESQueryFilter(param, t.Operator, query), nil
+}
+
+func (t *ESCompareTranslator) SupportedOperator() string {
+	return t.Operator
+}
+
+func (t *ESCompareTranslator) Validate(param FilterParam) error {
+	if param.Field == "" {
+		return fmt.Errorf("field cannot be empty")
+	}
+	if param.Value == nil {
+		return fmt.Errorf("value cannot be nil")
+	}
+	return nil
+}
+
+// ESInTranslator IN / NOT IN 翻译器
+type ESInTranslator struct {
+	Operator string
+}
+
+func (t *ESInTranslator) Translate(param FilterParam) (BaseFilter, error) {
+	values, ok := param.Value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("value must be array for %s operator", t.Operator)
+	}
+	query := esLeaf("terms", param.Field, values)
+	if t.Operator == "not_in" {
+		query = esNegate(param.Field, query)
+	}
+	return newESQueryFilter(param, t.Operator, query), nil
+}
+
+func (t *ESInTranslator) SupportedOperator() string {
+	return t.Operator
+}
+
+func (t *ESInTranslator) Validate(param FilterParam) error {
+	if param.Field == "" {
+		return fmt.Errorf("field cannot be empty")
+	}
+	values, ok := param.Value.([]interface{})
+	if !ok {
+		return fmt.Errorf("value must be array")
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("value array cannot be empty")
+	}
+	return nil
+}
+
+// ESBetweenTranslator BETWEEN / NOT BETWEEN 翻译器
+type ESBetweenTranslator struct {
+	Operator string
+}
+
+func (t *ESBetweenTranslator) Translate(param FilterParam) (BaseFilter, error) {
+	values, ok := param.Value.([]interface{})
+	if !ok || len(values) != 2 {
+		return nil, fmt.Errorf("value must be array with 2 elements for %s operator", t.Operator)
+	}
+	query := esLeaf("range", param.Field, map[string]interface{}{"gte": values[0], "lte": values[1]})
+	if t.Operator == "not_between" {
+		query = esNegate(param.Field, query)
+	}
+	return newESQueryFilter(param, t.Operator, query), nil
+}
+
+func (t *ESBetweenTranslator) SupportedOperator() string {
+	return t.Operator
+}
+
+func (t *ESBetweenTranslator) Validate(param FilterParam) error {
+	if param.Field == "" {
+		return fmt.Errorf("field cannot be empty")
+	}
+	values, ok := param.Value.([]interface{})
+	if !ok || len(values) != 2 {
+		return fmt.Errorf("value must be array with 2 elements")
+	}
+	return nil
+}
+
+// ESExistsTranslator isnull / isnotnull 翻译器
+type ESExistsTranslator struct {
+	Operator string
+}
+
+func (t *ESExistsTranslator) Translate(param FilterParam) (BaseFilter, error) {
+	query := esExists(param.Field)
+	if t.Operator == "isnull" {
+		query = esBool("must_not", []interface{}{query})
+	}
+	return newESQueryFilter(param, t.Operator, query), nil
+}
+
+func (t *ESExistsTranslator) SupportedOperator() string {
+	return t.Operator
+}
+
+func (t *ESExistsTranslator) Validate(param FilterParam) error {
+	if param.Field == "" {
+		return fmt.Errorf("field cannot be empty")
+	}
+	return nil
+}
+
+// ESStringTranslator 字符串翻译器（like / starts_with / ends_with / ieq / ilike / match）
+type ESStringTranslator struct {
+	Operator string
+}
+
+func (t *ESStringTranslator) Translate(param FilterParam) (BaseFilter, error) {
+	value, ok := param.Value.(string)
+	if !ok {
+		return nil, fmt.Errorf("value must be string for %s operator", t.Operator)
+	}
+	var query map[string]interface{}
+	switch t.Operator {
+	case "like":
+		// 与 SQL 一致：值中的 % _ 仍是通配符
+		query = esLeaf("wildcard", param.Field, map[string]interface{}{"value": "*" + likeToWildcard(value) + "*"})
+	case "starts_with":
+		query = esLeaf("wildcard", param.Field, map[string]interface{}{"value": escapeWildcard(value) + "*"})
+	case "ends_with":
+		query = esLeaf("wildcard", param.Field, map[string]interface{}{"value": "*" + escapeWildcard(value)})
+	case "ilike":
+		query = esLeaf("wildcard", param.Field, map[string]interface{}{
+			"value": "*" + escapeWildcard(value) + "*", "case_insensitive": true,
+		})
+	case "ieq":
+		query = esLeaf("term", param.Field, map[string]interface{}{"value": value, "case_insensitive": true})
+	case "match":
+		query = esLeaf("match", param.Field, value)
+	default:
+		return nil, fmt.Errorf("unsupported string operator: %s", t.Operator)
+	}
+	return newESQueryFilter(param, t.Operator, query), nil
+}
+
+func (t *ESStringTranslator) SupportedOperator() string {
+	return t.Operator
+}
+
+func (t *ESStringTranslator) Validate(param FilterParam) error {
+	if param.Field == "" {
+		return fmt.Errorf("field cannot be empty")
+	}
+	if _, ok := param.Value.(string); !ok {
+		return fmt.Errorf("value must be string")
+	}
+	return nil
+}
+
+// ESRegexTranslator 正则翻译器
+type ESRegexTranslator struct{}
+
+func (t *ESRegexTranslator) Translate(param FilterParam) (BaseFilter, error) {
+	query := esLeaf("regexp", param.Field, map[string]interface{}{"value": param.Value})
+	return newESQueryFilter(param, "regex", query), nil
+}
+
+func (t *ESRegexTranslator) SupportedOperator() string {
+	return "regex"
+}
+
+func (t *ESRegexTranslator) Validate(param FilterParam) error {
+	if param.Field == "" {
+		return fmt.Errorf("field cannot be empty")
+	}
+	pattern, ok := param.Value.(string)
+	if !ok {
+		return fmt.Errorf("value must be string")
+	}
+	// Lucene 正则总是匹配整个词项，语法与 Go 大体兼容，这里只拒绝明显无效的表达式
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("invalid regex: %w", err)
+	}
+	return nil
+}
+
+// ESRelativeTimeTranslator 相对时间翻译器，生成 now-7d 形式的日期运算
+type ESRelativeTimeTranslator struct {
+	Operator string
+}
+
+func (t *ESRelativeTimeTranslator) Translate(param FilterParam) (BaseFilter, error) {
+	filter, err := newRelativeTimeFilter(t.Operator, param)
+	if err != nil {
+		return nil, err
+	}
+	var bound map[string]interface{}
+	switch t.Operator {
+	case "within_last":
+		bound = map[string]interface{}{"gte": "now-" + esDuration(filter.Duration)}
+	case "older_than":
+		bound = map[string]interface{}{"lt": "now-" + esDuration(filter.Duration)}
+	case "before_now":
+		bound = map[string]interface{}{"lt": "now"}
+	default:
+		bound = map[string]interface{}{"gt": "now"}
+	}
+	return newESQueryFilter(param, t.Operator, esLeaf("range", param.Field, bound)), nil
+}
+
+func (t *ESRelativeTimeTranslator) SupportedOperator() string {
+	return t.Operator
+}
+
+func (t *ESRelativeTimeTranslator) Validate(param FilterParam) error {
+	if param.Field == "" {
+		return fmt.Errorf("field cannot be empty")
+	}
+	_, err := newRelativeTimeFilter(t.Operator, param)
+	return err
+}
+
+// ========== ES 工具函数 ==========
+
+func newESQueryFilter(param FilterParam, operator string, query map[string]interface{}) *ESQueryFilter {
+	return &ESQueryFilter{
+		GenericFilter: &GenericFilter{Field: param.Field, Operator: operator, Value: param.Value},
+		Query:         query,
+	}
+}
+
+// escapeWildcard 转义 wildcard 查询的通配符（* ? 与转义符本身），使值按字面匹配
+func escapeWildcard(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`).Replace(s)
+}
+
+// likeToWildcard 将 LIKE 模式转换为 wildcard 模式：% -> *，_ -> ?，\ 转义的字符与原有的 * ? 按字面匹配
+func likeToWildcard(pattern string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(escapeWildcard(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			b.WriteByte('*')
+		case r == '_':
+			b.WriteByte('?')
+		default:
+			b.WriteString(escapeWildcard(string(r)))
+		}
+	}
+	if escaped {
+		b.WriteString(`\\`)
+	}
+	return b.String()
+}
+
+// esDuration 将时长转换为 ES 日期运算单位（取能整除的最大单位）
+func esDuration(d time.Duration) string {
+	for _, unit := range []struct {
+		size time.Duration
+		name string
+	}{{24 * time.Hour, "d"}, {time.Hour, "h"}, {time.Minute, "m"}} {
+		if d%unit.size == 0 {
+			return fmt.Sprintf("%d%s", d/unit.size, unit.name)
+		}
+	}
+	return fmt.Sprintf("%ds", d/time.Second)
+}
+
+// ========== ES 翻译器注册表 ==========
+
+// ESTranslatorRegistry ES 翻译器注册表
+type ESTranslatorRegistry struct {
+	translators map[string]FilterTranslator
+}
+
+// NewESTranslatorRegistry 创建 ES 翻译器注册表
+func NewESTranslatorRegistry() *ESTranslatorRegistry {
+	registry := &ESTranslatorRegistry{
+		translators: make(map[string]FilterTranslator),
+	}
+
+	// 注册所有 ES 翻译器
+	for _, op := range []string{"=", "!=", ">", ">=", "<", "<="} {
+		registry.Register(&ESCompareTranslator{Operator: op})
+	}
+	registry.Register(&ESInTranslator{Operator: "in"})
+	registry.Register(&ESInTranslator{Operator: "not_in"})
+	registry.Register(&ESBetweenTranslator{Operator: "between"})
+	registry.Register(&ESBetweenTranslator{Operator: "not_between"})
+	registry.Register(&ESExistsTranslator{Operator: "isnull"})
+	registry.Register(&ESExistsTranslator{Operator: "isnotnull"})
+	for _, op := range []string{"like", "starts_with", "ends_with", "ieq", "ilike", "match"} {
+		registry.Register(&ESStringTranslator{Operator: op})
+	}
+	registry.Register(&ESRegexTranslator{})
+	for _, op := range RelativeTimeOperators {
+		registry.Register(&ESRelativeTimeTranslator{Operator: op})
+	}
+
+	return registry
+}
+
+// Register 注册翻译器
+func (r *ESTranslatorRegistry) Register(translator FilterTranslator) {
+	r.translators[translator.SupportedOperator()] = translator
+}
+
+// Translate 翻译前端参数为 Filter
+func (r *ESTranslatorRegistry) Translate(param FilterParam) (ESFilter, error) {
+	translator, ok := r.translators[param.Operator]
+	if !ok {
+		return nil, fmt.Errorf("unsupported operator: %s", param.Operator)
+	}
+
+	// 验证参数
+	if err := translator.Validate(param); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	baseFilter, err := translator.Translate(param)
+	if err != nil {
+		return nil, err
+	}
+
+	esFilter, ok := baseFilter.(ESFilter)
+	if !ok {
+		return nil, fmt.Errorf("translator returned non-ESFilter")
+	}
+
+	return esFilter, nil
+}
+
+// TranslateBatch 批量翻译
+func (r *ESTranslatorRegistry) TranslateBatch(params []FilterParam) ([]ESFilter, error) {
+	filters := make([]ESFilter, 0, len(params))
+
+	for _, param := range params {
+		filter, err := r.Translate(param)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+
+	return filters, nil
+}
+
+// TranslateTree 翻译条件树为单个 ESFilter
+func (r *ESTranslatorRegistry) TranslateTree(node *FilterNode) (ESFilter, error) {
+	if err := node.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	return r.translateNode(node)
+}
+
+func (r *ESTranslatorRegistry) translateNode(node *FilterNode) (ESFilter, error) {
+	if node.Logic() == "" {
+		return r.Translate(node.FilterParam)
+	}
+	group := &ESFilterGroup{Logic: node.Logic()}
+	for i := range node.Children() {
+		child, err := r.translateNode(&node.Children()[i])
+		if err != nil {
+			return nil, err
+		}
+		group.Children = append(group.Children, child)
+	}
+	return group, nil
+}
+
+// GetSupportedOperators 获取所有支持的操作符
+func (r *ESTranslatorRegistry) GetSupportedOperators() []string {
+	operators := make([]string, 0, len(r.translators))
+	for op := range r.translators {
+		operators = append(operators, op)
+	}
+	return operators
+}
+
+// DefaultESRegistry 默认 ES 翻译器注册表（全局单例）
+var DefaultESRegistry = NewESTranslatorRegistry()
+
+// ========== 搜索请求 ==========
+
+// ESSearchOptions 排序、分页与字段投影
+type ESSearchOptions struct {
+	Sorts    []QuerySort // 排序键，依次输出为 sort 数组
+	Page     int         // 页码（从 1 开始），生成 from
+	PageSize int         // 每页条数，生成 size；为 0 时使用集群默认值
+	Fields   []string    // 返回的字段，生成 _source
+}
+
+// BuildESSearch 生成 _search 请求体：filters 以 and 连接放入 bool.filter（不参与评分），没有条件时为 match_all
+func BuildESSearch(filters []ESFilter, opts ESSearchOptions) map[string]interface{} {
+	body := map[string]interface{}{}
+	if len(filters) == 0 {
+		body["query"] = map[string]interface{}{"match_all": map[string]interface{}{}}
+	} else if group, ok := filters[0].(*ESFilterGroup); ok && len(filters) == 1 && group.Logic == LogicAnd {
+		body["query"] = group.ToES()
+	} else {
+		body["query"] = (&ESFilterGroup{Logic: LogicAnd, Children: filters}).ToES()
+	}
+
+	if len(opts.Sorts) > 0 {
+		sorts := make([]interface{}, 0, len(opts.Sorts))
+		for _, s := range opts.Sorts {
+			order := "asc"
+			if s.Desc {
+				order = "desc"
+			}
+			sorts = append(sorts, map[string]interface{}{s.Field: map[string]interface{}{"order": order}})
+		}
+		body["sort"] = sorts
+	}
+	if opts.PageSize > 0 {
+		body["size"] = opts.PageSize
+		if opts.Page > 1 {
+			body["from"] = (opts.Page - 1) * opts.PageSize
+		}
+	}
+	if len(opts.Fields) > 0 {
+		body["_source"] = opts.Fields
+	}
+	return body
+}
@@ -1,5 +1,12 @@
 package filter_translator
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // ========== 通用过滤器接口 ==========
 
 // FilterParam 前端过滤参数（统一格式）
@@ -88,3 +95,75 @@ func (f *GenericBetweenFilter) GetValue() interface{} {
 func (f *GenericBetweenFilter) GetOperator() string {
 	return f.Operator
 }
+
+// GenericRelativeTimeFilter 相对时间过滤器通用数据（以当前时间为基准）
+type GenericRelativeTimeFilter struct {
+	Field    string
+	Operator string
+	Value    interface{}   // 原始参数，如 "7d"
+	Duration time.Duration // 解析后的时长，before_now / after_now 为 0
+}
+
+func (f *GenericRelativeTimeFilter) GetField() string {
+	return f.Field
+}
+
+func (f *GenericRelativeTimeFilter) GetValue() interface{} {
+	return f.Value
+}
+
+func (f *GenericRelativeTimeFilter) GetOperator() string {
+	return f.Operator
+}
+
+// RelativeTimeOperators 相对时间操作符：within_last / older_than 需要时长参数，before_now / after_now 不需要
+var RelativeTimeOperators = []string{"within_last", "older_than", "before_now", "after_now"}
+
+// ========== 通用工具函数 ==========
+
+// newRelativeTimeFilter 校验并解析相对时间参数
+func newRelativeTimeFilter(operator string, param FilterParam) (*GenericRelativeTimeFilter, error) {
+	filter := &GenericRelativeTimeFilter{Field: param.Field, Operator: operator, Value: param.Value}
+	switch operator {
+	case "within_last", "older_than":
+		d, err := ParseRelativeDuration(param.Value)
+		if err != nil {
+			return nil, err
+		}
+		filter.Duration = d
+	case "before_now", "after_now":
+	default:
+		return nil, fmt.Errorf("unsupported relative time operator: %s", operator)
+	}
+	return filter, nil
+}
+
+// ParseRelativeDuration 解析相对时长，支持 Go duration 格式（"90m"、"1h30m"）以及 d（天）、w（周）后缀（"7d"、"2w"）
+func ParseRelativeDuration(v interface{}) (time.Duration, error) {
+	s, ok := v.(string)
+	if !ok || s == "" {
+		return 0, fmt.Errorf("value must be a duration string such as \"7d\"")
+	}
+
+	var d time.Duration
+	var err error
+	switch unit := s[len(s)-1]; unit {
+	case 'd', 'w':
+		var n float64
+		n, err = strconv.ParseFloat(strings.TrimSpace(s[:len(s)-1]), 64)
+		day := 24 * time.Hour
+		if unit == 'w' {
+			day *= 7
+		}
+		d = time.Duration(n * float64(day))
+	default:
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive")
+	}
+	return d, nil
+}
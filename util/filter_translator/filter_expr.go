@@ -0,0 +1,707 @@
+package filter_translator
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ========== 文本过滤表达式 ==========
+// 供管理工具与脚本使用的紧凑写法，解析结果为与 JSON 相同的 FilterNode 条件树：
+//
+//	age > 18 and (name ~ "jo" or email ends "@corp.com") and deleted_at is null
+//
+// 语法（关键字不区分大小写，优先级 not > and > or）：
+//
+//	expr       = and { "or" and }
+//	and        = unary { "and" unary }
+//	unary      = "not" unary | "(" expr ")" | condition
+//	condition  = field op value
+//	           | field ["not"] "in" list
+//	           | field ["not"] "between" value "and" value
+//	           | field "is" ["not"] "null"
+//	           | field ("before_now" | "after_now")
+//	op         = "=" | "==" | "!=" | "<>" | ">" | ">=" | "<" | "<=" | "~"（包含，即 like）
+//	           | "starts" | "ends" | "contains" | 其它注册表操作符名（like / ilike / ieq / regex / within_last ...）
+//	value      = 数字 | "字符串" | '字符串' | true | false | null | 日期 | 时长 | list
+//	list       = "[" [ value { "," value } ] "]"（in 之后也可以用圆括号）
+//
+// 字面量带类型：整数为 int64，小数为 float64，日期（2024-01-02 / 2024-01-02T15:04:05Z）为 time.Time（UTC），
+// 时长（7d / 90m）为字符串。FormatExpr 把条件树格式化回文本，再次解析得到相同的条件树。
+
+// ExprError 表达式语法错误，带出错位置
+type ExprError struct {
+	Src    string // 原始表达式
+	Pos    int    // 出错位置（字节偏移）
+	Line   int    // 行号（从 1 开始）
+	Column int    // 列号（从 1 开始，按字符计）
+	Msg    string
+}
+
+func (e *ExprError) Error() string {
+	return fmt.Sprintf("filter expression: %s at line %d, column %d", e.Msg, e.Line, e.Column)
+}
+
+// Snippet 返回出错行并在出错位置下方标出 ^，用于展示给用户
+func (e *ExprError) Snippet() string {
+	start := strings.LastIndexByte(e.Src[:e.Pos], '\n') + 1
+	end := strings.IndexByte(e.Src[e.Pos:], '\n')
+	if end < 0 {
+		end = len(e.Src)
+	} else {
+		end += e.Pos
+	}
+	return e.Src[start:end] + "\n" + strings.Repeat(" ", e.Column-1) + "^"
+}
+
+// newExprError 按字节偏移计算行列号
+func newExprError(src string, pos int, format string, args ...interface{}) *ExprError {
+	line := 1 + strings.Count(src[:pos], "\n")
+	lineStart := strings.LastIndexByte(src[:pos], '\n') + 1
+	return &ExprError{
+		Src:    src,
+		Pos:    pos,
+		Line:   line,
+		Column: utf8.RuneCountInString(src[lineStart:pos]) + 1,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+// ---------- 词法分析 ----------
+
+type exprTokenKind int
+
+const (
+	tokEOF exprTokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokDate
+	tokDuration
+	tokOp
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokComma
+)
+
+type exprToken struct {
+	kind  exprTokenKind
+	text  string      // 原文（字符串为去掉引号并处理转义后的内容）
+	value interface{} // 字面量的值
+	pos   int
+}
+
+// describe 错误信息中的 token 描述
+func (t exprToken) describe() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+var (
+	exprNumberPattern   = regexp.MustCompile(`^-?\d+(\.\d+)?([eE][+-]?\d+)?`)
+	exprDatePattern     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(T\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:\d{2})?)?`)
+	exprDurationPattern = regexp.MustCompile(`^\d+(\.\d+)?[a-zµ]+(\d+(\.\d+)?[a-zµ]+)*`)
+	exprIdentPattern    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*`)
+)
+
+// exprOperators 符号操作符，较长的在前
+var exprOperators = []string{"==", "!=", "<>", ">=", "<=", "=", ">", "<", "~"}
+
+// lexExpr 把表达式切分为 token
+func lexExpr(src string) ([]exprToken, error) {
+	var tokens []exprToken
+	for pos := 0; pos < len(src); {
+		r, size := utf8.DecodeRuneInString(src[pos:])
+		if unicode.IsSpace(r) {
+			pos += size
+			continue
+		}
+		rest := src[pos:]
+
+		switch r {
+		case '(':
+			tokens = append(tokens, exprToken{kind: tokLParen, text: "(", pos: pos})
+			pos++
+			continue
+		case ')':
+			tokens = append(tokens, exprToken{kind: tokRParen, text: ")", pos: pos})
+			pos++
+			continue
+		case '[':
+			tokens = append(tokens, exprToken{kind: tokLBracket, text: "[", pos: pos})
+			pos++
+			continue
+		case ']':
+			tokens = append(tokens, exprToken{kind: tokRBracket, text: "]", pos: pos})
+			pos++
+			continue
+		case ',':
+			tokens = append(tokens, exprToken{kind: tokComma, text: ",", pos: pos})
+			pos++
+			continue
+		case '"', '\'':
+			s, n, err := lexString(rest)
+			if err != nil {
+				return nil, newExprError(src, pos, "%s", err.Error())
+			}
+			tokens = append(tokens, exprToken{kind: tokString, text: s, value: s, pos: pos})
+			pos += n
+			continue
+		}
+
+		if m := exprDatePattern.FindString(rest); m != "" && !isIdentByte(rest, len(m)) {
+			t, err := parseExprDate(m)
+			if err != nil {
+				return nil, newExprError(src, pos, "invalid date %q", m)
+			}
+			tokens = append(tokens, exprToken{kind: tokDate, text: m, value: t, pos: pos})
+			pos += len(m)
+			continue
+		}
+		if m := exprNumberPattern.FindString(rest); m != "" && !isIdentByte(rest, len(m)) {
+			tok := exprToken{kind: tokNumber, text: m, pos: pos}
+			if strings.ContainsAny(m, ".eE") {
+				f, err := strconv.ParseFloat(m, 64)
+				if err != nil {
+					return nil, newExprError(src, pos, "invalid number %q", m)
+				}
+				tok.value = f
+			} else {
+				n, err := strconv.ParseInt(m, 10, 64)
+				if err != nil {
+					return nil, newExprError(src, pos, "invalid number %q", m)
+				}
+				tok.value = n
+			}
+			tokens = append(tokens, tok)
+			pos += len(m)
+			continue
+		}
+		if m := exprDurationPattern.FindString(rest); m != "" && !isIdentByte(rest, len(m)) {
+			tokens = append(tokens, exprToken{kind: tokDuration, text: m, value: m, pos: pos})
+			pos += len(m)
+			continue
+		}
+		if m := exprIdentPattern.FindString(rest); m != "" {
+			tokens = append(tokens, exprToken{kind: tokIdent, text: m, pos: pos})
+			pos += len(m)
+			continue
+		}
+
+		matched := false
+		for _, op := range exprOperators {
+			if strings.HasPrefix(rest, op) {
+				tokens = append(tokens, exprToken{kind: tokOp, text: op, pos: pos})
+				pos += len(op)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil, newExprError(src, pos, "unexpected character %q", r)
+		}
+	}
+	return append(tokens, exprToken{kind: tokEOF, pos: len(src)}), nil
+}
+
+// isIdentByte 字面量之后紧跟标识符字符时不算完整的字面量（如 "7days" 不是数字 7）
+func isIdentByte(s string, i int) bool {
+	if i >= len(s) {
+		return false
+	}
+	c := s[i]
+	return c == '_' || c == '.' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// lexString 解析单 / 双引号字符串，支持 \" \' \\ \n \t 等转义，返回内容与消耗的字节数
+func lexString(s string) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\\':
+			if i+1 >= len(s) {
+				return "", 0, fmt.Errorf("unterminated string")
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '\\', '"', '\'':
+				b.WriteByte(s[i])
+			default:
+				return "", 0, fmt.Errorf("invalid escape \\%c", s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+// parseExprDate 解析日期或日期时间字面量，不带时区的按 UTC
+func parseExprDate(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+// ---------- 语法分析 ----------
+
+// exprSymbolOperators 符号 / 别名操作符 -> 注册表中的操作符
+var exprSymbolOperators = map[string]string{
+	"=": "=", "==": "=", "!=": "!=", "<>": "!=",
+	">": ">", ">=": ">=", "<": "<", "<=": "<=",
+	"~": "like", "contains": "like", "starts": "starts_with", "ends": "ends_with",
+}
+
+// exprNoValueOperators 不需要值的操作符
+var exprNoValueOperators = map[string]bool{"before_now": true, "after_now": true, "isnull": true, "isnotnull": true}
+
+type exprParser struct {
+	src    string
+	tokens []exprToken
+	pos    int
+}
+
+// ParseExpr 解析文本过滤表达式为条件树，语法错误返回 *ExprError
+func ParseExpr(src string) (*FilterNode, error) {
+	tokens, err := lexExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{src: src, tokens: tokens}
+	node, err := p.parseOr(1)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorAt(tok, "unexpected %s", tok.describe())
+	}
+	return node, nil
+}
+
+func (p *exprParser) peek() exprToken { return p.tokens[p.pos] }
+
+func (p *exprParser) next() exprToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *exprParser) errorAt(tok exprToken, format string, args ...interface{}) error {
+	return newExprError(p.src, tok.pos, format, args...)
+}
+
+// keyword 当前 token 是否为指定关键字（不区分大小写）
+func (p *exprParser) keyword(word string) bool {
+	tok := p.peek()
+	return tok.kind == tokIdent && strings.EqualFold(tok.text, word)
+}
+
+func (p *exprParser) expectKeyword(word string) error {
+	if !p.keyword(word) {
+		tok := p.peek()
+		return p.errorAt(tok, "expected %q, found %s", word, tok.describe())
+	}
+	p.next()
+	return nil
+}
+
+func (p *exprParser) parseOr(depth int) (*FilterNode, error) {
+	if depth > MaxFilterDepth {
+		return nil, p.errorAt(p.peek(), "expression exceeds max depth %d", MaxFilterDepth)
+	}
+	first, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	children := []FilterNode{*first}
+	for p.keyword(LogicOr) {
+		p.next()
+		child, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, *child)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return &FilterNode{Or: children}, nil
+}
+
+func (p *exprParser) parseAnd(depth int) (*FilterNode, error) {
+	first, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	children := []FilterNode{*first}
+	for p.keyword(LogicAnd) {
+		p.next()
+		child, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, *child)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return &FilterNode{And: children}, nil
+}
+
+func (p *exprParser) parseUnary(depth int) (*FilterNode, error) {
+	if p.keyword(LogicNot) {
+		p.next()
+		child, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &FilterNode{Not: child}, nil
+	}
+	if p.peek().kind == tokLParen {
+		p.next()
+		node, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok.kind != tokRParen {
+			return nil, p.errorAt(tok, "expected \")\", found %s", tok.describe())
+		}
+		return node, nil
+	}
+	return p.parseCondition()
+}
+
+func (p *exprParser) parseCondition() (*FilterNode, error) {
+	fieldTok := p.next()
+	if fieldTok.kind != tokIdent || isExprKeyword(fieldTok.text) {
+		return nil, p.errorAt(fieldTok, "expected field name, found %s", fieldTok.describe())
+	}
+	param := FilterParam{Field: fieldTok.text}
+
+	opTok := p.next()
+	switch {
+	case opTok.kind == tokOp:
+		param.Operator = exprSymbolOperators[opTok.text]
+	case opTok.kind != tokIdent:
+		return nil, p.errorAt(opTok, "expected operator, found %s", opTok.describe())
+	case strings.EqualFold(opTok.text, "is"):
+		param.Operator = "isnull"
+		if p.keyword(LogicNot) {
+			p.next()
+			param.Operator = "isnotnull"
+		}
+		if err := p.expectKeyword("null"); err != nil {
+			return nil, err
+		}
+		return &FilterNode{FilterParam: param}, nil
+	case strings.EqualFold(opTok.text, LogicNot):
+		switch {
+		case p.keyword("in"):
+			param.Operator = "not_in"
+		case p.keyword("between"):
+			param.Operator = "not_between"
+		default:
+			tok := p.peek()
+			return nil, p.errorAt(tok, "expected \"in\" or \"between\" after \"not\", found %s", tok.describe())
+		}
+		p.next()
+	default:
+		word := strings.ToLower(opTok.text)
+		if alias, ok := exprSymbolOperators[word]; ok {
+			word = alias
+		}
+		if isExprKeyword(word) && word != "in" && word != "between" {
+			return nil, p.errorAt(opTok, "expected operator, found %s", opTok.describe())
+		}
+		param.Operator = word
+	}
+
+	var err error
+	switch param.Operator {
+	case "in", "not_in":
+		param.Value, err = p.parseList(true)
+	case "between", "not_between":
+		var lo, hi interface{}
+		if lo, err = p.parseValue(); err != nil {
+			return nil, err
+		}
+		if err = p.expectKeyword(LogicAnd); err != nil {
+			return nil, err
+		}
+		if hi, err = p.parseValue(); err != nil {
+			return nil, err
+		}
+		param.Value = []interface{}{lo, hi}
+	default:
+		if !exprNoValueOperators[param.Operator] {
+			param.Value, err = p.parseValue()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &FilterNode{FilterParam: param}, nil
+}
+
+// parseValue 解析字面量或列表
+func (p *exprParser) parseValue() (interface{}, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokNumber, tokString, tokDate, tokDuration:
+		p.next()
+		return tok.value, nil
+	case tokLBracket:
+		return p.parseList(false)
+	case tokIdent:
+		switch strings.ToLower(tok.text) {
+		case "true":
+			p.next()
+			return true, nil
+		case "false":
+			p.next()
+			return false, nil
+		case "null":
+			p.next()
+			return nil, nil
+		}
+	}
+	return nil, p.errorAt(tok, "expected value, found %s", tok.describe())
+}
+
+// parseList 解析 [a, b, ...]；allowParen 为 true 时也接受 (a, b, ...)
+func (p *exprParser) parseList(allowParen bool) (interface{}, error) {
+	open := p.next()
+	closing := tokRBracket
+	switch {
+	case open.kind == tokLBracket:
+	case open.kind == tokLParen && allowParen:
+		closing = tokRParen
+	default:
+		return nil, p.errorAt(open, "expected list, found %s", open.describe())
+	}
+
+	values := []interface{}{}
+	if p.peek().kind == closing {
+		p.next()
+		return values, nil
+	}
+	for {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		tok := p.next()
+		if tok.kind == closing {
+			return values, nil
+		}
+		if tok.kind != tokComma {
+			return nil, p.errorAt(tok, "expected \",\" or end of list, found %s", tok.describe())
+		}
+	}
+}
+
+// isExprKeyword 保留字不能用作字段名
+func isExprKeyword(s string) bool {
+	switch strings.ToLower(s) {
+	case LogicAnd, LogicOr, LogicNot, "in", "is", "null", "between", "true", "false":
+		return true
+	default:
+		return false
+	}
+}
+
+// ---------- 格式化 ----------
+
+// exprOperatorText 操作符的文本写法（未列出的操作符直接使用其名字）
+var exprOperatorText = map[string]string{
+	"like": "~", "starts_with": "starts", "ends_with": "ends",
+	"not_in": "not in", "not_between": "not between",
+}
+
+// FormatExpr 把条件树格式化为文本表达式，ParseExpr 可以解析回相同的条件树
+// 值的类型须能用字面量表示（数字、字符串、布尔、nil、time.Time 与它们的切片）
+func FormatExpr(node *FilterNode) (string, error) {
+	if err := node.Validate(); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := formatNode(&b, node, LogicOr); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// formatNode parent 为外层逻辑，优先级更低的分组需要加括号
+func formatNode(b *strings.Builder, node *FilterNode, parent string) error {
+	switch logic := node.Logic(); logic {
+	case "":
+		return formatCondition(b, node.FilterParam)
+	case LogicNot:
+		b.WriteString("not ")
+		child := node.Not
+		if child.Logic() == "" || child.Logic() == LogicNot {
+			return formatNode(b, child, LogicNot)
+		}
+		b.WriteByte('(')
+		if err := formatNode(b, child, LogicOr); err != nil {
+			return err
+		}
+		b.WriteByte(')')
+		return nil
+	default:
+		// 单个子节点的分组在文本中无法区分，按其子节点输出
+		children := node.Children()
+		paren := len(children) > 1 && (parent == LogicNot || (parent == LogicAnd && logic == LogicOr))
+		if paren {
+			b.WriteByte('(')
+		}
+		for i := range children {
+			if i > 0 {
+				b.WriteString(" " + logic + " ")
+			}
+			// 同类嵌套分组加括号，保持树结构不被拍平
+			childParent := logic
+			if children[i].Logic() == logic {
+				childParent = LogicNot
+			}
+			if err := formatNode(b, &children[i], childParent); err != nil {
+				return err
+			}
+		}
+		if paren {
+			b.WriteByte(')')
+		}
+		return nil
+	}
+}
+
+func formatCondition(b *strings.Builder, param FilterParam) error {
+	if exprIdentPattern.FindString(param.Field) != param.Field || isExprKeyword(param.Field) {
+		return fmt.Errorf("field %q cannot be written in a filter expression", param.Field)
+	}
+	b.WriteString(param.Field)
+
+	switch param.Operator {
+	case "isnull":
+		b.WriteString(" is null")
+		return nil
+	case "isnotnull":
+		b.WriteString(" is not null")
+		return nil
+	}
+
+	op, ok := exprOperatorText[param.Operator]
+	if !ok {
+		op = param.Operator
+		_, symbol := exprSymbolOperators[op]
+		word := exprIdentPattern.FindString(op) == op && !strings.Contains(op, ".") && (!isExprKeyword(op) || op == "in" || op == "between")
+		if !symbol && !word {
+			return fmt.Errorf("operator %q cannot be written in a filter expression", op)
+		}
+	}
+	b.WriteString(" " + op)
+	if exprNoValueOperators[param.Operator] {
+		return nil
+	}
+
+	if param.Operator == "between" || param.Operator == "not_between" {
+		values, ok := param.Value.([]interface{})
+		if !ok || len(values) != 2 {
+			return fmt.Errorf("%s requires two values", param.Operator)
+		}
+		b.WriteByte(' ')
+		if err := formatValue(b, values[0]); err != nil {
+			return err
+		}
+		b.WriteString(" and ")
+		return formatValue(b, values[1])
+	}
+	b.WriteByte(' ')
+	return formatValue(b, param.Value)
+}
+
+func formatValue(b *strings.Builder, v interface{}) error {
+	switch val := v.(type) {
+	case nil:
+		b.WriteString("null")
+	case string:
+		b.WriteString(quoteExprString(val))
+	case bool:
+		b.WriteString(strconv.FormatBool(val))
+	case time.Time:
+		if val.Location() == time.UTC && val.Equal(val.Truncate(24*time.Hour)) {
+			b.WriteString(val.Format("2006-01-02"))
+		} else {
+			b.WriteString(val.Format(time.RFC3339Nano))
+		}
+	case float32:
+		return formatValue(b, float64(val))
+	case float64:
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return fmt.Errorf("value %v cannot be written in a filter expression", val)
+		}
+		// 与 encoding/json 相同：极大 / 极小的值用指数形式，避免展开成数百位的字面量
+		format := byte('f')
+		if abs := math.Abs(val); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+			format = 'e'
+		}
+		s := strconv.FormatFloat(val, format, -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0" // 保持浮点类型
+		}
+		b.WriteString(s)
+	default:
+		rv := reflect.ValueOf(v)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			b.WriteString(strconv.FormatInt(rv.Int(), 10))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			b.WriteString(strconv.FormatUint(rv.Uint(), 10))
+		case reflect.Slice, reflect.Array:
+			b.WriteByte('[')
+			for i := 0; i < rv.Len(); i++ {
+				if i > 0 {
+					b.WriteString(", ")
+				}
+				if err := formatValue(b, rv.Index(i).Interface()); err != nil {
+					return err
+				}
+			}
+			b.WriteByte(']')
+		default:
+			return fmt.Errorf("value of type %T cannot be written in a filter expression", v)
+		}
+	}
+	return nil
+}
+
+// quoteExprString 双引号字符串，只转义 lexString 支持的字符
+func quoteExprString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`).Replace(s) + `"`
+}
@@ -0,0 +1,125 @@
+package filter_translator_test
+
+import (
+	"database/sql"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"AbstractManager/util/filter_translator"
+
+	"gorm.io/gorm/schema"
+)
+
+type planStatus string
+
+func (planStatus) EnumValues() []string { return []string{"active", "trial", "canceled"} }
+
+type subscription struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Seats     int            `json:"seats"`
+	Level     int8           `json:"level"`
+	Quota     uint32         `json:"quota"`
+	Ratio     float64        `json:"ratio"`
+	Price     string         `gorm:"type:decimal(10,2)" json:"price"`
+	Active    bool           `json:"active"`
+	Code      string         `json:"code"`
+	Status    planStatus     `json:"status"`
+	StartedAt time.Time      `json:"started_at"`
+	EndedAt   *time.Time     `json:"ended_at"`
+	PausedAt  sql.NullTime   `json:"paused_at"`
+	Note      sql.NullString `json:"note"`
+}
+
+func subscriptionSchema(t *testing.T) *schema.Schema {
+	t.Helper()
+	s, err := schema.Parse(&subscription{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestCoerceParam(t *testing.T) {
+	s := subscriptionSchema(t)
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		param filter_translator.FilterParam
+		want  interface{}
+	}{
+		{filter_translator.FilterParam{Field: "seats", Operator: ">", Value: "18"}, int64(18)},
+		{filter_translator.FilterParam{Field: "seats", Operator: "=", Value: float64(3)}, int64(3)},
+		{filter_translator.FilterParam{Field: "quota", Operator: "<=", Value: "4096"}, uint64(4096)},
+		{filter_translator.FilterParam{Field: "ratio", Operator: ">=", Value: "0.25"}, 0.25},
+		{filter_translator.FilterParam{Field: "price", Operator: "=", Value: float64(9.9)}, "9.9"},
+		{filter_translator.FilterParam{Field: "active", Operator: "=", Value: "true"}, true},
+		{filter_translator.FilterParam{Field: "active", Operator: "=", Value: float64(0)}, false},
+		{filter_translator.FilterParam{Field: "code", Operator: "=", Value: float64(42)}, "42"},
+		{filter_translator.FilterParam{Field: "status", Operator: "=", Value: "trial"}, "trial"},
+		{filter_translator.FilterParam{Field: "started_at", Operator: ">", Value: "2024-05-01"}, day},
+		{filter_translator.FilterParam{Field: "ended_at", Operator: "<", Value: "2024-05-01 00:00:00"}, day},
+		{filter_translator.FilterParam{Field: "paused_at", Operator: "<", Value: "2024-05-01T00:00:00"}, day},
+		{filter_translator.FilterParam{Field: "note", Operator: "=", Value: "x"}, "x"},
+		{
+			filter_translator.FilterParam{Field: "seats", Operator: "in", Value: []interface{}{"1", float64(2)}},
+			[]interface{}{int64(1), int64(2)},
+		},
+	}
+	for _, tt := range tests {
+		field := s.LookUpField(tt.param.Field)
+		got, err := filter_translator.CoerceParam(field, tt.param)
+		if err != nil {
+			t.Errorf("%+v: %v", tt.param, err)
+			continue
+		}
+		if !reflect.DeepEqual(got.Value, tt.want) {
+			t.Errorf("%+v: got %#v, want %#v", tt.param, got.Value, tt.want)
+		}
+	}
+
+	for _, param := range []filter_translator.FilterParam{
+		{Field: "seats", Operator: "=", Value: "many"},
+		{Field: "seats", Operator: "=", Value: 1.5},
+		{Field: "level", Operator: "=", Value: float64(300)},
+		{Field: "quota", Operator: "=", Value: float64(-1)},
+		{Field: "price", Operator: "=", Value: "cheap"},
+		{Field: "active", Operator: "=", Value: "maybe"},
+		{Field: "status", Operator: "=", Value: "paused"},
+		{Field: "started_at", Operator: ">", Value: "yesterday"},
+		{Field: "seats", Operator: "in", Value: []interface{}{float64(1), "x"}},
+		{Field: "code", Operator: "like", Value: float64(1)},
+	} {
+		if _, err := filter_translator.CoerceParam(s.LookUpField(param.Field), param); err == nil {
+			t.Errorf("%+v: expected error", param)
+		}
+	}
+}
+
+func TestGormRegistryCoercesValues(t *testing.T) {
+	registry := filter_translator.DefaultGormRegistry.ForSchema(subscriptionSchema(t))
+
+	filter, err := registry.Translate(filter_translator.FilterParam{Field: "seats", Operator: "between", Value: []interface{}{"1", "10"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := filter.ApplyGorm(dryRunDB(t).Model(&subscription{})).Find(&[]subscription{}).Statement
+	if !reflect.DeepEqual(stmt.Vars, []interface{}{int64(1), int64(10)}) {
+		t.Errorf("vars = %#v", stmt.Vars)
+	}
+
+	if _, err := registry.Translate(filter_translator.FilterParam{Field: "seats", Operator: ">", Value: "ten"}); err == nil {
+		t.Error("expected validation error for non-numeric value")
+	}
+}
+
+func TestGormLikeFilterNonString(t *testing.T) {
+	filter := &filter_translator.GormLikeFilter{
+		GenericFilter: &filter_translator.GenericFilter{Field: "code", Operator: "like", Value: 42},
+	}
+	db := filter.ApplyGorm(dryRunDB(t).Model(&subscription{})).Find(&[]subscription{})
+	if db.Error == nil {
+		t.Error("expected error for non-string like value")
+	}
+}
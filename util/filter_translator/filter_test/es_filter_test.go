@@ -0,0 +1,132 @@
+package filter_translator_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"AbstractManager/util/filter_translator"
+)
+
+// go test ./util/filter_translator/filter_test -run ESGolden -update 重新生成 testdata/es 下的期望文件
+var updateGolden = flag.Bool("update", false, "rewrite golden files")
+
+func TestESGolden(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters []filter_translator.FilterParam
+		expr    string
+		opts    filter_translator.ESSearchOptions
+	}{
+		{name: "match_all", opts: filter_translator.ESSearchOptions{PageSize: 20}},
+		{
+			name: "flat_filters",
+			filters: []filter_translator.FilterParam{
+				{Field: "status", Operator: "=", Value: "active"},
+				{Field: "age", Operator: ">=", Value: 18},
+				{Field: "role", Operator: "in", Value: []interface{}{"admin", "editor"}},
+				{Field: "score", Operator: "between", Value: []interface{}{1, 9}},
+				{Field: "deleted_at", Operator: "isnull"},
+			},
+			opts: filter_translator.ESSearchOptions{
+				Sorts:    []filter_translator.QuerySort{{Field: "created_at", Desc: true}, {Field: "id"}},
+				Page:     3,
+				PageSize: 10,
+				Fields:   []string{"id", "name"},
+			},
+		},
+		{
+			name: "negations",
+			filters: []filter_translator.FilterParam{
+				{Field: "status", Operator: "!=", Value: "banned"},
+				{Field: "role", Operator: "not_in", Value: []interface{}{"guest"}},
+				{Field: "age", Operator: "not_between", Value: []interface{}{13, 17}},
+				{Field: "email", Operator: "isnotnull"},
+			},
+		},
+		{
+			name: "strings",
+			filters: []filter_translator.FilterParam{
+				{Field: "name", Operator: "like", Value: `jo_n%*`},
+				{Field: "name", Operator: "starts_with", Value: "a*b"},
+				{Field: "name", Operator: "ends_with", Value: "son"},
+				{Field: "email", Operator: "ilike", Value: "Example"},
+				{Field: "country", Operator: "ieq", Value: "CN"},
+				{Field: "bio", Operator: "match", Value: "golang redis"},
+				{Field: "sku", Operator: "regex", Value: "[A-Z]+-[0-9]+"},
+			},
+		},
+		{
+			name: "relative_time",
+			filters: []filter_translator.FilterParam{
+				{Field: "created_at", Operator: "within_last", Value: "7d"},
+				{Field: "updated_at", Operator: "older_than", Value: "90m"},
+				{Field: "expires_at", Operator: "after_now"},
+			},
+		},
+		{
+			name: "tree",
+			expr: `(status = "active" or status = "trial") and not age < 18 and profile.city in ["Beijing", "Shanghai"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var filters []filter_translator.ESFilter
+			var err error
+			if tt.expr != "" {
+				node, err := filter_translator.ParseExpr(tt.expr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				f, err := filter_translator.DefaultESRegistry.TranslateTree(node)
+				if err != nil {
+					t.Fatal(err)
+				}
+				filters = []filter_translator.ESFilter{f}
+			} else if filters, err = filter_translator.DefaultESRegistry.TranslateBatch(tt.filters); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := json.MarshalIndent(filter_translator.BuildESSearch(filters, tt.opts), "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			path := filepath.Join("testdata", "es", tt.name+".json")
+			if *updateGolden {
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s mismatch (run with -update to regenerate)\ngot:\n%s\nwant:\n%s", path, got, want)
+			}
+		})
+	}
+}
+
+func TestESTranslateErrors(t *testing.T) {
+	for _, param := range []filter_translator.FilterParam{
+		{Field: "age", Operator: "in", Value: []interface{}{}},
+		{Field: "age", Operator: "between", Value: []interface{}{1}},
+		{Field: "name", Operator: "like", Value: 1},
+		{Field: "created_at", Operator: "within_last", Value: "soon"},
+		{Field: "name", Operator: "unknown", Value: "x"},
+	} {
+		if _, err := filter_translator.DefaultESRegistry.Translate(param); err == nil {
+			t.Errorf("%+v: expected error", param)
+		}
+	}
+}
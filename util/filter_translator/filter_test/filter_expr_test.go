@@ -0,0 +1,165 @@
+package filter_translator_test
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"AbstractManager/util/filter_translator"
+)
+
+func leaf(field, op string, value interface{}) filter_translator.FilterNode {
+	return filter_translator.FilterNode{FilterParam: filter_translator.FilterParam{Field: field, Operator: op, Value: value}}
+}
+
+func TestParseExpr(t *testing.T) {
+	node, err := filter_translator.ParseExpr(`age > 18 and (name ~ "jo" or email ends "@corp.com") and deleted_at is null`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &filter_translator.FilterNode{And: []filter_translator.FilterNode{
+		leaf("age", ">", int64(18)),
+		{Or: []filter_translator.FilterNode{
+			leaf("name", "like", "jo"),
+			leaf("email", "ends_with", "@corp.com"),
+		}},
+		leaf("deleted_at", "isnull", nil),
+	}}
+	if !reflect.DeepEqual(node, want) {
+		t.Errorf("got %+v\nwant %+v", node, want)
+	}
+}
+
+func TestParseExprLiterals(t *testing.T) {
+	node, err := filter_translator.ParseExpr(`score >= -1.5 and active = TRUE and created_at between 2024-01-02 and 2024-03-01T08:30:00Z ` +
+		`and role not in ('admin', "ops") and updated_at within_last 7d and tags in [] and not profile.city = 'Paris'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []filter_translator.FilterNode{
+		leaf("score", ">=", -1.5),
+		leaf("active", "=", true),
+		leaf("created_at", "between", []interface{}{
+			time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC),
+		}),
+		leaf("role", "not_in", []interface{}{"admin", "ops"}),
+		leaf("updated_at", "within_last", "7d"),
+		leaf("tags", "in", []interface{}{}),
+		{Not: &filter_translator.FilterNode{FilterParam: filter_translator.FilterParam{Field: "profile.city", Operator: "=", Value: "Paris"}}},
+	}
+	if !reflect.DeepEqual(node.And, want) {
+		t.Errorf("got %+v\nwant %+v", node.And, want)
+	}
+}
+
+func TestParseExprErrors(t *testing.T) {
+	cases := []struct {
+		src    string
+		line   int
+		column int
+		msg    string
+	}{
+		{`age >`, 1, 6, "expected value"},
+		{`age > 18 and`, 1, 13, "expected field name"},
+		{`(age > 18`, 1, 10, `expected ")"`},
+		{"age > 18 and\n  name ~ \"jo", 2, 10, "unterminated string"},
+		{`age between 1 or 2`, 1, 15, `expected "and"`},
+		{`age > 18 # x`, 1, 10, "unexpected character"},
+		{`age not like "x"`, 1, 9, `expected "in" or "between"`},
+	}
+	for _, c := range cases {
+		_, err := filter_translator.ParseExpr(c.src)
+		var exprErr *filter_translator.ExprError
+		if !errors.As(err, &exprErr) {
+			t.Errorf("%q: expected ExprError, got %v", c.src, err)
+			continue
+		}
+		if exprErr.Line != c.line || exprErr.Column != c.column || !strings.Contains(exprErr.Msg, c.msg) {
+			t.Errorf("%q: got %d:%d %q, want %d:%d %q", c.src, exprErr.Line, exprErr.Column, exprErr.Msg, c.line, c.column, c.msg)
+		}
+	}
+
+	_, err := filter_translator.ParseExpr("age > 18 and\n  name ~ \"jo")
+	var exprErr *filter_translator.ExprError
+	errors.As(err, &exprErr)
+	if got, want := exprErr.Snippet(), "  name ~ \"jo\n         ^"; got != want {
+		t.Errorf("snippet:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatExprRoundTrip(t *testing.T) {
+	for _, src := range []string{
+		`age > 18 and (name ~ "jo" or email ends "@corp.com") and deleted_at is null`,
+		`not (status = "banned" or status = "spam") and score >= 0.5`,
+		`(a = 1 and b = 2) and c = 3`,
+		`a = 1 or (b = 2 or c = 3)`,
+		`created_at between 2024-01-02 and 2024-03-01T08:30:00Z and role not in ["admin", "o\"ps"]`,
+		`name starts "J" and bio is not null and seen_at before_now and total != 10.0`,
+	} {
+		node, err := filter_translator.ParseExpr(src)
+		if err != nil {
+			t.Fatalf("%q: %v", src, err)
+		}
+		text, err := filter_translator.FormatExpr(node)
+		if err != nil {
+			t.Fatalf("%q: %v", src, err)
+		}
+		again, err := filter_translator.ParseExpr(text)
+		if err != nil {
+			t.Fatalf("%q -> %q: %v", src, text, err)
+		}
+		if !reflect.DeepEqual(node, again) {
+			t.Errorf("round trip changed the tree: %q -> %q", src, text)
+		}
+	}
+
+	text, err := filter_translator.FormatExpr(&filter_translator.FilterNode{And: []filter_translator.FilterNode{
+		leaf("age", ">", 18),
+		{Or: []filter_translator.FilterNode{leaf("status", "=", "active"), leaf("status", "=", "trial")}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `age > 18 and (status = "active" or status = "trial")`; text != want {
+		t.Errorf("got %q, want %q", text, want)
+	}
+}
+
+func TestFormatExprFloats(t *testing.T) {
+	cases := map[float64]string{
+		10:      "x = 10.0",
+		-1.5:    "x = -1.5",
+		0:       "x = 0.0",
+		1e300:   "x = 1e+300",
+		-2.5e-9: "x = -2.5e-09",
+		1e20:    "x = 100000000000000000000.0",
+	}
+	for v, want := range cases {
+		node := leaf("x", "=", v)
+		text, err := filter_translator.FormatExpr(&node)
+		if err != nil {
+			t.Fatalf("%v: %v", v, err)
+		}
+		if text != want {
+			t.Errorf("%v: got %q, want %q", v, text, want)
+		}
+		again, err := filter_translator.ParseExpr(text)
+		if err != nil {
+			t.Fatalf("%q: %v", text, err)
+		}
+		if !reflect.DeepEqual(again, &node) {
+			t.Errorf("%v: round trip gave %+v", v, again)
+		}
+	}
+
+	for _, v := range []interface{}{math.NaN(), math.Inf(1), float32(math.Inf(-1)), []interface{}{1, math.NaN()}} {
+		node := leaf("x", "in", v)
+		if _, err := filter_translator.FormatExpr(&node); err == nil {
+			t.Errorf("%v: expected error", v)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package filter_translator_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"AbstractManager/internal/redistest"
+	"AbstractManager/util/filter_translator"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:1)/db",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestGormFilterTree(t *testing.T) {
+	var where filter_translator.FilterNode
+	err := json.Unmarshal([]byte(`{"and": [
+		{"or": [
+			{"field": "status", "operator": "=", "value": "active"},
+			{"field": "status", "operator": "=", "value": "trial"}
+		]},
+		{"not": {"field": "age", "operator": "<", "value": 18}}
+	]}`), &where)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree := filter_translator.Combine([]filter_translator.FilterParam{{Field: "name", Operator: "like", Value: "jo"}}, &where)
+	filter, err := filter_translator.DefaultGormRegistry.TranslateTree(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var users []User
+	sql := filter.ApplyGorm(dryRunDB(t).Model(&User{})).Find(&users).Statement.SQL.String()
+	want := "WHERE `name` LIKE ? AND (`status` = ? OR `status` = ?) AND NOT `age` < ?"
+	if !strings.Contains(sql, want) {
+		t.Errorf("SQL %q does not contain %q", sql, want)
+	}
+}
+
+func TestFilterTreeValidate(t *testing.T) {
+	cases := map[string]string{
+		"empty node":  `{}`,
+		"empty group": `{"or": []}`,
+		"mixed":       `{"field": "a", "operator": "=", "value": 1, "and": [{"field": "b", "operator": "=", "value": 2}]}`,
+		"two groups":  `{"and": [{"field": "a", "operator": "isnull"}], "or": [{"field": "b", "operator": "isnull"}]}`,
+	}
+	for name, raw := range cases {
+		var node filter_translator.FilterNode
+		if err := json.Unmarshal([]byte(raw), &node); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, err := filter_translator.DefaultGormRegistry.TranslateTree(&node); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+
+	deep := &filter_translator.FilterNode{FilterParam: filter_translator.FilterParam{Field: "a", Operator: "isnull"}}
+	for i := 0; i < filter_translator.MaxFilterDepth; i++ {
+		deep = &filter_translator.FilterNode{Not: deep}
+	}
+	if err := deep.Validate(); err == nil {
+		t.Error("expected max depth error")
+	}
+}
+
+func TestGormFilterTreeNestedAnd(t *testing.T) {
+	where := &filter_translator.FilterNode{Or: []filter_translator.FilterNode{
+		{And: []filter_translator.FilterNode{
+			{FilterParam: filter_translator.FilterParam{Field: "a", Operator: "=", Value: 1}},
+			{FilterParam: filter_translator.FilterParam{Field: "b", Operator: "=", Value: 2}},
+		}},
+		{FilterParam: filter_translator.FilterParam{Field: "c", Operator: "isnull"}},
+	}}
+	filter, err := filter_translator.DefaultGormRegistry.TranslateTree(where)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var users []User
+	sql := filter.ApplyGorm(dryRunDB(t).Model(&User{}).Where("tenant_id = ?", 7)).Find(&users).Statement.SQL.String()
+	want := "WHERE tenant_id = ? AND ((`a` = ? AND `b` = ?) OR `c` IS NULL)"
+	if !strings.Contains(sql, want) {
+		t.Errorf("SQL %q does not contain %q", sql, want)
+	}
+}
+
+func TestRedisFilterTreeNotThreeValued(t *testing.T) {
+	ctx := context.Background()
+	client, server := redistest.NewClient(t)
+	server.Set("u:1", `{"age": 30, "name": "ann"}`)
+	server.Set("u:2", `{"age": 10, "name": "bob"}`)
+	server.Set("u:3", `{"age": null, "name": "cat"}`)
+	server.Set("u:4", `{"name": "dan"}`)
+	server.Set("u:5", `not json`)
+	server.Set("u:6", `{"data": {"age": 40}}`)
+	keys := []string{"u:1", "u:2", "u:3", "u:4", "u:5", "u:6", "u:missing"}
+
+	cases := map[string]struct {
+		tree string
+		want []string
+	}{
+		// 字段缺失 / 为 null / 记录无效时比较为 UNKNOWN，NOT 后仍不选中
+		"not compare": {`{"not": {"field": "age", "operator": "<", "value": 18}}`, []string{"u:1", "u:6"}},
+		// UNKNOWN AND FALSE 为 FALSE，取反后为 TRUE；TRUE AND UNKNOWN（u:6 无 name）仍为 UNKNOWN
+		"not and": {`{"not": {"and": [
+			{"field": "age", "operator": ">", "value": 18},
+			{"field": "name", "operator": "=", "value": "ann"}
+		]}}`, []string{"u:2", "u:3", "u:4"}},
+		// FALSE OR UNKNOWN 为 UNKNOWN
+		"not or": {`{"not": {"or": [
+			{"field": "name", "operator": "=", "value": "ann"},
+			{"field": "age", "operator": "<", "value": 18}
+		]}}`, []string{}},
+		"double not": {`{"not": {"not": {"field": "age", "operator": "<", "value": 18}}}`, []string{"u:2"}},
+		// is null 不产生 UNKNOWN
+		"not isnull": {`{"not": {"field": "age", "operator": "isnull"}}`, []string{"u:1", "u:2", "u:6"}},
+	}
+	for name, c := range cases {
+		var where filter_translator.FilterNode
+		if err := json.Unmarshal([]byte(c.tree), &where); err != nil {
+			t.Fatal(err)
+		}
+		filter, err := filter_translator.DefaultRedisRegistry.TranslateTree(&where)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		got, err := filter.ApplyRedis(ctx, client, keys)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %v, want %v", name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package filter_translator_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"AbstractManager/util/filter_translator"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestGormFiltersQuoteIdentifiers(t *testing.T) {
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:1)/db",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filters, err := filter_translator.DefaultGormRegistry.TranslateBatch([]filter_translator.FilterParam{
+		{Field: "name", Operator: "=", Value: "tom"},
+		{Field: "age", Operator: "between", Value: []interface{}{1, 2}},
+		{Field: "email", Operator: "isnull"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var users []User
+	stmt := filter_translator.ApplyGormFilters(db.Model(&User{}), filters).Find(&users).Statement
+	sql := stmt.SQL.String()
+	for _, want := range []string{"`name` = ?", "`age` BETWEEN ? AND ?", "`email` IS NULL"} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("SQL %q does not contain %q", sql, want)
+		}
+	}
+}
+
+func TestGormExtendedOperators(t *testing.T) {
+	filters, err := filter_translator.DefaultGormRegistry.TranslateBatch([]filter_translator.FilterParam{
+		{Field: "status", Operator: "not_in", Value: []interface{}{"banned"}},
+		{Field: "age", Operator: "not_between", Value: []interface{}{1, 2}},
+		{Field: "name", Operator: "starts_with", Value: "50%_"},
+		{Field: "email", Operator: "ends_with", Value: "@example.com"},
+		{Field: "city", Operator: "ieq", Value: "Paris"},
+		{Field: "bio", Operator: "ilike", Value: "Go"},
+		{Field: "code", Operator: "regex", Value: "^[A-Z]{3}$"},
+		{Field: "created_at", Operator: "within_last", Value: "7d"},
+		{Field: "expires_at", Operator: "before_now"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var users []User
+	stmt := filter_translator.ApplyGormFilters(dryRunDB(t).Model(&User{}), filters).Find(&users).Statement
+	sql := stmt.SQL.String()
+	for _, want := range []string{
+		"`status` NOT IN (?)",
+		"`age` NOT BETWEEN ? AND ?",
+		"`name` LIKE ?",
+		"LOWER(`city`) = ?",
+		"LOWER(`bio`) LIKE ?",
+		"`code` REGEXP ?",
+		"`created_at` >= ?",
+		"`expires_at` < ?",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("SQL %q does not contain %q", sql, want)
+		}
+	}
+
+	vars := stmt.Vars
+	if vars[3] != `50\%\_%` || vars[4] != "%@example.com" || vars[5] != "paris" || vars[6] != "%go%" {
+		t.Errorf("unexpected vars %v", vars)
+	}
+	since, ok := vars[8].(time.Time)
+	if !ok || time.Since(since) < 7*24*time.Hour-time.Minute || time.Since(since) > 7*24*time.Hour+time.Minute {
+		t.Errorf("within_last bound = %v", vars[8])
+	}
+}
+
+func TestExtendedOperatorValidation(t *testing.T) {
+	invalid := []filter_translator.FilterParam{
+		{Field: "code", Operator: "regex", Value: "("},
+		{Field: "created_at", Operator: "within_last", Value: "7x"},
+		{Field: "created_at", Operator: "older_than", Value: "-1d"},
+		{Field: "name", Operator: "starts_with", Value: 1},
+		{Field: "status", Operator: "not_in", Value: []interface{}{}},
+	}
+	for _, p := range invalid {
+		if _, err := filter_translator.DefaultGormRegistry.Translate(p); err == nil {
+			t.Errorf("gorm %s %v: expected error", p.Operator, p.Value)
+		}
+	}
+
+	for in, want := range map[string]time.Duration{"90m": 90 * time.Minute, "7d": 7 * 24 * time.Hour, "2w": 14 * 24 * time.Hour, "1.5d": 36 * time.Hour} {
+		if got, err := filter_translator.ParseRelativeDuration(in); err != nil || got != want {
+			t.Errorf("ParseRelativeDuration(%q) = %v, %v", in, got, err)
+		}
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	cases := map[string]string{
+		"plain":    "plain",
+		"100%":     `100\%`,
+		"a_b":      `a\_b`,
+		`c:\dir%_`: `c:\\dir\%\_`,
+	}
+	for in, want := range cases {
+		if got := filter_translator.EscapeLike(in); got != want {
+			t.Errorf("EscapeLike(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package filter_translator_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"AbstractManager/util/filter_translator"
+)
+
+type account struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Name      string         `json:"name"`
+	Age       *int           `json:"age"`
+	Score     float64        `json:"score"`
+	Nickname  sql.NullString `json:"nickname"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+func intPtr(v int) *int { return &v }
+
+func memoryFilter(t *testing.T, expr string) filter_translator.MemoryFilter {
+	t.Helper()
+	node, err := filter_translator.ParseExpr(expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := filter_translator.DefaultMemoryRegistry.TranslateTree(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestMemoryFilterSemantics(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	accounts := []account{
+		{ID: 1, Name: "alice", Age: intPtr(30), Score: 9.5, Nickname: sql.NullString{String: "al", Valid: true}, CreatedAt: created},
+		{ID: 2, Name: "bob_1", Age: nil, Score: 7, CreatedAt: created.AddDate(0, 1, 0)},
+		{ID: 3, Name: "Carol", Age: intPtr(17), Score: 10, CreatedAt: created.AddDate(-1, 0, 0)},
+	}
+
+	tests := []struct {
+		expr string
+		want []uint
+	}{
+		{`age > 18`, []uint{1}},
+		// NULL 比较为 UNKNOWN，NOT 之后仍不满足
+		{`not age > 18`, []uint{3}},
+		{`age is null`, []uint{2}},
+		{`age not in [30]`, []uint{3}},
+		{`age > 18 or id = 2`, []uint{1, 2}},
+		{`nickname = "al"`, []uint{1}},
+		{`nickname is null`, []uint{2, 3}},
+		// like 中的 _ 是通配符，starts_with 按字面匹配
+		{`name ~ "b_b"`, []uint{2}},
+		{`name starts "bob_"`, []uint{2}},
+		{`name starts "bo%"`, nil},
+		{`name = "carol"`, nil},
+		{`name ieq "carol"`, []uint{3}},
+		// 数值字段与数值字符串按数值比较
+		{`score >= "9.5"`, []uint{1, 3}},
+		{`score between 7 and 9.5`, []uint{1, 2}},
+		{`created_at < 2024-03-02`, []uint{1, 3}},
+		{`created_at > "2024-03-01T12:00:00Z"`, []uint{2}},
+	}
+	for _, tt := range tests {
+		got, err := filter_translator.FilterSlice(accounts, memoryFilter(t, tt.expr))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.expr, err)
+		}
+		var ids []uint
+		for _, a := range got {
+			ids = append(ids, a.ID)
+		}
+		if len(ids) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.expr, ids, tt.want)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != tt.want[i] {
+				t.Errorf("%s: got %v, want %v", tt.expr, ids, tt.want)
+				break
+			}
+		}
+	}
+
+	if _, err := filter_translator.FilterSlice(accounts, memoryFilter(t, `missing is null`)); err == nil {
+		t.Error("expected unknown field error")
+	}
+}
+
+func TestMemoryFilterRelation(t *testing.T) {
+	members := map[string]*member{
+		"1": {ID: 1, Orders: []order{{Total: 50}, {Total: 150, Items: []orderItem{{SKU: "A-1"}}}}},
+		"2": {ID: 2, Orders: []order{{Total: 80}}},
+		"3": {ID: 3},
+	}
+
+	got, err := filter_translator.FilterMap(members, memoryFilter(t, `orders.total > 100`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got["1"] == nil {
+		t.Errorf("orders.total > 100: got %v", got)
+	}
+
+	got, err = filter_translator.FilterMap(members, memoryFilter(t, `not orders.items.sku = "A-1"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["1"] != nil {
+		t.Errorf("not orders.items.sku: got %v", got)
+	}
+}
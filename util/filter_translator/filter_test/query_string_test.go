@@ -0,0 +1,69 @@
+package filter_translator_test
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"AbstractManager/util/filter_translator"
+)
+
+func TestParseQueryString(t *testing.T) {
+	values, err := url.ParseQuery("filter[age][gt]=18&filter[name][like]=jo&filter[status]=active" +
+		"&filter[role][in]=admin,editor&filter[role][in]=owner&filter[score][between]=1,%209&filter[deleted_at][isnull]=" +
+		"&sort=-created_at,name&page=2&page_size=10&fields=id,name&method=list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := filter_translator.ParseQueryString(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantFilters := []filter_translator.FilterParam{
+		{Field: "age", Operator: ">", Value: "18"},
+		{Field: "deleted_at", Operator: "isnull"},
+		{Field: "name", Operator: "like", Value: "jo"},
+		{Field: "role", Operator: "in", Value: []interface{}{"admin", "editor", "owner"}},
+		{Field: "score", Operator: "between", Value: []interface{}{"1", "9"}},
+		{Field: "status", Operator: "=", Value: "active"},
+	}
+	if !reflect.DeepEqual(params.Filters, wantFilters) {
+		t.Errorf("filters = %#v", params.Filters)
+	}
+	wantSorts := []filter_translator.QuerySort{{Field: "created_at", Desc: true}, {Field: "name"}}
+	if !reflect.DeepEqual(params.Sorts, wantSorts) {
+		t.Errorf("sorts = %#v", params.Sorts)
+	}
+	if params.Page != 2 || params.PageSize != 10 || !reflect.DeepEqual(params.Fields, []string{"id", "name"}) {
+		t.Errorf("page = %d, page_size = %d, fields = %v", params.Page, params.PageSize, params.Fields)
+	}
+
+	// 解析结果可直接交给注册表翻译
+	for _, p := range params.Filters {
+		if _, err := filter_translator.DefaultGormRegistry.Translate(p); err != nil {
+			t.Errorf("translate %+v: %v", p, err)
+		}
+	}
+}
+
+func TestParseQueryStringErrors(t *testing.T) {
+	for _, raw := range []string{
+		"filter[age]gt=1",
+		"filter[a][b][c]=1",
+		"filter=1",
+		"filter[score][between]=1",
+		"filter[role][in]=",
+		"sort=-",
+		"page=0",
+		"page_size=abc",
+	} {
+		values, err := url.ParseQuery(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := filter_translator.ParseQueryString(values); err == nil {
+			t.Errorf("%q: expected error", raw)
+		}
+	}
+}
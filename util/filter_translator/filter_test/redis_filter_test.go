@@ -0,0 +1,124 @@
+package filter_translator_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"AbstractManager/internal/redistest"
+	"AbstractManager/util/filter_translator"
+)
+
+func TestRedisFilters(t *testing.T) {
+	ctx := context.Background()
+	client, server := redistest.NewClient(t)
+	now := time.Now()
+	server.Set("u:1", fmt.Sprintf(`{"name": "Alice", "status": "active", "created_at": %q}`, now.Add(-time.Hour).Format(time.RFC3339)))
+	server.Set("u:2", fmt.Sprintf(`{"name": "bob", "status": "banned", "created_at": %q}`, now.Add(-10*24*time.Hour).Format("2006-01-02 15:04:05")))
+	server.Set("u:3", fmt.Sprintf(`{"name": "Carol_x", "status": null, "created_at": %d}`, now.Add(time.Hour).Unix()))
+	server.Set("u:4", `{"data": {"name": "alina", "status": "trial", "created_at": "not a time"}}`)
+	server.Set("u:5", `not json`)
+	keys := []string{"u:1", "u:2", "u:3", "u:4", "u:5", "u:missing"}
+
+	cases := []struct {
+		name  string
+		param filter_translator.FilterParam
+		want  []string
+	}{
+		// not_in 与 SQL 一致：null 不满足
+		{"not in", filter_translator.FilterParam{Field: "status", Operator: "not_in", Value: []interface{}{"active", "banned"}}, []string{"u:4"}},
+		{"not in none", filter_translator.FilterParam{Field: "status", Operator: "not_in", Value: []interface{}{"gone"}}, []string{"u:1", "u:2", "u:4"}},
+
+		// starts_with / ends_with 区分大小写，ieq / ilike 忽略大小写；嵌套在 data 中的字段同样参与
+		{"starts with", filter_translator.FilterParam{Field: "name", Operator: "starts_with", Value: "al"}, []string{"u:4"}},
+		{"starts with upper", filter_translator.FilterParam{Field: "name", Operator: "starts_with", Value: "AL"}, []string{}},
+		{"ends with", filter_translator.FilterParam{Field: "name", Operator: "ends_with", Value: "ob"}, []string{"u:2"}},
+		{"ends with upper", filter_translator.FilterParam{Field: "name", Operator: "ends_with", Value: "OB"}, []string{}},
+		{"ieq", filter_translator.FilterParam{Field: "name", Operator: "ieq", Value: "alice"}, []string{"u:1"}},
+		{"ilike", filter_translator.FilterParam{Field: "name", Operator: "ilike", Value: "_X"}, []string{"u:3"}},
+		{"ilike null", filter_translator.FilterParam{Field: "status", Operator: "ilike", Value: ""}, []string{"u:1", "u:2", "u:4"}},
+
+		// 正则区分大小写
+		{"regex", filter_translator.FilterParam{Field: "name", Operator: "regex", Value: "^[A-Z]"}, []string{"u:1", "u:3"}},
+		{"regex lower", filter_translator.FilterParam{Field: "name", Operator: "regex", Value: "^a"}, []string{"u:4"}},
+		{"regex null", filter_translator.FilterParam{Field: "status", Operator: "regex", Value: ".*"}, []string{"u:1", "u:2", "u:4"}},
+
+		// 相对时间：RFC3339、"2006-01-02 15:04:05" 与 Unix 秒均可解析，无法解析的值不满足
+		{"within last", filter_translator.FilterParam{Field: "created_at", Operator: "within_last", Value: "1d"}, []string{"u:1", "u:3"}},
+		{"older than", filter_translator.FilterParam{Field: "created_at", Operator: "older_than", Value: "1w"}, []string{"u:2"}},
+		{"before now", filter_translator.FilterParam{Field: "created_at", Operator: "before_now"}, []string{"u:1", "u:2"}},
+		{"after now", filter_translator.FilterParam{Field: "created_at", Operator: "after_now"}, []string{"u:3"}},
+	}
+	for _, tc := range cases {
+		filter, err := filter_translator.DefaultRedisRegistry.Translate(tc.param)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		got, err := filter.ApplyRedis(ctx, client, keys)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestRedisFiltersRejectInvalidValues(t *testing.T) {
+	cases := map[string]filter_translator.FilterParam{
+		"starts_with number": {Field: "name", Operator: "starts_with", Value: 1},
+		"bad regex":          {Field: "name", Operator: "regex", Value: "("},
+		"regex number":       {Field: "name", Operator: "regex", Value: 1},
+		"bad duration":       {Field: "created_at", Operator: "within_last", Value: "soon"},
+		"negative duration":  {Field: "created_at", Operator: "older_than", Value: "-1d"},
+		"not_in scalar":      {Field: "status", Operator: "not_in", Value: "active"},
+	}
+	for name, param := range cases {
+		if _, err := filter_translator.DefaultRedisRegistry.Translate(param); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// 同一条件在 Redis 与内存过滤中的结果一致（GORM 端为 LIKE，区分大小写取决于排序规则）
+func TestRedisStringFiltersAgreeWithMemory(t *testing.T) {
+	ctx := context.Background()
+	client, server := redistest.NewClient(t)
+	accounts := []account{{ID: 1, Name: "Alice"}, {ID: 2, Name: "alina"}, {ID: 3, Name: "BOB"}, {ID: 4, Name: "bob"}}
+	keys := make([]string, len(accounts))
+	for i, a := range accounts {
+		keys[i] = fmt.Sprintf("a:%d", a.ID)
+		server.Set(keys[i], fmt.Sprintf(`{"id": %d, "name": %q}`, a.ID, a.Name))
+	}
+
+	for _, expr := range []string{
+		`name starts "Al"`, `name starts "al"`, `name ends "OB"`, `name ends "ob"`,
+		`name ieq "ALICE"`, `name ilike "LI"`,
+	} {
+		node, err := filter_translator.ParseExpr(expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filter, err := filter_translator.DefaultRedisRegistry.Translate(node.FilterParam)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fromRedis, err := filter.ApplyRedis(ctx, client, keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inMemory, err := filter_translator.FilterSlice(accounts, memoryFilter(t, expr))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want []string
+		for _, a := range inMemory {
+			want = append(want, fmt.Sprintf("a:%d", a.ID))
+		}
+		if len(want) == 0 || !reflect.DeepEqual(fromRedis, want) {
+			t.Errorf("%s: redis %v, memory %v", expr, fromRedis, want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package filter_translator_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"AbstractManager/util/filter_translator"
+
+	"gorm.io/gorm/schema"
+)
+
+type member struct {
+	ID        uint    `gorm:"primaryKey" json:"id"`
+	Name      string  `json:"name"`
+	CompanyID uint    `json:"company_id"`
+	Company   company `json:"company"`
+	Profile   profile `json:"profile"`
+	Orders    []order `json:"orders"`
+	Roles     []role  `gorm:"many2many:member_roles" json:"roles"`
+}
+
+type company struct {
+	ID   uint   `gorm:"primaryKey"`
+	Name string `json:"name"`
+}
+
+type profile struct {
+	ID       uint   `gorm:"primaryKey"`
+	MemberID uint   `json:"member_id"`
+	City     string `json:"city"`
+}
+
+type order struct {
+	ID       uint        `gorm:"primaryKey"`
+	MemberID uint        `json:"member_id"`
+	Total    int         `json:"total"`
+	Items    []orderItem `json:"items"`
+}
+
+type orderItem struct {
+	ID      uint   `gorm:"primaryKey"`
+	OrderID uint   `json:"order_id"`
+	SKU     string `gorm:"column:sku" json:"sku"`
+}
+
+type role struct {
+	ID   uint   `gorm:"primaryKey"`
+	Name string `json:"name"`
+}
+
+func memberRegistry(t *testing.T) *filter_translator.GormTranslatorRegistry {
+	t.Helper()
+	s, err := schema.Parse(&member{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filter_translator.DefaultGormRegistry.ForSchema(s)
+}
+
+func TestGormRelationFilters(t *testing.T) {
+	registry := memberRegistry(t)
+	cases := []struct {
+		param filter_translator.FilterParam
+		want  string
+	}{
+		{
+			filter_translator.FilterParam{Field: "profile.city", Operator: "=", Value: "Paris"},
+			"EXISTS (SELECT 1 FROM `profiles` `assoc_1` WHERE `assoc_1`.`member_id` = `members`.`id` AND `assoc_1`.`city` = ?)",
+		},
+		{
+			filter_translator.FilterParam{Field: "company.name", Operator: "like", Value: "acme"},
+			"EXISTS (SELECT 1 FROM `companies` `assoc_1` WHERE `assoc_1`.`id` = `members`.`company_id` AND `assoc_1`.`name` LIKE ?)",
+		},
+		{
+			filter_translator.FilterParam{Field: "orders.items.sku", Operator: "=", Value: "X1"},
+			"EXISTS (SELECT 1 FROM `orders` `assoc_1` WHERE `assoc_1`.`member_id` = `members`.`id` AND EXISTS (SELECT 1 FROM `order_items` `assoc_2` WHERE `assoc_2`.`order_id` = `assoc_1`.`id` AND `assoc_2`.`sku` = ?))",
+		},
+		{
+			filter_translator.FilterParam{Field: "Roles.name", Operator: "in", Value: []interface{}{"admin"}},
+			"EXISTS (SELECT 1 FROM `member_roles` `assoc_1_j` INNER JOIN `roles` `assoc_1` ON `assoc_1_j`.`role_id` = `assoc_1`.`id` WHERE `assoc_1_j`.`member_id` = `members`.`id` AND `assoc_1`.`name` IN (?))",
+		},
+	}
+	for _, tc := range cases {
+		filter, err := registry.Translate(tc.param)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.param.Field, err)
+		}
+		var members []member
+		sql := filter.ApplyGorm(dryRunDB(t).Table("members")).Find(&members).Statement.SQL.String()
+		if !strings.Contains(sql, tc.want) {
+			t.Errorf("%s:\n got  %s\n want %s", tc.param.Field, sql, tc.want)
+		}
+	}
+}
+
+func TestGormRelationPathValidation(t *testing.T) {
+	registry := memberRegistry(t)
+	for _, field := range []string{"profile.missing", "orders.items.missing", "orders.missing.sku"} {
+		if _, err := registry.Translate(filter_translator.FilterParam{Field: field, Operator: "=", Value: 1}); err == nil {
+			t.Errorf("%s: expected error", field)
+		}
+	}
+
+	// 前缀不是关联时按 table.column 处理
+	filter, err := registry.Translate(filter_translator.FilterParam{Field: "members.name", Operator: "=", Value: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := filter.(*filter_translator.GormRelationFilter); ok {
+		t.Error("table-qualified column translated as relation filter")
+	}
+}
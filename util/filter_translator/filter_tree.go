@@ -0,0 +1,400 @@
+package filter_translator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// ========== 过滤条件树 ==========
+// 在扁平的 FilterParam 列表之外，支持任意嵌套的 and / or / not 条件组：
+//
+//	{"and": [
+//	  {"or": [
+//	    {"field": "status", "operator": "=", "value": "active"},
+//	    {"field": "status", "operator": "=", "value": "trial"}
+//	  ]},
+//	  {"not": {"field": "age", "operator": "<", "value": 18}}
+//	]}
+//
+// 扁平列表等价于把所有条件放进一个 and 组。
+
+// 条件组逻辑
+const (
+	LogicAnd = "and"
+	LogicOr  = "or"
+	LogicNot = "not"
+)
+
+// MaxFilterDepth 条件树的最大嵌套深度
+const MaxFilterDepth = 16
+
+// FilterNode 条件树节点：叶子节点即 FilterParam，分组节点只设置 and / or / not 之一
+type FilterNode struct {
+	FilterParam
+	And []FilterNode `json:"and,omitempty"`
+	Or  []FilterNode `json:"or,omitempty"`
+	Not *FilterNode  `json:"not,omitempty"`
+}
+
+// Logic 返回节点类型：and / or / not，叶子节点返回空
+func (n *FilterNode) Logic() string {
+	switch {
+	case n.And != nil:
+		return LogicAnd
+	case n.Or != nil:
+		return LogicOr
+	case n.Not != nil:
+		return LogicNot
+	default:
+		return ""
+	}
+}
+
+// Children 返回分组节点的子节点
+func (n *FilterNode) Children() []FilterNode {
+	switch n.Logic() {
+	case LogicAnd:
+		return n.And
+	case LogicOr:
+		return n.Or
+	case LogicNot:
+		return []FilterNode{*n.Not}
+	default:
+		return nil
+	}
+}
+
+// Validate 检查树结构：每个节点只能是叶子或一种分组，分组不能为空，深度不超过 MaxFilterDepth
+func (n *FilterNode) Validate() error {
+	return n.validate(1)
+}
+
+func (n *FilterNode) validate(depth int) error {
+	if depth > MaxFilterDepth {
+		return fmt.Errorf("filter tree exceeds max depth %d", MaxFilterDepth)
+	}
+	kinds := 0
+	if n.And != nil {
+		kinds++
+	}
+	if n.Or != nil {
+		kinds++
+	}
+	if n.Not != nil {
+		kinds++
+	}
+	isLeaf := n.Field != "" || n.Operator != ""
+	switch {
+	case kinds > 1 || (kinds == 1 && isLeaf):
+		return fmt.Errorf("filter node must be exactly one of condition, and, or, not")
+	case kinds == 0 && !isLeaf:
+		return fmt.Errorf("empty filter node")
+	case (n.And != nil && len(n.And) == 0) || (n.Or != nil && len(n.Or) == 0):
+		return fmt.Errorf("%s group cannot be empty", n.Logic())
+	}
+	children := n.Children()
+	for i := range children {
+		if err := children[i].validate(depth + 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Map 复制条件树并用 fn 转换每个叶子条件（如把字段名映射为列名）
+func (n *FilterNode) Map(fn func(FilterParam) (FilterParam, error)) (*FilterNode, error) {
+	out := &FilterNode{}
+	switch n.Logic() {
+	case "":
+		param, err := fn(n.FilterParam)
+		if err != nil {
+			return nil, err
+		}
+		out.FilterParam = param
+	case LogicNot:
+		child, err := n.Not.Map(fn)
+		if err != nil {
+			return nil, err
+		}
+		out.Not = child
+	default:
+		children := make([]FilterNode, 0, len(n.Children()))
+		for i := range n.Children() {
+			child, err := n.Children()[i].Map(fn)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, *child)
+		}
+		if n.Logic() == LogicAnd {
+			out.And = children
+		} else {
+			out.Or = children
+		}
+	}
+	return out, nil
+}
+
+// Combine 将扁平列表与条件树合并为一棵 and 树，两者都为空时返回 nil
+func Combine(params []FilterParam, where *FilterNode) *FilterNode {
+	if len(params) == 0 {
+		return where
+	}
+	and := make([]FilterNode, 0, len(params)+1)
+	for _, p := range params {
+		and = append(and, FilterNode{FilterParam: p})
+	}
+	if where != nil {
+		and = append(and, *where)
+	}
+	return &FilterNode{And: and}
+}
+
+// ========== GORM 条件组 ==========
+
+// GormFilterGroup and / or / not 条件组，生成带括号的分组 Where
+type GormFilterGroup struct {
+	Logic    string
+	Children []GormFilter
+}
+
+func (g *GormFilterGroup) GetField() string      { return "" }
+func (g *GormFilterGroup) GetValue() interface{} { return g.Children }
+func (g *GormFilterGroup) GetOperator() string   { return g.Logic }
+
+func (g *GormFilterGroup) ApplyGorm(db *gorm.DB) *gorm.DB {
+	// 子条件在不带任何子句的新会话上构建，再作为分组条件整体加入
+	newDB := func() *gorm.DB { return db.Session(&gorm.Session{NewDB: true}) }
+
+	switch g.Logic {
+	case LogicNot:
+		return db.Not(g.Children[0].ApplyGorm(newDB()))
+	case LogicOr:
+		group := newDB()
+		for i, child := range g.Children {
+			if i == 0 {
+				group = group.Where(child.ApplyGorm(newDB()))
+			} else {
+				group = group.Or(child.ApplyGorm(newDB()))
+			}
+		}
+		return db.Where(group)
+	default:
+		// and 直接逐个追加即可；作为 or / not 的子条件时由外层负责加括号
+		for _, child := range g.Children {
+			db = child.ApplyGorm(db)
+		}
+		return db
+	}
+}
+
+// TranslateTree 翻译条件树为单个 GormFilter
+func (r *GormTranslatorRegistry) TranslateTree(node *FilterNode) (GormFilter, error) {
+	if err := node.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	return r.translateNode(node)
+}
+
+func (r *GormTranslatorRegistry) translateNode(node *FilterNode) (GormFilter, error) {
+	if node.Logic() == "" {
+		return r.Translate(node.FilterParam)
+	}
+	group := &GormFilterGroup{Logic: node.Logic()}
+	for i := range node.Children() {
+		child, err := r.translateNode(&node.Children()[i])
+		if err != nil {
+			return nil, err
+		}
+		group.Children = append(group.Children, child)
+	}
+	return group, nil
+}
+
+// ========== Redis 条件组 ==========
+
+// RedisFilterGroup and / or / not 条件组，在键集合上做布尔运算
+// not 与 SQL 一样按三值逻辑求值：键不存在、JSON 无法解析、字段缺失或为 null 时比较结果为 UNKNOWN，
+// NOT UNKNOWN 仍为 UNKNOWN，不会被选中；is null / is not null 不会产生 UNKNOWN
+type RedisFilterGroup struct {
+	Logic    string
+	Children []RedisFilter
+}
+
+func (g *RedisFilterGroup) GetField() string      { return "" }
+func (g *RedisFilterGroup) GetValue() interface{} { return g.Children }
+func (g *RedisFilterGroup) GetOperator() string   { return g.Logic }
+
+func (g *RedisFilterGroup) ApplyRedis(ctx context.Context, client *redis.Client, keys []string) ([]string, error) {
+	switch g.Logic {
+	case LogicAnd:
+		// 交集：逐个缩小键集合
+		return ApplyRedisFilters(ctx, client, keys, g.Children)
+	case LogicOr:
+		// 并集：每个子条件都在完整集合上求值
+		matched := make(map[string]bool, len(keys))
+		for _, child := range g.Children {
+			result, err := child.ApplyRedis(ctx, client, keys)
+			if err != nil {
+				return nil, err
+			}
+			for _, k := range result {
+				matched[k] = true
+			}
+		}
+		return keepKeys(keys, func(k string) bool { return matched[k] }), nil
+	case LogicNot:
+		// 子条件为 FALSE 的键
+		records, err := fetchRedisRecords(ctx, client, keys)
+		if err != nil {
+			return nil, err
+		}
+		_, falseKeys, err := evalRedisTruth(ctx, client, g.Children[0], keys, records)
+		if err != nil {
+			return nil, err
+		}
+		return keepKeys(keys, func(k string) bool { return falseKeys[k] }), nil
+	default:
+		return nil, fmt.Errorf("unsupported filter logic: %s", g.Logic)
+	}
+}
+
+// evalRedisTruth 三值求值：返回结果为 TRUE 与 FALSE 的键，其余键为 UNKNOWN
+func evalRedisTruth(ctx context.Context, client *redis.Client, f RedisFilter, keys []string, records map[string]map[string]interface{}) (trueKeys, falseKeys map[string]bool, err error) {
+	group, ok := f.(*RedisFilterGroup)
+	if !ok {
+		matched, err := f.ApplyRedis(ctx, client, keys)
+		if err != nil {
+			return nil, nil, err
+		}
+		trueKeys = make(map[string]bool, len(matched))
+		for _, k := range matched {
+			trueKeys[k] = true
+		}
+		falseKeys = make(map[string]bool, len(keys))
+		for _, k := range keys {
+			if !trueKeys[k] && redisKnown(f, records[k]) {
+				falseKeys[k] = true
+			}
+		}
+		return trueKeys, falseKeys, nil
+	}
+
+	switch group.Logic {
+	case LogicNot:
+		t, u, err := evalRedisTruth(ctx, client, group.Children[0], keys, records)
+		return u, t, err
+	case LogicAnd, LogicOr:
+		// and：全部 TRUE 为 TRUE，任一 FALSE 为 FALSE；or 反之
+		all, any := make(map[string]int, len(keys)), make(map[string]bool, len(keys))
+		for _, child := range group.Children {
+			t, u, err := evalRedisTruth(ctx, client, child, keys, records)
+			if err != nil {
+				return nil, nil, err
+			}
+			if group.Logic == LogicOr {
+				t, u = u, t
+			}
+			for k := range t {
+				all[k]++
+			}
+			for k := range u {
+				any[k] = true
+			}
+		}
+		trueKeys, falseKeys = make(map[string]bool, len(keys)), any
+		for k, n := range all {
+			if n == len(group.Children) {
+				trueKeys[k] = true
+			}
+		}
+		if group.Logic == LogicOr {
+			trueKeys, falseKeys = falseKeys, trueKeys
+		}
+		return trueKeys, falseKeys, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported filter logic: %s", group.Logic)
+	}
+}
+
+// redisKnown 叶子条件在记录上的结果是否确定（非 UNKNOWN）：记录存在且字段存在；
+// 除 is null / is not null 外字段还须不为 null。未声明字段的自定义过滤器总是确定
+func redisKnown(f RedisFilter, record map[string]interface{}) bool {
+	if f.GetField() == "" {
+		return true
+	}
+	if record == nil {
+		return false
+	}
+	v, ok := redisFieldValue(record, f.GetField())
+	if !ok {
+		return false
+	}
+	switch f.GetOperator() {
+	case "isnull", "isnotnull":
+		return true
+	default:
+		return v != nil
+	}
+}
+
+// fetchRedisRecords MGET 读取并解析键对应的 JSON 记录，键不存在或无法解析时不在结果中
+func fetchRedisRecords(ctx context.Context, client *redis.Client, keys []string) (map[string]map[string]interface{}, error) {
+	records := make(map[string]map[string]interface{}, len(keys))
+	if len(keys) == 0 {
+		return records, nil
+	}
+	values, err := client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis MGET failed: %w", err)
+	}
+	for i, val := range values {
+		jsonStr, ok := val.(string)
+		if !ok {
+			continue
+		}
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(jsonStr), &data); err == nil && data != nil {
+			records[keys[i]] = data
+		}
+	}
+	return records, nil
+}
+
+// keepKeys 按原顺序保留满足条件的键
+func keepKeys(keys []string, keep func(string) bool) []string {
+	out := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if keep(k) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// TranslateTree 翻译条件树为单个 RedisFilter
+func (r *RedisTranslatorRegistry) TranslateTree(node *FilterNode) (RedisFilter, error) {
+	if err := node.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	return r.translateNode(node)
+}
+
+func (r *RedisTranslatorRegistry) translateNode(node *FilterNode) (RedisFilter, error) {
+	if node.Logic() == "" {
+		return r.Translate(node.FilterParam)
+	}
+	group := &RedisFilterGroup{Logic: node.Logic()}
+	for i := range node.Children() {
+		child, err := r.translateNode(&node.Children()[i])
+		if err != nil {
+			return nil, err
+		}
+		group.Children = append(group.Children, child)
+	}
+	return group, nil
+}
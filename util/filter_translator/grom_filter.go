@@ -2,8 +2,13 @@ package filter_translator
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
 // ========== GORM 过滤器接口 ==========
@@ -14,6 +19,11 @@ type GormFilter interface {
 	ApplyGorm(db *gorm.DB) *gorm.DB
 }
 
+// column 将字段名包装为列标识符，由方言负责加引号（支持 "table.column"）
+func column(field string) clause.Column {
+	return clause.Column{Name: field}
+}
+
 // ========== GORM Filter 实现 ==========
 
 // GormEqualFilter 等于过滤器
@@ -22,7 +32,7 @@ type GormEqualFilter struct {
 }
 
 func (f *GormEqualFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
-	return db.Where(fmt.Sprintf("%s = ?", f.Field), f.Value)
+	return db.Where("? = ?", column(f.Field), f.Value)
 }
 
 // GormNotEqualFilter 不等于过滤器
@@ -31,7 +41,7 @@ type GormNotEqualFilter struct {
 }
 
 func (f *GormNotEqualFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
-	return db.Where(fmt.Sprintf("%s != ?", f.Field), f.Value)
+	return db.Where("? != ?", column(f.Field), f.Value)
 }
 
 // GormGreaterThanFilter 大于过滤器
@@ -40,7 +50,7 @@ type GormGreaterThanFilter struct {
 }
 
 func (f *GormGreaterThanFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
-	return db.Where(fmt.Sprintf("%s > ?", f.Field), f.Value)
+	return db.Where("? > ?", column(f.Field), f.Value)
 }
 
 // GormGreaterThanOrEqualFilter 大于等于过滤器
@@ -49,7 +59,7 @@ type GormGreaterThanOrEqualFilter struct {
 }
 
 func (f *GormGreaterThanOrEqualFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
-	return db.Where(fmt.Sprintf("%s >= ?", f.Field), f.Value)
+	return db.Where("? >= ?", column(f.Field), f.Value)
 }
 
 // GormLessThanFilter 小于过滤器
@@ -58,7 +68,7 @@ type GormLessThanFilter struct {
 }
 
 func (f *GormLessThanFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
-	return db.Where(fmt.Sprintf("%s < ?", f.Field), f.Value)
+	return db.Where("? < ?", column(f.Field), f.Value)
 }
 
 // GormLessThanOrEqualFilter 小于等于过滤器
@@ -67,7 +77,7 @@ type GormLessThanOrEqualFilter struct {
 }
 
 func (f *GormLessThanOrEqualFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
-	return db.Where(fmt.Sprintf("%s <= ?", f.Field), f.Value)
+	return db.Where("? <= ?", column(f.Field), f.Value)
 }
 
 // GormLikeFilter 模糊匹配过滤器
@@ -76,7 +86,11 @@ type GormLikeFilter struct {
 }
 
 func (f *GormLikeFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
-	return db.Where(fmt.Sprintf("%s LIKE ?", f.Field), "%"+f.Value.(string)+"%")
+	value, ok := stringValue(db, f.GenericFilter)
+	if !ok {
+		return db
+	}
+	return db.Where("? LIKE ?", column(f.Field), "%"+value+"%")
 }
 
 // GormInFilter IN 过滤器
@@ -85,7 +99,7 @@ type GormInFilter struct {
 }
 
 func (f *GormInFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
-	return db.Where(fmt.Sprintf("%s IN ?", f.Field), f.Values)
+	return db.Where("? IN ?", column(f.Field), f.Values)
 }
 
 // GormBetweenFilter BETWEEN 过滤器
@@ -94,7 +108,7 @@ type GormBetweenFilter struct {
 }
 
 func (f *GormBetweenFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
-	return db.Where(fmt.Sprintf("%s BETWEEN ? AND ?", f.Field), f.Min, f.Max)
+	return db.Where("? BETWEEN ? AND ?", column(f.Field), f.Min, f.Max)
 }
 
 // GormIsNullFilter IS NULL 过滤器
@@ -103,7 +117,7 @@ type GormIsNullFilter struct {
 }
 
 func (f *GormIsNullFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
-	return db.Where(fmt.Sprintf("%s IS NULL", f.Field))
+	return db.Where("? IS NULL", column(f.Field))
 }
 
 // GormIsNotNullFilter IS NOT NULL 过滤器
@@ -112,7 +126,116 @@ type GormIsNotNullFilter struct {
 }
 
 func (f *GormIsNotNullFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
-	return db.Where(fmt.Sprintf("%s IS NOT NULL", f.Field))
+	return db.Where("? IS NOT NULL", column(f.Field))
+}
+
+// GormNotInFilter NOT IN 过滤器
+type GormNotInFilter struct {
+	*GenericInFilter
+}
+
+func (f *GormNotInFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
+	return db.Where("? NOT IN ?", column(f.Field), f.Values)
+}
+
+// GormNotBetweenFilter NOT BETWEEN 过滤器
+type GormNotBetweenFilter struct {
+	*GenericBetweenFilter
+}
+
+func (f *GormNotBetweenFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
+	return db.Where("? NOT BETWEEN ? AND ?", column(f.Field), f.Min, f.Max)
+}
+
+// GormStartsWithFilter 前缀匹配过滤器（通配符会被转义）
+type GormStartsWithFilter struct {
+	*GenericFilter
+}
+
+func (f *GormStartsWithFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
+	value, ok := stringValue(db, f.GenericFilter)
+	if !ok {
+		return db
+	}
+	return db.Where("? LIKE ?", column(f.Field), EscapeLike(value)+"%")
+}
+
+// GormEndsWithFilter 后缀匹配过滤器（通配符会被转义）
+type GormEndsWithFilter struct {
+	*GenericFilter
+}
+
+func (f *GormEndsWithFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
+	value, ok := stringValue(db, f.GenericFilter)
+	if !ok {
+		return db
+	}
+	return db.Where("? LIKE ?", column(f.Field), "%"+EscapeLike(value))
+}
+
+// GormIEqualFilter 忽略大小写的等于过滤器
+type GormIEqualFilter struct {
+	*GenericFilter
+}
+
+func (f *GormIEqualFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
+	value, ok := stringValue(db, f.GenericFilter)
+	if !ok {
+		return db
+	}
+	return db.Where("LOWER(?) = ?", column(f.Field), strings.ToLower(value))
+}
+
+// GormILikeFilter 忽略大小写的包含过滤器（LOWER 两侧，不依赖列的排序规则与方言的 ILIKE）
+type GormILikeFilter struct {
+	*GenericFilter
+}
+
+func (f *GormILikeFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
+	value, ok := stringValue(db, f.GenericFilter)
+	if !ok {
+		return db
+	}
+	return db.Where("LOWER(?) LIKE ?", column(f.Field), "%"+EscapeLike(strings.ToLower(value))+"%")
+}
+
+// GormRegexFilter 正则匹配过滤器，按方言生成：MySQL / SQLite 使用 REGEXP，PostgreSQL 使用 ~
+type GormRegexFilter struct {
+	*GenericFilter
+}
+
+func (f *GormRegexFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return db.Where("? ~ ?", column(f.Field), f.Value)
+	case "sqlserver":
+		_ = db.AddError(fmt.Errorf("regex operator is not supported by sqlserver"))
+		return db
+	default:
+		return db.Where("? REGEXP ?", column(f.Field), f.Value)
+	}
+}
+
+// GormRelativeTimeFilter 相对时间过滤器，基准时间在生成查询时取当前时间
+//
+//	within_last: 列 >= now - d    older_than: 列 < now - d
+//	before_now:  列 < now         after_now:  列 > now
+type GormRelativeTimeFilter struct {
+	*GenericRelativeTimeFilter
+}
+
+func (f *GormRelativeTimeFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
+	now := time.Now()
+	switch f.Operator {
+	case "within_last":
+		return db.Where("? >= ?", column(f.Field), now.Add(-f.Duration))
+	case "older_than":
+		return db.Where("? < ?", column(f.Field), now.Add(-f.Duration))
+	case "before_now":
+		return db.Where("? < ?", column(f.Field), now)
+	default: // after_now
+		return db.Where("? > ?", column(f.Field), now)
+	}
 }
 
 // ========== GORM FilterTranslator 实现 ==========
@@ -429,11 +552,155 @@ func (t *GormIsNotNullTranslator) Validate(param FilterParam) error {
 	return nil
 }
 
+// GormNotInTranslator NOT IN 翻译器
+type GormNotInTranslator struct{}
+
+func (t *GormNotInTranslator) Translate(param FilterParam) (BaseFilter, error) {
+	values, ok := param.Value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("value must be array for NOT IN operator")
+	}
+	return &GormNotInFilter{
+		GenericInFilter: &GenericInFilter{
+			Field:    param.Field,
+			Operator: "not_in",
+			Values:   values,
+		},
+	}, nil
+}
+
+func (t *GormNotInTranslator) SupportedOperator() string {
+	return "not_in"
+}
+
+func (t *GormNotInTranslator) Validate(param FilterParam) error {
+	return (&GormInTranslator{}).Validate(param)
+}
+
+// GormNotBetweenTranslator NOT BETWEEN 翻译器
+type GormNotBetweenTranslator struct{}
+
+func (t *GormNotBetweenTranslator) Translate(param FilterParam) (BaseFilter, error) {
+	values, ok := param.Value.([]interface{})
+	if !ok || len(values) != 2 {
+		return nil, fmt.Errorf("value must be array with 2 elements for NOT BETWEEN operator")
+	}
+	return &GormNotBetweenFilter{
+		GenericBetweenFilter: &GenericBetweenFilter{
+			Field:    param.Field,
+			Operator: "not_between",
+			Min:      values[0],
+			Max:      values[1],
+		},
+	}, nil
+}
+
+func (t *GormNotBetweenTranslator) SupportedOperator() string {
+	return "not_between"
+}
+
+func (t *GormNotBetweenTranslator) Validate(param FilterParam) error {
+	return (&GormBetweenTranslator{}).Validate(param)
+}
+
+// GormStringTranslator 字符串匹配翻译器（starts_with / ends_with / ieq / ilike）
+type GormStringTranslator struct {
+	Operator string
+}
+
+func (t *GormStringTranslator) Translate(param FilterParam) (BaseFilter, error) {
+	generic := &GenericFilter{Field: param.Field, Operator: t.Operator, Value: param.Value}
+	switch t.Operator {
+	case "starts_with":
+		return &GormStartsWithFilter{GenericFilter: generic}, nil
+	case "ends_with":
+		return &GormEndsWithFilter{GenericFilter: generic}, nil
+	case "ieq":
+		return &GormIEqualFilter{GenericFilter: generic}, nil
+	case "ilike":
+		return &GormILikeFilter{GenericFilter: generic}, nil
+	default:
+		return nil, fmt.Errorf("unsupported string operator: %s", t.Operator)
+	}
+}
+
+func (t *GormStringTranslator) SupportedOperator() string {
+	return t.Operator
+}
+
+func (t *GormStringTranslator) Validate(param FilterParam) error {
+	if param.Field == "" {
+		return fmt.Errorf("field cannot be empty")
+	}
+	if _, ok := param.Value.(string); !ok {
+		return fmt.Errorf("value must be string")
+	}
+	return nil
+}
+
+// GormRegexTranslator 正则翻译器
+type GormRegexTranslator struct{}
+
+func (t *GormRegexTranslator) Translate(param FilterParam) (BaseFilter, error) {
+	return &GormRegexFilter{
+		GenericFilter: &GenericFilter{
+			Field:    param.Field,
+			Operator: "regex",
+			Value:    param.Value,
+		},
+	}, nil
+}
+
+func (t *GormRegexTranslator) SupportedOperator() string {
+	return "regex"
+}
+
+func (t *GormRegexTranslator) Validate(param FilterParam) error {
+	if param.Field == "" {
+		return fmt.Errorf("field cannot be empty")
+	}
+	pattern, ok := param.Value.(string)
+	if !ok {
+		return fmt.Errorf("value must be string")
+	}
+	// 提前拒绝无法编译的表达式；数据库方言的正则语法与 Go 大体兼容
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("invalid regex: %w", err)
+	}
+	return nil
+}
+
+// GormRelativeTimeTranslator 相对时间翻译器（within_last / older_than / before_now / after_now）
+type GormRelativeTimeTranslator struct {
+	Operator string
+}
+
+func (t *GormRelativeTimeTranslator) Translate(param FilterParam) (BaseFilter, error) {
+	filter, err := newRelativeTimeFilter(t.Operator, param)
+	if err != nil {
+		return nil, err
+	}
+	return &GormRelativeTimeFilter{GenericRelativeTimeFilter: filter}, nil
+}
+
+func (t *GormRelativeTimeTranslator) SupportedOperator() string {
+	return t.Operator
+}
+
+func (t *GormRelativeTimeTranslator) Validate(param FilterParam) error {
+	if param.Field == "" {
+		return fmt.Errorf("field cannot be empty")
+	}
+	_, err := newRelativeTimeFilter(t.Operator, param)
+	return err
+}
+
 // ========== GORM 翻译器注册表 ==========
 
 // GormTranslatorRegistry GORM 翻译器注册表
 type GormTranslatorRegistry struct {
 	translators map[string]FilterTranslator
+	schema      *schema.Schema // 绑定的模型 schema（见 ForSchema），用于解析关联字段路径
 }
 
 // NewGormTranslatorRegistry 创建 GORM 翻译器注册表
@@ -454,6 +721,15 @@ func NewGormTranslatorRegistry() *GormTranslatorRegistry {
 	registry.Register(&GormBetweenTranslator{})
 	registry.Register(&GormIsNullTranslator{})
 	registry.Register(&GormIsNotNullTranslator{})
+	registry.Register(&GormNotInTranslator{})
+	registry.Register(&GormNotBetweenTranslator{})
+	registry.Register(&GormRegexTranslator{})
+	for _, op := range []string{"starts_with", "ends_with", "ieq", "ilike"} {
+		registry.Register(&GormStringTranslator{Operator: op})
+	}
+	for _, op := range RelativeTimeOperators {
+		registry.Register(&GormRelativeTimeTranslator{Operator: op})
+	}
 
 	return registry
 }
@@ -470,11 +746,27 @@ func (r *GormTranslatorRegistry) Translate(param FilterParam) (GormFilter, error
 		return nil, fmt.Errorf("unsupported operator: %s", param.Operator)
 	}
 
+	// 按字段类型转换值（见 coerce.go）
+	if r.schema != nil {
+		var err error
+		if param, err = coerceWithSchema(r.schema, param); err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+	}
+
 	// 验证参数
 	if err := translator.Validate(param); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
+	// 关联字段路径（如 "profile.city"）
+	if r.schema != nil && strings.Contains(param.Field, ".") {
+		filter, err := r.translateRelation(translator, param)
+		if err != nil || filter != nil {
+			return filter, err
+		}
+	}
+
 	// 翻译为 Filter
 	baseFilter, err := translator.Translate(param)
 	if err != nil {
@@ -515,6 +807,20 @@ func (r *GormTranslatorRegistry) GetSupportedOperators() []string {
 
 // ========== GORM 工具函数 ==========
 
+// EscapeLike 转义 LIKE 通配符（% _ 与转义符本身），使值按字面匹配（MySQL 默认转义符为 \）
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// stringValue 取字符串操作符的值；直接构造的过滤器值不是字符串时记录到 db.Error 而不是 panic
+func stringValue(db *gorm.DB, f *GenericFilter) (string, bool) {
+	value, ok := f.Value.(string)
+	if !ok {
+		_ = db.AddError(fmt.Errorf("filter %s %s: value must be string, got %T", f.Field, f.Operator, f.Value))
+	}
+	return value, ok
+}
+
 // ApplyGormFilters 应用多个 GORM 过滤器
 func ApplyGormFilters(db *gorm.DB, filters []GormFilter) *gorm.DB {
 	for _, filter := range filters {
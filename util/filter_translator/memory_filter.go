@@ -0,0 +1,783 @@
+package filter_translator
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// ========== 内存过滤器 ==========
+// 在 Go 值（*T / []T / LookupQuery 的 map[string]*T）上求值与 SQL 相同的过滤条件，用于单元测试、
+// 对已从缓存加载的数据做二次过滤、判断变更事件是否命中订阅条件等。语义与 GormFilter 生成的 SQL 一致：
+//
+//   - 三值逻辑：与 NULL（nil 指针、Valid 为 false 的 sql.Null* / gorm.DeletedAt）比较的结果为 UNKNOWN，
+//     NOT UNKNOWN 仍为 UNKNOWN，最终只保留结果为 TRUE 的记录；is null / is not null 不会产生 UNKNOWN
+//   - like 与 SQL 相同：值两侧加 %，值中的 % _ 仍是通配符，\ 转义；starts_with / ends_with / ilike 转义通配符
+//   - 数字之间按数值比较；字符串字段与数字参数按字符串比较，数值字段与字符串参数（如查询字符串中的 "18"）按数值比较；
+//     时间字段可与 time.Time 或时间字符串比较。字符串按字节比较（相当于二进制排序规则），忽略大小写请用 ieq / ilike
+//   - 关联路径（如 "orders.total"）按 EXISTS 求值：任一已加载的关联记录满足即为 TRUE，否则为 FALSE（须预加载关联）
+//
+// 字段名可写列名、Go 字段名或 json 名。
+
+// Truth SQL 三值逻辑的求值结果
+type Truth int8
+
+const (
+	TruthFalse Truth = iota
+	TruthTrue
+	TruthUnknown
+)
+
+// Not 逻辑非，UNKNOWN 保持不变
+func (t Truth) Not() Truth {
+	switch t {
+	case TruthTrue:
+		return TruthFalse
+	case TruthFalse:
+		return TruthTrue
+	default:
+		return TruthUnknown
+	}
+}
+
+// truthOf 布尔值转 Truth
+func truthOf(b bool) Truth {
+	if b {
+		return TruthTrue
+	}
+	return TruthFalse
+}
+
+// MemoryFilter 内存过滤器接口
+type MemoryFilter interface {
+	BaseFilter
+	// Eval 对一条记录求值，字段不存在时返回错误
+	Eval(rec *Record) (Truth, error)
+}
+
+// Record 被求值的记录（结构体值 + GORM schema）
+type Record struct {
+	ctx    context.Context
+	value  reflect.Value
+	schema *schema.Schema
+}
+
+// memorySchemas 内存求值使用的 schema 缓存
+var memorySchemas sync.Map
+
+// NewRecord 包装结构体或结构体指针，解析其 GORM schema（按类型缓存）
+func NewRecord(v interface{}) (*Record, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("record is nil")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("record must be a struct, got %s", rv.Kind())
+	}
+	s, err := schema.Parse(reflect.New(rv.Type()).Interface(), &memorySchemas, schema.NamingStrategy{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	return &Record{ctx: context.Background(), value: rv, schema: s}, nil
+}
+
+// evalField 取字段值并用 test 求值；关联路径上的每条关联记录分别求值，任一为 TRUE 即为 TRUE（EXISTS 语义）
+func (rec *Record) evalField(field string, test func(interface{}) Truth) (Truth, error) {
+	if rec.schema.Relationships.Relations != nil && strings.Contains(field, ".") {
+		head, _, _ := strings.Cut(field, ".")
+		if FindRelation(rec.schema, head) != nil {
+			relations, target, err := ResolveRelationPath(rec.schema, field)
+			if err != nil {
+				return TruthFalse, err
+			}
+			for _, v := range relatedValues(rec.ctx, []reflect.Value{rec.value}, relations) {
+				value, _ := target.ValueOf(rec.ctx, v)
+				if test(value) == TruthTrue {
+					return TruthTrue, nil
+				}
+			}
+			return TruthFalse, nil
+		}
+		// "表名.列名"
+		if table, column, _ := strings.Cut(field, "."); table == rec.schema.Table {
+			field = column
+		}
+	}
+
+	target := lookupSchemaField(rec.schema, field)
+	if target == nil {
+		return TruthFalse, fmt.Errorf("unknown field %q", field)
+	}
+	value, _ := target.ValueOf(rec.ctx, rec.value)
+	return test(value), nil
+}
+
+// lookupSchemaField 按列名、Go 字段名或 json 名查找字段
+func lookupSchemaField(s *schema.Schema, name string) *schema.Field {
+	if f := s.LookUpField(name); f != nil {
+		return f
+	}
+	for _, f := range s.Fields {
+		if jsonName, _, _ := strings.Cut(f.Tag.Get("json"), ","); jsonName == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// relatedValues 沿关联链收集已加载的关联记录（结构体值）
+func relatedValues(ctx context.Context, values []reflect.Value, relations []*schema.Relationship) []reflect.Value {
+	for _, rel := range relations {
+		var next []reflect.Value
+		for _, v := range values {
+			next = appendStructs(next, rel.Field.ReflectValueOf(ctx, v))
+		}
+		values = next
+	}
+	return values
+}
+
+// appendStructs 展开指针与切片，收集其中的结构体
+func appendStructs(out []reflect.Value, v reflect.Value) []reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			out = appendStructs(out, v.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			out = appendStructs(out, v.Index(i))
+		}
+	case reflect.Struct:
+		out = append(out, v)
+	}
+	return out
+}
+
+// ========== 求值入口 ==========
+
+// Match 判断单条记录是否满足过滤器（结果为 UNKNOWN 时不满足），filter 为 nil 时总是满足
+func Match[T any](item *T, filter MemoryFilter) (bool, error) {
+	if filter == nil {
+		return true, nil
+	}
+	rec, err := NewRecord(item)
+	if err != nil {
+		return false, err
+	}
+	t, err := filter.Eval(rec)
+	return t == TruthTrue, err
+}
+
+// FilterSlice 返回满足过滤器的元素，保持原顺序
+func FilterSlice[T any](items []T, filter MemoryFilter) ([]T, error) {
+	out := make([]T, 0, len(items))
+	for i := range items {
+		ok, err := Match(&items[i], filter)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			out = append(out, items[i])
+		}
+	}
+	return out, nil
+}
+
+// FilterMap 过滤 LookupQuery 等返回的 键 -> 记录 映射，nil 记录视为不满足
+func FilterMap[T any](items map[string]*T, filter MemoryFilter) (map[string]*T, error) {
+	out := make(map[string]*T, len(items))
+	for key, item := range items {
+		if item == nil {
+			continue
+		}
+		ok, err := Match(item, filter)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			out[key] = item
+		}
+	}
+	return out, nil
+}
+
+// ========== 内存过滤器实现 ==========
+
+// MemoryConditionFilter 单个条件，test 对取出的字段值求值
+type MemoryConditionFilter struct {
+	*GenericFilter
+	test func(value interface{}) Truth
+}
+
+func (f *MemoryConditionFilter) Eval(rec *Record) (Truth, error) {
+	return rec.evalField(f.Field, f.test)
+}
+
+// MemoryFilterGroup and / or / not 条件组
+type MemoryFilterGroup struct {
+	Logic    string
+	Children []MemoryFilter
+}
+
+func (g *MemoryFilterGroup) GetField() string      { return "" }
+func (g *MemoryFilterGroup) GetValue() interface{} { return g.Children }
+func (g *MemoryFilterGroup) GetOperator() string   { return g.Logic }
+
+func (g *MemoryFilterGroup) Eval(rec *Record) (Truth, error) {
+	switch g.Logic {
+	case LogicNot:
+		t, err := g.Children[0].Eval(rec)
+		return t.Not(), err
+	case LogicOr:
+		// TRUE 优先，其次 UNKNOWN
+		result := TruthFalse
+		for _, child := range g.Children {
+			t, err := child.Eval(rec)
+			if err != nil {
+				return TruthFalse, err
+			}
+			if t == TruthTrue {
+				return TruthTrue, nil
+			}
+			if t == TruthUnknown {
+				result = TruthUnknown
+			}
+		}
+		return result, nil
+	default:
+		// FALSE 优先，其次 UNKNOWN
+		result := TruthTrue
+		for _, child := range g.Children {
+			t, err := child.Eval(rec)
+			if err != nil {
+				return TruthFalse, err
+			}
+			if t == TruthFalse {
+				return TruthFalse, nil
+			}
+			if t == TruthUnknown {
+				result = TruthUnknown
+			}
+		}
+		return result, nil
+	}
+}
+
+// ========== 内存 FilterTranslator 实现 ==========
+
+// MemoryTranslator 内存翻译器，按 Operator 生成对应的求值函数
+type MemoryTranslator struct {
+	Operator string
+}
+
+func (t *MemoryTranslator) SupportedOperator() string {
+	return t.Operator
+}
+
+func (t *MemoryTranslator) Validate(param FilterParam) error {
+	if param.Field == "" {
+		return fmt.Errorf("field cannot be empty")
+	}
+	_, err := t.tester(param)
+	return err
+}
+
+func (t *MemoryTranslator) Translate(param FilterParam) (BaseFilter, error) {
+	test, err := t.tester(param)
+	if err != nil {
+		return nil, err
+	}
+	return &MemoryConditionFilter{
+		GenericFilter: &GenericFilter{Field: param.Field, Operator: t.Operator, Value: param.Value},
+		test:          test,
+	}, nil
+}
+
+// tester 校验参数并生成求值函数（LIKE / 正则在这里预编译）
+func (t *MemoryTranslator) tester(param FilterParam) (func(interface{}) Truth, error) {
+	switch t.Operator {
+	case "=", "!=", ">", ">=", "<", "<=":
+		return compareTester(t.Operator, param.Value), nil
+
+	case "in", "not_in":
+		values, ok := param.Value.([]interface{})
+		if !ok || len(values) == 0 {
+			return nil, fmt.Errorf("value must be a non-empty array")
+		}
+		return func(v interface{}) Truth {
+			result := inList(v, values)
+			if t.Operator == "not_in" {
+				return result.Not()
+			}
+			return result
+		}, nil
+
+	case "between", "not_between":
+		values, ok := param.Value.([]interface{})
+		if !ok || len(values) != 2 {
+			return nil, fmt.Errorf("value must be array with 2 elements")
+		}
+		gte, lte := compareTester(">=", values[0]), compareTester("<=", values[1])
+		return func(v interface{}) Truth {
+			result := andTruth(gte(v), lte(v))
+			if t.Operator == "not_between" {
+				return result.Not()
+			}
+			return result
+		}, nil
+
+	case "isnull", "isnotnull":
+		return func(v interface{}) Truth {
+			_, null := sqlValue(v)
+			return truthOf(null == (t.Operator == "isnull"))
+		}, nil
+
+	case "like", "starts_with", "ends_with", "ilike", "ieq":
+		s, ok := param.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value must be string")
+		}
+		var pattern string
+		fold := false
+		switch t.Operator {
+		case "like":
+			pattern = "%" + s + "%"
+		case "starts_with":
+			pattern = EscapeLike(s) + "%"
+		case "ends_with":
+			pattern = "%" + EscapeLike(s)
+		case "ilike":
+			pattern, fold = "%"+EscapeLike(strings.ToLower(s))+"%", true
+		case "ieq":
+			pattern, fold = EscapeLike(strings.ToLower(s)), true
+		}
+		re, err := likeRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return stringTester(func(str string) bool {
+			if fold {
+				str = strings.ToLower(str)
+			}
+			return re.MatchString(str)
+		}), nil
+
+	case "regex":
+		s, ok := param.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value must be string")
+		}
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %w", err)
+		}
+		return stringTester(re.MatchString), nil
+
+	case "within_last", "older_than", "before_now", "after_now":
+		filter, err := newRelativeTimeFilter(t.Operator, param)
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}) Truth {
+			value, null := sqlValue(v)
+			if null {
+				return TruthUnknown
+			}
+			tm, ok := asTime(value)
+			if !ok {
+				return TruthFalse
+			}
+			now := time.Now()
+			switch filter.Operator {
+			case "within_last":
+				return truthOf(!tm.Before(now.Add(-filter.Duration)))
+			case "older_than":
+				return truthOf(tm.Before(now.Add(-filter.Duration)))
+			case "before_now":
+				return truthOf(tm.Before(now))
+			default:
+				return truthOf(tm.After(now))
+			}
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported operator: %s", t.Operator)
+	}
+}
+
+// andTruth 两个结果的逻辑与
+func andTruth(a, b Truth) Truth {
+	switch {
+	case a == TruthFalse || b == TruthFalse:
+		return TruthFalse
+	case a == TruthUnknown || b == TruthUnknown:
+		return TruthUnknown
+	default:
+		return TruthTrue
+	}
+}
+
+// compareTester 比较运算：任一侧为 NULL 时为 UNKNOWN，类型无法比较时为 FALSE
+func compareTester(op string, param interface{}) func(interface{}) Truth {
+	return func(v interface{}) Truth {
+		c, null, ok := compareSQL(v, param)
+		switch {
+		case null:
+			return TruthUnknown
+		case !ok:
+			return TruthFalse
+		}
+		switch op {
+		case "=":
+			return truthOf(c == 0)
+		case "!=":
+			return truthOf(c != 0)
+		case ">":
+			return truthOf(c > 0)
+		case ">=":
+			return truthOf(c >= 0)
+		case "<":
+			return truthOf(c < 0)
+		default:
+			return truthOf(c <= 0)
+		}
+	}
+}
+
+// inList v IN (values)：命中为 TRUE；未命中但任一侧有 NULL 时为 UNKNOWN
+func inList(v interface{}, values []interface{}) Truth {
+	result := TruthFalse
+	for _, item := range values {
+		c, null, ok := compareSQL(v, item)
+		if null {
+			result = TruthUnknown
+			continue
+		}
+		if ok && c == 0 {
+			return TruthTrue
+		}
+	}
+	return result
+}
+
+// stringTester 字符串匹配，非字符串值先转为字符串（与 SQL 的隐式转换一致），NULL 为 UNKNOWN
+func stringTester(match func(string) bool) func(interface{}) Truth {
+	return func(v interface{}) Truth {
+		value, null := sqlValue(v)
+		if null {
+			return TruthUnknown
+		}
+		return truthOf(match(sqlString(value)))
+	}
+}
+
+// likeRegexp 将 LIKE 模式转换为正则：% 匹配任意串，_ 匹配单个字符，\ 转义下一个字符
+func likeRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString(`(?s)^`)
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			b.WriteString(`.*`)
+		case r == '_':
+			b.WriteString(`.`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	if escaped {
+		b.WriteString(`\\`)
+	}
+	b.WriteString(`$`)
+	return regexp.Compile(b.String())
+}
+
+// ========== SQL 值比较 ==========
+
+// sqlValue 规范化 Go 值：nil 指针与 driver.Valuer 返回 nil 为 NULL；
+// 整数 -> int64，无符号整数 -> uint64，浮点 -> float64，字符串类型 / []byte -> string，bool 与 time.Time 保持不变
+func sqlValue(v interface{}) (interface{}, bool) {
+	for {
+		if v == nil {
+			return nil, true
+		}
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return nil, true
+			}
+			if valuer, ok := v.(driver.Valuer); ok {
+				return valuerValue(valuer)
+			}
+			v = rv.Elem().Interface()
+			continue
+		}
+		if _, isTime := v.(time.Time); !isTime {
+			if valuer, ok := v.(driver.Valuer); ok {
+				return valuerValue(valuer)
+			}
+		}
+		break
+	}
+
+	switch val := v.(type) {
+	case time.Time, bool, string:
+		return val, false
+	case []byte:
+		return string(val), false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), false
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), false
+	case reflect.String:
+		return rv.String(), false
+	case reflect.Bool:
+		return rv.Bool(), false
+	default:
+		return v, false
+	}
+}
+
+// valuerValue 取 driver.Valuer 的值（sql.NullString、gorm.DeletedAt 等）
+func valuerValue(valuer driver.Valuer) (interface{}, bool) {
+	dv, err := valuer.Value()
+	if err != nil || dv == nil {
+		return nil, true
+	}
+	return sqlValue(dv)
+}
+
+// compareSQL 比较字段值 a 与参数 b，返回 (比较结果, 是否有 NULL, 是否可比较)
+func compareSQL(a, b interface{}) (int, bool, bool) {
+	av, aNull := sqlValue(a)
+	bv, bNull := sqlValue(b)
+	if aNull || bNull {
+		return 0, true, true
+	}
+
+	// 时间：另一侧可以是时间字符串
+	if at, ok := av.(time.Time); ok {
+		bt, ok := asTime(bv)
+		if !ok {
+			return 0, false, false
+		}
+		return at.Compare(bt), false, true
+	}
+	if bt, ok := bv.(time.Time); ok {
+		at, ok := asTime(av)
+		if !ok {
+			return 0, false, false
+		}
+		return at.Compare(bt), false, true
+	}
+
+	// 数值：字段为数值时，字符串参数按数值解析（布尔字段也接受 "true" / "false"）
+	if isNumber(av) {
+		if _, isBool := av.(bool); isBool {
+			if s, ok := bv.(string); ok {
+				if b, err := strconv.ParseBool(strings.TrimSpace(s)); err == nil {
+					bv = b
+				}
+			}
+		}
+		if s, ok := bv.(string); ok {
+			f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return strings.Compare(sqlString(av), s), false, true
+			}
+			bv = f
+		}
+		if isNumber(bv) {
+			return compareNumbers(av, bv), false, true
+		}
+	}
+
+	return strings.Compare(sqlString(av), sqlString(bv)), false, true
+}
+
+// isNumber 规范化后的数值（bool 按 0 / 1 处理，与 MySQL 的 TINYINT(1) 一致）
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case int64, uint64, float64, bool:
+		return true
+	default:
+		return false
+	}
+}
+
+// compareNumbers 比较两个数值，整数之间精确比较
+func compareNumbers(a, b interface{}) int {
+	ai, aInt := a.(int64)
+	bi, bInt := b.(int64)
+	if aInt && bInt {
+		switch {
+		case ai < bi:
+			return -1
+		case ai > bi:
+			return 1
+		default:
+			return 0
+		}
+	}
+	af, bf := toNumber(a), toNumber(b)
+	switch {
+	case af < bf:
+		return -1
+	case af > bf:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func toNumber(v interface{}) float64 {
+	switch val := v.(type) {
+	case int64:
+		return float64(val)
+	case uint64:
+		return float64(val)
+	case float64:
+		return val
+	case bool:
+		if val {
+			return 1
+		}
+	}
+	return 0
+}
+
+// asTime 规范化后的值转为时间（time.Time 或时间字符串）
+func asTime(v interface{}) (time.Time, bool) {
+	switch val := v.(type) {
+	case time.Time:
+		return val, true
+	case string:
+		t, err := toTime(val)
+		return t, err == nil
+	default:
+		return time.Time{}, false
+	}
+}
+
+// sqlString 规范化后的值的字符串形式
+func sqlString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case time.Time:
+		return val.Format("2006-01-02 15:04:05")
+	case bool:
+		if val {
+			return "1"
+		}
+		return "0"
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// ========== 内存翻译器注册表 ==========
+
+// MemoryTranslatorRegistry 内存翻译器注册表
+type MemoryTranslatorRegistry struct {
+	translators map[string]FilterTranslator
+}
+
+// NewMemoryTranslatorRegistry 创建内存翻译器注册表，支持与 GORM 注册表相同的操作符
+func NewMemoryTranslatorRegistry() *MemoryTranslatorRegistry {
+	registry := &MemoryTranslatorRegistry{translators: make(map[string]FilterTranslator)}
+	for _, op := range []string{
+		"=", "!=", ">", ">=", "<", "<=", "like", "in", "between", "isnull", "isnotnull",
+		"not_in", "not_between", "regex", "starts_with", "ends_with", "ieq", "ilike",
+	} {
+		registry.Register(&MemoryTranslator{Operator: op})
+	}
+	for _, op := range RelativeTimeOperators {
+		registry.Register(&MemoryTranslator{Operator: op})
+	}
+	return registry
+}
+
+// Register 注册翻译器
+func (r *MemoryTranslatorRegistry) Register(translator FilterTranslator) {
+	r.translators[translator.SupportedOperator()] = translator
+}
+
+// Translate 翻译前端参数为内存过滤器
+func (r *MemoryTranslatorRegistry) Translate(param FilterParam) (MemoryFilter, error) {
+	translator, ok := r.translators[param.Operator]
+	if !ok {
+		return nil, fmt.Errorf("unsupported operator: %s", param.Operator)
+	}
+	if err := translator.Validate(param); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	baseFilter, err := translator.Translate(param)
+	if err != nil {
+		return nil, err
+	}
+	memoryFilter, ok := baseFilter.(MemoryFilter)
+	if !ok {
+		return nil, fmt.Errorf("translator returned non-MemoryFilter")
+	}
+	return memoryFilter, nil
+}
+
+// TranslateBatch 批量翻译，返回以 AND 连接的单个过滤器（空列表返回 nil）
+func (r *MemoryTranslatorRegistry) TranslateBatch(params []FilterParam) (MemoryFilter, error) {
+	return r.TranslateTree(Combine(params, nil))
+}
+
+// TranslateTree 翻译条件树为单个内存过滤器（nil 返回 nil）
+func (r *MemoryTranslatorRegistry) TranslateTree(node *FilterNode) (MemoryFilter, error) {
+	if node == nil {
+		return nil, nil
+	}
+	if err := node.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	return r.translateNode(node)
+}
+
+func (r *MemoryTranslatorRegistry) translateNode(node *FilterNode) (MemoryFilter, error) {
+	if node.Logic() == "" {
+		return r.Translate(node.FilterParam)
+	}
+	group := &MemoryFilterGroup{Logic: node.Logic()}
+	for i := range node.Children() {
+		child, err := r.translateNode(&node.Children()[i])
+		if err != nil {
+			return nil, err
+		}
+		group.Children = append(group.Children, child)
+	}
+	return group, nil
+}
+
+// DefaultMemoryRegistry 默认内存翻译器注册表（全局单例）
+var DefaultMemoryRegistry = NewMemoryTranslatorRegistry()
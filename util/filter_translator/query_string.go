@@ -0,0 +1,170 @@
+package filter_translator
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ========== 查询字符串语法 ==========
+// GET 列表接口用查询字符串表达过滤、排序与分页，便于 CDN 缓存与分享链接：
+//
+//	?filter[age][gt]=18&filter[name][like]=jo&filter[status][in]=active,trial&sort=-created_at,name&page=2
+//
+// filter[字段]=值 省略操作符时为等于；操作符可写别名（eq / ne / gt / gte / lt / lte）或注册表中的名字（like / in / not_in ...）。
+// 同一字段的多个条件以 AND 连接；in / not_in 的值以逗号分隔（重复的键合并），between / not_between 为 "最小,最大"，
+// isnull / isnotnull 忽略值。值一律保持字符串。
+
+// QueryOperatorAliases 查询字符串中的操作符别名 -> 注册表中的操作符
+var QueryOperatorAliases = map[string]string{
+	"eq":  "=",
+	"ne":  "!=",
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+}
+
+// QuerySort 查询字符串中的排序键
+type QuerySort struct {
+	Field string
+	Desc  bool
+}
+
+// QueryStringParams 解析后的查询字符串
+type QueryStringParams struct {
+	Filters  []FilterParam
+	Sorts    []QuerySort // sort=-created_at,name
+	Page     int         // page，未指定为 0
+	PageSize int         // page_size，未指定为 0
+	Fields   []string    // fields=id,name
+}
+
+// filterKeyPattern filter[字段] 或 filter[字段][操作符]
+var filterKeyPattern = regexp.MustCompile(`^filter\[([^\[\]]+)\](?:\[([^\[\]]+)\])?$`)
+
+// ParseQueryString 解析查询字符串中的 filter[...]、sort、page、page_size 与 fields，其余参数忽略
+// 过滤条件按键排序后输出，结果与参数顺序无关
+func ParseQueryString(values url.Values) (*QueryStringParams, error) {
+	params := &QueryStringParams{}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if key == "filter" || strings.HasPrefix(key, "filter[") {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		m := filterKeyPattern.FindStringSubmatch(key)
+		if m == nil {
+			return nil, fmt.Errorf("malformed filter parameter %q", key)
+		}
+		filters, err := parseQueryFilter(m[1], m[2], values[key])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		params.Filters = append(params.Filters, filters...)
+	}
+
+	for _, item := range splitList(values["sort"]) {
+		sort := QuerySort{Field: item}
+		switch item[0] {
+		case '-':
+			sort = QuerySort{Field: item[1:], Desc: true}
+		case '+':
+			sort.Field = item[1:]
+		}
+		if sort.Field == "" {
+			return nil, fmt.Errorf("malformed sort %q", item)
+		}
+		params.Sorts = append(params.Sorts, sort)
+	}
+
+	var err error
+	if params.Page, err = parsePositive(values, "page"); err != nil {
+		return nil, err
+	}
+	if params.PageSize, err = parsePositive(values, "page_size"); err != nil {
+		return nil, err
+	}
+	params.Fields = splitList(values["fields"])
+	return params, nil
+}
+
+// parseQueryFilter 将同一个 filter 键的取值转换为过滤条件
+func parseQueryFilter(field, operator string, raw []string) ([]FilterParam, error) {
+	if operator == "" {
+		operator = "eq"
+	}
+	if alias, ok := QueryOperatorAliases[operator]; ok {
+		operator = alias
+	}
+
+	switch operator {
+	case "in", "not_in":
+		values := splitList(raw)
+		if len(values) == 0 {
+			return nil, fmt.Errorf("value list cannot be empty")
+		}
+		return []FilterParam{{Field: field, Operator: operator, Value: toInterfaces(values)}}, nil
+	case "between", "not_between":
+		filters := make([]FilterParam, 0, len(raw))
+		for _, v := range raw {
+			bounds := strings.Split(v, ",")
+			for i := range bounds {
+				bounds[i] = strings.TrimSpace(bounds[i])
+			}
+			if len(bounds) != 2 {
+				return nil, fmt.Errorf("value must be \"min,max\"")
+			}
+			filters = append(filters, FilterParam{Field: field, Operator: operator, Value: toInterfaces(bounds)})
+		}
+		return filters, nil
+	case "isnull", "isnotnull":
+		return []FilterParam{{Field: field, Operator: operator}}, nil
+	default:
+		filters := make([]FilterParam, 0, len(raw))
+		for _, v := range raw {
+			filters = append(filters, FilterParam{Field: field, Operator: operator, Value: v})
+		}
+		return filters, nil
+	}
+}
+
+// splitList 合并重复参数并按逗号拆分，去掉空项
+func splitList(raw []string) []string {
+	var out []string
+	for _, v := range raw {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
+// parsePositive 解析正整数参数，未指定时返回 0
+func parsePositive(values url.Values, key string) (int, error) {
+	raw := values.Get(key)
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", key)
+	}
+	return n, nil
+}
+
+func toInterfaces(values []string) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
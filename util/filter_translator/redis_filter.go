@@ -4,10 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"strconv"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm/schema"
 )
 
 // ========== Redis 过滤器接口 ==========
@@ -52,14 +54,8 @@ func applyRedisBatchFilter(ctx context.Context, client *redis.Client, keys []str
 			continue // JSON 格式错误
 		}
 
-		// 3. 提取字段逻辑：优先提取顶层，若无则看是否在 data 嵌套里
-		fieldVal, exists := data[field]
-		if !exists {
-			if innerData, ok := data["data"].(map[string]interface{}); ok {
-				fieldVal, exists = innerData[field]
-			}
-		}
-
+		// 3. 提取字段
+		fieldVal, exists := redisFieldValue(data, field)
 		if exists && filterFunc(fieldVal) {
 			result = append(result, keys[i])
 		}
@@ -73,7 +69,7 @@ type RedisEqualFilter struct{ *GenericFilter }
 
 func (f *RedisEqualFilter) ApplyRedis(ctx context.Context, client *redis.Client, keys []string) ([]string, error) {
 	return applyRedisBatchFilter(ctx, client, keys, f.Field, func(val interface{}) bool {
-		return fmt.Sprintf("%v", val) == fmt.Sprintf("%v", f.Value)
+		return redisCompare(val, f.Value, func(c int) bool { return c == 0 })
 	})
 }
 
@@ -81,79 +77,69 @@ type RedisNotEqualFilter struct{ *GenericFilter }
 
 func (f *RedisNotEqualFilter) ApplyRedis(ctx context.Context, client *redis.Client, keys []string) ([]string, error) {
 	return applyRedisBatchFilter(ctx, client, keys, f.Field, func(val interface{}) bool {
-		return fmt.Sprintf("%v", val) != fmt.Sprintf("%v", f.Value)
+		return redisCompare(val, f.Value, func(c int) bool { return c != 0 })
 	})
 }
 
 type RedisGreaterThanFilter struct{ *GenericFilter }
 
 func (f *RedisGreaterThanFilter) ApplyRedis(ctx context.Context, client *redis.Client, keys []string) ([]string, error) {
-	target, _ := toFloat64(f.Value)
 	return applyRedisBatchFilter(ctx, client, keys, f.Field, func(val interface{}) bool {
-		v, err := toFloat64(val)
-		return err == nil && v > target
+		return redisCompare(val, f.Value, func(c int) bool { return c > 0 })
 	})
 }
 
 type RedisGreaterThanOrEqualFilter struct{ *GenericFilter }
 
 func (f *RedisGreaterThanOrEqualFilter) ApplyRedis(ctx context.Context, client *redis.Client, keys []string) ([]string, error) {
-	target, _ := toFloat64(f.Value)
 	return applyRedisBatchFilter(ctx, client, keys, f.Field, func(val interface{}) bool {
-		v, err := toFloat64(val)
-		return err == nil && v >= target
+		return redisCompare(val, f.Value, func(c int) bool { return c >= 0 })
 	})
 }
 
 type RedisLessThanFilter struct{ *GenericFilter }
 
 func (f *RedisLessThanFilter) ApplyRedis(ctx context.Context, client *redis.Client, keys []string) ([]string, error) {
-	target, _ := toFloat64(f.Value)
 	return applyRedisBatchFilter(ctx, client, keys, f.Field, func(val interface{}) bool {
-		v, err := toFloat64(val)
-		return err == nil && v < target
+		return redisCompare(val, f.Value, func(c int) bool { return c < 0 })
 	})
 }
 
 type RedisLessThanOrEqualFilter struct{ *GenericFilter }
 
 func (f *RedisLessThanOrEqualFilter) ApplyRedis(ctx context.Context, client *redis.Client, keys []string) ([]string, error) {
-	target, _ := toFloat64(f.Value)
 	return applyRedisBatchFilter(ctx, client, keys, f.Field, func(val interface{}) bool {
-		v, err := toFloat64(val)
-		return err == nil && v <= target
+		return redisCompare(val, f.Value, func(c int) bool { return c <= 0 })
 	})
 }
 
 type RedisLikeFilter struct{ *GenericFilter }
 
 func (f *RedisLikeFilter) ApplyRedis(ctx context.Context, client *redis.Client, keys []string) ([]string, error) {
-	search := strings.ToLower(f.Value.(string))
+	value, ok := f.Value.(string)
+	if !ok {
+		return nil, fmt.Errorf("filter %s like: value must be string, got %T", f.Field, f.Value)
+	}
+	search := strings.ToLower(value)
 	return applyRedisBatchFilter(ctx, client, keys, f.Field, func(val interface{}) bool {
-		return strings.Contains(strings.ToLower(fmt.Sprintf("%v", val)), search)
+		return val != nil && strings.Contains(strings.ToLower(fmt.Sprintf("%v", val)), search)
 	})
 }
 
 type RedisInFilter struct{ *GenericInFilter }
 
 func (f *RedisInFilter) ApplyRedis(ctx context.Context, client *redis.Client, keys []string) ([]string, error) {
-	valueSet := make(map[string]bool)
-	for _, v := range f.Values {
-		valueSet[fmt.Sprintf("%v", v)] = true
-	}
 	return applyRedisBatchFilter(ctx, client, keys, f.Field, func(val interface{}) bool {
-		return valueSet[fmt.Sprintf("%v", val)]
+		return redisIn(val, f.Values)
 	})
 }
 
 type RedisBetweenFilter struct{ *GenericBetweenFilter }
 
 func (f *RedisBetweenFilter) ApplyRedis(ctx context.Context, client *redis.Client, keys []string) ([]string, error) {
-	minV, _ := toFloat64(f.Min)
-	maxV, _ := toFloat64(f.Max)
 	return applyRedisBatchFilter(ctx, client, keys, f.Field, func(val interface{}) bool {
-		v, err := toFloat64(val)
-		return err == nil && v >= minV && v <= maxV
+		return redisCompare(val, f.Min, func(c int) bool { return c >= 0 }) &&
+			redisCompare(val, f.Max, func(c int) bool { return c <= 0 })
 	})
 }
 
@@ -173,21 +159,145 @@ func (f *RedisIsNotNullFilter) ApplyRedis(ctx context.Context, client *redis.Cli
 	})
 }
 
+type RedisNotInFilter struct{ *GenericInFilter }
+
+func (f *RedisNotInFilter) ApplyRedis(ctx context.Context, client *redis.Client, keys []string) ([]string, error) {
+	return applyRedisBatchFilter(ctx, client, keys, f.Field, func(val interface{}) bool {
+		return val != nil && !redisIn(val, f.Values)
+	})
+}
+
+type RedisNotBetweenFilter struct{ *GenericBetweenFilter }
+
+func (f *RedisNotBetweenFilter) ApplyRedis(ctx context.Context, client *redis.Client, keys []string) ([]string, error) {
+	return applyRedisBatchFilter(ctx, client, keys, f.Field, func(val interface{}) bool {
+		return redisCompare(val, f.Min, func(c int) bool { return c < 0 }) ||
+			redisCompare(val, f.Max, func(c int) bool { return c > 0 })
+	})
+}
+
+// RedisStringFilter 字符串匹配：starts_with / ends_with 区分大小写（与 SQL 的 LIKE 及内存过滤一致），ieq / ilike 忽略大小写
+type RedisStringFilter struct{ *GenericFilter }
+
+func (f *RedisStringFilter) ApplyRedis(ctx context.Context, client *redis.Client, keys []string) ([]string, error) {
+	value, ok := f.Value.(string)
+	if !ok {
+		return nil, fmt.Errorf("filter %s %s: value must be string, got %T", f.Field, f.Operator, f.Value)
+	}
+	var match func(string) bool
+	switch f.Operator {
+	case "starts_with":
+		match = func(s string) bool { return strings.HasPrefix(s, value) }
+	case "ends_with":
+		match = func(s string) bool { return strings.HasSuffix(s, value) }
+	case "ieq":
+		search := strings.ToLower(value)
+		match = func(s string) bool { return strings.ToLower(s) == search }
+	case "ilike":
+		search := strings.ToLower(value)
+		match = func(s string) bool { return strings.Contains(strings.ToLower(s), search) }
+	default:
+		return nil, fmt.Errorf("unsupported string operator: %s", f.Operator)
+	}
+	return applyRedisBatchFilter(ctx, client, keys, f.Field, func(val interface{}) bool {
+		return val != nil && match(fmt.Sprintf("%v", val))
+	})
+}
+
+// RedisRegexFilter 正则匹配（Go RE2 语法）
+type RedisRegexFilter struct {
+	*GenericFilter
+	re *regexp.Regexp
+}
+
+func (f *RedisRegexFilter) ApplyRedis(ctx context.Context, client *redis.Client, keys []string) ([]string, error) {
+	return applyRedisBatchFilter(ctx, client, keys, f.Field, func(val interface{}) bool {
+		return val != nil && f.re.MatchString(fmt.Sprintf("%v", val))
+	})
+}
+
+// RedisRelativeTimeFilter 相对时间过滤，字段值须为 RFC3339 / "2006-01-02 15:04:05" / "2006-01-02" 字符串或 Unix 秒
+type RedisRelativeTimeFilter struct{ *GenericRelativeTimeFilter }
+
+func (f *RedisRelativeTimeFilter) ApplyRedis(ctx context.Context, client *redis.Client, keys []string) ([]string, error) {
+	now := time.Now()
+	var match func(time.Time) bool
+	switch f.Operator {
+	case "within_last":
+		match = func(t time.Time) bool { return !t.Before(now.Add(-f.Duration)) }
+	case "older_than":
+		match = func(t time.Time) bool { return t.Before(now.Add(-f.Duration)) }
+	case "before_now":
+		match = func(t time.Time) bool { return t.Before(now) }
+	default: // after_now
+		match = func(t time.Time) bool { return t.After(now) }
+	}
+	return applyRedisBatchFilter(ctx, client, keys, f.Field, func(val interface{}) bool {
+		t, err := toTime(val)
+		return err == nil && match(t)
+	})
+}
+
 // ========== 工具函数 ==========
 
-func toFloat64(v interface{}) (float64, error) {
+// redisFieldValue 提取缓存记录中的字段：优先提取顶层，若无则看是否在 data 嵌套里；点路径（如 "profile.city"）逐级进入嵌套对象
+func redisFieldValue(data map[string]interface{}, field string) (interface{}, bool) {
+	if v, ok := lookupField(data, field); ok {
+		return v, true
+	}
+	if innerData, ok := data["data"].(map[string]interface{}); ok {
+		return lookupField(innerData, field)
+	}
+	return nil, false
+}
+
+// lookupField 提取字段值，字段名不存在且含 "." 时按路径进入嵌套对象
+func lookupField(data map[string]interface{}, field string) (interface{}, bool) {
+	if v, ok := data[field]; ok {
+		return v, true
+	}
+	head, rest, found := strings.Cut(field, ".")
+	if !found {
+		return nil, false
+	}
+	inner, ok := data[head].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookupField(inner, rest)
+}
+
+// toTime 解析 JSON 中的时间值
+func toTime(v interface{}) (time.Time, error) {
 	switch val := v.(type) {
-	case float64:
-		return val, nil
-	case int:
-		return float64(val), nil
-	case int64:
-		return float64(val), nil
 	case string:
-		return strconv.ParseFloat(val, 64)
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+			if t, err := time.ParseInLocation(layout, val, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("invalid time: %s", val)
+	case float64:
+		return time.Unix(int64(val), 0), nil
 	default:
-		return 0, fmt.Errorf("unknown type")
+		return time.Time{}, fmt.Errorf("unknown type")
+	}
+}
+
+// redisCompare 按类型比较 JSON 字段值与过滤值（数字按数值、时间按时刻、其余按字符串），字段为 null 时不满足
+func redisCompare(val, target interface{}, match func(int) bool) bool {
+	c, null, ok := compareSQL(val, target)
+	return !null && ok && match(c)
+}
+
+// redisIn 字段值是否等于 values 中任一值
+func redisIn(val interface{}, values []interface{}) bool {
+	for _, v := range values {
+		if redisCompare(val, v, func(c int) bool { return c == 0 }) {
+			return true
+		}
 	}
+	return false
 }
 
 // ========== Redis Translator 实现 ==========
@@ -327,12 +437,95 @@ func (t *RedisIsNotNullTranslator) Translate(param FilterParam) (BaseFilter, err
 	return &RedisIsNotNullFilter{GenericFilter: &GenericFilter{Field: param.Field, Operator: "isnotnull"}}, nil
 }
 
+type RedisNotInTranslator struct{}
+
+func (t *RedisNotInTranslator) SupportedOperator() string { return "not_in" }
+func (t *RedisNotInTranslator) Validate(param FilterParam) error {
+	if _, ok := param.Value.([]interface{}); !ok {
+		return fmt.Errorf("value must be array")
+	}
+	return nil
+}
+func (t *RedisNotInTranslator) Translate(param FilterParam) (BaseFilter, error) {
+	return &RedisNotInFilter{GenericInFilter: &GenericInFilter{Field: param.Field, Operator: "not_in", Values: param.Value.([]interface{})}}, nil
+}
+
+type RedisNotBetweenTranslator struct{}
+
+func (t *RedisNotBetweenTranslator) SupportedOperator() string { return "not_between" }
+func (t *RedisNotBetweenTranslator) Validate(param FilterParam) error {
+	v, ok := param.Value.([]interface{})
+	if !ok || len(v) != 2 {
+		return fmt.Errorf("not_between needs 2 values")
+	}
+	return nil
+}
+func (t *RedisNotBetweenTranslator) Translate(param FilterParam) (BaseFilter, error) {
+	v := param.Value.([]interface{})
+	return &RedisNotBetweenFilter{GenericBetweenFilter: &GenericBetweenFilter{Field: param.Field, Operator: "not_between", Min: v[0], Max: v[1]}}, nil
+}
+
+// RedisStringTranslator starts_with / ends_with / ieq / ilike
+type RedisStringTranslator struct{ Operator string }
+
+func (t *RedisStringTranslator) SupportedOperator() string { return t.Operator }
+func (t *RedisStringTranslator) Validate(param FilterParam) error {
+	if _, ok := param.Value.(string); !ok {
+		return fmt.Errorf("value must be string")
+	}
+	return nil
+}
+func (t *RedisStringTranslator) Translate(param FilterParam) (BaseFilter, error) {
+	return &RedisStringFilter{GenericFilter: &GenericFilter{Field: param.Field, Operator: t.Operator, Value: param.Value}}, nil
+}
+
+type RedisRegexTranslator struct{}
+
+func (t *RedisRegexTranslator) SupportedOperator() string { return "regex" }
+func (t *RedisRegexTranslator) Validate(param FilterParam) error {
+	pattern, ok := param.Value.(string)
+	if !ok {
+		return fmt.Errorf("value must be string")
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("invalid regex: %w", err)
+	}
+	return nil
+}
+func (t *RedisRegexTranslator) Translate(param FilterParam) (BaseFilter, error) {
+	re, err := regexp.Compile(param.Value.(string))
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %w", err)
+	}
+	return &RedisRegexFilter{GenericFilter: &GenericFilter{Field: param.Field, Operator: "regex", Value: param.Value}, re: re}, nil
+}
+
+// RedisRelativeTimeTranslator within_last / older_than / before_now / after_now
+type RedisRelativeTimeTranslator struct{ Operator string }
+
+func (t *RedisRelativeTimeTranslator) SupportedOperator() string { return t.Operator }
+func (t *RedisRelativeTimeTranslator) Validate(param FilterParam) error {
+	_, err := newRelativeTimeFilter(t.Operator, param)
+	return err
+}
+func (t *RedisRelativeTimeTranslator) Translate(param FilterParam) (BaseFilter, error) {
+	filter, err := newRelativeTimeFilter(t.Operator, param)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisRelativeTimeFilter{GenericRelativeTimeFilter: filter}, nil
+}
+
 // ========== Redis 翻译器注册表 ==========
 
 type RedisTranslatorRegistry struct {
 	translators map[string]FilterTranslator
+	schema      *schema.Schema // 绑定的模型 schema（见 ForSchema），用于按字段类型转换过滤值
 }
 
+// NewRedisTranslatorRegistry 创建包含全部内置操作符的注册表
+// 字符串匹配的大小写：starts_with / ends_with / regex 区分大小写，与 GORM 与内存过滤的结果一致；
+// ieq / ilike 忽略大小写；like 沿用原有行为忽略大小写（SQL 端取决于列的排序规则）
 func NewRedisTranslatorRegistry() *RedisTranslatorRegistry {
 	registry := &RedisTranslatorRegistry{translators: make(map[string]FilterTranslator)}
 	registry.Register(&RedisEqualTranslator{})
@@ -346,6 +539,15 @@ func NewRedisTranslatorRegistry() *RedisTranslatorRegistry {
 	registry.Register(&RedisBetweenTranslator{})
 	registry.Register(&RedisIsNullTranslator{})
 	registry.Register(&RedisIsNotNullTranslator{})
+	registry.Register(&RedisNotInTranslator{})
+	registry.Register(&RedisNotBetweenTranslator{})
+	registry.Register(&RedisRegexTranslator{})
+	for _, op := range []string{"starts_with", "ends_with", "ieq", "ilike"} {
+		registry.Register(&RedisStringTranslator{Operator: op})
+	}
+	for _, op := range RelativeTimeOperators {
+		registry.Register(&RedisRelativeTimeTranslator{Operator: op})
+	}
 	return registry
 }
 
@@ -353,11 +555,22 @@ func (r *RedisTranslatorRegistry) Register(translator FilterTranslator) {
 	r.translators[translator.SupportedOperator()] = translator
 }
 
+// ForSchema 返回绑定模型 schema 的注册表视图（与原注册表共享翻译器），翻译前按字段类型转换过滤值
+func (r *RedisTranslatorRegistry) ForSchema(s *schema.Schema) *RedisTranslatorRegistry {
+	return &RedisTranslatorRegistry{translators: r.translators, schema: s}
+}
+
 func (r *RedisTranslatorRegistry) Translate(param FilterParam) (RedisFilter, error) {
 	t, ok := r.translators[param.Operator]
 	if !ok {
 		return nil, fmt.Errorf("unsupported operator: %s", param.Operator)
 	}
+	if r.schema != nil {
+		var err error
+		if param, err = coerceWithSchema(r.schema, param); err != nil {
+			return nil, err
+		}
+	}
 	if err := t.Validate(param); err != nil {
 		return nil, err
 	}
@@ -365,7 +578,11 @@ func (r *RedisTranslatorRegistry) Translate(param FilterParam) (RedisFilter, err
 	if err != nil {
 		return nil, err
 	}
-	return base.(RedisFilter), nil
+	filter, ok := base.(RedisFilter)
+	if !ok {
+		return nil, fmt.Errorf("translator returned non-RedisFilter")
+	}
+	return filter, nil
 }
 
 func (r *RedisTranslatorRegistry) TranslateBatch(params []FilterParam) ([]RedisFilter, error) {
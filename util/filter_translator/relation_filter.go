@@ -0,0 +1,188 @@
+package filter_translator
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ========== 关联字段过滤 ==========
+// 绑定模型 schema 后（ForSchema），GORM 翻译器接受点路径字段，如 "profile.city"、"orders.items.sku"：
+// 路径前缀按 schema 中的关联（has one / has many / belongs to / many2many）逐级解析，最后一段为关联表的列。
+// 每一级关联生成一个相关 EXISTS 子查询而不是 JOIN，因此 has many 关联不会使父表行重复，也不需要 DISTINCT：
+//
+//	WHERE EXISTS (SELECT 1 FROM `orders` `assoc_1` WHERE `assoc_1`.`user_id` = `users`.`id` AND `assoc_1`.`total` > ?)
+//
+// 同一 has many 路径上的多个条件各自生成 EXISTS，可能由不同的子行满足。
+
+// ForSchema 返回绑定模型 schema 的注册表视图（与原注册表共享翻译器），用于解析关联字段路径
+func (r *GormTranslatorRegistry) ForSchema(s *schema.Schema) *GormTranslatorRegistry {
+	return &GormTranslatorRegistry{translators: r.translators, schema: s}
+}
+
+// GormRelationFilter 关联字段过滤器：沿关联链生成嵌套 EXISTS 子查询，Inner 作用于最后一级关联表
+type GormRelationFilter struct {
+	*GenericFilter
+	Relations []*schema.Relationship
+	Inner     GormFilter
+}
+
+func (f *GormRelationFilter) ApplyGorm(db *gorm.DB) *gorm.DB {
+	parent := db.Statement.Table
+	if parent == "" {
+		parent = f.Relations[0].Schema.Table
+	}
+	return db.Where("EXISTS (?)", f.exists(db, parent, 0))
+}
+
+// exists 构建第 depth 级关联的子查询，parent 为外层表名或别名
+func (f *GormRelationFilter) exists(db *gorm.DB, parent string, depth int) *gorm.DB {
+	rel := f.Relations[depth]
+	alias := relationAlias(depth)
+	target := clause.Table{Name: rel.FieldSchema.Table, Alias: alias}
+
+	sub := db.Session(&gorm.Session{NewDB: true}).Select("1")
+	var conds []clause.Expression
+	if rel.JoinTable != nil {
+		// many2many：从中间表出发，再 JOIN 关联表
+		joinAlias := alias + "_j"
+		var on []clause.Expression
+		for _, ref := range rel.References {
+			fk := clause.Column{Table: joinAlias, Name: ref.ForeignKey.DBName}
+			switch {
+			case ref.OwnPrimaryKey:
+				conds = append(conds, clause.Eq{Column: fk, Value: clause.Column{Table: parent, Name: ref.PrimaryKey.DBName}})
+			case ref.PrimaryValue != "":
+				conds = append(conds, clause.Eq{Column: fk, Value: ref.PrimaryValue})
+			default:
+				on = append(on, clause.Eq{Column: fk, Value: clause.Column{Table: alias, Name: ref.PrimaryKey.DBName}})
+			}
+		}
+		sub = sub.Clauses(clause.From{
+			Tables: []clause.Table{{Name: rel.JoinTable.Table, Alias: joinAlias}},
+			Joins:  []clause.Join{{Type: clause.InnerJoin, Table: target, ON: clause.Where{Exprs: on}}},
+		})
+	} else {
+		for _, ref := range rel.References {
+			switch {
+			case ref.OwnPrimaryKey:
+				// has one / has many：关联表外键 = 父表主键
+				conds = append(conds, clause.Eq{
+					Column: clause.Column{Table: alias, Name: ref.ForeignKey.DBName},
+					Value:  clause.Column{Table: parent, Name: ref.PrimaryKey.DBName},
+				})
+			case ref.PrimaryValue != "":
+				// 多态关联的类型列
+				conds = append(conds, clause.Eq{Column: clause.Column{Table: alias, Name: ref.ForeignKey.DBName}, Value: ref.PrimaryValue})
+			default:
+				// belongs to：关联表主键 = 父表外键
+				conds = append(conds, clause.Eq{
+					Column: clause.Column{Table: alias, Name: ref.PrimaryKey.DBName},
+					Value:  clause.Column{Table: parent, Name: ref.ForeignKey.DBName},
+				})
+			}
+		}
+		sub = sub.Clauses(clause.From{Tables: []clause.Table{target}})
+	}
+	sub = sub.Where(clause.And(conds...))
+
+	if depth+1 < len(f.Relations) {
+		return sub.Where("EXISTS (?)", f.exists(db, alias, depth+1))
+	}
+	return f.Inner.ApplyGorm(sub)
+}
+
+// relationAlias 第 depth 级关联表的别名，嵌套子查询中各级别名互不相同
+func relationAlias(depth int) string {
+	return fmt.Sprintf("assoc_%d", depth+1)
+}
+
+// translateRelation 解析点路径并翻译关联字段过滤器，路径前缀不是关联时返回 nil（按普通的 table.column 处理）
+func (r *GormTranslatorRegistry) translateRelation(translator FilterTranslator, param FilterParam) (GormFilter, error) {
+	segments := strings.Split(param.Field, ".")
+	if FindRelation(r.schema, segments[0]) == nil {
+		return nil, nil
+	}
+
+	relations, field, err := ResolveRelationPath(r.schema, param.Field)
+	if err != nil {
+		return nil, err
+	}
+
+	// 最后一级关联表的列以别名限定，避免与外层同名列混淆
+	innerParam := param
+	innerParam.Field = relationAlias(len(relations)-1) + "." + field.DBName
+	base, err := translator.Translate(innerParam)
+	if err != nil {
+		return nil, err
+	}
+	inner, ok := base.(GormFilter)
+	if !ok {
+		return nil, fmt.Errorf("translator returned non-GormFilter")
+	}
+
+	return &GormRelationFilter{
+		GenericFilter: &GenericFilter{Field: param.Field, Operator: param.Operator, Value: param.Value},
+		Relations:     relations,
+		Inner:         inner,
+	}, nil
+}
+
+// ResolveRelationPath 沿 s 的关联解析点路径，返回关联链与最后一段对应的列
+// 路径各段可写关联 / 字段的 Go 名、json 名或列名（关联名不区分大小写）
+func ResolveRelationPath(s *schema.Schema, path string) ([]*schema.Relationship, *schema.Field, error) {
+	segments := strings.Split(path, ".")
+	if len(segments) < 2 {
+		return nil, nil, fmt.Errorf("%q is not a relation path", path)
+	}
+
+	var relations []*schema.Relationship
+	current := s
+	for _, name := range segments[:len(segments)-1] {
+		rel := FindRelation(current, name)
+		if rel == nil {
+			return nil, nil, fmt.Errorf("unknown relation %q in %q", name, path)
+		}
+		relations = append(relations, rel)
+		current = rel.FieldSchema
+	}
+
+	last := segments[len(segments)-1]
+	field := current.LookUpField(last)
+	if field == nil {
+		for _, f := range current.Fields {
+			if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name == last {
+				field = f
+				break
+			}
+		}
+	}
+	if field == nil || field.DBName == "" {
+		return nil, nil, fmt.Errorf("unknown field %q in %q", last, path)
+	}
+	return relations, field, nil
+}
+
+// FindRelation 按 Go 名（不区分大小写）或 json 名查找 s 的关联
+func FindRelation(s *schema.Schema, name string) *schema.Relationship {
+	if s == nil || name == "" {
+		return nil
+	}
+	if rel, ok := s.Relationships.Relations[name]; ok && !strings.HasPrefix(name, "_") {
+		return rel
+	}
+	for key, rel := range s.Relationships.Relations {
+		// "_" 开头的是 GORM 为反向关联登记的内部条目
+		if strings.HasPrefix(key, "_") {
+			continue
+		}
+		jsonName, _, _ := strings.Cut(rel.Field.Tag.Get("json"), ",")
+		if strings.EqualFold(key, name) || jsonName == name {
+			return rel
+		}
+	}
+	return nil
+}
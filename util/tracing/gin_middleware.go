@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GinMiddleware 为单个路由处理器开启 span
+// span 的 context 会写回 c.Request，处理器中 c.Request.Context() 即可继续向下传递
+func GinMiddleware(resource, handler string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+
+		ctx, span := Tracer().Start(c.Request.Context(), fmt.Sprintf("%s %s", c.Request.Method, route),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				AttrResource.String(resource),
+				attribute.String("http.handler", handler),
+				attribute.String("http.method", c.Request.Method),
+				attribute.String("http.route", route),
+			),
+		)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.status_code", status))
+
+		// 只有 5xx 标记为失败；4xx 属于调用方错误，仅作为事件记录
+		var err error
+		if status >= 500 {
+			err = fmt.Errorf("http status %d", status)
+			if len(c.Errors) > 0 {
+				err = c.Errors.Last().Err
+			}
+		} else if len(c.Errors) > 0 {
+			span.RecordError(c.Errors.Last().Err)
+		}
+		End(span, err)
+	}
+}
@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "abstract_manager:tracing_span"
+
+// GormPlugin GORM 链路追踪插件
+// 为每条 SQL 语句（create/query/update/delete/row/raw）开启一个子 span
+// 父 span 取自 db.WithContext(ctx) 传入的 ctx
+type GormPlugin struct{}
+
+// NewGormPlugin 创建 GORM 链路追踪插件，使用方式：db.Use(tracing.NewGormPlugin())
+func NewGormPlugin() *GormPlugin {
+	return &GormPlugin{}
+}
+
+// Name 实现 gorm.Plugin 接口
+func (p *GormPlugin) Name() string {
+	return "abstract_manager:tracing"
+}
+
+// Initialize 实现 gorm.Plugin 接口，在各类回调前后注册 span 的开启与结束
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	name := p.Name()
+
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register(name+":before_create", p.before("create")),
+		cb.Create().After("gorm:create").Register(name+":after_create", p.after),
+		cb.Query().Before("gorm:query").Register(name+":before_query", p.before("query")),
+		cb.Query().After("gorm:query").Register(name+":after_query", p.after),
+		cb.Update().Before("gorm:update").Register(name+":before_update", p.before("update")),
+		cb.Update().After("gorm:update").Register(name+":after_update", p.after),
+		cb.Delete().Before("gorm:delete").Register(name+":before_delete", p.before("delete")),
+		cb.Delete().After("gorm:delete").Register(name+":after_delete", p.after),
+		cb.Row().Before("gorm:row").Register(name+":before_row", p.before("row")),
+		cb.Row().After("gorm:row").Register(name+":after_row", p.after),
+		cb.Raw().Before("gorm:raw").Register(name+":before_raw", p.before("raw")),
+		cb.Raw().After("gorm:raw").Register(name+":after_raw", p.after),
+	)
+}
+
+func (p *GormPlugin) before(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement == nil || db.Statement.Context == nil {
+			return
+		}
+		attrs := []attribute.KeyValue{
+			attribute.String("db.system", db.Dialector.Name()),
+			attribute.String("db.operation", op),
+		}
+		if db.Statement.Table != "" {
+			attrs = append(attrs, attribute.String("db.sql.table", db.Statement.Table))
+		}
+		ctx, span := Start(db.Statement.Context, "gorm."+op, attrs...)
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func (p *GormPlugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+
+	// 只记录带占位符的 SQL，不展开参数，避免敏感数据进入链路
+	if sql := db.Statement.SQL.String(); sql != "" {
+		span.SetAttributes(attribute.String("db.statement", sql))
+	}
+	if db.Statement.Table != "" {
+		span.SetAttributes(attribute.String("db.sql.table", db.Statement.Table))
+	}
+	span.SetAttributes(attribute.Int64("db.rows_affected", db.RowsAffected))
+
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 未找到记录属于正常业务结果，不标记为错误
+		span.SetAttributes(attribute.Bool("db.record_not_found", true))
+		err = nil
+	}
+	End(span, err)
+}
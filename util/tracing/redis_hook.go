@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RedisHook go-redis 链路追踪钩子
+// 每条命令开启一个 span，Pipeline 整体开启一个 span
+type RedisHook struct{}
+
+// NewRedisHook 创建 Redis 链路追踪钩子，使用方式：client.AddHook(tracing.NewRedisHook())
+func NewRedisHook() *RedisHook {
+	return &RedisHook{}
+}
+
+// DialHook 实现 redis.Hook 接口（建连不单独追踪）
+func (h *RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook 实现 redis.Hook 接口
+func (h *RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		name := strings.ToLower(cmd.Name())
+		attrs := []attribute.KeyValue{
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation", name),
+		}
+		if key := firstKey(cmd); key != "" {
+			attrs = append(attrs, AttrCacheKey.String(key))
+		}
+
+		ctx, span := Start(ctx, "redis."+name, attrs...)
+		err := next(ctx, cmd)
+		End(span, redisSpanError(err))
+		return err
+	}
+}
+
+// ProcessPipelineHook 实现 redis.Hook 接口
+func (h *RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		names := make([]string, 0, len(cmds))
+		for _, cmd := range cmds {
+			names = append(names, strings.ToLower(cmd.Name()))
+		}
+
+		ctx, span := Start(ctx, "redis.pipeline",
+			attribute.String("db.system", "redis"),
+			attribute.Int("db.redis.num_cmd", len(cmds)),
+			attribute.StringSlice("db.redis.cmds", names),
+		)
+		err := next(ctx, cmds)
+		End(span, redisSpanError(err))
+		return err
+	}
+}
+
+// firstKey 取命令的第一个参数作为键（GET/SET/DEL 等大多数命令如此）
+func firstKey(cmd redis.Cmder) string {
+	args := cmd.Args()
+	if len(args) < 2 {
+		return ""
+	}
+	key, _ := args[1].(string)
+	return key
+}
+
+// redisSpanError 键不存在（redis.Nil）属于正常未命中，不记为错误
+func redisSpanError(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName 本项目所有 span 使用的 tracer 名称
+const InstrumentationName = "AbstractManager"
+
+// ========== 通用属性键 ==========
+
+const (
+	AttrResource  = attribute.Key("am.resource")        // 资源名称（ServiceManager.ResourceName）
+	AttrMethod    = attribute.Key("am.method")          // 方法名称
+	AttrCacheKey  = attribute.Key("am.cache.key")       // 单个缓存键
+	AttrKeyCount  = attribute.Key("am.cache.key_count") // 批量缓存键数量
+	AttrFilters   = attribute.Key("am.filters")         // 过滤条件（JSON）
+	AttrQueryName = attribute.Key("am.query.method")    // 预定义查询方法名
+	AttrID        = attribute.Key("am.id")              // 主键值
+	AttrPattern   = attribute.Key("am.cache.pattern")   // 缓存键模式
+	AttrCacheHit  = attribute.Key("am.cache.hit")       // 是否命中缓存
+)
+
+// Tracer 返回全局 TracerProvider 上的 tracer
+// 每次调用都重新读取全局 Provider，测试中替换 Provider 后立即生效
+func Tracer() trace.Tracer {
+	return otel.GetTracerProvider().Tracer(InstrumentationName)
+}
+
+// Start 开启一个 span，ctx 为 nil 时使用 context.Background()
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End 结束 span，err 不为空时记录错误并将状态置为 Error
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// FiltersAttr 将过滤条件序列化为 JSON 字符串属性
+func FiltersAttr(filters interface{}) attribute.KeyValue {
+	data, err := json.Marshal(filters)
+	if err != nil {
+		return AttrFilters.String("<unserializable>")
+	}
+	return AttrFilters.String(string(data))
+}
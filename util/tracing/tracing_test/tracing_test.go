@@ -0,0 +1,174 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"AbstractManager/util/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// ========== 测试辅助 ==========
+
+// setupExporter 安装内存 exporter 作为全局 TracerProvider，测试结束后恢复
+func setupExporter(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previous)
+	})
+	return exporter
+}
+
+func findSpan(spans tracetest.SpanStubs, name string) (tracetest.SpanStub, bool) {
+	for _, s := range spans {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return tracetest.SpanStub{}, false
+}
+
+func attrValue(attrs []attribute.KeyValue, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+type User struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Name string `json:"name"`
+}
+
+// ========== Gin 中间件 ==========
+
+func TestGinMiddlewarePropagatesContext(t *testing.T) {
+	exporter := setupExporter(t)
+	gin.SetMode(gin.TestMode)
+
+	var childParent string
+	r := gin.New()
+	r.GET("/users/:id", tracing.GinMiddleware("User", "QueryRouterGroup.HandleGetByID"), func(c *gin.Context) {
+		_, child := tracing.Start(c.Request.Context(), "child")
+		childParent = child.SpanContext().TraceID().String()
+		child.End()
+		c.JSON(http.StatusNotFound, gin.H{"code": 404})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/7", nil))
+
+	spans := exporter.GetSpans()
+	server, ok := findSpan(spans, "GET /users/:id")
+	if !ok {
+		t.Fatalf("server span not found, got %d spans", len(spans))
+	}
+	if v, _ := attrValue(server.Attributes, tracing.AttrResource); v.AsString() != "User" {
+		t.Errorf("resource attribute = %q, want User", v.AsString())
+	}
+	if v, _ := attrValue(server.Attributes, "http.status_code"); v.AsInt64() != http.StatusNotFound {
+		t.Errorf("status attribute = %d, want 404", v.AsInt64())
+	}
+	if server.Status.Code == codes.Error {
+		t.Errorf("4xx responses should not mark the span as error")
+	}
+
+	child, ok := findSpan(spans, "child")
+	if !ok {
+		t.Fatal("child span not found")
+	}
+	if child.Parent.SpanID() != server.SpanContext.SpanID() || childParent != server.SpanContext.TraceID().String() {
+		t.Errorf("child span is not a child of the server span")
+	}
+}
+
+// ========== GORM 插件 ==========
+
+func TestGormPluginCreatesChildSpans(t *testing.T) {
+	exporter := setupExporter(t)
+
+	// DryRun 模式下回调照常执行，但不会真正访问数据库
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:1)/db",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(tracing.NewGormPlugin()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, parent := tracing.Start(context.Background(), "parent")
+	var users []User
+	db.WithContext(ctx).Where("name = ?", "jo").Find(&users)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	query, ok := findSpan(spans, "gorm.query")
+	if !ok {
+		t.Fatalf("gorm.query span not found, got %v", spans.Snapshots())
+	}
+	if query.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("gorm span is not a child of the caller span")
+	}
+	stmt, ok := attrValue(query.Attributes, "db.statement")
+	if !ok || stmt.AsString() == "" {
+		t.Errorf("db.statement attribute missing")
+	}
+	if v, _ := attrValue(query.Attributes, "db.sql.table"); v.AsString() != "users" {
+		t.Errorf("db.sql.table = %q, want users", v.AsString())
+	}
+}
+
+// ========== Redis 钩子 ==========
+
+func TestRedisHookRecordsCommandAndError(t *testing.T) {
+	exporter := setupExporter(t)
+
+	client := redis.NewClient(&redis.Options{
+		Addr:        "127.0.0.1:1",
+		MaxRetries:  -1,
+		DialTimeout: 100 * time.Millisecond,
+	})
+	defer client.Close()
+	client.AddHook(tracing.NewRedisHook())
+
+	ctx, parent := tracing.Start(context.Background(), "parent")
+	_ = client.Get(ctx, "user:1").Err()
+	parent.End()
+
+	span, ok := findSpan(exporter.GetSpans(), "redis.get")
+	if !ok {
+		t.Fatal("redis.get span not found")
+	}
+	if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("redis span is not a child of the caller span")
+	}
+	if v, _ := attrValue(span.Attributes, tracing.AttrCacheKey); v.AsString() != "user:1" {
+		t.Errorf("cache key attribute = %q, want user:1", v.AsString())
+	}
+	if span.Status.Code != codes.Error {
+		t.Errorf("connection failure should mark the span as error")
+	}
+}